@@ -1,4 +1,14 @@
 // The ast package declares the types used to represent syntax trees for DQDL.
+//
+// # JSON representation
+//
+// Nodes marshal to JSON objects whose keys are the Go field names, plus a
+// "Kind" key holding the name of the node type (e.g. "StringParameter").
+// Positions are objects with "Index", "Line" and "Column" keys. The format
+// is described by the JSON Schema returned by Schema, generated from the
+// Go types into schema.json by go generate, and is kept stable within
+// a major version: keys and kinds may be added, but existing ones are
+// neither renamed nor removed.
 package ast
 
 import (
@@ -14,6 +24,12 @@ type Node interface {
 	End() token.Pos
 }
 
+// SpanOf はノードの範囲を返します。
+// SpanOf returns the range [Pos, End) of n.
+func SpanOf(n Node) token.Span {
+	return token.Span{Start: n.Pos(), End: n.End()}
+}
+
 // パラメータを表すノードです。
 // A Parameter node represents a parameter.
 type Parameter interface {
@@ -51,7 +67,7 @@ type Comment struct {
 
 func (c *Comment) Pos() token.Pos { return c.SharpPos }
 func (c *Comment) End() token.Pos {
-	return c.SharpPos.AddColumn(len(c.Text))
+	return c.SharpPos.Advance(c.Text)
 }
 
 // コメントの塊を表すノードです。
@@ -75,8 +91,36 @@ func (g CommentGroup) End() token.Pos {
 // A File node represents a DQDL source file.
 type File struct {
 	Filename      string
+	Source        string         `json:"-"` // original source text
 	CommentGroups []CommentGroup // list of comments
 	Rulesets      []*Ruleset     // list of rulesets
+	EOF           token.Pos      // position of the end of the file
+}
+
+// Pos はファイルの先頭の位置を返します。
+// Pos returns the position of the start of the file, or NoPos if the file
+// has no EOF position, as a tree built in code.
+func (f *File) Pos() token.Pos {
+	if !f.EOF.IsValid() {
+		return token.NoPos
+	}
+	return token.Pos{Index: 0, Line: 1, Column: 1}
+}
+
+// End はファイルの終端の位置を返します。
+// End returns the position of the end of the file, where text appended to
+// the file goes.
+func (f *File) End() token.Pos { return f.EOF }
+
+// Snippet はノードに対応する元のソーステキストをそのまま返します。
+// Snippet returns the original source text of the node n, byte-for-byte.
+// It returns an empty string if n is not within the source of f.
+func (f *File) Snippet(n Node) string {
+	start, end := n.Pos().Index, n.End().Index
+	if start < 0 || start > end || end > len(f.Source) {
+		return ""
+	}
+	return f.Source[start:end]
 }
 
 // Ruleset の宣言を表すノードです。
@@ -84,11 +128,14 @@ type File struct {
 type Ruleset struct {
 	Description     CommentGroup   // comments before "Rules"
 	DeclPos         token.Pos      // position of "Rules" keyword
+	EqualPos        token.Pos      // position of "="
 	LeftBracketPos  token.Pos      // position of "["
 	Rules           []RuleDecl     // list of rules
+	CommaPositions  []token.Pos    // positions of the "," after the rules, if any
 	InnerComments   []CommentGroup // comments inside "[...]"
 	RightBracketPos token.Pos      // position of "]"
 	Comments        CommentGroup   // list of comments
+	Legacy          bool           // parsed from a bare list of rules without "Rules = [...]"
 }
 
 func (d *Ruleset) Pos() token.Pos { return d.DeclPos }
@@ -131,12 +178,13 @@ func (r *Rule) PrependComments(comments CommentGroup) {
 }
 
 type CombinedRule struct {
-	Description    CommentGroup // comments before first "("
-	FirstLParenPos token.Pos    // position of first "("
-	LastRParenPos  token.Pos    // position of last ")"
-	Rules          []*Rule      // list of rules
-	Operator       string       // operator and/or
-	Comments       CommentGroup // line comments
+	Description       CommentGroup // comments before first "("
+	FirstLParenPos    token.Pos    // position of first "("
+	LastRParenPos     token.Pos    // position of last ")"
+	Rules             []*Rule      // list of rules
+	Operator          string       // operator and/or
+	OperatorPositions []token.Pos  // positions of the operators between the rules
+	Comments          CommentGroup // line comments
 }
 
 func (r *CombinedRule) Pos() token.Pos { return r.FirstLParenPos }
@@ -148,6 +196,25 @@ func (r *CombinedRule) PrependComments(comments CommentGroup) {
 	r.Comments = append(comments, r.Comments...)
 }
 
+// BadRule は構文エラーのため解析できなかったルールを表すノードです。
+// A BadRule node is a placeholder for a rule containing syntax errors,
+// created by the parser in error recovery mode instead of dropping the
+// rule. Text is the source of the rule, which the printer writes back
+// as it is.
+type BadRule struct {
+	From     token.Pos    // position of the first character of the rule
+	To       token.Pos    // position after the last character of the rule
+	Text     string       // source text of the rule
+	Comments CommentGroup // line comments
+}
+
+func (r *BadRule) Pos() token.Pos { return r.From }
+func (r *BadRule) End() token.Pos { return r.To }
+func (r *BadRule) ruleDeclNode()  {}
+func (r *BadRule) PrependComments(comments CommentGroup) {
+	r.Comments = append(comments, r.Comments...)
+}
+
 // 識別子を表すノードです。
 // An Ident node represents an identifier.
 type Ident struct {
@@ -158,7 +225,7 @@ type Ident struct {
 
 func (x *Ident) Pos() token.Pos { return x.NamePos }
 func (x *Ident) End() token.Pos {
-	return x.NamePos.AddColumn(len(x.Name))
+	return x.NamePos.Advance(x.Name)
 }
 
 type StringParameter struct {
@@ -219,7 +286,7 @@ func (x *DurationParameter) End() token.Pos {
 }
 func (x *DurationParameter) parameterNode() {}
 
-type DateParamter struct {
+type DateParameter struct {
 	LeftParenPos  *token.Pos         // position of left paren
 	RightParenPos *token.Pos         // position of right paren
 	NowPos        token.Pos          // position of now()
@@ -228,19 +295,25 @@ type DateParamter struct {
 	Comments      CommentGroup       // list of comments
 }
 
-func (x *DateParamter) Pos() token.Pos {
+func (x *DateParameter) Pos() token.Pos {
 	if x.LeftParenPos != nil {
 		return *x.LeftParenPos
 	}
 	return x.NowPos
 }
-func (x *DateParamter) End() token.Pos {
+func (x *DateParameter) End() token.Pos {
 	if x.RightParenPos != nil {
 		return x.RightParenPos.AddColumn(1)
 	}
-	return x.NowPos.AddColumn(6)
+	return x.NowPos.AddColumn(5)
 }
-func (x *DateParamter) parameterNode() {}
+func (x *DateParameter) parameterNode() {}
+
+// DateParamter は DateParameter の古い名前です。
+// DateParamter is the former, misspelled name of DateParameter.
+//
+// Deprecated: Use DateParameter.
+type DateParamter = DateParameter
 
 // ComparisonExpressionは比較表現を表すノードです。
 // A ComparisonExpression node represents a comparison expression.
@@ -281,6 +354,7 @@ type InExpression struct {
 	LeftBracketPos  token.Pos    // position of left bracket
 	RightBracketPos token.Pos    // position of right bracket
 	Values          []Parameter  // list of values
+	CommaPositions  []token.Pos  // positions of the "," between the values
 	Comments        CommentGroup // list of comments
 }
 
@@ -302,7 +376,7 @@ type MatchesExpression struct {
 
 func (x *MatchesExpression) Pos() token.Pos { return x.ExprPos }
 func (x *MatchesExpression) End() token.Pos {
-	return x.RegexpPos.AddColumn(len(x.Value) + 2)
+	return x.RegexpPos.Advance(`"` + x.Value + `"`)
 }
 func (x *MatchesExpression) expressionNode()      {}
 func (x *MatchesExpression) thresholdTargetNode() {}
@@ -319,3 +393,19 @@ func (x *WithThresholdExpression) End() token.Pos {
 	return x.Threshold.End()
 }
 func (x *WithThresholdExpression) expressionNode() {}
+
+// BadExpr は構文エラーのため解析できなかった表現を表すノードです。
+// A BadExpr node is a placeholder for an expression containing syntax
+// errors, created by the parser in error recovery mode, so that the rule
+// type and the parameters before it are kept. Text is the source of the
+// expression, which the printer writes back as it is.
+type BadExpr struct {
+	From     token.Pos    // position of the first character of the expression
+	To       token.Pos    // position after the last character of the expression
+	Text     string       // source text of the expression
+	Comments CommentGroup // list of comments
+}
+
+func (x *BadExpr) Pos() token.Pos  { return x.From }
+func (x *BadExpr) End() token.Pos  { return x.To }
+func (x *BadExpr) expressionNode() {}
@@ -0,0 +1,26 @@
+package astutil
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/printer"
+)
+
+// Canonicalize はルールセットを Glue の API が返す正規形の文字列に変換します。
+// Canonicalize returns ruleset in the normalized form returned by the AWS
+// Glue Data Quality API: one rule per line indented by four spaces, tokens
+// separated by single spaces, no comments, numbers in the form of
+// printer.NormalizeNumber, and no trailing newline. Rulesets that differ
+// only in layout, comments and number formatting have the same canonical
+// form, so it can be compared with the deployed ruleset. It returns an
+// empty string if ruleset can not be printed.
+func Canonicalize(ruleset *ast.Ruleset) string {
+	cfg := &printer.Config{Mode: printer.NormalizeNumbers | printer.OmitComments, Indent: "    "}
+	var buf bytes.Buffer
+	if err := cfg.Fprint(&buf, ruleset); err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,40 @@
+package astutil
+
+import (
+	"testing"
+
+	"github.com/mashiike/go-dqdl/parser"
+)
+
+func TestCanonicalize(t *testing.T) {
+	const want = "Rules = [\n    RowCount between 10 and 100.5,\n    ColumnValues \"status\" in [\"a\", \"b\"] with threshold > 0.9,\n    (IsComplete \"id\") and (IsUnique \"id\")\n]"
+	cases := []struct {
+		name  string
+		input string
+	}{
+		{name: "canonical", input: want},
+		{
+			name: "layout, comments and numbers",
+			input: `# orders
+Rules = [
+	RowCount   between 010 and 100.50, # rows
+	ColumnValues "status"
+		in ["a","b"] with threshold > 0.90,
+	# keys
+	(IsComplete "id")  and  (IsUnique "id")
+]
+`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rs, err := parser.ParseRuleset(c.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Canonicalize(rs); got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// Package astutil は構文木を操作するためのユーティリティを提供します。
+// Package astutil provides utilities for manipulating DQDL syntax trees.
+package astutil
+
+import (
+	"bytes"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/printer"
+	"github.com/mashiike/go-dqdl/validate"
+)
+
+// MergeOption は MergeRulesets の設定を変更します。
+// A MergeOption configures MergeRulesets.
+type MergeOption func(*mergeConfig)
+
+type mergeConfig struct {
+	combine bool
+}
+
+// CombineSameColumn は同じ列を検査するルールを and で結合します。
+// CombineSameColumn combines the rules checking the same column into one
+// `(rule) and (rule) ...`, in the position of the first of them. Only
+// rules of built-in rule types taking a single column are combined.
+func CombineSameColumn() MergeOption {
+	return func(c *mergeConfig) {
+		c.combine = true
+	}
+}
+
+// MergeRulesets は2つのルールセットのルールを連結し、重複したルールを取り除いたルールセットを返します。
+// MergeRulesets returns a ruleset with the rules of a followed by the ones
+// of b, without duplicates. Rules are duplicates if they print the same,
+// ignoring comments and the formatting of numbers; the first one is kept.
+// The descriptions of a and b are concatenated, and comments not attached
+// to a rule are dropped.
+//
+// The result shares the nodes of a and b, whose positions it keeps. Use
+// printer.Relayout on a copy made with ast.Clone for positions matching
+// its printed form.
+func MergeRulesets(a, b *ast.Ruleset, opts ...MergeOption) *ast.Ruleset {
+	cfg := &mergeConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	merged := &ast.Ruleset{}
+	merged.Description = append(merged.Description, a.Description...)
+	merged.Description = append(merged.Description, b.Description...)
+	seen := make(map[string]bool, len(a.Rules)+len(b.Rules))
+	for _, rules := range [][]ast.RuleDecl{a.Rules, b.Rules} {
+		for _, rule := range rules {
+			key := canonical(rule)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged.Rules = append(merged.Rules, rule)
+		}
+	}
+	if cfg.combine {
+		merged.Rules = combineSameColumn(merged.Rules)
+	}
+	return merged
+}
+
+// combineSameColumn replaces the rules on the same column with a combined
+// rule in the position of the first of them.
+func combineSameColumn(rules []ast.RuleDecl) []ast.RuleDecl {
+	groups := make(map[string][]*ast.Rule)
+	for _, decl := range rules {
+		if column, ok := singleColumn(decl); ok {
+			groups[column] = append(groups[column], decl.(*ast.Rule))
+		}
+	}
+	result := make([]ast.RuleDecl, 0, len(rules))
+	for _, decl := range rules {
+		column, ok := singleColumn(decl)
+		group := groups[column]
+		if !ok || len(group) < 2 {
+			result = append(result, decl)
+			continue
+		}
+		if group[0] != decl {
+			continue
+		}
+		combined := &ast.CombinedRule{Operator: "and"}
+		for _, r := range group {
+			combined.Description = append(combined.Description, r.Description...)
+			nested := *r
+			nested.Description = nil
+			nested.Comments = nil
+			combined.Rules = append(combined.Rules, &nested)
+		}
+		result = append(result, combined)
+	}
+	return result
+}
+
+// singleColumn returns the column of a rule of a built-in rule type that
+// takes a single column.
+func singleColumn(decl ast.RuleDecl) (string, bool) {
+	rule, ok := decl.(*ast.Rule)
+	if !ok || len(rule.Parameters) != 1 {
+		return "", false
+	}
+	spec, ok := validate.Lookup(rule.Type.Name)
+	if !ok || spec.Variadic || len(spec.Params) != 1 || spec.Params[0].Name != "column" {
+		return "", false
+	}
+	column, ok := rule.Parameters[0].(*ast.StringParameter)
+	if !ok {
+		return "", false
+	}
+	return column.Value, true
+}
+
+func canonical(rule ast.RuleDecl) string {
+	cfg := &printer.Config{Mode: printer.NormalizeNumbers | printer.OmitComments}
+	var buf bytes.Buffer
+	if err := cfg.Fprint(&buf, rule); err != nil {
+		return ""
+	}
+	return buf.String()
+}
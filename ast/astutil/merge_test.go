@@ -0,0 +1,55 @@
+package astutil
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/parser"
+	"github.com/mashiike/go-dqdl/printer"
+)
+
+func TestMergeRulesets(t *testing.T) {
+	const (
+		a = "# team a\nRules = [\n\tIsComplete \"id\",\n\t# price must be positive\n\tColumnValues \"price\" > 0\n]\n"
+		b = "# team b\nRules = [\n\tColumnValues \"price\" > 0.0,\n\tIsUnique \"id\",\n\tRowCount > 10\n]\n"
+	)
+	cases := []struct {
+		name string
+		opts []MergeOption
+		want string
+	}{
+		{
+			name: "default",
+			want: "# team a\n# team b\nRules = [\n\tIsComplete \"id\",\n\t# price must be positive\n\tColumnValues \"price\" > 0,\n\tIsUnique \"id\",\n\tRowCount > 10\n]\n",
+		},
+		{
+			name: "combine same column",
+			opts: []MergeOption{CombineSameColumn()},
+			want: "# team a\n# team b\nRules = [\n\t(IsComplete \"id\") and (IsUnique \"id\"),\n\t# price must be positive\n\tColumnValues \"price\" > 0,\n\tRowCount > 10\n]\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ra, err := parser.ParseRuleset(a)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rb, err := parser.ParseRuleset(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			merged := MergeRulesets(ra, rb, c.opts...)
+			var buf bytes.Buffer
+			if err := printer.Fprint(&buf, merged); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, buf.String()); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+			if len(ra.Rules) != 2 || len(rb.Rules) != 3 {
+				t.Error("the input rulesets were modified")
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package ast
+
+// Children は n の子ノードをソースの順に返します。
+// Children returns the child nodes of n in source order: the nodes of its
+// fields, and the comments of its comment groups as *Comment nodes. Nil
+// fields are skipped. A Comment has no children.
+//
+// Generic traversals use Children instead of a type switch over the node
+// types, so that they keep working when node types are added:
+//
+//	var walk func(n ast.Node)
+//	walk = func(n ast.Node) {
+//		visit(n)
+//		for _, c := range ast.Children(n) {
+//			walk(c)
+//		}
+//	}
+func Children(n Node) []Node {
+	var nodes []Node
+	add := func(children ...Node) {
+		for _, c := range children {
+			if c != nil && !isNilNode(c) {
+				nodes = append(nodes, c)
+			}
+		}
+	}
+	comments := func(g CommentGroup) {
+		for _, c := range g {
+			add(c)
+		}
+	}
+	switch n := n.(type) {
+	case CommentGroup:
+		comments(n)
+	case *File:
+		// detached comment groups lie between the rulesets.
+		groups := n.CommentGroups
+		for _, r := range n.Rulesets {
+			for len(groups) > 0 && groups[0].Pos().Before(r.Pos()) {
+				comments(groups[0])
+				groups = groups[1:]
+			}
+			add(r)
+		}
+		for _, g := range groups {
+			comments(g)
+		}
+	case *Ruleset:
+		comments(n.Description)
+		for _, r := range n.Rules {
+			add(r)
+		}
+		for _, g := range n.InnerComments {
+			comments(g)
+		}
+		comments(n.Comments)
+	case *Rule:
+		comments(n.Description)
+		add(n.Type)
+		for _, p := range n.Parameters {
+			add(p)
+		}
+		add(n.Expression)
+		comments(n.Comments)
+	case *CombinedRule:
+		comments(n.Description)
+		for _, r := range n.Rules {
+			add(r)
+		}
+		comments(n.Comments)
+	case *BadRule:
+		comments(n.Comments)
+	case *Ident:
+		comments(n.Comments)
+	case *StringParameter:
+		comments(n.Comments)
+	case *NumberParameter:
+		comments(n.Comments)
+	case *BoolParameter:
+		comments(n.Comments)
+	case *DurationParameter:
+		comments(n.Comments)
+	case *DateParameter:
+		add(n.Duration)
+		comments(n.Comments)
+	case *ComparisonExpression:
+		add(n.Right)
+		comments(n.Comments)
+	case *BetweenExpression:
+		add(n.Left, n.Right)
+		comments(n.Comments)
+	case *InExpression:
+		for _, v := range n.Values {
+			add(v)
+		}
+		comments(n.Comments)
+	case *MatchesExpression:
+		comments(n.Comments)
+	case *WithThresholdExpression:
+		add(n.Target, n.Threshold)
+		comments(n.Comments)
+	case *BadExpr:
+		comments(n.Comments)
+	}
+	return nodes
+}
+
+// isNilNode reports whether n holds a nil pointer.
+func isNilNode(n Node) bool {
+	switch n := n.(type) {
+	case *Ident:
+		return n == nil
+	case *Ruleset:
+		return n == nil
+	case *DurationParameter:
+		return n == nil
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+func TestChildren(t *testing.T) {
+	at := func(line, col int) token.Pos { return token.Pos{Index: line*100 + col, Line: line, Column: col} }
+	header := &Comment{SharpPos: at(1, 1), Text: "# header"}
+	footer := &Comment{SharpPos: at(5, 1), Text: "# footer"}
+	description := &Comment{SharpPos: at(3, 2), Text: "# rule"}
+	trailing := &Comment{SharpPos: at(4, 25), Text: "# trailing"}
+	typ := &Ident{NamePos: at(4, 2), Name: "ColumnValues"}
+	column := &StringParameter{LeftQuotePos: at(4, 15), RightQuotePos: at(4, 17), Value: "a"}
+	expr := &ComparisonExpression{ExprPos: at(4, 19), Operator: ">", Right: &NumberParameter{NumberPos: at(4, 21), Value: "0"}}
+	rule := &Rule{
+		Description: CommentGroup{description},
+		Type:        typ,
+		Parameters:  []Parameter{column},
+		Expression:  expr,
+		Comments:    CommentGroup{trailing},
+	}
+	ruleset := &Ruleset{DeclPos: at(2, 1), LeftBracketPos: at(2, 9), Rules: []RuleDecl{rule}, RightBracketPos: at(4, 40)}
+	file := &File{
+		CommentGroups: []CommentGroup{{header}, {footer}},
+		Rulesets:      []*Ruleset{ruleset},
+		EOF:           at(6, 1),
+	}
+
+	cases := []struct {
+		name string
+		node Node
+		want []Node
+	}{
+		{name: "file", node: file, want: []Node{header, ruleset, footer}},
+		{name: "ruleset", node: ruleset, want: []Node{rule}},
+		{name: "rule", node: rule, want: []Node{description, typ, column, expr, trailing}},
+		{name: "comparison", node: expr, want: []Node{expr.Right}},
+		{name: "comment group", node: CommentGroup{header, footer}, want: []Node{header, footer}},
+		{name: "comment", node: header, want: nil},
+		{name: "rule without type", node: &Rule{Parameters: []Parameter{column}}, want: []Node{column}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if diff := cmp.Diff(c.want, Children(c.node)); diff != "" {
+				t.Errorf("unexpected children (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
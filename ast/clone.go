@@ -0,0 +1,257 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// Clone はノードの深いコピーを返します。
+// Clone returns a deep copy of n, including its comments, so the copy can
+// be modified without affecting n. It returns nil for a nil node.
+func Clone(n Node) Node {
+	switch n := n.(type) {
+	case nil:
+		return nil
+	case *Comment:
+		return cloneComment(n)
+	case CommentGroup:
+		return cloneCommentGroup(n)
+	case *File:
+		return cloneFile(n)
+	case *Ruleset:
+		return cloneRuleset(n)
+	case *Rule:
+		return cloneRule(n)
+	case *CombinedRule:
+		return cloneCombinedRule(n)
+	case *BadRule:
+		return cloneRuleDecl(n)
+	case *Ident:
+		return cloneIdent(n)
+	case Parameter:
+		return cloneParameter(n)
+	case Expression:
+		return cloneExpression(n)
+	}
+	panic(fmt.Sprintf("ast: Clone: unexpected node type %T", n))
+}
+
+func cloneComment(c *Comment) *Comment {
+	if c == nil {
+		return nil
+	}
+	cp := *c
+	return &cp
+}
+
+func cloneCommentGroup(g CommentGroup) CommentGroup {
+	if g == nil {
+		return nil
+	}
+	cp := make(CommentGroup, len(g))
+	for i, c := range g {
+		cp[i] = cloneComment(c)
+	}
+	return cp
+}
+
+func cloneCommentGroups(groups []CommentGroup) []CommentGroup {
+	if groups == nil {
+		return nil
+	}
+	cp := make([]CommentGroup, len(groups))
+	for i, g := range groups {
+		cp[i] = cloneCommentGroup(g)
+	}
+	return cp
+}
+
+func clonePos(pos *token.Pos) *token.Pos {
+	if pos == nil {
+		return nil
+	}
+	cp := *pos
+	return &cp
+}
+
+func clonePositions(list []token.Pos) []token.Pos {
+	if list == nil {
+		return nil
+	}
+	return append([]token.Pos(nil), list...)
+}
+
+func cloneFile(f *File) *File {
+	if f == nil {
+		return nil
+	}
+	cp := *f
+	cp.CommentGroups = cloneCommentGroups(f.CommentGroups)
+	if f.Rulesets != nil {
+		cp.Rulesets = make([]*Ruleset, len(f.Rulesets))
+		for i, r := range f.Rulesets {
+			cp.Rulesets[i] = cloneRuleset(r)
+		}
+	}
+	return &cp
+}
+
+func cloneRuleset(r *Ruleset) *Ruleset {
+	if r == nil {
+		return nil
+	}
+	cp := *r
+	cp.Description = cloneCommentGroup(r.Description)
+	cp.InnerComments = cloneCommentGroups(r.InnerComments)
+	cp.Comments = cloneCommentGroup(r.Comments)
+	cp.CommaPositions = clonePositions(r.CommaPositions)
+	if r.Rules != nil {
+		cp.Rules = make([]RuleDecl, len(r.Rules))
+		for i, rule := range r.Rules {
+			cp.Rules[i] = cloneRuleDecl(rule)
+		}
+	}
+	return &cp
+}
+
+func cloneRuleDecl(rule RuleDecl) RuleDecl {
+	switch r := rule.(type) {
+	case *Rule:
+		return cloneRule(r)
+	case *CombinedRule:
+		return cloneCombinedRule(r)
+	case *BadRule:
+		cp := *r
+		cp.Comments = cloneCommentGroup(r.Comments)
+		return &cp
+	}
+	return rule
+}
+
+func cloneRule(r *Rule) *Rule {
+	if r == nil {
+		return nil
+	}
+	cp := *r
+	cp.Description = cloneCommentGroup(r.Description)
+	cp.Type = cloneIdent(r.Type)
+	if r.Parameters != nil {
+		cp.Parameters = make([]Parameter, len(r.Parameters))
+		for i, param := range r.Parameters {
+			cp.Parameters[i] = cloneParameter(param)
+		}
+	}
+	cp.Expression = cloneExpression(r.Expression)
+	cp.Comments = cloneCommentGroup(r.Comments)
+	return &cp
+}
+
+func cloneCombinedRule(r *CombinedRule) *CombinedRule {
+	if r == nil {
+		return nil
+	}
+	cp := *r
+	cp.Description = cloneCommentGroup(r.Description)
+	if r.Rules != nil {
+		cp.Rules = make([]*Rule, len(r.Rules))
+		for i, rule := range r.Rules {
+			cp.Rules[i] = cloneRule(rule)
+		}
+	}
+	cp.OperatorPositions = clonePositions(r.OperatorPositions)
+	cp.Comments = cloneCommentGroup(r.Comments)
+	return &cp
+}
+
+func cloneIdent(x *Ident) *Ident {
+	if x == nil {
+		return nil
+	}
+	cp := *x
+	cp.Comments = cloneCommentGroup(x.Comments)
+	return &cp
+}
+
+func cloneParameter(param Parameter) Parameter {
+	switch x := param.(type) {
+	case *StringParameter:
+		cp := *x
+		cp.Comments = cloneCommentGroup(x.Comments)
+		return &cp
+	case *NumberParameter:
+		cp := *x
+		cp.Comments = cloneCommentGroup(x.Comments)
+		return &cp
+	case *BoolParameter:
+		cp := *x
+		cp.Comments = cloneCommentGroup(x.Comments)
+		return &cp
+	case *DurationParameter:
+		return cloneDuration(x)
+	case *DateParameter:
+		cp := *x
+		cp.LeftParenPos = clonePos(x.LeftParenPos)
+		cp.RightParenPos = clonePos(x.RightParenPos)
+		cp.MinusPos = clonePos(x.MinusPos)
+		cp.Duration = cloneDuration(x.Duration)
+		cp.Comments = cloneCommentGroup(x.Comments)
+		return &cp
+	}
+	return param
+}
+
+func cloneDuration(x *DurationParameter) *DurationParameter {
+	if x == nil {
+		return nil
+	}
+	cp := *x
+	cp.Comments = cloneCommentGroup(x.Comments)
+	return &cp
+}
+
+func cloneExpression(expr Expression) Expression {
+	switch x := expr.(type) {
+	case *ComparisonExpression:
+		cp := *x
+		cp.Right = cloneParameter(x.Right)
+		cp.Comments = cloneCommentGroup(x.Comments)
+		return &cp
+	case *BetweenExpression:
+		cp := *x
+		cp.Left = cloneParameter(x.Left)
+		cp.Right = cloneParameter(x.Right)
+		cp.Comments = cloneCommentGroup(x.Comments)
+		return &cp
+	case *InExpression:
+		cp := *x
+		if x.Values != nil {
+			cp.Values = make([]Parameter, len(x.Values))
+			for i, v := range x.Values {
+				cp.Values[i] = cloneParameter(v)
+			}
+		}
+		cp.CommaPositions = clonePositions(x.CommaPositions)
+		cp.Comments = cloneCommentGroup(x.Comments)
+		return &cp
+	case *MatchesExpression:
+		cp := *x
+		cp.Comments = cloneCommentGroup(x.Comments)
+		return &cp
+	case *WithThresholdExpression:
+		cp := *x
+		if x.Target != nil {
+			cp.Target = cloneExpression(x.Target).(ThresholdTarget)
+		}
+		if x.Threshold != nil {
+			cp.Threshold = cloneExpression(x.Threshold).(ThresholdExpression)
+		}
+		cp.Comments = cloneCommentGroup(x.Comments)
+		return &cp
+	case *BadExpr:
+		cp := *x
+		cp.Comments = cloneCommentGroup(x.Comments)
+		return &cp
+	}
+	return expr
+}
@@ -0,0 +1,98 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+func TestClone(t *testing.T) {
+	pos := func(col int) token.Pos { return token.Pos{Index: col - 1, Line: 1, Column: col} }
+	left, minus, right := pos(30), pos(37), pos(46)
+	comment := func(text string) CommentGroup { return CommentGroup{{SharpPos: pos(1), Text: text}} }
+	ruleset := &Ruleset{
+		Description:    comment("# ruleset"),
+		DeclPos:        pos(1),
+		LeftBracketPos: pos(9),
+		Rules: []RuleDecl{
+			&Rule{
+				Description: comment("# rule"),
+				Type:        &Ident{NamePos: pos(11), Name: "ColumnValues"},
+				Parameters:  []Parameter{&StringParameter{LeftQuotePos: pos(24), RightQuotePos: pos(26), Value: "a"}},
+				Expression: &ComparisonExpression{
+					ExprPos:  pos(28),
+					Operator: ">",
+					Right: &DateParameter{
+						LeftParenPos:  &left,
+						NowPos:        pos(31),
+						MinusPos:      &minus,
+						Duration:      &DurationParameter{NumberPos: pos(39), UnitPos: pos(41), Value: "3 days", Number: "3", Unit: "days"},
+						RightParenPos: &right,
+					},
+				},
+				Comments: comment("# trailing"),
+			},
+			&CombinedRule{
+				Operator: "or",
+				Rules: []*Rule{
+					{
+						Type: &Ident{Name: "ColumnValues"},
+						Parameters: []Parameter{
+							&StringParameter{Value: "b"},
+						},
+						Expression: &WithThresholdExpression{
+							Target:    &InExpression{Values: []Parameter{&NumberParameter{Value: "1"}, &BoolParameter{Value: true}}},
+							Threshold: &BetweenExpression{Left: &NumberParameter{Value: "0.5"}, Right: &NumberParameter{Value: "1"}},
+						},
+					},
+					{
+						Type:       &Ident{Name: "ColumnValues"},
+						Parameters: []Parameter{&StringParameter{Value: "c"}},
+						Expression: &MatchesExpression{Value: "[a-z]+"},
+					},
+				},
+			},
+		},
+		InnerComments: []CommentGroup{comment("# inner")},
+		Comments:      comment("# after"),
+	}
+	cloned := Clone(ruleset)
+	if diff := cmp.Diff(ruleset, cloned); diff != "" {
+		t.Fatalf("(-original, +clone)\n%s", diff)
+	}
+	original := make(map[Node]bool)
+	var collect func(n Node)
+	collect = func(n Node) {
+		original[n] = true
+		for _, c := range Children(n) {
+			collect(c)
+		}
+	}
+	collect(ruleset)
+	var check func(n Node)
+	check = func(n Node) {
+		if original[n] {
+			t.Errorf("%T is shared with the original", n)
+		}
+		for _, c := range Children(n) {
+			check(c)
+		}
+	}
+	check(cloned)
+	date := cloned.(*Ruleset).Rules[0].(*Rule).Expression.(*ComparisonExpression).Right.(*DateParameter)
+	if date.LeftParenPos == &left || date.MinusPos == &minus || date.RightParenPos == &right {
+		t.Error("the positions of DateParameter are shared with the original")
+	}
+	file := &File{Filename: "a.dqdl", CommentGroups: []CommentGroup{comment("# file")}, Rulesets: []*Ruleset{ruleset}, EOF: pos(48)}
+	clonedFile := Clone(file).(*File)
+	if diff := cmp.Diff(file, clonedFile); diff != "" {
+		t.Fatalf("(-original, +clone)\n%s", diff)
+	}
+	if clonedFile.Rulesets[0] == ruleset || clonedFile.CommentGroups[0][0] == file.CommentGroups[0][0] {
+		t.Error("the rulesets and comments of File are shared with the original")
+	}
+	if Clone(nil) != nil {
+		t.Error("Clone(nil) should be nil")
+	}
+}
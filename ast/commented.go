@@ -0,0 +1,63 @@
+package ast
+
+// Commented はコメントを持つことができるノードです。
+// A Commented node carries line comments in its Comments field. The
+// methods give access to the field without a type switch over the node
+// types; they are not named Comments, since the field is.
+//
+// Every node type but Comment, CommentGroup and File is Commented. The
+// descriptions of rules and rulesets and the inner comments of rulesets
+// are not line comments, see CommentMap for all the comments of a node.
+type Commented interface {
+	Node
+	LineComments() CommentGroup
+	SetLineComments(CommentGroup)
+}
+
+func (n *Ruleset) LineComments() CommentGroup     { return n.Comments }
+func (n *Ruleset) SetLineComments(g CommentGroup) { n.Comments = g }
+
+func (n *Rule) LineComments() CommentGroup     { return n.Comments }
+func (n *Rule) SetLineComments(g CommentGroup) { n.Comments = g }
+
+func (n *CombinedRule) LineComments() CommentGroup     { return n.Comments }
+func (n *CombinedRule) SetLineComments(g CommentGroup) { n.Comments = g }
+
+func (n *BadRule) LineComments() CommentGroup     { return n.Comments }
+func (n *BadRule) SetLineComments(g CommentGroup) { n.Comments = g }
+
+func (n *Ident) LineComments() CommentGroup     { return n.Comments }
+func (n *Ident) SetLineComments(g CommentGroup) { n.Comments = g }
+
+func (n *StringParameter) LineComments() CommentGroup     { return n.Comments }
+func (n *StringParameter) SetLineComments(g CommentGroup) { n.Comments = g }
+
+func (n *NumberParameter) LineComments() CommentGroup     { return n.Comments }
+func (n *NumberParameter) SetLineComments(g CommentGroup) { n.Comments = g }
+
+func (n *BoolParameter) LineComments() CommentGroup     { return n.Comments }
+func (n *BoolParameter) SetLineComments(g CommentGroup) { n.Comments = g }
+
+func (n *DurationParameter) LineComments() CommentGroup     { return n.Comments }
+func (n *DurationParameter) SetLineComments(g CommentGroup) { n.Comments = g }
+
+func (n *DateParameter) LineComments() CommentGroup     { return n.Comments }
+func (n *DateParameter) SetLineComments(g CommentGroup) { n.Comments = g }
+
+func (n *ComparisonExpression) LineComments() CommentGroup     { return n.Comments }
+func (n *ComparisonExpression) SetLineComments(g CommentGroup) { n.Comments = g }
+
+func (n *BetweenExpression) LineComments() CommentGroup     { return n.Comments }
+func (n *BetweenExpression) SetLineComments(g CommentGroup) { n.Comments = g }
+
+func (n *InExpression) LineComments() CommentGroup     { return n.Comments }
+func (n *InExpression) SetLineComments(g CommentGroup) { n.Comments = g }
+
+func (n *MatchesExpression) LineComments() CommentGroup     { return n.Comments }
+func (n *MatchesExpression) SetLineComments(g CommentGroup) { n.Comments = g }
+
+func (n *WithThresholdExpression) LineComments() CommentGroup     { return n.Comments }
+func (n *WithThresholdExpression) SetLineComments(g CommentGroup) { n.Comments = g }
+
+func (n *BadExpr) LineComments() CommentGroup     { return n.Comments }
+func (n *BadExpr) SetLineComments(g CommentGroup) { n.Comments = g }
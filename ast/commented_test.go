@@ -0,0 +1,51 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+func TestCommented(t *testing.T) {
+	nodes := []Node{
+		&Ruleset{},
+		&Rule{},
+		&CombinedRule{},
+		&BadRule{},
+		&Ident{},
+		&StringParameter{},
+		&NumberParameter{},
+		&BoolParameter{},
+		&DurationParameter{},
+		&DateParameter{},
+		&ComparisonExpression{},
+		&BetweenExpression{},
+		&InExpression{},
+		&MatchesExpression{},
+		&WithThresholdExpression{},
+		&BadExpr{},
+	}
+	for _, n := range nodes {
+		c, ok := n.(Commented)
+		if !ok {
+			t.Errorf("%T is not Commented", n)
+			continue
+		}
+		group := CommentGroup{{SharpPos: token.Pos{Index: 0, Line: 1, Column: 1}, Text: "# note"}}
+		c.SetLineComments(group)
+		if diff := cmp.Diff(group, c.LineComments()); diff != "" {
+			t.Errorf("%T: LineComments after SetLineComments (-want +got):\n%s", n, diff)
+		}
+		// the line comments are the Comments field, the last group of a node.
+		groups := commentGroups(n)
+		if diff := cmp.Diff(group, groups[len(groups)-1]); diff != "" {
+			t.Errorf("%T: Comments field after SetLineComments (-want +got):\n%s", n, diff)
+		}
+	}
+	for _, n := range []Node{&Comment{}, CommentGroup{}, &File{}} {
+		if _, ok := n.(Commented); ok {
+			t.Errorf("%T should not be Commented", n)
+		}
+	}
+}
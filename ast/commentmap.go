@@ -0,0 +1,119 @@
+package ast
+
+import "sort"
+
+// CommentMap はノードとそのノードに付属するコメントの塊の対応です。
+// A CommentMap maps a node to the comment groups attached to it, gathering
+// in one place the Description, Comments and InnerComments fields of the
+// nodes. The detached comment groups of a file, in
+// File.CommentGroups, belong to no node and are not in the map.
+//
+// The map is a snapshot: editing it does not change the fields of the
+// nodes. Rewriters use it to keep track of the comments of the nodes they
+// replace, see Update.
+type CommentMap map[Node][]CommentGroup
+
+// NewCommentMap はファイルの全てのノードについてコメントの対応を作ります。
+// NewCommentMap returns the comment map of every node of file. Nodes
+// without comments are not in the map.
+func NewCommentMap(file *File) CommentMap {
+	cmap := make(CommentMap)
+	for _, ruleset := range file.Rulesets {
+		cmap.collect(ruleset)
+	}
+	return cmap
+}
+
+func (cmap CommentMap) add(n Node, groups ...CommentGroup) {
+	for _, g := range groups {
+		if len(g) > 0 {
+			cmap[n] = append(cmap[n], g)
+		}
+	}
+}
+
+// collect adds the comment groups of n and of the nodes below it.
+func (cmap CommentMap) collect(n Node) {
+	cmap.add(n, commentGroups(n)...)
+	for _, child := range Children(n) {
+		if _, ok := child.(*Comment); !ok {
+			cmap.collect(child)
+		}
+	}
+}
+
+// commentGroups returns the comment groups stored in the fields of n.
+func commentGroups(n Node) []CommentGroup {
+	switch n := n.(type) {
+	case *Ruleset:
+		groups := append([]CommentGroup{n.Description}, n.InnerComments...)
+		return append(groups, n.Comments)
+	case *Rule:
+		return []CommentGroup{n.Description, n.Comments}
+	case *CombinedRule:
+		return []CommentGroup{n.Description, n.Comments}
+	case Commented:
+		return []CommentGroup{n.LineComments()}
+	}
+	return nil
+}
+
+// Update は old のコメントを new に付け替えます。
+// Update moves the comment groups of old, if any, to new and returns new.
+// Use it when a rewrite replaces old by new.
+func (cmap CommentMap) Update(old, new Node) Node {
+	if groups := cmap[old]; len(groups) > 0 {
+		delete(cmap, old)
+		cmap[new] = append(cmap[new], groups...)
+	}
+	return new
+}
+
+// Filter は node 以下のノードのコメントの対応を返します。
+// Filter returns a new comment map holding the entries of cmap for node
+// and the nodes below it.
+func (cmap CommentMap) Filter(node Node) CommentMap {
+	umap := make(CommentMap)
+	var visit func(n Node)
+	visit = func(n Node) {
+		if groups, ok := cmap[n]; ok {
+			umap[n] = groups
+		}
+		for _, child := range Children(n) {
+			if _, ok := child.(*Comment); !ok {
+				visit(child)
+			}
+		}
+	}
+	visit(node)
+	return umap
+}
+
+// Comments はコメントの塊を位置の順に返します。
+// Comments returns the comment groups of cmap sorted by position. A group
+// attached to several nodes is returned once.
+func (cmap CommentMap) Comments() []CommentGroup {
+	type key struct {
+		first *Comment
+		n     int
+	}
+	seen := make(map[key]bool)
+	var groups []CommentGroup
+	for _, list := range cmap {
+		for _, g := range list {
+			k := key{g[0], len(g)}
+			if !seen[k] {
+				seen[k] = true
+				groups = append(groups, g)
+			}
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		a, b := groups[i].Pos(), groups[j].Pos()
+		if a.Index != b.Index {
+			return a.Index < b.Index
+		}
+		return a.Before(b)
+	})
+	return groups
+}
@@ -0,0 +1,56 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+func TestCommentMap(t *testing.T) {
+	comment := func(line int, text string) *Comment {
+		return &Comment{SharpPos: token.Pos{Index: line * 100, Line: line, Column: 1}, Text: text}
+	}
+	header := CommentGroup{comment(1, "# header")}
+	description := CommentGroup{comment(3, "# orders")}
+	ruleDescription := CommentGroup{comment(5, "# ids")}
+	trailing := CommentGroup{comment(6, "# trailing")}
+	param := CommentGroup{comment(7, "# column")}
+	footer := CommentGroup{comment(9, "# footer")}
+
+	column := &StringParameter{Value: "id", Comments: param}
+	rule := &Rule{Description: ruleDescription, Type: &Ident{Name: "IsComplete"}, Parameters: []Parameter{column}, Comments: trailing}
+	plain := newTestRule("RowCount")
+	ruleset := &Ruleset{Description: description, Rules: []RuleDecl{rule, plain}, Comments: footer}
+	file := &File{CommentGroups: []CommentGroup{header}, Rulesets: []*Ruleset{ruleset}}
+
+	cmap := NewCommentMap(file)
+	want := CommentMap{
+		ruleset: {description, footer},
+		rule:    {ruleDescription, trailing},
+		column:  {param},
+	}
+	if diff := cmp.Diff(want, cmap); diff != "" {
+		t.Errorf("NewCommentMap (-want, +got)\n%s", diff)
+	}
+	if diff := cmp.Diff([]CommentGroup{description, ruleDescription, trailing, param, footer}, cmap.Comments()); diff != "" {
+		t.Errorf("Comments (-want, +got)\n%s", diff)
+	}
+	if diff := cmp.Diff(CommentMap{rule: {ruleDescription, trailing}, column: {param}}, cmap.Filter(rule)); diff != "" {
+		t.Errorf("Filter (-want, +got)\n%s", diff)
+	}
+
+	replacement := newTestRule("IsUnique")
+	if got := cmap.Update(rule, replacement); got != replacement {
+		t.Errorf("Update returned %v, want the new node", got)
+	}
+	if _, ok := cmap[rule]; ok {
+		t.Error("the old node is still in the map")
+	}
+	if diff := cmp.Diff([]CommentGroup{ruleDescription, trailing}, cmap[replacement]); diff != "" {
+		t.Errorf("Update (-want, +got)\n%s", diff)
+	}
+	if got := cmap.Update(plain, rule); got != rule || len(cmap[rule]) != 0 {
+		t.Errorf("Update of a node without comments changed the map: %v", cmap[rule])
+	}
+}
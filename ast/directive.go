@@ -0,0 +1,125 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Directives はコメント中のディレクティブをキーと値の組にして返します。
+// Directives returns the directives written in the comments of g, mapping
+// each key to its value. Two forms are recognized:
+//
+//	# @severity: critical
+//	#dqdl:owner team-data
+//
+// The value is the rest of the comment after the key and an optional ":",
+// with spaces trimmed; it is empty for a bare key. A later directive with
+// the same key overrides an earlier one. Directives returns nil if g has
+// no directives.
+func (g CommentGroup) Directives() map[string]string {
+	var directives map[string]string
+	for _, c := range g {
+		key, value, ok := c.Directive()
+		if !ok {
+			continue
+		}
+		if directives == nil {
+			directives = make(map[string]string)
+		}
+		directives[key] = value
+	}
+	return directives
+}
+
+// Directive はコメントがディレクティブであればそのキーと値を返します。
+// Directive returns the key and the value of c if it is a directive, see
+// CommentGroup.Directives.
+func (c *Comment) Directive() (key, value string, ok bool) {
+	text := strings.TrimPrefix(c.Text, "#")
+	switch {
+	case strings.HasPrefix(text, "dqdl:"):
+		text = text[len("dqdl:"):]
+	case strings.HasPrefix(strings.TrimSpace(text), "@"):
+		text = strings.TrimSpace(text)[1:]
+	default:
+		return "", "", false
+	}
+	end := strings.IndexAny(text, ": \t")
+	if end < 0 {
+		end = len(text)
+	}
+	key = text[:end]
+	if key == "" {
+		return "", "", false
+	}
+	value = strings.TrimSpace(text[end:])
+	value = strings.TrimSpace(strings.TrimPrefix(value, ":"))
+	return key, value, true
+}
+
+// Directives はルールの説明コメント中のディレクティブを返します。
+// Directives returns the directives in the description of r, see
+// CommentGroup.Directives.
+func (r *Rule) Directives() map[string]string {
+	return r.Description.Directives()
+}
+
+// Directives はルールの説明コメント中のディレクティブを返します。
+// Directives returns the directives in the description of r, see
+// CommentGroup.Directives.
+func (r *CombinedRule) Directives() map[string]string {
+	return r.Description.Directives()
+}
+
+// Name はルールセットの名前を返します。
+// Name returns the name of d given by a `#dqdl:name` or `# @name:`
+// directive in its description, or "" if d has no name:
+//
+//	#dqdl:name orders
+//	Rules = [ ... ]
+func (d *Ruleset) Name() string {
+	return d.Description.Directives()["name"]
+}
+
+// SnoozeUntil はルールの説明コメント中の snooze-until ディレクティブの日付を返します。
+// SnoozeUntil returns the date of the snooze-until directive in the
+// description of decl, which disables a rule for a while instead of
+// deleting it, e.g. during an incident:
+//
+//	#dqdl:snooze-until 2025-01-31
+//	IsComplete "id"
+//
+// The date is in the form YYYY-MM-DD and in UTC; the rule is snoozed until
+// the end of that day. ok is false if decl has no such directive, and err
+// is not nil if the date is invalid.
+func SnoozeUntil(decl RuleDecl) (until time.Time, ok bool, err error) {
+	var directives map[string]string
+	switch r := decl.(type) {
+	case *Rule:
+		directives = r.Directives()
+	case *CombinedRule:
+		directives = r.Directives()
+	}
+	value, ok := directives["snooze-until"]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	until, err = time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, true, fmt.Errorf("ast: invalid snooze-until date %q, want YYYY-MM-DD", value)
+	}
+	return until, true, nil
+}
+
+// Snoozed は now の時点でルールが snooze-until ディレクティブにより無効かどうかを返します。
+// Snoozed reports whether decl is snoozed at now by its snooze-until
+// directive, that is, whether now is before the end of the date. See
+// SnoozeUntil for the errors.
+func Snoozed(decl RuleDecl, now time.Time) (bool, error) {
+	until, ok, err := SnoozeUntil(decl)
+	if !ok || err != nil {
+		return false, err
+	}
+	return now.Before(until.AddDate(0, 0, 1)), nil
+}
@@ -0,0 +1,86 @@
+package ast
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCommentGroup__Directives(t *testing.T) {
+	cases := []struct {
+		name     string
+		comments []string
+		want     map[string]string
+	}{
+		{
+			name:     "none",
+			comments: []string{"# order ids are unique", "# see the spec"},
+		},
+		{
+			name:     "at",
+			comments: []string{"# order ids are unique", "# @severity: critical", "#@owner team-data"},
+			want:     map[string]string{"severity": "critical", "owner": "team-data"},
+		},
+		{
+			name:     "dqdl",
+			comments: []string{"#dqdl:owner team-data", "#dqdl:experimental", "#dqdl:severity:warning"},
+			want:     map[string]string{"owner": "team-data", "experimental": "", "severity": "warning"},
+		},
+		{
+			name:     "not directives",
+			comments: []string{"# dqdl:owner team-data", "# @", "# mail me@example.com"},
+		},
+		{
+			name:     "override",
+			comments: []string{"# @severity: warning", "# @severity: critical"},
+			want:     map[string]string{"severity": "critical"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var g CommentGroup
+			for _, text := range c.comments {
+				g = append(g, &Comment{Text: text})
+			}
+			rule := &Rule{Description: g}
+			if diff := cmp.Diff(c.want, rule.Directives()); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSnoozed(t *testing.T) {
+	now := time.Date(2025, 1, 31, 23, 59, 0, 0, time.UTC)
+	cases := []struct {
+		name    string
+		comment string
+		want    bool
+		errStr  string
+	}{
+		{name: "no directive", comment: "# order ids"},
+		{name: "until today", comment: "#dqdl:snooze-until 2025-01-31", want: true},
+		{name: "until later", comment: "# @snooze-until: 2025-02-01", want: true},
+		{name: "expired", comment: "#dqdl:snooze-until 2025-01-30"},
+		{name: "invalid date", comment: "#dqdl:snooze-until next week", errStr: `ast: invalid snooze-until date "next week", want YYYY-MM-DD`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rule := &Rule{Description: CommentGroup{{Text: c.comment}}}
+			got, err := Snoozed(rule, now)
+			if c.errStr != "" {
+				if err == nil || err.Error() != c.errStr {
+					t.Fatalf("got error %v, want %s", err, c.errStr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package ast
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// EqualOption は Equal の比較方法を変更します。go-cmp の cmp.Option と同じものです。
+// An EqualOption configures Equal. It is a go-cmp option, so the options
+// below can be passed to cmp.Diff and cmp.Equal as well.
+type EqualOption = cmp.Option
+
+var (
+	// IgnorePositions は位置を無視して比較します。
+	// IgnorePositions ignores all positions, including the optional ones of
+	// a DateParameter and the lists of separator positions.
+	IgnorePositions EqualOption = cmpopts.IgnoreTypes(token.Pos{}, &token.Pos{}, []token.Pos{})
+
+	// IgnoreComments はコメントを無視して比較します。
+	// IgnoreComments ignores all comments: descriptions, line comments and
+	// the free-standing comments of files and rulesets.
+	IgnoreComments EqualOption = cmpopts.IgnoreTypes(CommentGroup{}, []CommentGroup{})
+
+	// IgnoreSource はファイルの元のソーステキストを無視して比較します。
+	// IgnoreSource ignores the Source of files, which differs between
+	// files that only differ in layout.
+	IgnoreSource EqualOption = cmpopts.IgnoreFields(File{}, "Source")
+)
+
+// Equal は2つのノードが等しいかどうかを返します。
+// Equal reports whether the nodes a and b are deeply equal. By default
+// everything is compared; pass IgnorePositions, IgnoreComments and
+// IgnoreSource to compare the semantic content only.
+func Equal(a, b Node, opts ...EqualOption) bool {
+	return cmp.Equal(a, b, opts...)
+}
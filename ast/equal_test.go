@@ -0,0 +1,60 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+func TestEqual(t *testing.T) {
+	pos := func(col int) token.Pos { return token.Pos{Index: col - 1, Line: 1, Column: col} }
+	rule := func(offset int, description CommentGroup) *Rule {
+		left := pos(offset + 21)
+		return &Rule{
+			Description: description,
+			Type:        &Ident{NamePos: pos(offset + 1), Name: "ColumnValues"},
+			Parameters: []Parameter{
+				&StringParameter{LeftQuotePos: pos(offset + 14), RightQuotePos: pos(offset + 18), Value: "col"},
+			},
+			Expression: &ComparisonExpression{
+				ExprPos:  pos(offset + 20),
+				Operator: ">=",
+				Right:    &DateParameter{LeftParenPos: &left, NowPos: pos(offset + 22)},
+			},
+		}
+	}
+	comment := CommentGroup{{SharpPos: pos(1), Text: "# description"}}
+	cases := []struct {
+		name string
+		a, b Node
+		opts []EqualOption
+		want bool
+	}{
+		{name: "identical", a: rule(0, comment), b: rule(0, comment), want: true},
+		{name: "positions", a: rule(0, nil), b: rule(2, nil)},
+		{name: "ignore positions", a: rule(0, nil), b: rule(2, nil), opts: []EqualOption{IgnorePositions}, want: true},
+		{name: "comments", a: rule(0, comment), b: rule(0, nil)},
+		{name: "ignore comments", a: rule(0, comment), b: rule(0, nil), opts: []EqualOption{IgnoreComments}, want: true},
+		{
+			name: "values",
+			a:    rule(0, nil),
+			b:    &Rule{Type: &Ident{Name: "IsComplete"}},
+			opts: []EqualOption{IgnorePositions, IgnoreComments},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := Equal(c.a, c.b, c.opts...); got != c.want {
+				t.Errorf("got %v, want %v\n%s", got, c.want, cmp.Diff(c.a, c.b, c.opts...))
+			}
+		})
+	}
+	t.Run("source", func(t *testing.T) {
+		a := &File{Source: "Rules = [ IsComplete \"a\" ]"}
+		b := &File{Source: "Rules = [\n  IsComplete \"a\"\n]"}
+		if diff := cmp.Diff(a, b, IgnoreSource); diff != "" {
+			t.Errorf("(-a, +b)\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package ast
+
+import (
+	"fmt"
+	"math"
+)
+
+// Eval は観測値が比較を満たすか判定します。
+// Eval reports whether the observed value satisfies the comparison, e.g.
+// whether 12 satisfies `> 10`. The right operand must be a number. NaN
+// satisfies no comparison.
+func (x *ComparisonExpression) Eval(observed float64) (bool, error) {
+	want, err := number(x.Right)
+	if err != nil {
+		return false, err
+	}
+	if math.IsNaN(observed) {
+		return false, nil
+	}
+	switch x.Operator {
+	case "=":
+		return observed == want, nil
+	case ">":
+		return observed > want, nil
+	case ">=":
+		return observed >= want, nil
+	case "<":
+		return observed < want, nil
+	case "<=":
+		return observed <= want, nil
+	}
+	return false, fmt.Errorf("ast: unknown operator %q", x.Operator)
+}
+
+// Eval は観測値が範囲内にあるか判定します。
+// Eval reports whether the observed value is between the bounds. Both
+// bounds are inclusive and must be numbers.
+func (x *BetweenExpression) Eval(observed float64) (bool, error) {
+	lo, err := number(x.Left)
+	if err != nil {
+		return false, err
+	}
+	hi, err := number(x.Right)
+	if err != nil {
+		return false, err
+	}
+	return lo <= observed && observed <= hi, nil
+}
+
+// Eval は対象の式を満たす値の割合が閾値を満たすか判定します。
+// Eval reports whether ratio, the fraction of the values that satisfy the
+// target, satisfies the threshold, e.g. whether 0.95 satisfies
+// `with threshold > 0.9`. It fails if ratio is not in [0, 1].
+func (x *WithThresholdExpression) Eval(ratio float64) (bool, error) {
+	if !(0 <= ratio && ratio <= 1) {
+		return false, fmt.Errorf("ast: ratio %v is out of range [0, 1]", ratio)
+	}
+	switch t := x.Threshold.(type) {
+	case nil:
+		return false, fmt.Errorf("ast: missing threshold")
+	case *ComparisonExpression:
+		return t.Eval(ratio)
+	case *BetweenExpression:
+		return t.Eval(ratio)
+	}
+	return false, fmt.Errorf("ast: unsupported threshold %T", x.Threshold)
+}
+
+// number returns the value of a number parameter.
+func number(param Parameter) (float64, error) {
+	n, ok := param.(*NumberParameter)
+	switch {
+	case ok && n != nil:
+		return n.Float64()
+	case ok || param == nil:
+		return 0, fmt.Errorf("ast: missing number")
+	}
+	return 0, fmt.Errorf("ast: %s: %T is not a number", param.Pos(), param)
+}
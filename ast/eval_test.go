@@ -0,0 +1,76 @@
+package ast
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mashiike/go-dqdl/token"
+)
+
+func TestExpression__Eval(t *testing.T) {
+	num := func(v string) *NumberParameter { return &NumberParameter{Value: v} }
+	cases := []struct {
+		name     string
+		eval     func(float64) (bool, error)
+		observed float64
+		want     bool
+		errStr   string
+	}{
+		{name: "greater", eval: (&ComparisonExpression{Operator: ">", Right: num("10")}).Eval, observed: 12, want: true},
+		{name: "not greater", eval: (&ComparisonExpression{Operator: ">", Right: num("10")}).Eval, observed: 10, want: false},
+		{name: "greater equal", eval: (&ComparisonExpression{Operator: ">=", Right: num("10")}).Eval, observed: 10, want: true},
+		{name: "less", eval: (&ComparisonExpression{Operator: "<", Right: num("0.5")}).Eval, observed: 0.4, want: true},
+		{name: "less equal", eval: (&ComparisonExpression{Operator: "<=", Right: num("0.5")}).Eval, observed: 0.6, want: false},
+		{name: "equal", eval: (&ComparisonExpression{Operator: "=", Right: num("3")}).Eval, observed: 3, want: true},
+		{name: "NaN", eval: (&ComparisonExpression{Operator: "<=", Right: num("3")}).Eval, observed: math.NaN(), want: false},
+		{
+			name:   "not a number",
+			eval:   (&ComparisonExpression{Operator: "=", Right: &StringParameter{Value: "a", LeftQuotePos: token.Pos{Line: 1, Column: 17}}}).Eval,
+			errStr: "ast: 1:17: *ast.StringParameter is not a number",
+		},
+		{name: "unknown operator", eval: (&ComparisonExpression{Operator: "!=", Right: num("3")}).Eval, errStr: `ast: unknown operator "!="`},
+		{name: "between lower bound", eval: (&BetweenExpression{Left: num("1"), Right: num("10")}).Eval, observed: 1, want: true},
+		{name: "between upper bound", eval: (&BetweenExpression{Left: num("1"), Right: num("10")}).Eval, observed: 10, want: true},
+		{name: "not between", eval: (&BetweenExpression{Left: num("1"), Right: num("10")}).Eval, observed: 10.5, want: false},
+		{
+			name:     "threshold",
+			eval:     (&WithThresholdExpression{Threshold: &ComparisonExpression{Operator: ">", Right: num("0.9")}}).Eval,
+			observed: 0.95,
+			want:     true,
+		},
+		{
+			name:     "threshold between",
+			eval:     (&WithThresholdExpression{Threshold: &BetweenExpression{Left: num("0.1"), Right: num("0.2")}}).Eval,
+			observed: 0.3,
+			want:     false,
+		},
+		{
+			name:     "threshold out of range",
+			eval:     (&WithThresholdExpression{Threshold: &ComparisonExpression{Operator: ">", Right: num("0.9")}}).Eval,
+			observed: 1.5,
+			errStr:   "ast: ratio 1.5 is out of range [0, 1]",
+		},
+		{
+			name:   "missing threshold",
+			eval:   (&WithThresholdExpression{}).Eval,
+			errStr: "ast: missing threshold",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := c.eval(c.observed)
+			if c.errStr != "" {
+				if err == nil || err.Error() != c.errStr {
+					t.Fatalf("got error %v, want %s", err, c.errStr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
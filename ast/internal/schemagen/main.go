@@ -0,0 +1,194 @@
+// Command schemagen generates the JSON Schema of the ast package from its
+// Go types. It is run by go generate in the ast package:
+//
+//	go generate ./ast
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// nodes lists every node type with the kind it marshals with.
+var nodes = []struct {
+	kind string
+	node interface{}
+}{
+	{"File", ast.File{}},
+	{"Ruleset", ast.Ruleset{}},
+	{"Comment", ast.Comment{}},
+	{"Rule", ast.Rule{}},
+	{"CombinedRule", ast.CombinedRule{}},
+	{"BadRule", ast.BadRule{}},
+	{"Ident", ast.Ident{}},
+	{"StringParameter", ast.StringParameter{}},
+	{"NumberParameter", ast.NumberParameter{}},
+	{"BoolParameter", ast.BoolParameter{}},
+	{"DurationParameter", ast.DurationParameter{}},
+	{"DateParameter", ast.DateParameter{}},
+	{"ComparisonExpression", ast.ComparisonExpression{}},
+	{"BetweenExpression", ast.BetweenExpression{}},
+	{"InExpression", ast.InExpression{}},
+	{"MatchesExpression", ast.MatchesExpression{}},
+	{"WithThresholdExpression", ast.WithThresholdExpression{}},
+	{"BadExpr", ast.BadExpr{}},
+}
+
+// interfaces lists the interface types of the ast package and the kinds that implement them.
+var interfaces = []struct {
+	name  string
+	iface reflect.Type
+}{
+	{"RuleDecl", reflect.TypeOf((*ast.RuleDecl)(nil)).Elem()},
+	{"Parameter", reflect.TypeOf((*ast.Parameter)(nil)).Elem()},
+	{"Expression", reflect.TypeOf((*ast.Expression)(nil)).Elem()},
+	{"ThresholdTarget", reflect.TypeOf((*ast.ThresholdTarget)(nil)).Elem()},
+	{"ThresholdExpression", reflect.TypeOf((*ast.ThresholdExpression)(nil)).Elem()},
+}
+
+type schema map[string]interface{}
+
+var (
+	posType          = reflect.TypeOf(token.Pos{})
+	commentGroupType = reflect.TypeOf(ast.CommentGroup{})
+)
+
+func main() {
+	output := flag.String("o", "schema.json", "output file")
+	flag.Parse()
+
+	bs, err := generate()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, bs, 0644); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "wrote %s\n", *output)
+}
+
+// generate returns the JSON Schema of the ast package.
+func generate() ([]byte, error) {
+	kinds := make(map[reflect.Type]string, len(nodes))
+	for _, n := range nodes {
+		kinds[reflect.TypeOf(n.node)] = n.kind
+	}
+	ifaceNames := make(map[reflect.Type]string, len(interfaces))
+	for _, i := range interfaces {
+		ifaceNames[i.iface] = i.name
+	}
+
+	defs := schema{
+		"Pos": schema{
+			"type": "object",
+			"properties": schema{
+				"Index":  schema{"type": "integer", "description": "byte offset in the source, starting at 0"},
+				"Line":   schema{"type": "integer", "description": "line number, starting at 1"},
+				"Column": schema{"type": "integer", "description": "column number, starting at 1"},
+			},
+			"required": []string{"Index", "Line", "Column"},
+		},
+		"CommentGroup": schema{
+			"type":  []string{"array", "null"},
+			"items": ref("Comment"),
+		},
+	}
+	var typeErr error
+	var typeOf func(t reflect.Type) schema
+	typeOf = func(t reflect.Type) schema {
+		switch {
+		case t == posType:
+			return ref("Pos")
+		case t == commentGroupType:
+			return ref("CommentGroup")
+		case t.Kind() == reflect.Ptr:
+			return nullable(typeOf(t.Elem()))
+		case t.Kind() == reflect.Interface:
+			name, ok := ifaceNames[t]
+			if !ok {
+				typeErr = fmt.Errorf("unknown interface %s", t)
+			}
+			return nullable(ref(name))
+		case t.Kind() == reflect.Slice:
+			return schema{"type": []string{"array", "null"}, "items": typeOf(t.Elem())}
+		case t.Kind() == reflect.Struct:
+			kind, ok := kinds[t]
+			if !ok {
+				typeErr = fmt.Errorf("unknown struct %s", t)
+			}
+			return ref(kind)
+		case t.Kind() == reflect.String:
+			return schema{"type": "string"}
+		case t.Kind() == reflect.Bool:
+			return schema{"type": "boolean"}
+		}
+		typeErr = fmt.Errorf("unsupported type %s", t)
+		return nil
+	}
+	for _, n := range nodes {
+		t := reflect.TypeOf(n.node)
+		props := schema{
+			"Kind": schema{"const": n.kind},
+		}
+		required := []string{"Kind"}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.Tag.Get("json") == "-" {
+				continue
+			}
+			props[f.Name] = typeOf(f.Type)
+			required = append(required, f.Name)
+		}
+		defs[n.kind] = schema{
+			"type":                 "object",
+			"properties":           props,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	}
+	for _, i := range interfaces {
+		var oneOf []schema
+		for _, n := range nodes {
+			if reflect.PtrTo(reflect.TypeOf(n.node)).Implements(i.iface) {
+				oneOf = append(oneOf, ref(n.kind))
+			}
+		}
+		defs[i.name] = schema{"oneOf": oneOf}
+	}
+	if typeErr != nil {
+		return nil, typeErr
+	}
+
+	root := schema{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"$id":         "https://github.com/mashiike/go-dqdl/ast/schema.json",
+		"title":       "DQDL syntax tree",
+		"description": "JSON representation of github.com/mashiike/go-dqdl/ast nodes. Every node carries a Kind discriminator.",
+		"$ref":        "#/$defs/File",
+		"$defs":       defs,
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func ref(name string) schema {
+	return schema{"$ref": "#/$defs/" + name}
+}
+
+func nullable(s schema) schema {
+	return schema{"anyOf": []schema{s, {"type": "null"}}}
+}
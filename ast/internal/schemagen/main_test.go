@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestGenerate fails when schema.json is out of date with the ast types.
+func TestGenerate(t *testing.T) {
+	got, err := generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("../../schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(want), string(got)); diff != "" {
+		t.Errorf("schema.json is out of date, run go generate ./ast (-file +generated):\n%s", diff)
+	}
+}
@@ -0,0 +1,378 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// JSON 表現では、全てのノードが "Kind" フィールドで種類を区別します。
+// In the JSON representation every node carries a "Kind" field naming its
+// type, so that a File survives a JSON round-trip and non-Go consumers can
+// dispatch on it. The format is described by schema.json in this directory.
+
+func (c *Comment) MarshalJSON() ([]byte, error) {
+	type alias Comment
+	return json.Marshal(struct {
+		Kind string
+		*alias
+	}{"Comment", (*alias)(c)})
+}
+
+func (f *File) MarshalJSON() ([]byte, error) {
+	type alias File
+	return json.Marshal(struct {
+		Kind string
+		*alias
+	}{"File", (*alias)(f)})
+}
+
+func (d *Ruleset) MarshalJSON() ([]byte, error) {
+	type alias Ruleset
+	return json.Marshal(struct {
+		Kind string
+		*alias
+	}{"Ruleset", (*alias)(d)})
+}
+
+func (r *Rule) MarshalJSON() ([]byte, error) {
+	type alias Rule
+	return json.Marshal(struct {
+		Kind string
+		*alias
+	}{"Rule", (*alias)(r)})
+}
+
+func (r *Rule) UnmarshalJSON(data []byte) error {
+	type alias Rule
+	aux := struct {
+		*alias
+		Parameters []json.RawMessage
+		Expression json.RawMessage
+	}{alias: (*alias)(r)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	params, err := unmarshalParameters(aux.Parameters)
+	if err != nil {
+		return err
+	}
+	r.Parameters = params
+	r.Expression, err = unmarshalExpression(aux.Expression)
+	return err
+}
+
+func (r *CombinedRule) MarshalJSON() ([]byte, error) {
+	type alias CombinedRule
+	return json.Marshal(struct {
+		Kind string
+		*alias
+	}{"CombinedRule", (*alias)(r)})
+}
+
+func (r *BadRule) MarshalJSON() ([]byte, error) {
+	type alias BadRule
+	return json.Marshal(struct {
+		Kind string
+		*alias
+	}{"BadRule", (*alias)(r)})
+}
+
+func (d *Ruleset) UnmarshalJSON(data []byte) error {
+	type alias Ruleset
+	aux := struct {
+		*alias
+		Rules []json.RawMessage
+	}{alias: (*alias)(d)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	d.Rules = nil
+	for _, raw := range aux.Rules {
+		n, err := unmarshalNode(raw)
+		if err != nil {
+			return err
+		}
+		rule, ok := n.(RuleDecl)
+		if !ok {
+			return fmt.Errorf("ast: %T is not a rule", n)
+		}
+		d.Rules = append(d.Rules, rule)
+	}
+	return nil
+}
+
+func (x *Ident) MarshalJSON() ([]byte, error) {
+	type alias Ident
+	return json.Marshal(struct {
+		Kind string
+		*alias
+	}{"Ident", (*alias)(x)})
+}
+
+func (x *StringParameter) MarshalJSON() ([]byte, error) {
+	type alias StringParameter
+	return json.Marshal(struct {
+		Kind string
+		*alias
+	}{"StringParameter", (*alias)(x)})
+}
+
+func (x *NumberParameter) MarshalJSON() ([]byte, error) {
+	type alias NumberParameter
+	return json.Marshal(struct {
+		Kind string
+		*alias
+	}{"NumberParameter", (*alias)(x)})
+}
+
+func (x *BoolParameter) MarshalJSON() ([]byte, error) {
+	type alias BoolParameter
+	return json.Marshal(struct {
+		Kind string
+		*alias
+	}{"BoolParameter", (*alias)(x)})
+}
+
+func (x *DurationParameter) MarshalJSON() ([]byte, error) {
+	type alias DurationParameter
+	return json.Marshal(struct {
+		Kind string
+		*alias
+	}{"DurationParameter", (*alias)(x)})
+}
+
+func (x *DateParameter) MarshalJSON() ([]byte, error) {
+	type alias DateParameter
+	return json.Marshal(struct {
+		Kind string
+		*alias
+	}{"DateParameter", (*alias)(x)})
+}
+
+func (x *ComparisonExpression) MarshalJSON() ([]byte, error) {
+	type alias ComparisonExpression
+	return json.Marshal(struct {
+		Kind string
+		*alias
+	}{"ComparisonExpression", (*alias)(x)})
+}
+
+func (x *ComparisonExpression) UnmarshalJSON(data []byte) error {
+	type alias ComparisonExpression
+	aux := struct {
+		*alias
+		Right json.RawMessage
+	}{alias: (*alias)(x)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	x.Right, err = unmarshalParameter(aux.Right)
+	return err
+}
+
+func (x *BetweenExpression) MarshalJSON() ([]byte, error) {
+	type alias BetweenExpression
+	return json.Marshal(struct {
+		Kind string
+		*alias
+	}{"BetweenExpression", (*alias)(x)})
+}
+
+func (x *BetweenExpression) UnmarshalJSON(data []byte) error {
+	type alias BetweenExpression
+	aux := struct {
+		*alias
+		Left  json.RawMessage
+		Right json.RawMessage
+	}{alias: (*alias)(x)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var err error
+	if x.Left, err = unmarshalParameter(aux.Left); err != nil {
+		return err
+	}
+	x.Right, err = unmarshalParameter(aux.Right)
+	return err
+}
+
+func (x *InExpression) MarshalJSON() ([]byte, error) {
+	type alias InExpression
+	return json.Marshal(struct {
+		Kind string
+		*alias
+	}{"InExpression", (*alias)(x)})
+}
+
+func (x *InExpression) UnmarshalJSON(data []byte) error {
+	type alias InExpression
+	aux := struct {
+		*alias
+		Values []json.RawMessage
+	}{alias: (*alias)(x)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	values, err := unmarshalParameters(aux.Values)
+	if err != nil {
+		return err
+	}
+	x.Values = values
+	return nil
+}
+
+func (x *MatchesExpression) MarshalJSON() ([]byte, error) {
+	type alias MatchesExpression
+	return json.Marshal(struct {
+		Kind string
+		*alias
+	}{"MatchesExpression", (*alias)(x)})
+}
+
+func (x *BadExpr) MarshalJSON() ([]byte, error) {
+	type alias BadExpr
+	return json.Marshal(struct {
+		Kind string
+		*alias
+	}{"BadExpr", (*alias)(x)})
+}
+
+func (x *WithThresholdExpression) MarshalJSON() ([]byte, error) {
+	type alias WithThresholdExpression
+	return json.Marshal(struct {
+		Kind string
+		*alias
+	}{"WithThresholdExpression", (*alias)(x)})
+}
+
+func (x *WithThresholdExpression) UnmarshalJSON(data []byte) error {
+	type alias WithThresholdExpression
+	aux := struct {
+		*alias
+		Target    json.RawMessage
+		Threshold json.RawMessage
+	}{alias: (*alias)(x)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	target, err := unmarshalExpression(aux.Target)
+	if err != nil {
+		return err
+	}
+	if target != nil {
+		t, ok := target.(ThresholdTarget)
+		if !ok {
+			return fmt.Errorf("ast: %T can not be a threshold target", target)
+		}
+		x.Target = t
+	}
+	threshold, err := unmarshalExpression(aux.Threshold)
+	if err != nil {
+		return err
+	}
+	if threshold != nil {
+		t, ok := threshold.(ThresholdExpression)
+		if !ok {
+			return fmt.Errorf("ast: %T can not be a threshold expression", threshold)
+		}
+		x.Threshold = t
+	}
+	return nil
+}
+
+// newNode returns a new node of the given kind.
+func newNode(kind string) (Node, error) {
+	switch kind {
+	case "Rule":
+		return &Rule{}, nil
+	case "CombinedRule":
+		return &CombinedRule{}, nil
+	case "BadRule":
+		return &BadRule{}, nil
+	case "StringParameter":
+		return &StringParameter{}, nil
+	case "NumberParameter":
+		return &NumberParameter{}, nil
+	case "BoolParameter":
+		return &BoolParameter{}, nil
+	case "DurationParameter":
+		return &DurationParameter{}, nil
+	case "DateParameter":
+		return &DateParameter{}, nil
+	case "ComparisonExpression":
+		return &ComparisonExpression{}, nil
+	case "BetweenExpression":
+		return &BetweenExpression{}, nil
+	case "InExpression":
+		return &InExpression{}, nil
+	case "MatchesExpression":
+		return &MatchesExpression{}, nil
+	case "WithThresholdExpression":
+		return &WithThresholdExpression{}, nil
+	case "BadExpr":
+		return &BadExpr{}, nil
+	default:
+		return nil, fmt.Errorf("ast: unknown node kind %q", kind)
+	}
+}
+
+// unmarshalNode decodes a node using its "Kind" field. It returns nil for JSON null.
+func unmarshalNode(data json.RawMessage) (Node, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+	var head struct {
+		Kind string
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, err
+	}
+	n, err := newNode(head.Kind)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func unmarshalParameter(data json.RawMessage) (Parameter, error) {
+	n, err := unmarshalNode(data)
+	if err != nil || n == nil {
+		return nil, err
+	}
+	param, ok := n.(Parameter)
+	if !ok {
+		return nil, fmt.Errorf("ast: %T is not a parameter", n)
+	}
+	return param, nil
+}
+
+func unmarshalParameters(data []json.RawMessage) ([]Parameter, error) {
+	if data == nil {
+		return nil, nil
+	}
+	params := make([]Parameter, 0, len(data))
+	for _, raw := range data {
+		param, err := unmarshalParameter(raw)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, param)
+	}
+	return params, nil
+}
+
+func unmarshalExpression(data json.RawMessage) (Expression, error) {
+	n, err := unmarshalNode(data)
+	if err != nil || n == nil {
+		return nil, err
+	}
+	expr, ok := n.(Expression)
+	if !ok {
+		return nil, fmt.Errorf("ast: %T is not an expression", n)
+	}
+	return expr, nil
+}
@@ -0,0 +1,50 @@
+package ast
+
+import "github.com/mashiike/go-dqdl/token"
+
+// NodeAt は指定された位置を含む最も内側のノードと、その祖先のノードを返します。
+// NodeAt returns the innermost node of file whose range [Pos, End)
+// contains pos, and the path of its ancestors from the ruleset down to
+// its parent. Comments are returned as *Comment nodes. If pos has no line
+// (pos.Line is 0), pos.Index is used as a byte offset instead.
+// NodeAt returns nil if no node contains pos.
+func NodeAt(file *File, pos token.Pos) (Node, []Node) {
+	f := nodeFinder{pos: pos, depth: -1}
+	for _, ruleset := range file.Rulesets {
+		f.visit(ruleset, nil)
+	}
+	return f.node, f.path
+}
+
+type nodeFinder struct {
+	pos   token.Pos
+	node  Node
+	path  []Node
+	depth int
+}
+
+// visit records n if it contains the position and is deeper than the node
+// found so far. Children are visited even if n does not contain the
+// position, since comments lie outside the range of the node they belong to.
+func (f *nodeFinder) visit(n Node, path []Node) {
+	if f.contains(n) && len(path) > f.depth {
+		f.node = n
+		f.path = append([]Node(nil), path...)
+		f.depth = len(path)
+	}
+	path = append(path, n)
+	for _, child := range Children(n) {
+		f.visit(child, path)
+	}
+}
+
+func (f *nodeFinder) contains(n Node) bool {
+	span := SpanOf(n)
+	if !span.Start.IsValid() || !span.End.IsValid() {
+		return false
+	}
+	if f.pos.IsValid() {
+		return span.Contains(f.pos)
+	}
+	return span.Start.Index <= f.pos.Index && f.pos.Index < span.End.Index
+}
@@ -0,0 +1,79 @@
+package ast
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// testNodeAtFile returns the tree of
+//
+//	Rules = [
+//		# desc
+//		ColumnValues "a" between 1 and 10
+//	]
+func testNodeAtFile() *File {
+	line3 := func(col int) token.Pos { return token.Pos{Index: 17 + col, Line: 3, Column: col} }
+	return &File{
+		Rulesets: []*Ruleset{{
+			DeclPos:        token.Pos{Index: 0, Line: 1, Column: 1},
+			LeftBracketPos: token.Pos{Index: 8, Line: 1, Column: 9},
+			Rules: []RuleDecl{&Rule{
+				Description: CommentGroup{{SharpPos: token.Pos{Index: 11, Line: 2, Column: 2}, Text: "# desc"}},
+				Type:        &Ident{NamePos: line3(2), Name: "ColumnValues"},
+				Parameters: []Parameter{
+					&StringParameter{LeftQuotePos: line3(15), RightQuotePos: line3(17), Value: "a"},
+				},
+				Expression: &BetweenExpression{
+					ExprPos: line3(19),
+					Left:    &NumberParameter{NumberPos: line3(27), Value: "1"},
+					Right:   &NumberParameter{NumberPos: line3(33), Value: "10"},
+				},
+			}},
+			RightBracketPos: token.Pos{Index: 53, Line: 4, Column: 1},
+		}},
+	}
+}
+
+func TestNodeAt(t *testing.T) {
+	file := testNodeAtFile()
+	cases := []struct {
+		name string
+		pos  token.Pos
+		node string
+		path []string
+	}{
+		{name: "rule type", pos: token.Pos{Line: 3, Column: 5}, node: "*ast.Ident", path: []string{"*ast.Ruleset", "*ast.Rule"}},
+		{name: "string parameter", pos: token.Pos{Line: 3, Column: 17}, node: "*ast.StringParameter", path: []string{"*ast.Ruleset", "*ast.Rule"}},
+		{name: "between keyword", pos: token.Pos{Line: 3, Column: 19}, node: "*ast.BetweenExpression", path: []string{"*ast.Ruleset", "*ast.Rule"}},
+		{name: "between operand", pos: token.Pos{Line: 3, Column: 34}, node: "*ast.NumberParameter", path: []string{"*ast.Ruleset", "*ast.Rule", "*ast.BetweenExpression"}},
+		{name: "space between nodes", pos: token.Pos{Line: 3, Column: 14}, node: "*ast.Rule", path: []string{"*ast.Ruleset"}},
+		{name: "comment", pos: token.Pos{Line: 2, Column: 4}, node: "*ast.Comment", path: []string{"*ast.Ruleset", "*ast.Rule"}},
+		{name: "byte offset", pos: token.Pos{Index: 44}, node: "*ast.NumberParameter", path: []string{"*ast.Ruleset", "*ast.Rule", "*ast.BetweenExpression"}},
+		{name: "ruleset", pos: token.Pos{Line: 4, Column: 1}, node: "*ast.Ruleset", path: []string{}},
+		{name: "outside", pos: token.Pos{Line: 5, Column: 1}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			node, path := NodeAt(file, c.pos)
+			if node == nil {
+				if c.node != "" {
+					t.Fatalf("got no node, want %s", c.node)
+				}
+				return
+			}
+			if got := fmt.Sprintf("%T", node); got != c.node {
+				t.Errorf("got %s, want %s", got, c.node)
+			}
+			got := []string{}
+			for _, n := range path {
+				got = append(got, fmt.Sprintf("%T", n))
+			}
+			if diff := cmp.Diff(c.path, got); diff != "" {
+				t.Errorf("unexpected path (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
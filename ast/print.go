@@ -0,0 +1,177 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// FieldFilter は Fprint が出力するフィールドを選ぶ関数です。
+// A FieldFilter may be provided to Fprint to control the output: a struct
+// field is printed only if the filter returns true for its name and value.
+type FieldFilter func(name string, value reflect.Value) bool
+
+// NotNilFilter は nil でないフィールドを選びます。
+// NotNilFilter returns true for field values that are not nil; it returns
+// false otherwise.
+func NotNilFilter(_ string, v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		return !v.IsNil()
+	}
+	return true
+}
+
+// Fprint は木の構造をフィールドごとに字下げして出力します。
+// Fprint prints the tree x to w, one field per line, indented by depth,
+// for debugging the parser and writing tests. Positions are printed as
+// "line:column". A pointer printed before is printed as "*(obj @ N)",
+// where N is the line where it was printed, so comments shared by several
+// comment groups are printed once. If f is not nil, it selects the
+// struct fields to print; only exported fields are printed.
+//
+//	0  *ast.ComparisonExpression {
+//	1  .  ExprPos: 1:21
+//	2  .  Operator: ">"
+//	3  .  Right: *ast.NumberParameter {
+//	4  .  .  NumberPos: 1:23
+//	5  .  .  Value: "0.5"
+//	6  .  .  Comments: nil
+//	7  .  }
+//	8  .  Comments: nil
+//	9  }
+func Fprint(w io.Writer, x interface{}, f FieldFilter) error {
+	d := dumper{filter: f, ptrs: make(map[interface{}]int), bol: true}
+	if x == nil {
+		d.printf("nil\n")
+	} else {
+		d.print(reflect.ValueOf(x))
+		d.printf("\n")
+	}
+	_, err := w.Write(d.buf.Bytes())
+	return err
+}
+
+// Print は nil のフィールドを省いて木の構造を標準出力に出力します。
+// Print prints x to standard output, skipping nil fields. It is a
+// shorthand for Fprint(os.Stdout, x, NotNilFilter).
+func Print(x interface{}) error {
+	return Fprint(os.Stdout, x, NotNilFilter)
+}
+
+var posType = reflect.TypeOf(token.Pos{})
+
+type dumper struct {
+	buf    bytes.Buffer
+	filter FieldFilter
+	ptrs   map[interface{}]int // line of each pointer printed
+	indent int
+	line   int
+	bol    bool // at the beginning of a line
+}
+
+// printf writes the formatted text, starting each line with its number
+// and the indentation.
+func (d *dumper) printf(format string, args ...interface{}) {
+	s := fmt.Sprintf(format, args...)
+	for len(s) > 0 {
+		if d.bol {
+			fmt.Fprintf(&d.buf, "%6d  %s", d.line, strings.Repeat(".  ", d.indent))
+			d.bol = false
+		}
+		i := strings.IndexByte(s, '\n')
+		if i < 0 {
+			d.buf.WriteString(s)
+			return
+		}
+		d.buf.WriteString(s[:i+1])
+		d.line++
+		d.bol = true
+		s = s[i+1:]
+	}
+}
+
+func (d *dumper) print(v reflect.Value) {
+	if !NotNilFilter("", v) {
+		d.printf("nil")
+		return
+	}
+	if v.Type() == posType {
+		d.printf("%s", v.Interface().(token.Pos))
+		return
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		d.print(v.Elem())
+	case reflect.Ptr:
+		d.printf("*")
+		ptr := v.Interface()
+		if line, ok := d.ptrs[ptr]; ok {
+			d.printf("(obj @ %d)", line)
+			return
+		}
+		d.ptrs[ptr] = d.line
+		d.print(v.Elem())
+	case reflect.Slice, reflect.Array:
+		d.printf("%s (len = %d) {", v.Type(), v.Len())
+		if v.Len() > 0 {
+			d.indent++
+			d.printf("\n")
+			for i := 0; i < v.Len(); i++ {
+				d.printf("%d: ", i)
+				d.print(v.Index(i))
+				d.printf("\n")
+			}
+			d.indent--
+		}
+		d.printf("}")
+	case reflect.Map:
+		d.printf("%s (len = %d) {", v.Type(), v.Len())
+		if v.Len() > 0 {
+			d.indent++
+			d.printf("\n")
+			iter := v.MapRange()
+			for iter.Next() {
+				d.print(iter.Key())
+				d.printf(": ")
+				d.print(iter.Value())
+				d.printf("\n")
+			}
+			d.indent--
+		}
+		d.printf("}")
+	case reflect.Struct:
+		t := v.Type()
+		d.printf("%s {", t)
+		d.indent++
+		first := true
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue // unexported
+			}
+			value := v.Field(i)
+			if d.filter != nil && !d.filter(field.Name, value) {
+				continue
+			}
+			if first {
+				d.printf("\n")
+				first = false
+			}
+			d.printf("%s: ", field.Name)
+			d.print(value)
+			d.printf("\n")
+		}
+		d.indent--
+		d.printf("}")
+	case reflect.String:
+		d.printf("%q", v.String())
+	default:
+		d.printf("%v", v.Interface())
+	}
+}
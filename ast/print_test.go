@@ -0,0 +1,84 @@
+package ast
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+func TestFprint(t *testing.T) {
+	pos := func(col int) token.Pos { return token.Pos{Index: col - 1, Line: 1, Column: col} }
+	comment := &Comment{SharpPos: pos(27), Text: "# ratio"}
+	expr := &ComparisonExpression{
+		ExprPos:  pos(21),
+		Operator: ">",
+		Right:    &NumberParameter{NumberPos: pos(23), Value: "0.5", Comments: CommentGroup{comment}},
+		Comments: CommentGroup{comment},
+	}
+	cases := []struct {
+		name   string
+		node   interface{}
+		filter FieldFilter
+		want   string
+	}{
+		{
+			name: "all fields",
+			node: expr,
+			want: `     0  *ast.ComparisonExpression {
+     1  .  ExprPos: 1:21
+     2  .  Operator: ">"
+     3  .  Right: *ast.NumberParameter {
+     4  .  .  NumberPos: 1:23
+     5  .  .  Value: "0.5"
+     6  .  .  Comments: ast.CommentGroup (len = 1) {
+     7  .  .  .  0: *ast.Comment {
+     8  .  .  .  .  SharpPos: 1:27
+     9  .  .  .  .  Text: "# ratio"
+    10  .  .  .  }
+    11  .  .  }
+    12  .  }
+    13  .  Comments: ast.CommentGroup (len = 1) {
+    14  .  .  0: *(obj @ 7)
+    15  .  }
+    16  }
+`,
+		},
+		{
+			name:   "not nil",
+			node:   &Rule{Type: &Ident{NamePos: pos(1), Name: "IsComplete"}, Parameters: []Parameter{&StringParameter{Value: "id"}}},
+			filter: NotNilFilter,
+			want: `     0  *ast.Rule {
+     1  .  Type: *ast.Ident {
+     2  .  .  NamePos: 1:1
+     3  .  .  Name: "IsComplete"
+     4  .  }
+     5  .  Parameters: []ast.Parameter (len = 1) {
+     6  .  .  0: *ast.StringParameter {
+     7  .  .  .  LeftQuotePos: -
+     8  .  .  .  RightQuotePos: -
+     9  .  .  .  Value: "id"
+    10  .  .  }
+    11  .  }
+    12  }
+`,
+		},
+		{
+			name: "nil",
+			node: nil,
+			want: "     0  nil\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Fprint(&buf, c.node, c.filter); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, buf.String()); diff != "" {
+				t.Errorf("unexpected output (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
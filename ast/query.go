@@ -0,0 +1,63 @@
+package ast
+
+// RulesOfType はルールタイプが name のルールを返します。
+// RulesOfType returns the rules of d whose rule type is name. A combined
+// rule is returned as a whole if one of its rules is of the type.
+func (d *Ruleset) RulesOfType(name string) []RuleDecl {
+	return d.filter(func(r *Rule) bool {
+		return r.Type != nil && r.Type.Name == name
+	})
+}
+
+// RulesReferencing はカラム column を参照するルールを返します。
+// RulesReferencing returns the rules of d that have a string parameter
+// equal to column, that is, the rules checking the column. A combined
+// rule is returned as a whole if one of its rules references the column.
+func (d *Ruleset) RulesReferencing(column string) []RuleDecl {
+	return d.filter(func(r *Rule) bool {
+		for _, param := range r.Parameters {
+			if p, ok := param.(*StringParameter); ok && p.Value == column {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// filter returns the rules of d for which match reports true for the rule
+// or one of its nested rules.
+func (d *Ruleset) filter(match func(*Rule) bool) []RuleDecl {
+	var rules []RuleDecl
+	for _, decl := range d.Rules {
+		switch r := decl.(type) {
+		case *Rule:
+			if match(r) {
+				rules = append(rules, r)
+			}
+		case *CombinedRule:
+			for _, nested := range r.Rules {
+				if match(nested) {
+					rules = append(rules, r)
+					break
+				}
+			}
+		}
+	}
+	return rules
+}
+
+// Ruleset は名前が name のルールセットを返します。
+// Ruleset returns the first ruleset of f named name, see Ruleset.Name, or
+// nil if there is none. Unnamed rulesets are never returned, even for an
+// empty name.
+func (f *File) Ruleset(name string) *Ruleset {
+	if name == "" {
+		return nil
+	}
+	for _, rs := range f.Rulesets {
+		if rs.Name() == name {
+			return rs
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newColumnRule(name string, columns ...string) *Rule {
+	r := newTestRule(name)
+	for _, c := range columns {
+		r.Parameters = append(r.Parameters, &StringParameter{Value: c})
+	}
+	return r
+}
+
+func TestRuleset__Query(t *testing.T) {
+	uniqueID := newColumnRule("IsUnique", "order_id")
+	completeID := newColumnRule("IsComplete", "order_id")
+	uniqueName := newColumnRule("IsUnique", "name")
+	combined := &CombinedRule{
+		Rules:    []*Rule{newColumnRule("RowCount"), newColumnRule("IsPrimaryKey", "shop_id", "order_id")},
+		Operator: "and",
+	}
+	rs := &Ruleset{Rules: []RuleDecl{uniqueID, completeID, uniqueName, combined}}
+
+	cases := []struct {
+		name string
+		got  []RuleDecl
+		want []RuleDecl
+	}{
+		{name: "of type", got: rs.RulesOfType("IsUnique"), want: []RuleDecl{uniqueID, uniqueName}},
+		{name: "of type in combined", got: rs.RulesOfType("RowCount"), want: []RuleDecl{combined}},
+		{name: "of unknown type", got: rs.RulesOfType("Mean"), want: nil},
+		{name: "referencing", got: rs.RulesReferencing("order_id"), want: []RuleDecl{uniqueID, completeID, combined}},
+		{name: "referencing unknown", got: rs.RulesReferencing("price"), want: nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if diff := cmp.Diff(c.want, c.got); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFile__Ruleset(t *testing.T) {
+	named := func(comments ...string) *Ruleset {
+		rs := &Ruleset{}
+		for _, text := range comments {
+			rs.Description = append(rs.Description, &Comment{Text: text})
+		}
+		return rs
+	}
+	orders := named("# checks of orders", "#dqdl:name orders")
+	users := named("# @name: users")
+	anonymous := named("# no name")
+	file := &File{Rulesets: []*Ruleset{anonymous, orders, users}}
+
+	cases := []struct {
+		name string
+		want *Ruleset
+	}{
+		{name: "orders", want: orders},
+		{name: "users", want: users},
+		{name: "items", want: nil},
+		{name: "", want: nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := file.Ruleset(c.name); got != c.want {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+	if got := anonymous.Name(); got != "" {
+		t.Errorf("got name %q, want none", got)
+	}
+}
@@ -0,0 +1,18 @@
+package ast
+
+import _ "embed"
+
+//go:generate go run ./internal/schemagen -o schema.json
+
+//go:embed schema.json
+var schema []byte
+
+// Schema は構文木の JSON 表現を記述する JSON Schema を返します。
+// Schema returns the JSON Schema describing the JSON representation of
+// syntax trees, as documented in the package comment. The returned slice
+// is a copy and may be modified by the caller.
+func Schema() []byte {
+	bs := make([]byte, len(schema))
+	copy(bs, schema)
+	return bs
+}
@@ -0,0 +1,29 @@
+package ast
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	var s struct {
+		Ref  string                     `json:"$ref"`
+		Defs map[string]json.RawMessage `json:"$defs"`
+	}
+	if err := json.Unmarshal(Schema(), &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Ref != "#/$defs/File" {
+		t.Errorf("got root $ref %q, want %q", s.Ref, "#/$defs/File")
+	}
+	for _, kind := range []string{"File", "Ruleset", "Rule", "CombinedRule", "DateParameter", "WithThresholdExpression", "Parameter", "Expression"} {
+		if _, ok := s.Defs[kind]; !ok {
+			t.Errorf("%s is not defined in the schema", kind)
+		}
+	}
+	bs := Schema()
+	bs[0] = 'x'
+	if Schema()[0] == 'x' {
+		t.Error("Schema returns a shared slice")
+	}
+}
@@ -0,0 +1,97 @@
+package ast
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration は期間を time.Duration に変換します。
+// Duration converts the duration, e.g. `3 days` or `24 hours`, into a
+// time.Duration. It fails if the number is not a non-negative integer, if
+// the unit is unknown, or if the duration overflows.
+func (x *DurationParameter) Duration() (time.Duration, error) {
+	n, err := strconv.ParseInt(x.Number, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("ast: invalid duration %s", x.Value)
+	}
+	var unit time.Duration
+	switch strings.ToLower(x.Unit) {
+	case "hours":
+		unit = time.Hour
+	case "days":
+		unit = 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("ast: invalid duration unit %s", x.Unit)
+	}
+	if n > math.MaxInt64/int64(unit) {
+		return 0, fmt.Errorf("ast: duration %s is too long", x.Value)
+	}
+	return time.Duration(n) * unit, nil
+}
+
+// Resolve は now を現在時刻として日付を解決します。
+// Resolve returns the time the date stands for when now() is now, e.g.
+// now minus three days for `now() - 3 days`. It fails if the duration is
+// invalid.
+func (x *DateParameter) Resolve(now time.Time) (time.Time, error) {
+	if x.Duration == nil {
+		return now, nil
+	}
+	d, err := x.Duration.Duration()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return now.Add(-d), nil
+}
+
+// Float64 は数値を float64 に変換します。
+// Float64 converts the number into a float64. It fails if the number
+// overflows, or if it is an integer that float64 can not represent
+// exactly, such as 9007199254740993.
+func (x *NumberParameter) Float64() (float64, error) {
+	f, err := strconv.ParseFloat(x.Value, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, fmt.Errorf("ast: number %s is out of range", x.Value)
+		}
+		return 0, fmt.Errorf("ast: invalid number %s", x.Value)
+	}
+	if x.IsInteger() && (f >= 1<<53 || f <= -(1<<53)) {
+		n, _ := new(big.Int).SetString(x.Value, 10)
+		if exact, _ := big.NewFloat(f).Int(nil); n == nil || exact.Cmp(n) != 0 {
+			return 0, fmt.Errorf("ast: number %s can not be represented exactly", x.Value)
+		}
+	}
+	return f, nil
+}
+
+// Int64 は数値を int64 に変換します。
+// Int64 converts the number into an int64. A number with a decimal point
+// is accepted if it has no fractional part, e.g. 10.0. It fails if the
+// number has a fractional part or overflows.
+func (x *NumberParameter) Int64() (int64, error) {
+	digits := x.Value
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		fraction := digits[i+1:]
+		if strings.Trim(fraction, "0123456789") != "" {
+			return 0, fmt.Errorf("ast: invalid number %s", x.Value)
+		}
+		if strings.Trim(fraction, "0") != "" {
+			return 0, fmt.Errorf("ast: number %s is not an integer", x.Value)
+		}
+		digits = digits[:i]
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, fmt.Errorf("ast: number %s overflows int64", x.Value)
+		}
+		return 0, fmt.Errorf("ast: invalid number %s", x.Value)
+	}
+	return n, nil
+}
@@ -0,0 +1,155 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDurationParameter__Duration(t *testing.T) {
+	cases := []struct {
+		number, unit string
+		want         time.Duration
+		errStr       string
+	}{
+		{number: "3", unit: "days", want: 72 * time.Hour},
+		{number: "24", unit: "hours", want: 24 * time.Hour},
+		{number: "0", unit: "HOURS", want: 0},
+		{number: "1.5", unit: "days", errStr: "ast: invalid duration 1.5 days"},
+		{number: "3", unit: "weeks", errStr: "ast: invalid duration unit weeks"},
+		{number: "9999999", unit: "hours", errStr: "ast: duration 9999999 hours is too long"},
+		{number: "99999999999999999999", unit: "days", errStr: "ast: invalid duration 99999999999999999999 days"},
+	}
+	for _, c := range cases {
+		t.Run(c.number+" "+c.unit, func(t *testing.T) {
+			x := &DurationParameter{Value: c.number + " " + c.unit, Number: c.number, Unit: c.unit}
+			got, err := x.Duration()
+			if c.errStr != "" {
+				if err == nil || err.Error() != c.errStr {
+					t.Fatalf("got error %v, want %s", err, c.errStr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestDateParameter__Resolve(t *testing.T) {
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		date   *DateParameter
+		want   time.Time
+		errStr string
+	}{
+		{name: "now", date: &DateParameter{}, want: now},
+		{
+			name: "days",
+			date: &DateParameter{Duration: &DurationParameter{Value: "3 days", Number: "3", Unit: "days"}},
+			want: time.Date(2024, 1, 7, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "hours",
+			date: &DateParameter{Duration: &DurationParameter{Value: "36 hours", Number: "36", Unit: "hours"}},
+			want: time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "invalid unit",
+			date:   &DateParameter{Duration: &DurationParameter{Value: "3 weeks", Number: "3", Unit: "weeks"}},
+			errStr: "ast: invalid duration unit weeks",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := c.date.Resolve(now)
+			if c.errStr != "" {
+				if err == nil || err.Error() != c.errStr {
+					t.Fatalf("got error %v, want %s", err, c.errStr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(c.want) {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestNumberParameter__Float64(t *testing.T) {
+	cases := []struct {
+		value  string
+		want   float64
+		errStr string
+	}{
+		{value: "0.5", want: 0.5},
+		{value: "10", want: 10},
+		{value: "9007199254740992", want: 1 << 53},
+		{value: "9007199254740993", errStr: "ast: number 9007199254740993 can not be represented exactly"},
+		{value: "1" + strings.Repeat("0", 400), errStr: "ast: number 1" + strings.Repeat("0", 400) + " is out of range"},
+		{value: "1.2.3", errStr: "ast: invalid number 1.2.3"},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			got, err := (&NumberParameter{Value: c.value}).Float64()
+			if c.errStr != "" {
+				if err == nil || err.Error() != c.errStr {
+					t.Fatalf("got error %v, want %s", err, c.errStr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestNumberParameter__Int64(t *testing.T) {
+	cases := []struct {
+		value  string
+		want   int64
+		errStr string
+	}{
+		{value: "42", want: 42},
+		{value: "10.0", want: 10},
+		{value: "9223372036854775807", want: 1<<63 - 1},
+		{value: "9223372036854775808", errStr: "ast: number 9223372036854775808 overflows int64"},
+		{value: "1e30", errStr: "ast: invalid number 1e30"},
+		{value: "99999999999999999999.0", errStr: "ast: number 99999999999999999999.0 overflows int64"},
+		{value: "1.5", errStr: "ast: number 1.5 is not an integer"},
+		{value: "9007199254740993.0", want: 1<<53 + 1},
+		{value: "9223372036854775807.00", want: 1<<63 - 1},
+		{value: "-9223372036854775808.0", want: -1 << 63},
+		{value: "9007199254740993.5", errStr: "ast: number 9007199254740993.5 is not an integer"},
+		{value: "1.0e3", errStr: "ast: invalid number 1.0e3"},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			got, err := (&NumberParameter{Value: c.value}).Int64()
+			if c.errStr != "" {
+				if err == nil || err.Error() != c.errStr {
+					t.Fatalf("got error %v, want %s", err, c.errStr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
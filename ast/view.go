@@ -0,0 +1,110 @@
+package ast
+
+// View は File の読み取り専用のビューです。
+// View の編集メソッドは元の File を変更せず、変更されていないノードを共有した新しい View を返します。
+// A View is a read-only view of a File. The editing methods never modify the
+// underlying File; they return a new View that shares every unchanged node
+// with the original (copy-on-write), so a View can be used from multiple
+// goroutines without deep-cloning the tree.
+//
+// Nodes obtained from a View must be treated as immutable.
+type View struct {
+	file *File
+}
+
+// NewView は File の読み取り専用のビューを返します。
+// NewView returns a read-only view of f. The caller must not modify f afterwards.
+func NewView(f *File) View {
+	return View{file: f}
+}
+
+// File は View が参照している File を返します。返された File を変更してはいけません。
+// File returns the File the view refers to. The returned File must not be modified.
+func (v View) File() *File {
+	return v.file
+}
+
+// NumRulesets はルールセットの数を返します。
+// NumRulesets returns the number of rulesets.
+func (v View) NumRulesets() int {
+	if v.file == nil {
+		return 0
+	}
+	return len(v.file.Rulesets)
+}
+
+// Ruleset は i 番目のルールセットを返します。
+// Ruleset returns the i-th ruleset.
+func (v View) Ruleset(i int) *Ruleset {
+	return v.file.Rulesets[i]
+}
+
+// ReplaceRuleset は i 番目のルールセットを置き換えた新しい View を返します。
+// ReplaceRuleset returns a new View with the i-th ruleset replaced by rs.
+func (v View) ReplaceRuleset(i int, rs *Ruleset) View {
+	f := v.copyFile()
+	f.Rulesets[i] = rs
+	return View{file: f}
+}
+
+// AppendRuleset はルールセットを末尾に追加した新しい View を返します。
+// AppendRuleset returns a new View with rs appended to the rulesets.
+func (v View) AppendRuleset(rs *Ruleset) View {
+	f := v.copyFile()
+	f.Rulesets = append(f.Rulesets, rs)
+	return View{file: f}
+}
+
+// RemoveRuleset は i 番目のルールセットを取り除いた新しい View を返します。
+// RemoveRuleset returns a new View without the i-th ruleset.
+func (v View) RemoveRuleset(i int) View {
+	f := v.copyFile()
+	f.Rulesets = append(f.Rulesets[:i], f.Rulesets[i+1:]...)
+	return View{file: f}
+}
+
+// ReplaceRule は i 番目のルールセットの j 番目のルールを置き換えた新しい View を返します。
+// ReplaceRule returns a new View with the j-th rule of the i-th ruleset replaced by rule.
+func (v View) ReplaceRule(i, j int, rule RuleDecl) View {
+	f, rs := v.copyRuleset(i)
+	rs.Rules[j] = rule
+	return View{file: f}
+}
+
+// InsertRule は i 番目のルールセットの j 番目にルールを挿入した新しい View を返します。
+// InsertRule returns a new View with rule inserted at index j of the i-th ruleset.
+func (v View) InsertRule(i, j int, rule RuleDecl) View {
+	f, rs := v.copyRuleset(i)
+	rs.Rules = append(rs.Rules, nil)
+	copy(rs.Rules[j+1:], rs.Rules[j:])
+	rs.Rules[j] = rule
+	return View{file: f}
+}
+
+// RemoveRule は i 番目のルールセットの j 番目のルールを取り除いた新しい View を返します。
+// RemoveRule returns a new View without the j-th rule of the i-th ruleset.
+func (v View) RemoveRule(i, j int) View {
+	f, rs := v.copyRuleset(i)
+	rs.Rules = append(rs.Rules[:j], rs.Rules[j+1:]...)
+	return View{file: f}
+}
+
+// copyFile returns a shallow copy of the file with its own Rulesets slice.
+func (v View) copyFile() *File {
+	f := &File{}
+	if v.file != nil {
+		*f = *v.file
+	}
+	f.Rulesets = append([]*Ruleset(nil), f.Rulesets...)
+	return f
+}
+
+// copyRuleset returns a copy of the file in which the i-th ruleset
+// and its Rules slice are copied, and the copied ruleset.
+func (v View) copyRuleset(i int) (*File, *Ruleset) {
+	f := v.copyFile()
+	rs := *f.Rulesets[i]
+	rs.Rules = append([]RuleDecl(nil), rs.Rules...)
+	f.Rulesets[i] = &rs
+	return f, &rs
+}
@@ -0,0 +1,50 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func newTestRule(name string) *Rule {
+	return &Rule{Type: &Ident{Name: name}}
+}
+
+func ruleNames(rs *Ruleset) []string {
+	names := make([]string, 0, len(rs.Rules))
+	for _, r := range rs.Rules {
+		names = append(names, r.(*Rule).Type.Name)
+	}
+	return names
+}
+
+func TestView__CopyOnWrite(t *testing.T) {
+	first := &Ruleset{Rules: []RuleDecl{newTestRule("IsUnique"), newTestRule("IsComplete")}}
+	second := &Ruleset{Rules: []RuleDecl{newTestRule("RowCount")}}
+	orig := NewView(&File{Filename: "a.dqdl", Rulesets: []*Ruleset{first, second}})
+
+	edited := orig.InsertRule(0, 1, newTestRule("ColumnCount")).
+		RemoveRule(0, 0).
+		ReplaceRule(0, 1, newTestRule("Completeness"))
+
+	if diff := cmp.Diff([]string{"ColumnCount", "Completeness"}, ruleNames(edited.Ruleset(0))); diff != "" {
+		t.Errorf("unexpected edited rules (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"IsUnique", "IsComplete"}, ruleNames(orig.Ruleset(0))); diff != "" {
+		t.Errorf("original rules must not change (-want +got):\n%s", diff)
+	}
+	if edited.Ruleset(1) != second {
+		t.Error("unchanged ruleset must be shared between views")
+	}
+	if edited.File().Filename != "a.dqdl" {
+		t.Errorf("edited filename = %q, want %q", edited.File().Filename, "a.dqdl")
+	}
+
+	removed := edited.RemoveRuleset(0).AppendRuleset(first)
+	if removed.NumRulesets() != 2 || removed.Ruleset(0) != second || removed.Ruleset(1) != first {
+		t.Error("unexpected rulesets after RemoveRuleset and AppendRuleset")
+	}
+	if edited.NumRulesets() != 2 || edited.Ruleset(1) != second {
+		t.Error("RemoveRuleset must not modify the receiver")
+	}
+}
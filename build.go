@@ -0,0 +1,380 @@
+package dqdl
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/parser"
+	"github.com/mashiike/go-dqdl/printer"
+)
+
+// RuleBuilder はルールを組み立てます。
+// A RuleBuilder assembles a rule step by step, e.g.
+//
+//	dqdl.Rule("ColumnValues").Str("colA").In("a", "b").WithThreshold(dqdl.GT(0.8))
+//
+// Build returns the rule with the positions it has in its printed form.
+type RuleBuilder struct {
+	rule *ast.Rule
+	err  error
+}
+
+// Rule は指定したルールタイプのルールを組み立てる RuleBuilder を返します。
+// Rule returns a builder of a rule of the given type.
+func Rule(ruleType string) *RuleBuilder {
+	return &RuleBuilder{rule: &ast.Rule{Type: &ast.Ident{Name: ruleType}}}
+}
+
+// Str は文字列のパラメータを追加します。
+// Str appends string parameters, e.g. column names.
+func (b *RuleBuilder) Str(values ...string) *RuleBuilder {
+	for _, v := range values {
+		b.rule.Parameters = append(b.rule.Parameters, &ast.StringParameter{Value: v})
+	}
+	return b
+}
+
+// Num は数値のパラメータを追加します。
+// Num appends a number parameter.
+func (b *RuleBuilder) Num(v float64) *RuleBuilder {
+	b.rule.Parameters = append(b.rule.Parameters, number(v))
+	return b
+}
+
+// Param は任意のパラメータを追加します。
+// Param appends a parameter node, e.g. one returned by Days.
+func (b *RuleBuilder) Param(param ast.Parameter) *RuleBuilder {
+	b.rule.Parameters = append(b.rule.Parameters, param)
+	return b
+}
+
+// Expr はルールの式を設定します。
+// Expr sets the expression of the rule, e.g. one returned by GT or Between.
+func (b *RuleBuilder) Expr(expr ast.Expression) *RuleBuilder {
+	b.rule.Expression = expr
+	return b
+}
+
+// In はルールの式を in 式に設定します。
+// In sets the expression of the rule to `in [values...]`. See Value for
+// the accepted types of values.
+func (b *RuleBuilder) In(values ...interface{}) *RuleBuilder {
+	expr := &ast.InExpression{}
+	for _, v := range values {
+		expr.Values = append(expr.Values, Value(v))
+	}
+	b.rule.Expression = expr
+	return b
+}
+
+// Matches はルールの式を matches 式に設定します。
+// Matches sets the expression of the rule to `matches "pattern"`.
+func (b *RuleBuilder) Matches(pattern string) *RuleBuilder {
+	b.rule.Expression = &ast.MatchesExpression{Value: pattern}
+	return b
+}
+
+// WithThreshold は in 式または matches 式に閾値を追加します。
+// WithThreshold adds `with threshold` to the in or matches expression of
+// the rule. Build fails if the rule has no such expression.
+func (b *RuleBuilder) WithThreshold(threshold ast.ThresholdExpression) *RuleBuilder {
+	target, ok := b.rule.Expression.(ast.ThresholdTarget)
+	if !ok {
+		if b.err == nil {
+			b.err = fmt.Errorf("dqdl: %s: with threshold requires an in or matches expression", b.rule.Type.Name)
+		}
+		return b
+	}
+	b.rule.Expression = &ast.WithThresholdExpression{Target: target, Threshold: threshold}
+	return b
+}
+
+// Describe はルールの説明のコメントを追加します。
+// Describe appends description comments written before the rule, one per
+// line. A "# " prefix is added to lines that do not start with "#".
+func (b *RuleBuilder) Describe(lines ...string) *RuleBuilder {
+	b.rule.Description = append(b.rule.Description, comments(lines)...)
+	return b
+}
+
+// Build はルールを返します。
+// Build returns the rule. The rule is printed and parsed back, so the
+// nodes carry the positions of the printed form and an error is returned
+// for a rule that is not valid DQDL syntax.
+func (b *RuleBuilder) Build() (*ast.Rule, error) {
+	decl, err := b.Decl()
+	if err != nil {
+		return nil, err
+	}
+	return decl.(*ast.Rule), nil
+}
+
+// Decl implements RuleDeclBuilder.
+func (b *RuleBuilder) Decl() (ast.RuleDecl, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return reparseRule(b.rule)
+}
+
+// CombinedRuleBuilder は and または or で結合されたルールを組み立てます。
+// A CombinedRuleBuilder assembles rules combined with and or or.
+type CombinedRuleBuilder struct {
+	operator    string
+	rules       []*RuleBuilder
+	description ast.CommentGroup
+}
+
+// And は全てのルールを満たすことを要求する結合ルールを組み立てます。
+// And returns a builder of `(rule) and (rule) ...`.
+func And(rules ...*RuleBuilder) *CombinedRuleBuilder {
+	return &CombinedRuleBuilder{operator: "and", rules: rules}
+}
+
+// Or はいずれかのルールを満たすことを要求する結合ルールを組み立てます。
+// Or returns a builder of `(rule) or (rule) ...`.
+func Or(rules ...*RuleBuilder) *CombinedRuleBuilder {
+	return &CombinedRuleBuilder{operator: "or", rules: rules}
+}
+
+// Describe は結合ルールの説明のコメントを追加します。
+// Describe appends description comments written before the combined rule.
+func (b *CombinedRuleBuilder) Describe(lines ...string) *CombinedRuleBuilder {
+	b.description = append(b.description, comments(lines)...)
+	return b
+}
+
+// Build は結合ルールを返します。
+// Build returns the combined rule, printed and parsed back like
+// RuleBuilder.Build. At least two rules are required.
+func (b *CombinedRuleBuilder) Build() (*ast.CombinedRule, error) {
+	decl, err := b.Decl()
+	if err != nil {
+		return nil, err
+	}
+	return decl.(*ast.CombinedRule), nil
+}
+
+// Decl implements RuleDeclBuilder.
+func (b *CombinedRuleBuilder) Decl() (ast.RuleDecl, error) {
+	combined, err := b.combined()
+	if err != nil {
+		return nil, err
+	}
+	return reparseRule(combined)
+}
+
+func (b *CombinedRuleBuilder) combined() (*ast.CombinedRule, error) {
+	if len(b.rules) < 2 {
+		return nil, fmt.Errorf("dqdl: %s needs at least 2 rules, got %d", b.operator, len(b.rules))
+	}
+	combined := &ast.CombinedRule{Operator: b.operator, Description: b.description}
+	for _, rb := range b.rules {
+		if rb.err != nil {
+			return nil, rb.err
+		}
+		if len(rb.rule.Description) > 0 {
+			return nil, fmt.Errorf("dqdl: %s: a rule in %s can not have a description", rb.rule.Type.Name, b.operator)
+		}
+		combined.Rules = append(combined.Rules, rb.rule)
+	}
+	return combined, nil
+}
+
+// RuleDeclBuilder は RuleBuilder と CombinedRuleBuilder が実装するインタフェースです。
+// RuleDeclBuilder is implemented by RuleBuilder and CombinedRuleBuilder.
+type RuleDeclBuilder interface {
+	// Decl returns the built rule declaration.
+	Decl() (ast.RuleDecl, error)
+}
+
+// RulesetBuilder はルールセットを組み立てます。
+// A RulesetBuilder assembles a ruleset.
+type RulesetBuilder struct {
+	rules       []RuleDeclBuilder
+	description ast.CommentGroup
+}
+
+// Ruleset は指定したルールからなるルールセットを組み立てる RulesetBuilder を返します。
+// Ruleset returns a builder of a ruleset of the given rules.
+func Ruleset(rules ...RuleDeclBuilder) *RulesetBuilder {
+	return &RulesetBuilder{rules: rules}
+}
+
+// Add はルールを追加します。
+// Add appends rules to the ruleset.
+func (b *RulesetBuilder) Add(rules ...RuleDeclBuilder) *RulesetBuilder {
+	b.rules = append(b.rules, rules...)
+	return b
+}
+
+// Describe はルールセットの説明のコメントを追加します。
+// Describe appends description comments written before `Rules`.
+func (b *RulesetBuilder) Describe(lines ...string) *RulesetBuilder {
+	b.description = append(b.description, comments(lines)...)
+	return b
+}
+
+// Build はルールセットを返します。
+// Build returns the ruleset. It is printed and parsed back, so the nodes
+// carry the positions of the printed form, which starts at line 1.
+func (b *RulesetBuilder) Build() (*ast.Ruleset, error) {
+	if len(b.rules) == 0 {
+		return nil, fmt.Errorf("dqdl: ruleset has no rules")
+	}
+	ruleset := &ast.Ruleset{Description: b.description}
+	for _, rb := range b.rules {
+		var decl ast.RuleDecl
+		switch rb := rb.(type) {
+		case *RuleBuilder:
+			if rb.err != nil {
+				return nil, rb.err
+			}
+			decl = rb.rule
+		case *CombinedRuleBuilder:
+			combined, err := rb.combined()
+			if err != nil {
+				return nil, err
+			}
+			decl = combined
+		default:
+			var err error
+			if decl, err = rb.Decl(); err != nil {
+				return nil, err
+			}
+		}
+		ruleset.Rules = append(ruleset.Rules, decl)
+	}
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, ruleset); err != nil {
+		return nil, err
+	}
+	built, err := parser.ParseRuleset(buf.String())
+	if err != nil {
+		return nil, fmt.Errorf("dqdl: invalid ruleset: %w", err)
+	}
+	return built, nil
+}
+
+func reparseRule(rule ast.RuleDecl) (ast.RuleDecl, error) {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, rule); err != nil {
+		return nil, err
+	}
+	built, err := parser.ParseRule(buf.String())
+	if err != nil {
+		return nil, fmt.Errorf("dqdl: invalid rule `%s`: %w", buf.String(), err)
+	}
+	return built, nil
+}
+
+func comments(lines []string) ast.CommentGroup {
+	g := make(ast.CommentGroup, 0, len(lines))
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "#") {
+			line = "# " + line
+		}
+		g = append(g, &ast.Comment{Text: line})
+	}
+	return g
+}
+
+// Value は Go の値をパラメータのノードに変換します。
+// Value converts v to a parameter node: a string to a StringParameter,
+// an integer or float to a NumberParameter, a bool to a BoolParameter,
+// and an ast.Parameter is returned as is. It panics for other types.
+func Value(v interface{}) ast.Parameter {
+	param, err := value(v)
+	if err != nil {
+		panic("dqdl: " + err.Error())
+	}
+	return param
+}
+
+func value(v interface{}) (ast.Parameter, error) {
+	switch v := v.(type) {
+	case ast.Parameter:
+		return v, nil
+	case string:
+		return &ast.StringParameter{Value: v}, nil
+	case bool:
+		return &ast.BoolParameter{Value: v}, nil
+	case int:
+		return &ast.NumberParameter{Value: strconv.Itoa(v)}, nil
+	case int64:
+		return &ast.NumberParameter{Value: strconv.FormatInt(v, 10)}, nil
+	case int32:
+		return &ast.NumberParameter{Value: strconv.FormatInt(int64(v), 10)}, nil
+	case uint:
+		return &ast.NumberParameter{Value: strconv.FormatUint(uint64(v), 10)}, nil
+	case uint64:
+		return &ast.NumberParameter{Value: strconv.FormatUint(v, 10)}, nil
+	case float64:
+		return number(v), nil
+	case float32:
+		return number(float64(v)), nil
+	}
+	return nil, fmt.Errorf("unsupported value type %T", v)
+}
+
+func number(v float64) *ast.NumberParameter {
+	return &ast.NumberParameter{Value: strconv.FormatFloat(v, 'f', -1, 64)}
+}
+
+func comparison(op string, v interface{}) *ast.ComparisonExpression {
+	return &ast.ComparisonExpression{Operator: op, Right: Value(v)}
+}
+
+// GT は `> v` の式を返します。
+// GT returns the expression `> v`. See Value for the accepted types of v.
+func GT(v interface{}) *ast.ComparisonExpression { return comparison(">", v) }
+
+// GE は `>= v` の式を返します。
+// GE returns the expression `>= v`.
+func GE(v interface{}) *ast.ComparisonExpression { return comparison(">=", v) }
+
+// LT は `< v` の式を返します。
+// LT returns the expression `< v`.
+func LT(v interface{}) *ast.ComparisonExpression { return comparison("<", v) }
+
+// LE は `<= v` の式を返します。
+// LE returns the expression `<= v`.
+func LE(v interface{}) *ast.ComparisonExpression { return comparison("<=", v) }
+
+// EQ は `= v` の式を返します。
+// EQ returns the expression `= v`.
+func EQ(v interface{}) *ast.ComparisonExpression { return comparison("=", v) }
+
+// Between は `between lo and hi` の式を返します。
+// Between returns the expression `between lo and hi`.
+func Between(lo, hi interface{}) *ast.BetweenExpression {
+	return &ast.BetweenExpression{Left: Value(lo), Right: Value(hi)}
+}
+
+// Hours は `n hours` のパラメータを返します。
+// Hours returns the duration parameter `n hours`.
+func Hours(n int) *ast.DurationParameter { return duration(n, "hours") }
+
+// Days は `n days` のパラメータを返します。
+// Days returns the duration parameter `n days`.
+func Days(n int) *ast.DurationParameter { return duration(n, "days") }
+
+func duration(n int, unit string) *ast.DurationParameter {
+	number := strconv.Itoa(n)
+	return &ast.DurationParameter{Value: number + " " + unit, Number: number, Unit: unit}
+}
+
+// Now は `now()` のパラメータを返します。
+// Now returns the date parameter `now()`.
+func Now() *ast.DateParameter {
+	return &ast.DateParameter{}
+}
+
+// Ago は `(now() - d)` のパラメータを返します。
+// Ago returns the date parameter `(now() - d)`.
+func Ago(d *ast.DurationParameter) *ast.DateParameter {
+	return &ast.DateParameter{Duration: d}
+}
@@ -0,0 +1,153 @@
+package dqdl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/printer"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+func TestRuleBuilder(t *testing.T) {
+	cases := []struct {
+		name    string
+		builder RuleDeclBuilder
+		want    string
+	}{
+		{
+			name:    "in with threshold",
+			builder: Rule("ColumnValues").Str("colA").In("a", "b").WithThreshold(GT(0.8)),
+			want:    `ColumnValues "colA" in ["a", "b"] with threshold > 0.8`,
+		},
+		{
+			name:    "no expression",
+			builder: Rule("IsUnique").Str("id"),
+			want:    `IsUnique "id"`,
+		},
+		{
+			name:    "between",
+			builder: Rule("Mean").Str("price").Expr(Between(1, 10.5)),
+			want:    `Mean "price" between 1 and 10.5`,
+		},
+		{
+			name:    "date",
+			builder: Rule("ColumnValues").Str("load_date").Expr(GE(Ago(Days(3)))),
+			want:    `ColumnValues "load_date" >= (now() - 3 days)`,
+		},
+		{
+			name:    "duration",
+			builder: Rule("DataFreshness").Str("load_date").Expr(LE(Hours(24))),
+			want:    `DataFreshness "load_date" <= 24 hours`,
+		},
+		{
+			name:    "matches",
+			builder: Rule("ColumnValues").Str("name").Matches("[a-z]+").WithThreshold(Between(0.5, 1)),
+			want:    `ColumnValues "name" matches "[a-z]+" with threshold between 0.5 and 1`,
+		},
+		{
+			name:    "custom sql",
+			builder: Rule("CustomSql").Str("select count(*) from primary").Expr(EQ(true)),
+			want:    `CustomSql "select count(*) from primary" = true`,
+		},
+		{
+			name:    "combined",
+			builder: Or(Rule("IsUnique").Str("id"), Rule("IsPrimaryKey").Str("id")),
+			want:    `(IsUnique "id") or (IsPrimaryKey "id")`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			decl, err := c.builder.Decl()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := printer.Fprint(&buf, decl); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, buf.String()); diff != "" {
+				t.Errorf("unexpected rule (-want +got):\n%s", diff)
+			}
+			// the positions are the ones of the printed form.
+			if got := decl.Pos(); got != (token.Pos{Index: 0, Line: 1, Column: 1}) {
+				t.Errorf("got Pos %v, want 1:1", got)
+			}
+			if got := decl.End().Index; got != len(c.want) {
+				t.Errorf("got End index %d, want %d", got, len(c.want))
+			}
+		})
+	}
+}
+
+func TestRuleBuilder__Error(t *testing.T) {
+	cases := []struct {
+		name    string
+		builder RuleDeclBuilder
+		want    string
+	}{
+		{
+			name:    "threshold without target",
+			builder: Rule("Mean").Str("a").Expr(GT(1)).WithThreshold(GT(0.5)),
+			want:    "dqdl: Mean: with threshold requires an in or matches expression",
+		},
+		{
+			name:    "invalid rule type",
+			builder: Rule("Is Unique").Str("a"),
+			want:    "dqdl: invalid rule `Is Unique \"a\"`: 1:4: syntax error near ` Unique \"a\"`, RuleType is already defined",
+		},
+		{
+			name:    "single combined rule",
+			builder: And(Rule("IsUnique").Str("a")),
+			want:    "dqdl: and needs at least 2 rules, got 1",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := c.builder.Decl()
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if got := err.Error(); got != c.want {
+				t.Errorf("got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestRulesetBuilder(t *testing.T) {
+	ruleset, err := Ruleset(
+		Rule("IsComplete").Str("id").Describe("id is required"),
+		Or(Rule("IsUnique").Str("id"), Rule("IsPrimaryKey").Str("id")),
+	).Describe("orders").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, ruleset); err != nil {
+		t.Fatal(err)
+	}
+	want := `# orders
+Rules = [
+	# id is required
+	IsComplete "id",
+	(IsUnique "id") or (IsPrimaryKey "id")
+]
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("unexpected ruleset (-want +got):\n%s", diff)
+	}
+	if got := ruleset.Rules[1].(*ast.CombinedRule).Pos(); got != (token.Pos{Index: 56, Line: 5, Column: 2}) {
+		t.Errorf("got Pos %v of the combined rule, want 5:2", got)
+	}
+}
+
+func TestValue__Panic(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	Value(struct{}{})
+}
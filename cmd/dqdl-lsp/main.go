@@ -0,0 +1,27 @@
+// Command dqdl-lsp は DQDL の Language Server です。
+// Command dqdl-lsp is a Language Server for DQDL. It speaks the Language
+// Server Protocol over stdin and stdout and provides diagnostics, hover
+// documentation of rule types, completion of rule types and keywords, and
+// document formatting. On start, it parses the .dqdl files of the
+// workspace folders concurrently and publishes their diagnostics,
+// reporting the progress if the client supports window/workDoneProgress.
+package main
+
+import (
+	"log"
+	"os"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("dqdl-lsp: ")
+	s := newServer(os.Stdin, os.Stdout)
+	if err := s.run(); err != nil {
+		log.Fatal(err)
+	}
+	if !s.shutdown {
+		// exit without a shutdown request is an error, see the spec of
+		// the exit notification.
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/mashiike/go-dqdl/diag"
+)
+
+// The server works with characters counted in runes, as the columns of
+// the parser. When the client did not agree to utf-32, the characters of
+// the positions it sends and receives are counted in UTF-16 code units,
+// and are converted at the boundary.
+
+// fromClient converts pos received from the client to runes.
+func (s *server) fromClient(text string, pos diag.LSPPosition) diag.LSPPosition {
+	if s.positionEncoding != positionEncodingUTF16 {
+		return pos
+	}
+	units := pos.Character
+	runes := 0
+	for _, r := range lineOf(text, pos.Line) {
+		w := utf16Len(r)
+		if units < w {
+			break
+		}
+		units -= w
+		runes++
+	}
+	pos.Character = runes + units
+	return pos
+}
+
+// toClient converts pos in runes to the encoding of the client.
+func (s *server) toClient(text string, pos diag.LSPPosition) diag.LSPPosition {
+	if s.positionEncoding != positionEncodingUTF16 {
+		return pos
+	}
+	runes := pos.Character
+	units := 0
+	for _, r := range lineOf(text, pos.Line) {
+		if runes == 0 {
+			break
+		}
+		units += utf16Len(r)
+		runes--
+	}
+	pos.Character = units + runes
+	return pos
+}
+
+func (s *server) toClientRange(text string, rng diag.LSPRange) diag.LSPRange {
+	return diag.LSPRange{Start: s.toClient(text, rng.Start), End: s.toClient(text, rng.End)}
+}
+
+// utf16Len returns the number of UTF-16 code units encoding r.
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// lineOf returns the zero-based line n of text without its newline, or
+// the empty string if there is no such line.
+func lineOf(text string, n int) string {
+	for i := 0; i < n; i++ {
+		j := strings.IndexByte(text, '\n')
+		if j < 0 {
+			return ""
+		}
+		text = text[j+1:]
+	}
+	if j := strings.IndexByte(text, '\n'); j >= 0 {
+		text = text[:j]
+	}
+	return text
+}
@@ -0,0 +1,136 @@
+package main
+
+import "github.com/mashiike/go-dqdl/diag"
+
+// The subset of the Language Server Protocol used by the server.
+// Positions and diagnostics reuse the types of the diag package.
+
+type initializeParams struct {
+	RootURI          string            `json:"rootUri"`
+	WorkspaceFolders []workspaceFolder `json:"workspaceFolders"`
+	Capabilities     struct {
+		General struct {
+			PositionEncodings []string `json:"positionEncodings"`
+		} `json:"general"`
+		Window struct {
+			WorkDoneProgress bool `json:"workDoneProgress"`
+		} `json:"window"`
+	} `json:"capabilities"`
+}
+
+type workspaceFolder struct {
+	URI string `json:"uri"`
+}
+
+// Position encodings of the protocol. The columns of the parser count
+// runes, which is utf-32; utf-16 is the default of the protocol.
+const (
+	positionEncodingUTF16 = "utf-16"
+	positionEncodingUTF32 = "utf-32"
+)
+
+type initializeResult struct {
+	Capabilities serverCapabilities `json:"capabilities"`
+	ServerInfo   serverInfo         `json:"serverInfo"`
+}
+
+type serverCapabilities struct {
+	PositionEncoding           string            `json:"positionEncoding"`
+	TextDocumentSync           int               `json:"textDocumentSync"`
+	HoverProvider              bool              `json:"hoverProvider"`
+	CompletionProvider         completionOptions `json:"completionProvider"`
+	DocumentFormattingProvider bool              `json:"documentFormattingProvider"`
+}
+
+// textDocumentSyncFull tells the client to send the whole document on
+// every change.
+const textDocumentSyncFull = 1
+
+type completionOptions struct{}
+
+type serverInfo struct {
+	Name string `json:"name"`
+}
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type textDocumentItem struct {
+	URI  string `json:"uri"`
+	Text string `json:"text"`
+}
+
+type didOpenParams struct {
+	TextDocument textDocumentItem `json:"textDocument"`
+}
+
+type didChangeParams struct {
+	TextDocument   textDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type didCloseParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     diag.LSPPosition       `json:"position"`
+}
+
+type documentFormattingParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type publishDiagnosticsParams struct {
+	URI         string               `json:"uri"`
+	Diagnostics []diag.LSPDiagnostic `json:"diagnostics"`
+}
+
+type hover struct {
+	Contents markupContent  `json:"contents"`
+	Range    *diag.LSPRange `json:"range,omitempty"`
+}
+
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// CompletionItemKind values of the protocol.
+const (
+	completionKindFunction = 3
+	completionKindKeyword  = 14
+)
+
+type completionItem struct {
+	Label  string `json:"label"`
+	Kind   int    `json:"kind"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type textEdit struct {
+	Range   diag.LSPRange `json:"range"`
+	NewText string        `json:"newText"`
+}
+
+type workDoneProgressCreateParams struct {
+	Token string `json:"token"`
+}
+
+type progressParams struct {
+	Token string                `json:"token"`
+	Value workDoneProgressValue `json:"value"`
+}
+
+// workDoneProgressValue is the value of a begin, report or end
+// notification, told apart by Kind.
+type workDoneProgressValue struct {
+	Kind       string `json:"kind"`
+	Title      string `json:"title,omitempty"`
+	Message    string `json:"message,omitempty"`
+	Percentage *int   `json:"percentage,omitempty"`
+}
@@ -0,0 +1,466 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/diag"
+	"github.com/mashiike/go-dqdl/lint"
+	"github.com/mashiike/go-dqdl/parser"
+	"github.com/mashiike/go-dqdl/printer"
+	"github.com/mashiike/go-dqdl/validate"
+)
+
+// server handles the messages of one client, one at a time.
+type server struct {
+	in       *bufio.Reader
+	out      io.Writer
+	docs     map[string]string // open documents by URI
+	linter   *lint.Linter
+	shutdown bool // a shutdown request has been received
+
+	// positionEncoding is the encoding of the characters of the
+	// positions exchanged with the client.
+	positionEncoding string
+
+	roots            []string // directories of the workspace folders
+	workDoneProgress bool     // the client accepts progress created by the server
+}
+
+func newServer(in io.Reader, out io.Writer) *server {
+	return &server{
+		in:     bufio.NewReader(in),
+		out:    out,
+		docs:   make(map[string]string),
+		linter: lint.New(),
+
+		positionEncoding: positionEncodingUTF16,
+	}
+}
+
+// run handles messages until the exit notification or the end of input.
+func (s *server) run() error {
+	for {
+		body, err := readMessage(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			if err := s.reply(nil, nil, &rpcError{Code: codeParseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "" {
+			// a response to a request of the server
+			if err := s.handleResponse(body); err != nil {
+				log.Printf("response: %s", err)
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+		result, err := s.handle(&req)
+		if req.ID == nil {
+			if err != nil {
+				log.Printf("%s: %s", req.Method, err)
+			}
+			continue
+		}
+		var rerr *rpcError
+		if err != nil && !errors.As(err, &rerr) {
+			rerr = &rpcError{Code: codeRequestFailed, Message: err.Error()}
+		}
+		if err := s.reply(req.ID, result, rerr); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *server) reply(id *json.RawMessage, result interface{}, rerr *rpcError) error {
+	resp := &response{JSONRPC: "2.0", ID: id, Error: rerr}
+	if rerr == nil {
+		bs, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		resp.Result = bs
+	}
+	return writeMessage(s.out, resp)
+}
+
+func (s *server) notify(method string, params interface{}) error {
+	bs, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return writeMessage(s.out, &request{JSONRPC: "2.0", Method: method, Params: bs})
+}
+
+// call sends a request to the client. The response is passed to
+// handleResponse.
+func (s *server) call(id, method string, params interface{}) error {
+	bs, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	rawID, err := json.Marshal(id)
+	if err != nil {
+		return err
+	}
+	return writeMessage(s.out, &request{JSONRPC: "2.0", ID: (*json.RawMessage)(&rawID), Method: method, Params: bs})
+}
+
+func (s *server) handle(req *request) (interface{}, error) {
+	switch req.Method {
+	case "initialize":
+		var params initializeParams
+		if len(req.Params) > 0 {
+			if err := unmarshalParams(req, &params); err != nil {
+				return nil, err
+			}
+		}
+		for _, enc := range params.Capabilities.General.PositionEncodings {
+			if enc == positionEncodingUTF32 {
+				s.positionEncoding = positionEncodingUTF32
+			}
+		}
+		s.roots = rootDirs(params)
+		s.workDoneProgress = params.Capabilities.Window.WorkDoneProgress
+		return initializeResult{
+			Capabilities: serverCapabilities{
+				PositionEncoding:           s.positionEncoding,
+				TextDocumentSync:           textDocumentSyncFull,
+				HoverProvider:              true,
+				CompletionProvider:         completionOptions{},
+				DocumentFormattingProvider: true,
+			},
+			ServerInfo: serverInfo{Name: "dqdl-lsp"},
+		}, nil
+	case "initialized":
+		return nil, s.startIndex()
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.update(params.TextDocument.URI, params.TextDocument.Text)
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		if n := len(params.ContentChanges); n > 0 {
+			return nil, s.update(params.TextDocument.URI, params.ContentChanges[n-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return nil, s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []diag.LSPDiagnostic{},
+		})
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		return s.hover(params), nil
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		return s.complete(params), nil
+	case "textDocument/formatting":
+		var params documentFormattingParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		return s.format(params.TextDocument.URI)
+	}
+	if req.ID == nil || strings.HasPrefix(req.Method, "$/") {
+		// unknown notifications are ignored.
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not found: %s", req.Method)}
+}
+
+func unmarshalParams(req *request, v interface{}) error {
+	if err := json.Unmarshal(req.Params, v); err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// update stores the text of a document and publishes its diagnostics.
+func (s *server) update(uri, text string) error {
+	s.docs[uri] = text
+	return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: s.diagnostics(uri, text),
+	})
+}
+
+// diagnostics returns the syntax errors of text together with the
+// findings of the validator and the linter on the rest of it, or the
+// syntax error if text can not be parsed at all.
+func (s *server) diagnostics(uri, text string) []diag.LSPDiagnostic {
+	var diags []diag.Diagnostic
+	file, err := parser.ParseFile(filename(uri), strings.NewReader(text), parser.WithErrorRecovery(func(d diag.Diagnostic) {
+		diags = append(diags, d)
+	}))
+	return s.check(text, file, err, diags)
+}
+
+// check returns diags, the syntax errors recovered from in parsing text
+// into file, together with the findings of the validator and the linter
+// on file, or with err if text could not be parsed at all.
+func (s *server) check(text string, file *ast.File, err error, diags []diag.Diagnostic) []diag.LSPDiagnostic {
+	if err != nil {
+		var perr *parser.Error
+		if errors.As(err, &perr) {
+			diags = append(diags, perr.Diagnostic())
+		} else {
+			diags = append(diags, diag.Diagnostic{
+				Code:     "syntax-error",
+				Severity: diag.SeverityError,
+				Message:  err.Error(),
+				Source:   "parser",
+			})
+		}
+	} else {
+		diags = append(diags, validate.File(file)...)
+		diags = append(diags, s.linter.Lint(file)...)
+		diag.Sort(diags)
+	}
+	lspDiags := make([]diag.LSPDiagnostic, 0, len(diags))
+	for _, d := range diags {
+		ld := d.LSP()
+		ld.Range = s.toClientRange(text, ld.Range)
+		lspDiags = append(lspDiags, ld)
+	}
+	return lspDiags
+}
+
+// hover returns the documentation of the rule type under the cursor, or
+// nil if there is none. It looks at the text rather than the syntax tree,
+// so that it works while the document does not parse.
+func (s *server) hover(params textDocumentPositionParams) *hover {
+	text, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+	word, rng, ok := wordAt(text, s.fromClient(text, params.Position))
+	if !ok {
+		return nil
+	}
+	rng = s.toClientRange(text, rng)
+	spec, ok := validate.Lookup(word)
+	if !ok {
+		return nil
+	}
+	return &hover{
+		Contents: markupContent{Kind: "markdown", Value: describe(spec)},
+		Range:    &rng,
+	}
+}
+
+// wordAt returns the identifier at pos and its range.
+func wordAt(text string, pos diag.LSPPosition) (string, diag.LSPRange, bool) {
+	lines := strings.Split(text, "\n")
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return "", diag.LSPRange{}, false
+	}
+	line := []rune(lines[pos.Line])
+	isWord := func(i int) bool {
+		return 0 <= i && i < len(line) && (unicode.IsLetter(line[i]) || unicode.IsDigit(line[i]) || line[i] == '_')
+	}
+	start, end := pos.Character, pos.Character
+	for isWord(start - 1) {
+		start--
+	}
+	for isWord(end) {
+		end++
+	}
+	if start == end {
+		return "", diag.LSPRange{}, false
+	}
+	return string(line[start:end]), diag.LSPRange{
+		Start: diag.LSPPosition{Line: pos.Line, Character: start},
+		End:   diag.LSPPosition{Line: pos.Line, Character: end},
+	}, true
+}
+
+// describe returns the markdown documentation of a rule type.
+func describe(spec *validate.RuleSpec) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "```dqdl\n%s\n```\n\n", signature(spec))
+	switch spec.Expression {
+	case validate.ExpressionForbidden:
+		b.WriteString("Takes no expression.")
+	case validate.ExpressionOptional:
+		fmt.Fprintf(&b, "Takes an optional %s expression.", expressionKinds(spec.Expressions))
+	default:
+		fmt.Fprintf(&b, "Takes %s expression.", withArticle(expressionKinds(spec.Expressions)))
+	}
+	fmt.Fprintf(&b, "\n\n[Reference](https://docs.aws.amazon.com/glue/latest/dg/dqdl.html#dqdl-rule-types-%s)", spec.Name)
+	return b.String()
+}
+
+// signature returns e.g. `IsUnique <column: string>`.
+func signature(spec *validate.RuleSpec) string {
+	var b strings.Builder
+	b.WriteString(spec.Name)
+	for _, p := range spec.Params {
+		fmt.Fprintf(&b, " <%s: %s>", p.Name, p.Type)
+	}
+	if spec.Variadic {
+		b.WriteString(" ...")
+	}
+	return b.String()
+}
+
+var allExpressionKinds = []validate.ExpressionKind{
+	validate.ExpressionComparison,
+	validate.ExpressionBetween,
+	validate.ExpressionIn,
+	validate.ExpressionMatches,
+	validate.ExpressionWithThreshold,
+}
+
+// expressionKinds returns e.g. "comparison or between".
+func expressionKinds(kinds validate.ExpressionKind) string {
+	if kinds == 0 {
+		return "any"
+	}
+	var names []string
+	for _, k := range allExpressionKinds {
+		if kinds&k != 0 {
+			names = append(names, k.String())
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+func withArticle(s string) string {
+	if s == "any" {
+		return "any"
+	}
+	return "a " + s
+}
+
+// complete returns the rule types and keywords that may follow the
+// cursor.
+func (s *server) complete(params textDocumentPositionParams) []completionItem {
+	items := []completionItem{}
+	text, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		return items
+	}
+	offset, ok := offsetOf(text, s.fromClient(text, params.Position))
+	if !ok {
+		return items
+	}
+	for _, suggestion := range parser.Complete(text, offset) {
+		item := completionItem{Label: suggestion.Text, Kind: completionKindKeyword}
+		if suggestion.Kind == parser.SuggestRuleType {
+			item.Kind = completionKindFunction
+			if spec, ok := validate.Lookup(suggestion.Text); ok {
+				item.Detail = signature(spec)
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// offsetOf returns the byte offset of pos in text.
+func offsetOf(text string, pos diag.LSPPosition) (int, bool) {
+	offset := 0
+	for i := 0; i < pos.Line; i++ {
+		n := strings.IndexByte(text[offset:], '\n')
+		if n < 0 {
+			return 0, false
+		}
+		offset += n + 1
+	}
+	line := text[offset:]
+	if n := strings.IndexByte(line, '\n'); n >= 0 {
+		line = line[:n]
+	}
+	for i := 0; i < pos.Character; i++ {
+		if len(line) == 0 {
+			return 0, false
+		}
+		_, size := utf8.DecodeRuneInString(line)
+		offset += size
+		line = line[size:]
+	}
+	return offset, true
+}
+
+// format returns an edit replacing the document with its printed form,
+// or no edits if it is already formatted.
+func (s *server) format(uri string) ([]textEdit, error) {
+	text, ok := s.docs[uri]
+	if !ok {
+		return nil, fmt.Errorf("unknown document: %s", uri)
+	}
+	file, err := parser.ParseFile(filename(uri), strings.NewReader(text))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, file); err != nil {
+		return nil, err
+	}
+	if buf.String() == text {
+		return []textEdit{}, nil
+	}
+	return []textEdit{{
+		Range: diag.LSPRange{
+			Start: diag.LSPPosition{},
+			End:   s.toClient(text, endPosition(text)),
+		},
+		NewText: buf.String(),
+	}}, nil
+}
+
+// endPosition returns the position after the last character of text.
+func endPosition(text string) diag.LSPPosition {
+	line := strings.Count(text, "\n")
+	last := text[strings.LastIndex(text, "\n")+1:]
+	return diag.LSPPosition{Line: line, Character: utf8.RuneCountInString(last)}
+}
+
+// filename returns the path of a file URI, or uri itself otherwise.
+func filename(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return u.Path
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// session runs the server on the given messages and returns its output
+// messages decoded as generic JSON values.
+func session(t *testing.T, msgs ...string) []map[string]interface{} {
+	t.Helper()
+	var in, out bytes.Buffer
+	for _, msg := range msgs {
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+	if err := newServer(&in, &out).run(); err != nil {
+		t.Fatal(err)
+	}
+	var got []map[string]interface{}
+	r := bufio.NewReader(&out)
+	for {
+		body, err := readMessage(r)
+		if err == io.EOF {
+			return got
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v map[string]interface{}
+		if err := json.Unmarshal(body, &v); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+	}
+}
+
+func didOpen(text string) string {
+	bs, _ := json.Marshal(text)
+	return `{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///rules/a.dqdl","languageId":"dqdl","version":1,"text":` + string(bs) + `}}}`
+}
+
+func TestServer__Diagnostics(t *testing.T) {
+	got := session(t,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		didOpen("Rules = [\n\t# unique\n\tIsUniq \"id\"\n]\n"),
+		didOpen("Rules = [\n\tIsUnique \"id\" >\n]\n"),
+		didOpen("Rules = [\n\tIsUniq \"id\",\n\tIsUnique \"id\" >\n]\n"),
+	)
+	if len(got) != 4 {
+		t.Fatalf("got %d messages, want 4", len(got))
+	}
+	if caps := got[0]["result"].(map[string]interface{})["capabilities"]; caps.(map[string]interface{})["hoverProvider"] != true {
+		t.Errorf("unexpected capabilities: %v", caps)
+	}
+	messages := func(msg map[string]interface{}) []string {
+		var ms []string
+		for _, d := range msg["params"].(map[string]interface{})["diagnostics"].([]interface{}) {
+			ms = append(ms, d.(map[string]interface{})["message"].(string))
+		}
+		return ms
+	}
+	want := []string{"unknown rule type `IsUniq`, did you mean `IsUnique`?"}
+	if diff := cmp.Diff(want, messages(got[1])); diff != "" {
+		t.Errorf("unexpected diagnostics (-want +got):\n%s", diff)
+	}
+	want = []string{"rule has no description comment", "syntax error near ``, unexpected token `]`"}
+	if diff := cmp.Diff(want, messages(got[2])); diff != "" {
+		t.Errorf("unexpected diagnostics (-want +got):\n%s", diff)
+	}
+	// the rest of a file with a syntax error is checked.
+	want = []string{
+		"unknown rule type `IsUniq`, did you mean `IsUnique`?",
+		"rule has no description comment",
+		"rule has no description comment",
+		"syntax error near ``, unexpected token `]`",
+	}
+	if diff := cmp.Diff(want, messages(got[3])); diff != "" {
+		t.Errorf("unexpected diagnostics (-want +got):\n%s", diff)
+	}
+}
+
+func TestServer__Hover(t *testing.T) {
+	got := session(t,
+		didOpen("Rules = [\n\tIsUnique \"id\"\n]\n"),
+		`{"jsonrpc":"2.0","id":1,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///rules/a.dqdl"},"position":{"line":1,"character":3}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///rules/a.dqdl"},"position":{"line":1,"character":12}}}`,
+	)
+	want := map[string]interface{}{
+		"contents": map[string]interface{}{
+			"kind":  "markdown",
+			"value": "```dqdl\nIsUnique <column: string>\n```\n\nTakes no expression.\n\n[Reference](https://docs.aws.amazon.com/glue/latest/dg/dqdl.html#dqdl-rule-types-IsUnique)",
+		},
+		"range": map[string]interface{}{
+			"start": map[string]interface{}{"line": 1.0, "character": 1.0},
+			"end":   map[string]interface{}{"line": 1.0, "character": 9.0},
+		},
+	}
+	if diff := cmp.Diff(want, got[1]["result"]); diff != "" {
+		t.Errorf("unexpected hover (-want +got):\n%s", diff)
+	}
+	if r, ok := got[2]["result"]; !ok || r != nil {
+		t.Errorf("got %v, want a null result", got[2])
+	}
+}
+
+func TestServer__Completion(t *testing.T) {
+	got := session(t,
+		didOpen("Rules = [\n\tIsU\n]\n"),
+		`{"jsonrpc":"2.0","id":1,"method":"textDocument/completion","params":{"textDocument":{"uri":"file:///rules/a.dqdl"},"position":{"line":1,"character":4}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/completion","params":{"textDocument":{"uri":"file:///rules/a.dqdl"},"position":{"line":0,"character":2}}}`,
+	)
+	want := []interface{}{
+		map[string]interface{}{"label": "IsUnique", "kind": float64(completionKindFunction), "detail": "IsUnique <column: string>"},
+	}
+	if diff := cmp.Diff(want, got[1]["result"]); diff != "" {
+		t.Errorf("unexpected completion (-want +got):\n%s", diff)
+	}
+	want = []interface{}{
+		map[string]interface{}{"label": "Rules", "kind": float64(completionKindKeyword)},
+	}
+	if diff := cmp.Diff(want, got[2]["result"]); diff != "" {
+		t.Errorf("unexpected completion (-want +got):\n%s", diff)
+	}
+}
+
+func TestServer__Formatting(t *testing.T) {
+	format := `{"jsonrpc":"2.0","id":1,"method":"textDocument/formatting","params":{"textDocument":{"uri":"file:///rules/a.dqdl"},"options":{"tabSize":4,"insertSpaces":false}}}`
+	got := session(t,
+		didOpen("Rules = [ IsUnique   \"id\",\n  IsComplete \"id\" ]"),
+		format,
+		didOpen("Rules = [\n\tIsUnique \"id\"\n]\n"),
+		format,
+	)
+	want := []interface{}{
+		map[string]interface{}{
+			"range": map[string]interface{}{
+				"start": map[string]interface{}{"line": 0.0, "character": 0.0},
+				"end":   map[string]interface{}{"line": 1.0, "character": 19.0},
+			},
+			"newText": "Rules = [\n\tIsUnique \"id\",\n\tIsComplete \"id\"\n]\n",
+		},
+	}
+	if diff := cmp.Diff(want, got[1]["result"]); diff != "" {
+		t.Errorf("unexpected edits (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]interface{}{}, got[3]["result"]); diff != "" {
+		t.Errorf("unexpected edits for a formatted document (-want +got):\n%s", diff)
+	}
+}
+
+func TestServer__Shutdown(t *testing.T) {
+	var in, out bytes.Buffer
+	for _, msg := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"unreachable"}`,
+	} {
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+	s := newServer(&in, &out)
+	if err := s.run(); err != nil {
+		t.Fatal(err)
+	}
+	if !s.shutdown {
+		t.Error("server is not shut down")
+	}
+	want := "Content-Length: 38\r\n\r\n" + `{"jsonrpc":"2.0","id":1,"result":null}`
+	if got := out.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReadMessage__ContentLength(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      string
+		wantErr string
+	}{
+		{name: "ok", in: "Content-Length: 2\r\n\r\n{}"},
+		{name: "negative", in: "Content-Length: -1\r\n\r\n{}", wantErr: "invalid Content-Length: -1 is out of range [0, 67108864]"},
+		{name: "too large", in: "Content-Length: 1099511627776\r\n\r\n{}", wantErr: "invalid Content-Length: 1099511627776 is out of range [0, 67108864]"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := readMessage(bufio.NewReader(strings.NewReader(c.in)))
+			var got string
+			if err != nil {
+				got = err.Error()
+			}
+			if diff := cmp.Diff(c.wantErr, got); diff != "" {
+				t.Errorf("unexpected error (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestServer__PositionEncoding(t *testing.T) {
+	// the second IsUnique starts at the 16th rune, which is the 17th
+	// UTF-16 code unit after the surrogate pair of the emoji.
+	text := "Rules = [\n\tIsUnique \"\U0001F600\", IsUnique \"id\"\n]\n"
+	hover := func(character int) string {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///rules/a.dqdl"},"position":{"line":1,"character":%d}}}`, character)
+	}
+	cases := []struct {
+		name       string
+		initialize string
+		character  int
+		wantEnc    string
+		wantRange  [2]float64
+	}{
+		{
+			name:       "utf-16",
+			initialize: `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"capabilities":{}}}`,
+			character:  18,
+			wantEnc:    "utf-16",
+			wantRange:  [2]float64{16, 24},
+		},
+		{
+			name:       "utf-32",
+			initialize: `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"capabilities":{"general":{"positionEncodings":["utf-16","utf-32"]}}}}`,
+			character:  17,
+			wantEnc:    "utf-32",
+			wantRange:  [2]float64{15, 23},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := session(t, c.initialize, didOpen(text), hover(c.character))
+			caps := got[0]["result"].(map[string]interface{})["capabilities"].(map[string]interface{})
+			if caps["positionEncoding"] != c.wantEnc {
+				t.Errorf("got position encoding %v, want %s", caps["positionEncoding"], c.wantEnc)
+			}
+			rng := got[2]["result"].(map[string]interface{})["range"].(map[string]interface{})
+			gotRange := [2]float64{
+				rng["start"].(map[string]interface{})["character"].(float64),
+				rng["end"].(map[string]interface{})["character"].(float64),
+			}
+			if diff := cmp.Diff(c.wantRange, gotRange); diff != "" {
+				t.Errorf("unexpected range (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestServer__IndexWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, src := range map[string]string{
+		"a.dqdl":     "Rules = [\n\t# unique\n\tIsUniq \"id\"\n]\n",
+		"sub/b.dqdl": "Rules = [\n\t# rows\n\tRowCount >\n]\n",
+		"README.md":  "not a ruleset",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	initialize := func(workDoneProgress bool) string {
+		return fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"rootUri":%q,"capabilities":{"window":{"workDoneProgress":%t}}}}`, fileURI(dir), workDoneProgress)
+	}
+	summarize := func(msgs []map[string]interface{}) []string {
+		var got []string
+		for _, msg := range msgs[1:] {
+			params := msg["params"].(map[string]interface{})
+			switch msg["method"] {
+			case "window/workDoneProgress/create":
+				got = append(got, fmt.Sprintf("create %v %v", msg["id"], params["token"]))
+			case "$/progress":
+				value := params["value"].(map[string]interface{})
+				got = append(got, fmt.Sprintf("%v %v %v", value["kind"], value["percentage"], value["message"]))
+			case "textDocument/publishDiagnostics":
+				uri := strings.TrimPrefix(params["uri"].(string), fileURI(dir)+"/")
+				for _, d := range params["diagnostics"].([]interface{}) {
+					got = append(got, uri+": "+d.(map[string]interface{})["message"].(string))
+				}
+			default:
+				got = append(got, fmt.Sprint(msg["method"]))
+			}
+		}
+		return got
+	}
+	diagnostics := []string{
+		"a.dqdl: unknown rule type `IsUniq`, did you mean `IsUnique`?",
+		"sub/b.dqdl: syntax error near ``, unexpected token `]`",
+	}
+
+	got := summarize(session(t,
+		initialize(true),
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		`{"jsonrpc":"2.0","id":"dqdl/index","result":null}`,
+	))
+	want := append([]string{
+		"create dqdl/index dqdl/index",
+		"begin 0 <nil>",
+		"report 50 1/2 files, 0 errors",
+		"report 100 2/2 files, 1 errors",
+	}, diagnostics...)
+	want = append(want, "end <nil> 2 files, 1 errors")
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+
+	got = summarize(session(t,
+		initialize(false),
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+	))
+	if diff := cmp.Diff(diagnostics, got); diff != "" {
+		t.Errorf("without progress (-want, +got)\n%s", diff)
+	}
+}
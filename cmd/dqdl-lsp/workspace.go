@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/mashiike/go-dqdl/diag"
+	"github.com/mashiike/go-dqdl/parser"
+)
+
+// indexRequestID is the ID of the window/workDoneProgress/create request
+// sent before indexing the workspace, and indexProgressToken is the token
+// of the progress it creates.
+const (
+	indexRequestID     = "dqdl/index"
+	indexProgressToken = "dqdl/index"
+)
+
+// rootDirs returns the directories of the workspace folders, or of the
+// root if the client does not support folders.
+func rootDirs(params initializeParams) []string {
+	uris := []string{params.RootURI}
+	if len(params.WorkspaceFolders) > 0 {
+		uris = uris[:0]
+		for _, folder := range params.WorkspaceFolders {
+			uris = append(uris, folder.URI)
+		}
+	}
+	var dirs []string
+	for _, uri := range uris {
+		if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+			dirs = append(dirs, filepath.FromSlash(u.Path))
+		}
+	}
+	return dirs
+}
+
+// fileURI returns the file URI of path.
+func fileURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// startIndex starts indexing the workspace. If the client accepts
+// progress created by the server, it asks the client to create one, and
+// the indexing starts on the response.
+func (s *server) startIndex() error {
+	if len(s.roots) == 0 {
+		return nil
+	}
+	if !s.workDoneProgress {
+		return s.index(false)
+	}
+	return s.call(indexRequestID, "window/workDoneProgress/create", workDoneProgressCreateParams{Token: indexProgressToken})
+}
+
+// handleResponse handles a response of the client to a request of the
+// server.
+func (s *server) handleResponse(body []byte) error {
+	var resp response
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return err
+	}
+	var id string
+	if resp.ID == nil || json.Unmarshal(*resp.ID, &id) != nil || id != indexRequestID {
+		return nil
+	}
+	// without the progress if the client failed to create it
+	return s.index(resp.Error == nil)
+}
+
+// index parses the .dqdl files of the workspace folders and publishes
+// their diagnostics. If withProgress, the files parsed and the errors so
+// far are reported through the progress of indexProgressToken.
+func (s *server) index(withProgress bool) error {
+	progress := func(workDoneProgressValue) error { return nil }
+	if withProgress {
+		progress = func(v workDoneProgressValue) error {
+			return s.notify("$/progress", progressParams{Token: indexProgressToken, Value: v})
+		}
+	}
+	percentage := 0
+	if err := progress(workDoneProgressValue{Kind: "begin", Title: "Indexing DQDL files", Percentage: &percentage}); err != nil {
+		return err
+	}
+	var files, failed int
+	for i, root := range s.roots {
+		var progressErr error
+		total, errs, err := s.indexDir(root, func(p parser.Progress) {
+			// the percentage spreads over the folders
+			pct := (100*i + 100*p.Parsed/p.Total) / len(s.roots)
+			if pct == percentage || progressErr != nil {
+				return
+			}
+			percentage = pct
+			progressErr = progress(workDoneProgressValue{
+				Kind:       "report",
+				Message:    fmt.Sprintf("%d/%d files, %d errors", p.Parsed, p.Total, failed+p.Errors),
+				Percentage: &percentage,
+			})
+		})
+		if err == nil {
+			err = progressErr
+		}
+		if err != nil {
+			progress(workDoneProgressValue{Kind: "end", Message: err.Error()})
+			return err
+		}
+		files += total
+		failed += errs
+	}
+	return progress(workDoneProgressValue{Kind: "end", Message: fmt.Sprintf("%d files, %d errors", files, failed)})
+}
+
+// indexDir parses the .dqdl files under dir concurrently, calling
+// progress as each is parsed, and publishes their diagnostics, except for
+// the open documents. It returns the number of files and of those with
+// syntax errors.
+func (s *server) indexDir(dir string, progress func(parser.Progress)) (total, failed int, err error) {
+	fsys := os.DirFS(dir)
+	recovered := make(map[string][]diag.Diagnostic) // by path in fsys
+	// The errors of ParseDir are the files that fail to parse at all; the
+	// files parsed with syntax errors recovered from count as well.
+	var errorsBefore, recoveredFiles int
+	files, err := parser.ParseDir(fsys, ".",
+		parser.WithErrorRecovery(func(d diag.Diagnostic) {
+			recovered[d.Filename] = append(recovered[d.Filename], d)
+		}),
+		parser.WithProgress(func(p parser.Progress) {
+			if p.Errors == errorsBefore && len(recovered[p.Filename]) > 0 {
+				recoveredFiles++
+			}
+			errorsBefore = p.Errors
+			p.Errors += recoveredFiles
+			progress(p)
+		}),
+	)
+	var errs parser.ErrorList
+	if err != nil && !errors.As(err, &errs) {
+		return 0, 0, err
+	}
+	fileErrs := make(map[string]error, len(errs))
+	paths := make([]string, 0, len(files)+len(errs))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	for _, e := range errs {
+		fileErrs[e.Filename] = e.Err
+		paths = append(paths, e.Filename)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		uri := fileURI(filepath.Join(dir, filepath.FromSlash(p)))
+		if _, ok := s.docs[uri]; ok {
+			continue
+		}
+		file, ok := files[p]
+		var text string
+		if ok {
+			text = file.Source
+		} else {
+			// only to convert the positions of the error
+			bs, _ := fs.ReadFile(fsys, p)
+			text = string(bs)
+		}
+		if err := s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         uri,
+			Diagnostics: s.check(text, file, fileErrs[p], recovered[p]),
+		}); err != nil {
+			return 0, 0, err
+		}
+	}
+	return len(paths), len(errs) + recoveredFiles, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/eval"
+	"github.com/mashiike/go-dqdl/eval/sqleval"
+	"github.com/mashiike/go-dqdl/parser"
+	"github.com/mashiike/go-dqdl/printer"
+	"github.com/mashiike/go-dqdl/report/html"
+	"github.com/mashiike/go-dqdl/report/junit"
+	"github.com/mashiike/go-dqdl/sqlgen"
+)
+
+// runCheck loads the data file into DuckDB and evaluates the ruleset
+// against it. It exits with exitFail if a rule does not pass.
+func runCheck(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	data := fs.String("data", "", "CSV or Parquet `file` to check")
+	format := fs.String("format", "text", "output `format`, text, json, junit or html")
+	quiet := quietFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: dqdl check --data file [--format text|json|junit|html] [--quiet] ruleset.dqdl")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if *data == "" || fs.NArg() != 1 || (*format != "text" && *format != "json" && *format != "junit" && *format != "html") {
+		fs.Usage()
+		return exitError
+	}
+	if *quiet {
+		stderr = io.Discard
+	}
+	src, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "dqdl: %v\n", err)
+		return exitIO
+	}
+	ruleset, err := parser.ParseRuleset(string(src))
+	if err != nil {
+		writeError(stderr, fmt.Errorf("%s: %w", fs.Arg(0), err), string(src))
+		return exitSyntax
+	}
+	table, err := sqleval.DuckDBTable(*data)
+	if err != nil {
+		fmt.Fprintf(stderr, "dqdl: %v\n", err)
+		return exitError
+	}
+	db, err := openDuckDB()
+	if err != nil {
+		fmt.Fprintf(stderr, "dqdl: %v\n", err)
+		return exitError
+	}
+	defer db.Close()
+	result, err := sqleval.Evaluate(context.Background(), db, ruleset, table, sqlgen.DuckDB)
+	if err != nil {
+		fmt.Fprintf(stderr, "dqdl: %v\n", err)
+		return exitError
+	}
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			fmt.Fprintf(stderr, "dqdl: %v\n", err)
+			return exitError
+		}
+	case "junit":
+		if err := junit.Write(stdout, junit.FromResult(*data, result)); err != nil {
+			fmt.Fprintf(stderr, "dqdl: %v\n", err)
+			return exitError
+		}
+	case "html":
+		file := &ast.File{Filename: fs.Arg(0), Source: string(src), Rulesets: []*ast.Ruleset{ruleset}}
+		title := fmt.Sprintf("%s on %s", fs.Arg(0), *data)
+		if err := html.Write(stdout, file, html.WithTitle(title), html.WithResult(result)); err != nil {
+			fmt.Fprintf(stderr, "dqdl: %v\n", err)
+			return exitError
+		}
+	default:
+		writeResult(stdout, result)
+	}
+	if !result.Passed() {
+		return exitFail
+	}
+	return exitOK
+}
+
+// writeResult writes a line per rule with its outcome, followed by the
+// reason if the rule did not pass.
+func writeResult(w io.Writer, result *eval.Result) {
+	for _, r := range result.Rules {
+		var buf bytes.Buffer
+		printer.Fprint(&buf, r.Rule)
+		fmt.Fprintf(w, "%-5s %s\n", r.Outcome, buf.String())
+		if r.Message != "" {
+			fmt.Fprintf(w, "      %s\n", r.Message)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/printer"
+)
+
+// runDiff writes the rules removed from and added to a file. Rules are
+// compared in their printed form, ignoring layout, comments and the
+// formatting of numbers. It exits with exitFail if the files differ.
+func runDiff(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	quiet := quietFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: dqdl diff [--quiet] old.dqdl new.dqdl")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitError
+	}
+	if *quiet {
+		stderr = io.Discard
+	}
+	oldFile, oldCode := parseFile(fs.Arg(0), stderr)
+	newFile, newCode := parseFile(fs.Arg(1), stderr)
+	if code := worse(oldCode, newCode); code != exitOK {
+		return code
+	}
+	oldRules, newRules := ruleStrings(oldFile), ruleStrings(newFile)
+	removed, added := subtract(oldRules, newRules), subtract(newRules, oldRules)
+	if len(removed) == 0 && len(added) == 0 {
+		return exitOK
+	}
+	fmt.Fprintf(stdout, "--- %s\n+++ %s\n", fs.Arg(0), fs.Arg(1))
+	for _, r := range removed {
+		fmt.Fprintf(stdout, "-\t%s\n", r)
+	}
+	for _, r := range added {
+		fmt.Fprintf(stdout, "+\t%s\n", r)
+	}
+	return exitFail
+}
+
+// ruleStrings returns the printed form of the rules of file.
+func ruleStrings(file *ast.File) []string {
+	cfg := &printer.Config{Mode: printer.NormalizeNumbers | printer.OmitComments}
+	var rules []string
+	for _, ruleset := range file.Rulesets {
+		for _, rule := range ruleset.Rules {
+			var buf bytes.Buffer
+			if err := cfg.Fprint(&buf, rule); err == nil {
+				rules = append(rules, buf.String())
+			}
+		}
+	}
+	return rules
+}
+
+// subtract returns the elements of a that are not in b, counting
+// duplicates, in the order of a.
+func subtract(a, b []string) []string {
+	count := make(map[string]int, len(b))
+	for _, s := range b {
+		count[s]++
+	}
+	var diff []string
+	for _, s := range a {
+		if count[s] > 0 {
+			count[s]--
+			continue
+		}
+		diff = append(diff, s)
+	}
+	return diff
+}
@@ -0,0 +1,15 @@
+//go:build duckdb
+
+package main
+
+import (
+	"database/sql"
+
+	// The DuckDB driver needs cgo, which is only built with `-tags duckdb`.
+	_ "github.com/marcboeker/go-duckdb"
+)
+
+// openDuckDB opens an in-memory DuckDB database.
+func openDuckDB() (*sql.DB, error) {
+	return sql.Open("duckdb", "")
+}
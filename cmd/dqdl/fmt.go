@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mashiike/go-dqdl/printer"
+)
+
+// runFmt formats files. By default the formatted files are written to
+// stdout.
+func runFmt(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	write := fs.Bool("w", false, "write the result to the file instead of stdout")
+	list := fs.Bool("l", false, "list the files whose formatting differs")
+	quiet := quietFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: dqdl fmt [-w] [-l] [--quiet] file.dqdl...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitError
+	}
+	if *quiet {
+		stderr = io.Discard
+	}
+	code := exitOK
+	for _, name := range fs.Args() {
+		file, c := parseFile(name, stderr)
+		if c != exitOK {
+			code = worse(code, c)
+			continue
+		}
+		var buf bytes.Buffer
+		if err := printer.Fprint(&buf, file); err != nil {
+			fmt.Fprintf(stderr, "dqdl: %s: %v\n", name, err)
+			code = worse(code, exitError)
+			continue
+		}
+		changed := buf.String() != file.Source
+		if *list && changed {
+			fmt.Fprintln(stdout, name)
+		}
+		if *write {
+			if changed {
+				if err := os.WriteFile(name, buf.Bytes(), 0o644); err != nil {
+					fmt.Fprintf(stderr, "dqdl: %v\n", err)
+					code = worse(code, exitIO)
+				}
+			}
+			continue
+		}
+		if !*list {
+			stdout.Write(buf.Bytes())
+		}
+	}
+	return code
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/report/graph"
+)
+
+// runGraph writes the structure of the rulesets of a file as graphs.
+func runGraph(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("graph", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "dot", "output `format`: dot or mermaid")
+	quiet := quietFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: dqdl graph [--format dot|mermaid] [--quiet] file.dqdl")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	var write func(io.Writer, *ast.Ruleset) error
+	switch *format {
+	case "dot":
+		write = graph.WriteDOT
+	case "mermaid":
+		write = graph.WriteMermaid
+	}
+	if fs.NArg() != 1 || write == nil {
+		fs.Usage()
+		return exitError
+	}
+	if *quiet {
+		stderr = io.Discard
+	}
+	file, code := parseFile(fs.Arg(0), stderr)
+	if code != exitOK {
+		return code
+	}
+	for _, ruleset := range file.Rulesets {
+		if err := write(stdout, ruleset); err != nil {
+			fmt.Fprintf(stderr, "dqdl: %v\n", err)
+			return exitIO
+		}
+	}
+	return exitOK
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/diag"
+	"github.com/mashiike/go-dqdl/lint"
+	"github.com/mashiike/go-dqdl/parser"
+	"github.com/mashiike/go-dqdl/report/junit"
+)
+
+// runLint runs the built-in lint checks on files.
+func runLint(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	flags := newCheckFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: dqdl lint [--fail-on severity] [--format text|junit] [--quiet] file.dqdl|dir...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	opts, err := flags.options()
+	if err != nil || fs.NArg() == 0 {
+		fs.Usage()
+		return exitError
+	}
+	linter := lint.New()
+	return checkFiles(fs.Args(), opts, stdout, stderr, linter.Lint)
+}
+
+// checkFlags are the flags of the commands reporting diagnostics.
+type checkFlags struct {
+	failOn *string
+	format *string
+	quiet  *bool
+}
+
+func newCheckFlags(fs *flag.FlagSet) checkFlags {
+	return checkFlags{
+		failOn: fs.String("fail-on", "error", "exit with 1 if a diagnostic at or above `severity` (error, warning, info or hint) is reported"),
+		format: fs.String("format", "text", "output `format`, text or junit"),
+		quiet:  quietFlag(fs),
+	}
+}
+
+// checkOptions are the values of checkFlags.
+type checkOptions struct {
+	failOn diag.Severity
+	format string
+	quiet  bool
+}
+
+// options returns the values of the flags. It fails if one is invalid.
+func (f checkFlags) options() (checkOptions, error) {
+	failOn, err := diag.ParseSeverity(*f.failOn)
+	if err != nil {
+		return checkOptions{}, err
+	}
+	if *f.format != "text" && *f.format != "junit" {
+		return checkOptions{}, fmt.Errorf("unknown format %q", *f.format)
+	}
+	return checkOptions{failOn: failOn, format: *f.format, quiet: *f.quiet}, nil
+}
+
+// checkFiles parses files, and the .dqdl files under directories, and
+// writes the diagnostics reported by check, each with its line of the
+// source or, in quiet mode, a line per diagnostic. If the format is "junit", it writes a JUnit XML report with
+// a test suite per file instead. It exits with exitFail if a diagnostic at
+// least as severe as failOn is reported.
+func checkFiles(names []string, opts checkOptions, stdout, stderr io.Writer, check func(*ast.File) []diag.Diagnostic) int {
+	if opts.quiet {
+		stderr = io.Discard
+	}
+	code := exitOK
+	var suites []junit.TestSuite
+	for _, name := range names {
+		for _, in := range readInputs(name, stderr) {
+			if in.err != nil {
+				writeError(stderr, in.err, in.src)
+				code = worse(code, exitCode(in.err))
+				suites = append(suites, junit.FromError(in.name, in.err))
+				continue
+			}
+			diags := check(in.file)
+			switch {
+			case opts.format == "junit":
+				suites = append(suites, junit.FromDiagnostics(in.name, diags, opts.failOn))
+			case opts.quiet:
+				for _, d := range diags {
+					fmt.Fprintln(stdout, d)
+				}
+			default:
+				for _, d := range diags {
+					fmt.Fprintln(stdout, d.Annotate(in.file.Source))
+				}
+			}
+			if diag.HasSeverity(diags, opts.failOn) {
+				code = worse(code, exitFail)
+			}
+		}
+	}
+	if opts.format == "junit" {
+		if err := junit.Write(stdout, suites...); err != nil {
+			fmt.Fprintf(stderr, "dqdl: %v\n", err)
+			return worse(code, exitIO)
+		}
+	}
+	return code
+}
+
+// input is a file read by readInputs.
+type input struct {
+	name string
+	file *ast.File
+	src  string
+	err  error
+}
+
+// readInputs reads the file name or, if name is a directory, the .dqdl
+// files under it in the order of their paths. The files of a directory
+// are parsed concurrently, with a progress bar on stderr if it is a
+// terminal.
+func readInputs(name string, stderr io.Writer) []input {
+	if fi, err := os.Stat(name); err != nil || !fi.IsDir() {
+		file, src, err := readFile(name)
+		return []input{{name: name, file: file, src: src, err: err}}
+	}
+	var opts []parser.Option
+	if isTerminal(stderr) {
+		bar := &progressBar{w: stderr, width: 40}
+		opts = append(opts, parser.WithProgress(bar.update))
+	}
+	files, err := parser.ParseDir(os.DirFS(name), ".", opts...)
+	var errs parser.ErrorList
+	if err != nil && !errors.As(err, &errs) {
+		return []input{{name: name, err: err}}
+	}
+	paths := make([]string, 0, len(files)+len(errs))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	for _, e := range errs {
+		paths = append(paths, e.Filename)
+	}
+	sort.Strings(paths)
+	inputs := make([]input, 0, len(paths))
+	for _, p := range paths {
+		in := input{name: filepath.Join(name, filepath.FromSlash(p))}
+		if file, ok := files[p]; ok {
+			file.Filename = in.name
+			in.file = file
+		} else {
+			// read it again for the error named after the path and the source
+			in.file, in.src, in.err = readFile(in.name)
+		}
+		inputs = append(inputs, in)
+	}
+	return inputs
+}
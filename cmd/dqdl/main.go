@@ -0,0 +1,166 @@
+// Command dqdl は DQDL のルールセットを扱うコマンドです。
+// Command dqdl is a command line tool for DQDL rulesets.
+//
+// Usage:
+//
+//	dqdl <command> [flags] [arguments]
+//
+// The commands are:
+//
+//	check    evaluate a ruleset against a local CSV or Parquet file
+//	diff     show the rules removed and added between two files
+//	fmt      format files
+//	graph    write the structure of the rulesets as DOT or Mermaid graphs
+//	lint     report questionable constructs
+//	parse    write the syntax tree of a file as JSON
+//	validate check rule types, parameters and expressions
+//
+// lint and validate also take directories, whose .dqdl files are parsed
+// concurrently, with a progress bar on stderr if it is a terminal.
+//
+// Diagnostics and syntax errors are shown with the line of the source they
+// are on. Every command accepts --quiet, which writes only the results,
+// such as diagnostics or JSON, to stdout, a line per diagnostic without
+// the source, and no messages to stderr. The exit status
+// tells the outcomes apart:
+//
+//	0  success
+//	1  the data does not satisfy the ruleset, a diagnostic at or above the
+//	   --fail-on severity of lint and validate was reported, or diff found
+//	   differences
+//	2  invalid usage or another error
+//	3  a file can not be parsed
+//	4  a file can not be read or written
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/diag"
+	"github.com/mashiike/go-dqdl/parser"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// Exit codes of the commands. When several files are processed, the
+// greatest code is returned.
+const (
+	exitOK     = 0 // success
+	exitFail   = 1 // the data does not satisfy the ruleset, or findings were reported
+	exitError  = 2 // invalid usage or an error
+	exitSyntax = 3 // a file can not be parsed
+	exitIO     = 4 // a file can not be read or written
+)
+
+type command struct {
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) int
+}
+
+var commands = map[string]command{
+	"check":    {"evaluate a ruleset against a local CSV or Parquet file", runCheck},
+	"diff":     {"show the rules removed and added between two files", runDiff},
+	"fmt":      {"format files", runFmt},
+	"graph":    {"write the structure of the rulesets as DOT or Mermaid graphs", runGraph},
+	"lint":     {"report questionable constructs", runLint},
+	"parse":    {"write the syntax tree of a file as JSON", runParse},
+	"validate": {"check rule types, parameters and expressions", runValidate},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		usage(stderr)
+		return exitError
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "dqdl: unknown command %q\n", args[0])
+		usage(stderr)
+		return exitError
+	}
+	return cmd.run(args[1:], stdout, stderr)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: dqdl <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-8s %s\n", name, commands[name].summary)
+	}
+}
+
+// quietFlag defines the --quiet flag of a command.
+func quietFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("quiet", false, "write only the results to stdout and no messages to stderr")
+}
+
+// parseFile reads and parses the file name. Errors are written to stderr,
+// and the exit code is exitIO if the file can not be read or exitSyntax if
+// it can not be parsed.
+func parseFile(name string, stderr io.Writer) (*ast.File, int) {
+	file, src, err := readFile(name)
+	if err != nil {
+		writeError(stderr, err, src)
+		return nil, exitCode(err)
+	}
+	return file, exitOK
+}
+
+// readFile reads and parses the file name. It returns the source even if
+// it can not be parsed.
+func readFile(name string) (*ast.File, string, error) {
+	src, err := os.ReadFile(name)
+	if err != nil {
+		return nil, "", err
+	}
+	file, err := parser.ParseFile(name, bytes.NewReader(src))
+	return file, string(src), err
+}
+
+// writeError writes err to stderr. A syntax error is followed by the line
+// of src it is on, with the position marked.
+func writeError(stderr io.Writer, err error, src string) {
+	fmt.Fprintf(stderr, "dqdl: %v\n", err)
+	var perr *parser.Error
+	if errors.As(err, &perr) {
+		if snippet := diag.Snippet(src, perr.Pos, token.NoPos); snippet != "" {
+			fmt.Fprintln(stderr, snippet)
+		}
+	}
+}
+
+// exitCode returns the exit code of an error returned by readFile.
+func exitCode(err error) int {
+	var perr *parser.Error
+	switch {
+	case errors.As(err, &perr):
+		return exitSyntax
+	case errors.Is(err, parser.ErrInternal):
+		return exitError
+	}
+	return exitIO
+}
+
+// worse returns the greater of the exit codes a and b.
+func worse(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/eval"
+	"github.com/mashiike/go-dqdl/parser"
+)
+
+func TestRun__Usage(t *testing.T) {
+	dir := t.TempDir()
+	ruleset := filepath.Join(dir, "ruleset.dqdl")
+	if err := os.WriteFile(ruleset, []byte(`Rules = [ RowCount > 0 ]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name string
+		args []string
+	}{
+		{name: "no command", args: nil},
+		{name: "unknown command", args: []string{"frobnicate"}},
+		{name: "check without data", args: []string{"check", ruleset}},
+		{name: "check unknown output format", args: []string{"check", "--data", "orders.csv", "--format", "xml", ruleset}},
+		{name: "check unknown format", args: []string{"check", "--data", "orders.json", ruleset}},
+		{name: "parse unknown format", args: []string{"parse", "--format", "yaml", ruleset}},
+		{name: "fmt without files", args: []string{"fmt"}},
+		{name: "lint unknown severity", args: []string{"lint", "--fail-on", "fatal", ruleset}},
+		{name: "diff one file", args: []string{"diff", ruleset}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if got := run(c.args, &stdout, &stderr); got != exitError {
+				t.Errorf("got exit code %d, want %d", got, exitError)
+			}
+			if stderr.Len() == 0 {
+				t.Error("got no message on stderr")
+			}
+		})
+	}
+}
+
+func TestWriteResult(t *testing.T) {
+	ruleset, err := parser.ParseRuleset(`Rules = [ RowCount > 1, IsComplete "name" ]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := eval.Evaluate(ruleset, eval.Rows{{"name": "alice"}, {"name": nil}})
+	var buf bytes.Buffer
+	writeResult(&buf, result)
+	want := `PASS  RowCount > 1
+FAIL  IsComplete "name"
+      Value: 0.5 does not meet the constraint requirement
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+}
+
+func TestRun__Commands(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, src string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	formatted := write("formatted.dqdl", "Rules = [\n\tRowCount > 0,\n\tIsComplete \"id\"\n]\n")
+	messy := write("messy.dqdl", "Rules = [ RowCount > 0.0,   IsComplete \"id\" ]")
+	changed := write("changed.dqdl", "Rules = [\n\tRowCount > 0,\n\tIsUnique \"id\"\n]\n")
+	unknown := write("unknown.dqdl", "Rules = [ IsComplet \"id\" ]")
+	duplicate := write("duplicate.dqdl", "Rules = [\n\t# a\n\tRowCount > 0,\n\t# b\n\tRowCount > 0\n]\n")
+	described := write("described.dqdl", "Rules = [\n\t# rows\n\tRowCount > 0\n]\n")
+
+	cases := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+	}{
+		{name: "fmt", args: []string{"fmt", messy}, code: exitOK, stdout: "Rules = [\n\tRowCount > 0.0,\n\tIsComplete \"id\"\n]\n"},
+		{name: "fmt list", args: []string{"fmt", "-l", formatted, messy}, code: exitOK, stdout: messy + "\n"},
+		{
+			name:   "graph mermaid",
+			args:   []string{"graph", "--format", "mermaid", described},
+			code:   exitOK,
+			stdout: "flowchart TD\n\tn0[\"Rules\"]\n\tn1[\"RowCount\"]\n\tn2[\"#gt; 0\"]\n\tn0 --> n1\n\tn1 --> n2\n",
+		},
+		{
+			name:   "lint",
+			args:   []string{"lint", duplicate},
+			code:   exitOK,
+			stdout: duplicate + ":5:2: warning: duplicate rule `RowCount > 0` [duplicate-rule]\n5 | \tRowCount > 0\n  | \t^~~~~~~~~~~~\n",
+		},
+		{name: "lint quiet", args: []string{"lint", "--quiet", duplicate}, code: exitOK, stdout: duplicate + ":5:2: warning: duplicate rule `RowCount > 0` [duplicate-rule]\n"},
+		{
+			name: "lint junit",
+			args: []string{"lint", "--format", "junit", "--fail-on", "warning", duplicate, described},
+			code: exitFail,
+			stdout: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="2" failures="1" errors="0" skipped="0">
+  <testsuite name="` + duplicate + `" tests="1" failures="1" errors="0" skipped="0">
+    <testcase name="5:2: duplicate-rule" classname="` + duplicate + `" file="` + duplicate + `" line="5">
+      <failure message="duplicate rule ` + "`RowCount &gt; 0`" + `" type="warning">` + duplicate + `:5:2: warning: duplicate rule ` + "`RowCount &gt; 0`" + ` [duplicate-rule]</failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="` + described + `" tests="1" failures="0" errors="0" skipped="0">
+    <testcase name="` + described + `" classname="` + described + `" file="` + described + `"></testcase>
+  </testsuite>
+</testsuites>
+`,
+		},
+		{name: "validate", args: []string{"validate", formatted}, code: exitOK},
+		{
+			name:   "validate unknown rule type",
+			args:   []string{"validate", unknown},
+			code:   exitFail,
+			stdout: unknown + ":1:11: error: unknown rule type `IsComplet`, did you mean `IsComplete`? [unknown-rule-type]\n1 | Rules = [ IsComplet \"id\" ]\n  |           ^~~~~~~~~\n",
+		},
+		{name: "diff same", args: []string{"diff", formatted, messy}, code: exitOK},
+		{
+			name:   "diff",
+			args:   []string{"diff", formatted, changed},
+			code:   exitFail,
+			stdout: "--- " + formatted + "\n+++ " + changed + "\n-\tIsComplete \"id\"\n+\tIsUnique \"id\"\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if got := run(c.args, &stdout, &stderr); got != c.code {
+				t.Errorf("got exit code %d, want %d; stderr: %s", got, c.code, stderr.String())
+			}
+			if diff := cmp.Diff(c.stdout, stdout.String()); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRun__FmtWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ruleset.dqdl")
+	if err := os.WriteFile(path, []byte("Rules = [ RowCount > 0 ]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if got := run([]string{"fmt", "-w", path}, &stdout, &stderr); got != exitOK {
+		t.Fatalf("got exit code %d, want %d; stderr: %s", got, exitOK, stderr.String())
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("Rules = [\n\tRowCount > 0\n]\n", string(got)); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+}
+
+func TestRun__Parse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ruleset.dqdl")
+	if err := os.WriteFile(path, []byte("Rules = [ RowCount > 0 ]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if got := run([]string{"parse", path}, &stdout, &stderr); got != exitOK {
+		t.Fatalf("got exit code %d, want %d; stderr: %s", got, exitOK, stderr.String())
+	}
+	var file ast.File
+	if err := json.Unmarshal(stdout.Bytes(), &file); err != nil {
+		t.Fatal(err)
+	}
+	if len(file.Rulesets) != 1 || len(file.Rulesets[0].Rules) != 1 {
+		t.Errorf("got %d rulesets, want 1 with 1 rule", len(file.Rulesets))
+	}
+}
+
+func TestRun__ExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, src string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	valid := write("valid.dqdl", "Rules = [\n\t# rows\n\tRowCount > 0\n]\n")
+	invalid := write("invalid.dqdl", "Rules = [ RowCount > ]")
+	duplicate := write("duplicate.dqdl", "Rules = [\n\t# a\n\tRowCount > 0,\n\t# b\n\tRowCount > 0\n]\n")
+	missing := filepath.Join(dir, "missing.dqdl")
+
+	cases := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "lint", args: []string{"lint", valid}, want: exitOK},
+		{name: "lint warning", args: []string{"lint", duplicate}, want: exitOK},
+		{name: "lint fail on warning", args: []string{"lint", "--fail-on", "warning", duplicate}, want: exitFail},
+		{name: "lint syntax error", args: []string{"lint", invalid}, want: exitSyntax},
+		{name: "lint missing file", args: []string{"lint", missing}, want: exitIO},
+		{name: "lint worst of files", args: []string{"lint", "--fail-on", "warning", duplicate, invalid, valid}, want: exitSyntax},
+		{name: "validate syntax error", args: []string{"validate", invalid}, want: exitSyntax},
+		{name: "check missing ruleset", args: []string{"check", "--data", "orders.csv", missing}, want: exitIO},
+		{name: "check syntax error", args: []string{"check", "--data", "orders.csv", invalid}, want: exitSyntax},
+		{name: "diff missing file", args: []string{"diff", valid, missing}, want: exitIO},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if got := run(c.args, &stdout, &stderr); got != c.want {
+				t.Errorf("got exit code %d, want %d; stderr: %s", got, c.want, stderr.String())
+			}
+			args := append([]string{c.args[0], "--quiet"}, c.args[1:]...)
+			stderr.Reset()
+			if got := run(args, &stdout, &stderr); got != c.want {
+				t.Errorf("--quiet: got exit code %d, want %d", got, c.want)
+			}
+			if stderr.Len() != 0 {
+				t.Errorf("--quiet: got %q on stderr, want nothing", stderr.String())
+			}
+		})
+	}
+}
+
+func TestRun__SyntaxError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ruleset.dqdl")
+	if err := os.WriteFile(path, []byte("Rules = [\n\tRowCount > \n]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if got := run([]string{"lint", path}, &stdout, &stderr); got != exitSyntax {
+		t.Errorf("got exit code %d, want %d", got, exitSyntax)
+	}
+	want := "dqdl: " + path + ":3:1: syntax error near ``, unexpected token `]`\n3 | ]\n  | ^\n"
+	if diff := cmp.Diff(want, stderr.String()); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+}
+
+func TestRun__Directory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	duplicate := filepath.Join(dir, "sub", "duplicate.dqdl")
+	invalid := filepath.Join(dir, "invalid.dqdl")
+	for name, src := range map[string]string{
+		duplicate:                        "Rules = [\n\t# a\n\tRowCount > 0,\n\t# b\n\tRowCount > 0\n]\n",
+		invalid:                          "Rules = [\n\tRowCount > \n]\n",
+		filepath.Join(dir, "valid.dqdl"): "Rules = [\n\t# rows\n\tRowCount > 0\n]\n",
+		filepath.Join(dir, "README.md"):  "not a ruleset",
+	} {
+		if err := os.WriteFile(name, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var stdout, stderr bytes.Buffer
+	if got := run([]string{"lint", "--quiet", dir}, &stdout, &stderr); got != exitSyntax {
+		t.Errorf("got exit code %d, want %d", got, exitSyntax)
+	}
+	want := duplicate + ":5:2: warning: duplicate rule `RowCount > 0` [duplicate-rule]\n"
+	if diff := cmp.Diff(want, stdout.String()); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+
+	stdout.Reset()
+	if got := run([]string{"validate", dir}, &stdout, &stderr); got != exitSyntax {
+		t.Errorf("got exit code %d, want %d", got, exitSyntax)
+	}
+	want = "dqdl: " + invalid + ":3:1: syntax error near ``, unexpected token `]`\n3 | ]\n  | ^\n"
+	if diff := cmp.Diff(want, stderr.String()); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	var buf bytes.Buffer
+	bar := &progressBar{w: &buf, width: 4}
+	bar.update(parser.Progress{Filename: "a.dqdl", Parsed: 1, Total: 3})
+	bar.update(parser.Progress{Filename: "b.dqdl", Parsed: 2, Errors: 1, Total: 3})
+	bar.update(parser.Progress{Filename: "c.dqdl", Parsed: 3, Errors: 1, Total: 3})
+	want := "\rparsing [=   ] 1/3 files" +
+		"\rparsing [==  ] 2/3 files, 1 errors" +
+		"\rparsing [====] 3/3 files, 1 errors\n"
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+}
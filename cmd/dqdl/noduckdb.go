@@ -0,0 +1,12 @@
+//go:build !duckdb
+
+package main
+
+import (
+	"database/sql"
+	"errors"
+)
+
+func openDuckDB() (*sql.DB, error) {
+	return nil, errors.New("check requires DuckDB, build dqdl with -tags duckdb")
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+)
+
+// runParse parses a file and writes its syntax tree.
+func runParse(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("parse", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "json", "output `format`; only json is supported")
+	quiet := quietFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: dqdl parse [--format json] [--quiet] file.dqdl")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() != 1 || *format != "json" {
+		fs.Usage()
+		return exitError
+	}
+	if *quiet {
+		stderr = io.Discard
+	}
+	file, code := parseFile(fs.Arg(0), stderr)
+	if code != exitOK {
+		return code
+	}
+	enc := json.NewEncoder(stdout)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(file); err != nil {
+		fmt.Fprintf(stderr, "dqdl: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
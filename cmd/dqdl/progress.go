@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mashiike/go-dqdl/parser"
+)
+
+// progressBar renders the progress of parser.ParseDir on a line of a
+// terminal, rewriting the line as files are parsed.
+type progressBar struct {
+	w     io.Writer
+	width int // of the bar, in characters
+}
+
+// update rewrites the line with p, and ends it once every file is parsed.
+func (b *progressBar) update(p parser.Progress) {
+	done := b.width
+	if p.Total > 0 {
+		done = b.width * p.Parsed / p.Total
+	}
+	fmt.Fprintf(b.w, "\rparsing [%s%s] %d/%d files", strings.Repeat("=", done), strings.Repeat(" ", b.width-done), p.Parsed, p.Total)
+	if p.Errors > 0 {
+		fmt.Fprintf(b.w, ", %d errors", p.Errors)
+	}
+	if p.Parsed == p.Total {
+		fmt.Fprintln(b.w)
+	}
+}
+
+// isTerminal reports whether w is a terminal, on which a progress bar can
+// be rendered.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/diag"
+	"github.com/mashiike/go-dqdl/validate"
+)
+
+// runValidate checks the rule types, parameters and expressions of files
+// against the specs of the built-in rule types.
+func runValidate(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	flags := newCheckFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: dqdl validate [--fail-on severity] [--format text|junit] [--quiet] file.dqdl|dir...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	opts, err := flags.options()
+	if err != nil || fs.NArg() == 0 {
+		fs.Usage()
+		return exitError
+	}
+	return checkFiles(fs.Args(), opts, stdout, stderr, func(file *ast.File) []diag.Diagnostic {
+		return validate.File(file)
+	})
+}
@@ -0,0 +1,155 @@
+// Package diag はパーサー、バリデーター、リンター、評価器が共通で用いる診断情報の型を定義します。
+// Package diag defines the Diagnostic type shared by the parser, validator,
+// linter and evaluator, and encoders for the formats consumed by other tools.
+package diag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// Severity は診断情報の重要度を表します。
+// Severity represents the severity of a diagnostic.
+type Severity int
+
+const (
+	SeverityError Severity = iota + 1
+	SeverityWarning
+	SeverityInfo
+	SeverityHint
+)
+
+var severityStrings = map[Severity]string{
+	SeverityError:   "error",
+	SeverityWarning: "warning",
+	SeverityInfo:    "info",
+	SeverityHint:    "hint",
+}
+
+// String returns the lower case name of the severity, e.g. "error".
+func (s Severity) String() string {
+	if str, ok := severityStrings[s]; ok {
+		return str
+	}
+	return "unknown severity"
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Severity) MarshalText() ([]byte, error) {
+	if _, ok := severityStrings[s]; !ok {
+		return nil, fmt.Errorf("diag: invalid severity %d", int(s))
+	}
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *Severity) UnmarshalText(text []byte) error {
+	v, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = v
+	return nil
+}
+
+// ParseSeverity は文字列から重要度を返します。
+// ParseSeverity returns the severity named by str, e.g. "warning".
+func ParseSeverity(str string) (Severity, error) {
+	for s, name := range severityStrings {
+		if strings.EqualFold(name, str) {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("diag: unknown severity %q", str)
+}
+
+// Diagnostic は1つの診断情報を表します。
+// A Diagnostic is a single finding reported by any component of go-dqdl.
+type Diagnostic struct {
+	Code     string    // stable identifier, e.g. "syntax-error"
+	Severity Severity  // severity of the finding
+	Message  string    // human readable message
+	Source   string    // component that reported it, e.g. "parser", "lint"
+	Filename string    // name of the file, if any
+	Pos      token.Pos // start of the primary range
+	End      token.Pos // end of the primary range; may be invalid
+	Related  []Related `json:",omitempty"` // secondary locations
+	Fixes    []Fix     `json:",omitempty"` // suggested fixes
+	// likely intended values, best first, e.g. the known rule types
+	// closest to a misspelled one
+	Suggestions []string `json:",omitempty"`
+}
+
+// Related は診断情報に関連する別の位置を表します。
+// Related is a secondary location relevant to a diagnostic.
+type Related struct {
+	Filename string
+	Pos      token.Pos
+	End      token.Pos
+	Message  string
+}
+
+// Fix は診断情報を解消するための修正案です。
+// A Fix is a suggested change that resolves a diagnostic.
+type Fix struct {
+	Message string
+	Edits   []Edit
+}
+
+// Edit はソースの [Pos, End) を NewText で置き換える編集です。
+// An Edit replaces the source in [Pos, End) with NewText.
+type Edit struct {
+	Pos     token.Pos
+	End     token.Pos
+	NewText string
+}
+
+// Position returns the primary position including the filename.
+func (d Diagnostic) Position() token.Position {
+	return token.Position{Filename: d.Filename, Pos: d.Pos}
+}
+
+// String returns a string in the form "file:line:column: severity: message [code]".
+func (d Diagnostic) String() string {
+	var b strings.Builder
+	if pos := d.Position().String(); pos != "-" {
+		b.WriteString(pos)
+		b.WriteString(": ")
+	}
+	b.WriteString(d.Severity.String())
+	b.WriteString(": ")
+	b.WriteString(d.Message)
+	if d.Code != "" {
+		fmt.Fprintf(&b, " [%s]", d.Code)
+	}
+	return b.String()
+}
+
+// Sort は診断情報をファイル名、位置、重要度の順に並べ替えます。
+// Sort sorts diagnostics by filename, position and severity.
+func Sort(diags []Diagnostic) {
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i], diags[j]
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Pos.Index != b.Pos.Index {
+			return a.Pos.Index < b.Pos.Index
+		}
+		return a.Severity < b.Severity
+	})
+}
+
+// HasSeverity は指定された重要度以上の診断情報が含まれているかどうかを返します。
+// HasSeverity reports whether diags contains a diagnostic at least as severe as s.
+func HasSeverity(diags []Diagnostic, s Severity) bool {
+	for _, d := range diags {
+		if d.Severity <= s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package diag
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+func testDiagnostics() []Diagnostic {
+	return []Diagnostic{
+		{
+			Code:     "missing-description",
+			Severity: SeverityWarning,
+			Message:  "rule has no description",
+			Source:   "lint",
+			Filename: "rules/b.dqdl",
+			Pos:      token.Pos{Index: 10, Line: 2, Column: 2},
+			End:      token.Pos{Index: 18, Line: 2, Column: 10},
+		},
+		{
+			Code:     "syntax-error",
+			Severity: SeverityError,
+			Message:  "missing `]`\nat end of file",
+			Source:   "parser",
+			Filename: "rules/a.dqdl",
+			Pos:      token.Pos{Index: 9, Line: 1, Column: 10},
+		},
+	}
+}
+
+func TestSort(t *testing.T) {
+	diags := testDiagnostics()
+	Sort(diags)
+	var got []string
+	for _, d := range diags {
+		got = append(got, d.String())
+	}
+	want := []string{
+		"rules/a.dqdl:1:10: error: missing `]`\nat end of file [syntax-error]",
+		"rules/b.dqdl:2:2: warning: rule has no description [missing-description]",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected diagnostics (-want +got):\n%s", diff)
+	}
+	if !HasSeverity(diags, SeverityWarning) || HasSeverity(diags[1:], SeverityError) {
+		t.Error("unexpected HasSeverity result")
+	}
+}
+
+func TestSeverity__JSON(t *testing.T) {
+	bs, err := json.Marshal([]Severity{SeverityError, SeverityHint})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bs) != `["error","hint"]` {
+		t.Errorf("got %s, want %s", bs, `["error","hint"]`)
+	}
+	var got []Severity
+	if err := json.Unmarshal([]byte(`["Warning","info"]`), &got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]Severity{SeverityWarning, SeverityInfo}, got); diff != "" {
+		t.Errorf("unexpected severities (-want +got):\n%s", diff)
+	}
+	if err := json.Unmarshal([]byte(`["fatal"]`), &got); err == nil {
+		t.Error("expected error for unknown severity, got nil")
+	}
+}
+
+func TestWriteGitHub(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteGitHub(&buf, testDiagnostics()); err != nil {
+		t.Fatal(err)
+	}
+	want := "::warning file=rules/b.dqdl,line=2,col=2,endLine=2,endColumn=10,title=missing-description::rule has no description\n" +
+		"::error file=rules/a.dqdl,line=1,col=10,title=syntax-error::missing `]`%0Aat end of file\n"
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+}
+
+func TestDiagnostic__LSP(t *testing.T) {
+	got := testDiagnostics()[1].LSP()
+	want := LSPDiagnostic{
+		Range: LSPRange{
+			Start: LSPPosition{Line: 0, Character: 9},
+			End:   LSPPosition{Line: 0, Character: 9},
+		},
+		Severity: 1,
+		Code:     "syntax-error",
+		Source:   "parser",
+		Message:  "missing `]`\nat end of file",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected LSP diagnostic (-want +got):\n%s", diff)
+	}
+}
+
+func TestDiagnostic__LSPSuggestions(t *testing.T) {
+	d := Diagnostic{
+		Code:        "unknown-rule-type",
+		Severity:    SeverityError,
+		Message:     "unknown rule type `IsUnqiue`",
+		Pos:         token.Pos{Index: 0, Line: 1, Column: 1},
+		Suggestions: []string{"IsUnique"},
+	}
+	bs, err := json.Marshal(d.LSP())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"range":{"start":{"line":0,"character":0},"end":{"line":0,"character":0}},"severity":1,"code":"unknown-rule-type","source":"dqdl","message":"unknown rule type ` + "`IsUnqiue`" + `","data":{"suggestions":["IsUnique"]}}`
+	if diff := cmp.Diff(want, string(bs)); diff != "" {
+		t.Errorf("unexpected LSP diagnostic (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,56 @@
+package diag
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteGitHub は診断情報を GitHub Actions のワークフローコマンドとして書き出します。
+// WriteGitHub writes diags as GitHub Actions workflow commands, which show
+// up as annotations on pull requests.
+func WriteGitHub(w io.Writer, diags []Diagnostic) error {
+	for _, d := range diags {
+		var params []string
+		if d.Filename != "" {
+			params = append(params, "file="+escapeGitHubProperty(d.Filename))
+		}
+		if d.Pos.IsValid() {
+			params = append(params, fmt.Sprintf("line=%d", d.Pos.Line), fmt.Sprintf("col=%d", d.Pos.Column))
+		}
+		if d.End.IsValid() {
+			params = append(params, fmt.Sprintf("endLine=%d", d.End.Line), fmt.Sprintf("endColumn=%d", d.End.Column))
+		}
+		if d.Code != "" {
+			params = append(params, "title="+escapeGitHubProperty(d.Code))
+		}
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", githubCommand(d.Severity), strings.Join(params, ","), escapeGitHubData(d.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func githubCommand(s Severity) string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+var (
+	githubDataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	githubPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+func escapeGitHubData(s string) string {
+	return githubDataEscaper.Replace(s)
+}
+
+func escapeGitHubProperty(s string) string {
+	return githubPropertyEscaper.Replace(s)
+}
@@ -0,0 +1,72 @@
+package diag
+
+import "github.com/mashiike/go-dqdl/token"
+
+// LSPPosition は Language Server Protocol の Position です。行と列は0始まりです。
+// LSPPosition is a Language Server Protocol Position. Line and character are zero-based.
+type LSPPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// LSPRange は Language Server Protocol の Range です。
+// LSPRange is a Language Server Protocol Range.
+type LSPRange struct {
+	Start LSPPosition `json:"start"`
+	End   LSPPosition `json:"end"`
+}
+
+// LSPDiagnostic は Language Server Protocol の Diagnostic です。
+// LSPDiagnostic is a Language Server Protocol Diagnostic.
+type LSPDiagnostic struct {
+	Range    LSPRange `json:"range"`
+	Severity int      `json:"severity"`
+	Code     string   `json:"code,omitempty"`
+	Source   string   `json:"source,omitempty"`
+	Message  string   `json:"message"`
+	Data     *LSPData `json:"data,omitempty"`
+}
+
+// LSPData は LSPDiagnostic の data フィールドに格納される追加情報です。
+// LSPData is the extra information stored in the data field of an
+// LSPDiagnostic, which clients pass back in code action requests.
+type LSPData struct {
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// LSP は診断情報を Language Server Protocol の Diagnostic に変換します。
+// LSP converts d to a Language Server Protocol Diagnostic.
+// Columns are passed through as characters; clients negotiating UTF-16
+// offsets must convert non-ASCII lines themselves.
+func (d Diagnostic) LSP() LSPDiagnostic {
+	end := d.End
+	if !end.IsValid() {
+		end = d.Pos
+	}
+	source := d.Source
+	if source == "" {
+		source = "dqdl"
+	}
+	var data *LSPData
+	if len(d.Suggestions) > 0 {
+		data = &LSPData{Suggestions: d.Suggestions}
+	}
+	return LSPDiagnostic{
+		Data: data,
+		Range: LSPRange{
+			Start: lspPosition(d.Pos),
+			End:   lspPosition(end),
+		},
+		Severity: int(d.Severity), // the LSP DiagnosticSeverity values match Severity
+		Code:     d.Code,
+		Source:   source,
+		Message:  d.Message,
+	}
+}
+
+func lspPosition(pos token.Pos) LSPPosition {
+	if !pos.IsValid() {
+		return LSPPosition{}
+	}
+	return LSPPosition{Line: pos.Line - 1, Character: pos.Column - 1}
+}
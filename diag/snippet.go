@@ -0,0 +1,92 @@
+package diag
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// Snippet はソースの該当行を範囲に下線を引いて返します。
+// Snippet renders the line of src at pos with the range [pos, end)
+// underlined, in the style of gcc and rustc:
+//
+//	3 |     IsComplete "id" >
+//	  |                     ^~~
+//
+// The first character of the range is marked with a caret and the others
+// with tildes. A range spanning several lines is underlined up to the end
+// of the first line; an invalid end marks pos only. Columns count runes
+// and tabs are kept in the underline, so that it lines up with the source
+// in any terminal. Snippet returns an empty string if pos is not in src.
+func Snippet(src string, pos, end token.Pos) string {
+	if !pos.IsValid() {
+		return ""
+	}
+	lines := strings.Split(src, "\n")
+	if pos.Line > len(lines) {
+		return ""
+	}
+	line := strings.TrimSuffix(lines[pos.Line-1], "\r")
+	n := utf8.RuneCountInString(line)
+	col := pos.Column
+	if col < 1 {
+		col = 1
+	}
+	if col > n+1 {
+		return ""
+	}
+	width := 1
+	switch {
+	case !end.IsValid():
+	case end.Line == pos.Line && end.Column > col:
+		width = end.Column - col
+	case end.Line > pos.Line:
+		width = n + 1 - col
+	}
+	if col+width > n+1 {
+		width = n + 1 - col
+	}
+	if width < 1 {
+		width = 1
+	}
+
+	var mark strings.Builder
+	i := 1
+	for _, r := range line {
+		if i >= col {
+			break
+		}
+		if r == '\t' {
+			mark.WriteByte('\t')
+		} else {
+			mark.WriteByte(' ')
+		}
+		i++
+	}
+	mark.WriteByte('^')
+	mark.WriteString(strings.Repeat("~", width-1))
+
+	number := strconv.Itoa(pos.Line)
+	gutter := strings.Repeat(" ", len(number))
+	return fmt.Sprintf("%s | %s\n%s | %s", number, line, gutter, mark.String())
+}
+
+// Annotate は診断情報に該当箇所のスニペットを付けて返します。
+// Annotate returns the String of d followed by the Snippet of its range in
+// src, the source of the file of d, e.g.
+//
+//	rules.dqdl:3:21: error: syntax error near `>` [syntax-error]
+//	3 |     IsComplete "id" >
+//	  |                     ^
+//
+// It returns the String of d alone if d has no position in src.
+func (d Diagnostic) Annotate(src string) string {
+	s := d.String()
+	if snippet := Snippet(src, d.Pos, d.End); snippet != "" {
+		s += "\n" + snippet
+	}
+	return s
+}
@@ -0,0 +1,81 @@
+package diag
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+func TestSnippet(t *testing.T) {
+	src := "Rules = [\n\tIsComplete \"id\" >,\n    ColumnValues \"名前\" = \"x\"\r\n]"
+	cases := []struct {
+		name     string
+		pos, end token.Pos
+		want     string
+	}{
+		{
+			name: "caret",
+			pos:  token.Pos{Line: 2, Column: 18},
+			want: "2 | \tIsComplete \"id\" >,\n  | \t                ^",
+		},
+		{
+			name: "range",
+			pos:  token.Pos{Line: 2, Column: 2},
+			end:  token.Pos{Line: 2, Column: 12},
+			want: "2 | \tIsComplete \"id\" >,\n  | \t^~~~~~~~~~",
+		},
+		{
+			name: "multibyte",
+			pos:  token.Pos{Line: 3, Column: 18},
+			end:  token.Pos{Line: 3, Column: 22},
+			want: "3 |     ColumnValues \"名前\" = \"x\"\n  |                  ^~~~",
+		},
+		{
+			name: "multiline",
+			pos:  token.Pos{Line: 3, Column: 25},
+			end:  token.Pos{Line: 4, Column: 2},
+			want: "3 |     ColumnValues \"名前\" = \"x\"\n  |                         ^~~",
+		},
+		{
+			name: "end of line",
+			pos:  token.Pos{Line: 1, Column: 10},
+			want: "1 | Rules = [\n  |          ^",
+		},
+		{
+			name: "end before pos",
+			pos:  token.Pos{Line: 1, Column: 9},
+			end:  token.Pos{Line: 1, Column: 1},
+			want: "1 | Rules = [\n  |         ^",
+		},
+		{name: "invalid", pos: token.NoPos},
+		{name: "line out of range", pos: token.Pos{Line: 5, Column: 1}},
+		{name: "column out of range", pos: token.Pos{Line: 1, Column: 11}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if diff := cmp.Diff(c.want, Snippet(src, c.pos, c.end)); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiagnostic__Annotate(t *testing.T) {
+	d := Diagnostic{
+		Code:     "syntax-error",
+		Severity: SeverityError,
+		Message:  "syntax error near `]`",
+		Filename: "rules.dqdl",
+		Pos:      token.Pos{Index: 20, Line: 1, Column: 21},
+	}
+	want := "rules.dqdl:1:21: error: syntax error near `]` [syntax-error]\n" +
+		"1 | Rules = [ RowCount > ]\n" +
+		"  |                     ^"
+	if diff := cmp.Diff(want, d.Annotate("Rules = [ RowCount > ]")); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+	if got := d.Annotate(""); got != d.String() {
+		t.Errorf("got %q, want %q", got, d.String())
+	}
+}
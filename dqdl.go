@@ -0,0 +1,40 @@
+// Package dqdl は DQDL(Data Quality Definition Language) を扱うための高水準な API を提供します。
+// Package dqdl provides high level entry points that tie together the
+// parser, printer and other packages of go-dqdl.
+package dqdl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/mashiike/go-dqdl/parser"
+	"github.com/mashiike/go-dqdl/printer"
+)
+
+// digestPrefix identifies the algorithm and the canonical form hashed by
+// SourceDigest. It must change whenever the digest of a document changes.
+const digestPrefix = "sha256:"
+
+// SourceDigest はソースを構文解析して正規化し、そのハッシュ値を返します。
+// SourceDigest parses src, prints it in canonical form and returns the
+// SHA-256 digest of the result as "sha256:<hex>". The canonical form drops
+// comments and normalizes layout and number literals, so documents that
+// differ only in those have the same digest. It returns an error if src
+// can not be parsed.
+//
+// The digest of a document is stable within a major version of go-dqdl,
+// which makes it suitable for HTTP ETags and deduplication.
+func SourceDigest(src []byte) (string, error) {
+	file, err := parser.ParseFile("", bytes.NewReader(src))
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	cfg := &printer.Config{Mode: printer.NormalizeNumbers | printer.OmitComments}
+	if err := cfg.Fprint(&buf, file); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return digestPrefix + hex.EncodeToString(sum[:]), nil
+}
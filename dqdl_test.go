@@ -0,0 +1,59 @@
+package dqdl
+
+import (
+	"testing"
+)
+
+func TestSourceDigest(t *testing.T) {
+	// The digest of this document is pinned: it must not change within a
+	// major version, since callers persist it as an ETag or cache key.
+	const (
+		src  = "Rules = [\n\tIsComplete \"id\",\n\tColumnValues \"price\" between 0.5 and 10\n]\n"
+		want = "sha256:5c4c8d4a3766a6a12cc32d9ab3550308bdb85577dbc8ff85bd2264d7511635d5"
+	)
+	cases := []struct {
+		name  string
+		input string
+		same  bool
+	}{
+		{name: "canonical", input: src, same: true},
+		{
+			name:  "comments and layout",
+			input: "# comment\nRules = [ IsComplete \"id\", # trailing\n\n  ColumnValues \"price\" between 0.5 and 10 ]",
+			same:  true,
+		},
+		{
+			name:  "number literals",
+			input: "Rules=[IsComplete \"id\",ColumnValues \"price\" between 00.50 and 10.0]",
+			same:  true,
+		},
+		{
+			name:  "different value",
+			input: "Rules = [ IsComplete \"id\", ColumnValues \"price\" between 0.5 and 11 ]",
+		},
+		{
+			name:  "different order",
+			input: "Rules = [ ColumnValues \"price\" between 0.5 and 10, IsComplete \"id\" ]",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := SourceDigest([]byte(c.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.same && got != want {
+				t.Errorf("got %s, want %s", got, want)
+			}
+			if !c.same && got == want {
+				t.Errorf("got %s, want a different digest", got)
+			}
+		})
+	}
+}
+
+func TestSourceDigest__Error(t *testing.T) {
+	if _, err := SourceDigest([]byte("Rules = [")); err == nil {
+		t.Error("expected an error for an unparsable source")
+	}
+}
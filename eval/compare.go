@@ -0,0 +1,194 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mashiike/go-dqdl/ast"
+)
+
+// match reports whether the value v satisfies expr. Null never satisfies
+// an expression. between is inclusive, and a matches pattern must match
+// the whole value.
+func (e *evaluation) match(expr ast.Expression, v interface{}) (bool, error) {
+	if v == nil {
+		return false, nil
+	}
+	switch x := expr.(type) {
+	case *ast.ComparisonExpression:
+		c, ok, err := e.compare(v, x.Right)
+		if err != nil || !ok {
+			return false, err
+		}
+		switch x.Operator {
+		case "=":
+			return c == 0, nil
+		case ">":
+			return c > 0, nil
+		case ">=":
+			return c >= 0, nil
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		}
+		return false, fmt.Errorf("unknown operator %q", x.Operator)
+	case *ast.BetweenExpression:
+		lo, ok, err := e.compare(v, x.Left)
+		if err != nil || !ok {
+			return false, err
+		}
+		hi, ok, err := e.compare(v, x.Right)
+		if err != nil || !ok {
+			return false, err
+		}
+		return lo >= 0 && hi <= 0, nil
+	case *ast.InExpression:
+		for _, param := range x.Values {
+			c, ok, err := e.compare(v, param)
+			if err != nil {
+				return false, err
+			}
+			if ok && c == 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	case *ast.MatchesExpression:
+		re, err := e.pattern(x.Value)
+		if err != nil {
+			return false, err
+		}
+		s, ok := v.(string)
+		return ok && re.MatchString(s), nil
+	}
+	return false, fmt.Errorf("unsupported expression %T", expr)
+}
+
+// pattern returns the compiled pattern of a matches expression.
+func (e *evaluation) pattern(s string) (*regexp.Regexp, error) {
+	if re, ok := e.patterns[s]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(`^(?:` + s + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", s, err)
+	}
+	if e.patterns == nil {
+		e.patterns = make(map[string]*regexp.Regexp)
+	}
+	e.patterns[s] = re
+	return re, nil
+}
+
+// compare compares v with the value of param. It returns false if they
+// are not comparable, e.g. a string and a number.
+func (e *evaluation) compare(v interface{}, param ast.Parameter) (int, bool, error) {
+	switch p := param.(type) {
+	case *ast.NumberParameter:
+		want, err := p.Float64()
+		if err != nil {
+			return 0, false, err
+		}
+		got, ok := toFloat(v)
+		if !ok {
+			return 0, false, nil
+		}
+		return compareFloat(got, want), true, nil
+	case *ast.StringParameter:
+		s, ok := v.(string)
+		if !ok {
+			return 0, false, nil
+		}
+		return strings.Compare(s, p.Value), true, nil
+	case *ast.BoolParameter:
+		b, ok := v.(bool)
+		if !ok || b != p.Value {
+			return 0, ok, nil
+		}
+		return 0, true, nil
+	case *ast.DurationParameter:
+		want, err := p.Duration()
+		if err != nil {
+			return 0, false, err
+		}
+		got, ok := v.(time.Duration)
+		if !ok {
+			return 0, false, nil
+		}
+		return compareFloat(float64(got), float64(want)), true, nil
+	case *ast.DateParameter:
+		want, err := p.Resolve(e.now)
+		if err != nil {
+			return 0, false, err
+		}
+		got, ok := v.(time.Time)
+		if !ok {
+			return 0, false, nil
+		}
+		switch {
+		case got.Before(want):
+			return -1, true, nil
+		case got.After(want):
+			return 1, true, nil
+		}
+		return 0, true, nil
+	}
+	return 0, false, fmt.Errorf("unsupported parameter %T", param)
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// toFloat converts a number, or a string holding a number as read from
+// CSV, to a float64.
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,81 @@
+package eval
+
+import "sort"
+
+// Dataset はルールを評価する対象のデータです。
+// A Dataset is the data rules are evaluated against. A value is nil for
+// null.
+type Dataset interface {
+	// Columns returns the names of the columns.
+	Columns() []string
+	// Len returns the number of rows.
+	Len() int
+	// Column returns the values of the named column, one per row, and
+	// whether the column exists.
+	Column(name string) ([]interface{}, bool)
+}
+
+// Rows は行指向のデータセットです。
+// Rows is a row-oriented dataset. A key missing from a row is null, and a
+// column exists if any row has its key.
+type Rows []map[string]interface{}
+
+// Columns implements Dataset.
+func (r Rows) Columns() []string {
+	seen := make(map[string]bool)
+	var columns []string
+	for _, row := range r {
+		for name := range row {
+			if !seen[name] {
+				seen[name] = true
+				columns = append(columns, name)
+			}
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// Len implements Dataset.
+func (r Rows) Len() int { return len(r) }
+
+// Column implements Dataset.
+func (r Rows) Column(name string) ([]interface{}, bool) {
+	values := make([]interface{}, len(r))
+	exists := false
+	for i, row := range r {
+		v, ok := row[name]
+		values[i] = v
+		exists = exists || ok
+	}
+	return values, exists
+}
+
+// Table は列指向のデータセットです。全ての列は同じ長さでなければいけません。
+// Table is a column-oriented dataset. All columns must have the same
+// length.
+type Table map[string][]interface{}
+
+// Columns implements Dataset.
+func (t Table) Columns() []string {
+	columns := make([]string, 0, len(t))
+	for name := range t {
+		columns = append(columns, name)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// Len implements Dataset.
+func (t Table) Len() int {
+	for _, values := range t {
+		return len(values)
+	}
+	return 0
+}
+
+// Column implements Dataset.
+func (t Table) Column(name string) ([]interface{}, bool) {
+	values, ok := t[name]
+	return values, ok
+}
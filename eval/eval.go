@@ -0,0 +1,210 @@
+// Package eval はメモリ上のデータに対してルールを評価します。
+// Package eval evaluates rules against in-memory data, so that rulesets
+// can be tested without a Glue run.
+//
+// Only a subset of the rule types is supported: RowCount, ColumnCount,
+// ColumnExists, IsComplete, Completeness, IsUnique, Uniqueness,
+// UniqueValueRatio, DistinctValuesCount, IsPrimaryKey, Mean, Sum,
+// StandardDeviation, ColumnLength and ColumnValues. Other rules are
+// skipped. The observed metrics are named like the ones Glue reports,
+// e.g. "Column.price.Mean" or "Dataset.*.RowCount".
+package eval
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mashiike/go-dqdl/ast"
+)
+
+// Outcome はルールの評価結果です。
+// Outcome is the outcome of evaluating a rule.
+type Outcome int
+
+const (
+	OutcomePass    Outcome = iota + 1 // the data satisfies the rule
+	OutcomeFail                       // the data does not satisfy the rule
+	OutcomeSkip                       // the rule is not supported
+	OutcomeError                      // the rule can not be evaluated on the data
+	OutcomeSnoozed                    // the rule is disabled by a snooze-until directive
+)
+
+var outcomeStrings = map[Outcome]string{
+	OutcomePass:    "PASS",
+	OutcomeFail:    "FAIL",
+	OutcomeSkip:    "SKIP",
+	OutcomeError:   "ERROR",
+	OutcomeSnoozed: "SNOOZED",
+}
+
+// String returns the upper case name of the outcome, e.g. "PASS".
+func (o Outcome) String() string {
+	if s, ok := outcomeStrings[o]; ok {
+		return s
+	}
+	return fmt.Sprintf("Outcome(%d)", int(o))
+}
+
+// RuleResult は1つのルールの評価結果です。
+// A RuleResult is the result of evaluating a rule.
+type RuleResult struct {
+	Rule    ast.RuleDecl
+	Outcome Outcome
+	Message string             // reason of a failure, a skip or an error
+	Metrics map[string]float64 // observed metrics, e.g. "Column.id.Uniqueness"
+	Samples []interface{}      // some of the values that failed the rule, at most MaxSamples
+}
+
+// MaxSamples は RuleResult.Samples に記録する値の最大数です。
+// MaxSamples is the maximum number of failed values recorded in
+// RuleResult.Samples.
+const MaxSamples = 5
+
+// Result はルールセットの評価結果です。
+// A Result is the result of evaluating a ruleset. It is encoded to JSON
+// in a stable form, see RuleResult.MarshalJSON.
+type Result struct {
+	Rules []RuleResult // in the order of the rules of the ruleset
+}
+
+// Passed は全てのルールが成功したかどうかを返します。スヌーズされたルールは無視します。
+// Passed reports whether every rule passed, ignoring the snoozed rules.
+func (r *Result) Passed() bool {
+	for _, rule := range r.Rules {
+		if rule.Outcome != OutcomePass && rule.Outcome != OutcomeSnoozed {
+			return false
+		}
+	}
+	return true
+}
+
+// Option は評価の設定を変更します。
+// An Option configures evaluation.
+type Option func(*config)
+
+type config struct {
+	now time.Time
+}
+
+// WithNow は now() の値を指定します。デフォルトは評価を開始した時刻です。
+// WithNow sets the time now() evaluates to. The default is the time the
+// evaluation starts.
+func WithNow(t time.Time) Option {
+	return func(c *config) {
+		c.now = t
+	}
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{now: time.Now()}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// Evaluate はルールセットの全てのルールを評価します。
+// Evaluate evaluates every rule of ruleset against data.
+func Evaluate(ruleset *ast.Ruleset, data Dataset, opts ...Option) *Result {
+	cfg := newConfig(opts)
+	result := &Result{Rules: make([]RuleResult, 0, len(ruleset.Rules))}
+	for _, rule := range ruleset.Rules {
+		result.Rules = append(result.Rules, cfg.evaluate(rule, data))
+	}
+	return result
+}
+
+// EvaluateRule はルールを評価します。
+// EvaluateRule evaluates rule against data. A combined rule passes if all
+// (and) or any (or) of its rules pass.
+func EvaluateRule(rule ast.RuleDecl, data Dataset, opts ...Option) RuleResult {
+	return newConfig(opts).evaluate(rule, data)
+}
+
+// Snooze はスヌーズされたルールの評価結果を返します。
+// Snooze returns the result of a rule with a snooze-until directive in its
+// description, see ast.SnoozeUntil, without evaluating it: OutcomeSnoozed
+// until the end of the date, and OutcomeError once the date has passed or
+// if it is invalid, so that a forgotten snooze fails the run. It returns
+// false if the rule has no such directive. Evaluate and EvaluateRule call
+// it first; other evaluators, such as sqleval, should do the same.
+func Snooze(decl ast.RuleDecl, now time.Time) (RuleResult, bool) {
+	until, ok, err := ast.SnoozeUntil(decl)
+	if !ok {
+		return RuleResult{}, false
+	}
+	result := RuleResult{Rule: decl, Metrics: make(map[string]float64)}
+	if err != nil {
+		result.Outcome, result.Message = OutcomeError, strings.TrimPrefix(err.Error(), "ast: ")
+		return result, true
+	}
+	date := until.Format("2006-01-02")
+	if snoozed, _ := ast.Snoozed(decl, now); snoozed {
+		result.Outcome, result.Message = OutcomeSnoozed, fmt.Sprintf("snoozed until %s", date)
+	} else {
+		result.Outcome, result.Message = OutcomeError, fmt.Sprintf("snooze expired on %s", date)
+	}
+	return result, true
+}
+
+func (cfg *config) evaluate(decl ast.RuleDecl, data Dataset) RuleResult {
+	if result, ok := Snooze(decl, cfg.now); ok {
+		return result
+	}
+	result := RuleResult{Rule: decl, Metrics: make(map[string]float64)}
+	switch r := decl.(type) {
+	case *ast.Rule:
+		e := &evaluation{config: cfg, rule: r, data: data, metrics: result.Metrics}
+		result.Outcome, result.Message = e.run()
+		result.Samples = e.samples
+	case *ast.CombinedRule:
+		results := make([]RuleResult, 0, len(r.Rules))
+		for _, nested := range r.Rules {
+			nr := cfg.evaluate(nested, data)
+			for k, v := range nr.Metrics {
+				result.Metrics[k] = v
+			}
+			for _, v := range nr.Samples {
+				if len(result.Samples) < MaxSamples {
+					result.Samples = append(result.Samples, v)
+				}
+			}
+			results = append(results, nr)
+		}
+		result.Outcome, result.Message = combine(r.Operator, results)
+		if result.Outcome == OutcomePass {
+			result.Samples = nil
+		}
+	default:
+		result.Outcome, result.Message = OutcomeError, fmt.Sprintf("unexpected rule %T", decl)
+	}
+	return result
+}
+
+// combine returns the outcome of rules combined with operator. A decisive
+// outcome wins over a skip or an error: a failure for and, a pass for or.
+func combine(operator string, results []RuleResult) (Outcome, string) {
+	decisive, other := OutcomeFail, OutcomePass
+	if operator == "or" {
+		decisive, other = OutcomePass, OutcomeFail
+	}
+	var undecided *RuleResult
+	for i, r := range results {
+		switch r.Outcome {
+		case decisive:
+			return r.Outcome, r.Message
+		case OutcomeSkip, OutcomeError:
+			if undecided == nil {
+				undecided = &results[i]
+			}
+		}
+	}
+	if undecided != nil {
+		return undecided.Outcome, undecided.Message
+	}
+	if other == OutcomeFail {
+		return OutcomeFail, "no rule passed"
+	}
+	return OutcomePass, ""
+}
@@ -0,0 +1,125 @@
+package eval
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/parser"
+)
+
+var now = time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+
+var testRows = Rows{
+	{"id": 1, "name": "alice", "status": "active", "price": 10.0, "updated_at": now.Add(-time.Hour)},
+	{"id": 2, "name": "bob", "status": "active", "price": 20.0, "updated_at": now.Add(-2 * time.Hour)},
+	{"id": 3, "name": nil, "status": "deleted", "price": 30.0, "updated_at": now.Add(-72 * time.Hour)},
+	{"id": 3, "name": "carol", "status": "unknown", "price": "40", "updated_at": now.Add(-96 * time.Hour)},
+}
+
+func TestEvaluateRule(t *testing.T) {
+	cases := []struct {
+		rule        string
+		want        Outcome
+		wantMessage string
+		wantMetrics map[string]float64
+	}{
+		{rule: `RowCount > 3`, want: OutcomePass, wantMetrics: map[string]float64{"Dataset.*.RowCount": 4}},
+		{rule: `RowCount between 5 and 10`, want: OutcomeFail, wantMessage: "Value: 4 does not meet the constraint requirement"},
+		{rule: `ColumnCount = 5`, want: OutcomePass},
+		{rule: `ColumnExists "status"`, want: OutcomePass},
+		{rule: `ColumnExists "email"`, want: OutcomeFail, wantMessage: `column "email" does not exist`},
+		{rule: `IsComplete "id"`, want: OutcomePass},
+		{rule: `IsComplete "name"`, want: OutcomeFail, wantMetrics: map[string]float64{"Column.name.Completeness": 0.75}},
+		{rule: `Completeness "name" >= 0.75`, want: OutcomePass},
+		{rule: `IsUnique "name"`, want: OutcomeFail, wantMetrics: map[string]float64{"Column.name.Uniqueness": 0.75}},
+		{rule: `IsUnique "id"`, want: OutcomeFail, wantMetrics: map[string]float64{"Column.id.Uniqueness": 0.5}},
+		{rule: `Uniqueness "status" = 0.5`, want: OutcomePass},
+		{rule: `UniqueValueRatio "status" > 0.6`, want: OutcomePass, wantMetrics: map[string]float64{"Column.status.UniqueValueRatio": 2.0 / 3}},
+		{rule: `DistinctValuesCount "status" = 3`, want: OutcomePass},
+		{rule: `IsPrimaryKey "id" "name"`, want: OutcomeFail, wantMessage: `column "name" has a null value`},
+		{rule: `IsPrimaryKey "id" "status"`, want: OutcomePass},
+		{rule: `Mean "price" = 25`, want: OutcomePass, wantMetrics: map[string]float64{"Column.price.Mean": 25}},
+		{rule: `Sum "price" > 100`, want: OutcomeFail, wantMessage: "Value: 100 does not meet the constraint requirement"},
+		{rule: `StandardDeviation "id" < 1`, want: OutcomePass},
+		{rule: `Mean "name" > 0`, want: OutcomeError, wantMessage: `column "name" has a value that is not a number: alice`},
+		{rule: `ColumnLength "name" between 3 and 5`, want: OutcomePass},
+		{rule: `ColumnLength "status" <= 6`, want: OutcomeFail, wantMessage: `2 values do not meet the constraint requirement, e.g. "deleted"`},
+		{rule: `ColumnValues "status" in ["active", "deleted"]`, want: OutcomeFail, wantMessage: `1 values do not meet the constraint requirement, e.g. "unknown"`},
+		{
+			rule:        `ColumnValues "status" in ["active", "deleted"] with threshold >= 0.75`,
+			want:        OutcomePass,
+			wantMetrics: map[string]float64{"Column.status.ColumnValues.Compliance": 0.75},
+		},
+		{rule: `ColumnValues "name" matches "[a-z]+"`, want: OutcomeFail, wantMessage: "1 values do not meet the constraint requirement, e.g. null"},
+		{rule: `ColumnValues "name" matches "[a-z]+" with threshold > 0.7`, want: OutcomePass},
+		{rule: `ColumnValues "price" between 10 and 40`, want: OutcomePass},
+		{rule: `ColumnValues "updated_at" > (now() - 3 days)`, want: OutcomeFail, wantMessage: "2 values do not meet the constraint requirement, e.g. 2024-03-29 12:00:00 +0000 UTC"},
+		{rule: `(IsComplete "id") and (IsComplete "name")`, want: OutcomeFail},
+		{rule: `(IsComplete "id") or (IsComplete "name")`, want: OutcomePass},
+		{rule: `(Entropy "id" > 1) or (IsComplete "id")`, want: OutcomePass},
+		{rule: `(Entropy "id" > 1) and (IsComplete "id")`, want: OutcomeSkip, wantMessage: "rule type Entropy is not supported"},
+		{rule: `IsComplete "email"`, want: OutcomeError, wantMessage: `column "email" does not exist`},
+		{rule: `Mean "price"`, want: OutcomeError, wantMessage: "Mean requires an expression"},
+		{rule: "#dqdl:snooze-until 2024-04-01\nIsComplete \"name\"", want: OutcomeSnoozed, wantMessage: "snoozed until 2024-04-01"},
+		{rule: "#dqdl:snooze-until 2024-03-31\nIsComplete \"id\"", want: OutcomeError, wantMessage: "snooze expired on 2024-03-31"},
+		{rule: "#dqdl:snooze-until soon\nIsComplete \"id\"", want: OutcomeError, wantMessage: `invalid snooze-until date "soon", want YYYY-MM-DD`},
+	}
+	for _, c := range cases {
+		t.Run(c.rule, func(t *testing.T) {
+			rule, err := parser.ParseRule(c.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := EvaluateRule(rule, testRows, WithNow(now))
+			if got.Outcome != c.want || (c.wantMessage != "" && got.Message != c.wantMessage) {
+				t.Errorf("got %s %q, want %s %q", got.Outcome, got.Message, c.want, c.wantMessage)
+			}
+			for name, want := range c.wantMetrics {
+				if got.Metrics[name] != want {
+					t.Errorf("metric %s = %v, want %v", name, got.Metrics[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	ruleset, err := parser.ParseRuleset(`Rules = [ RowCount > 0, IsComplete "name", Entropy "id" > 1 ]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table := Table{
+		"id":   {1, 2},
+		"name": {"a", nil},
+	}
+	result := Evaluate(ruleset, table)
+	var got []Outcome
+	for _, r := range result.Rules {
+		got = append(got, r.Outcome)
+	}
+	if diff := cmp.Diff([]Outcome{OutcomePass, OutcomeFail, OutcomeSkip}, got); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+	if result.Passed() {
+		t.Error("Passed() = true, want false")
+	}
+}
+
+func TestResult__PassedSnoozed(t *testing.T) {
+	ruleset, err := parser.ParseRuleset("Rules = [\n\tRowCount > 0,\n\t# @snooze-until: 2024-04-30\n\tIsComplete \"name\"\n]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := Evaluate(ruleset, testRows, WithNow(now))
+	var got []Outcome
+	for _, r := range result.Rules {
+		got = append(got, r.Outcome)
+	}
+	if diff := cmp.Diff([]Outcome{OutcomePass, OutcomeSnoozed}, got); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+	if !result.Passed() {
+		t.Error("Passed() = false, want true")
+	}
+}
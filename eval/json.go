@@ -0,0 +1,164 @@
+package eval
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/parser"
+	"github.com/mashiike/go-dqdl/printer"
+)
+
+// MarshalText は評価結果を "PASS" のような文字列に変換します。
+// MarshalText implements encoding.TextMarshaler, encoding the outcome as
+// its String.
+func (o Outcome) MarshalText() ([]byte, error) {
+	if _, ok := outcomeStrings[o]; !ok {
+		return nil, fmt.Errorf("eval: invalid outcome %d", int(o))
+	}
+	return []byte(o.String()), nil
+}
+
+// UnmarshalText は "PASS" のような文字列を評価結果に変換します。
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (o *Outcome) UnmarshalText(text []byte) error {
+	for outcome, s := range outcomeStrings {
+		if s == string(text) {
+			*o = outcome
+			return nil
+		}
+	}
+	return fmt.Errorf("eval: invalid outcome %q", text)
+}
+
+// jsonRuleResult is the JSON form of a RuleResult. The fields must not be
+// renamed, as the form is consumed by other tools.
+type jsonRuleResult struct {
+	Rule      string             `json:"rule"`
+	Outcome   Outcome            `json:"outcome"`
+	Message   string             `json:"message,omitempty"`
+	Metrics   map[string]float64 `json:"metrics"`
+	Threshold string             `json:"threshold,omitempty"`
+	Samples   []interface{}      `json:"samples,omitempty"`
+}
+
+// MarshalJSON はルールの評価結果を JSON に変換します。
+// MarshalJSON encodes the result as a JSON object with the rule as DQDL
+// text, the outcome, the message, the metrics, the threshold the metric is
+// checked against as DQDL text and the failure samples, e.g.
+//
+//	{"rule":"Completeness \"name\" > 0.9","outcome":"FAIL","message":"...",
+//	 "metrics":{"Column.name.Completeness":0.5},"threshold":"> 0.9"}
+func (r RuleResult) MarshalJSON() ([]byte, error) {
+	v := jsonRuleResult{
+		Outcome: r.Outcome,
+		Message: r.Message,
+		Metrics: r.Metrics,
+		Samples: r.Samples,
+	}
+	if v.Metrics == nil {
+		v.Metrics = map[string]float64{}
+	}
+	if r.Rule != nil {
+		rule, err := format(r.Rule)
+		if err != nil {
+			return nil, err
+		}
+		v.Rule = rule
+		if threshold := thresholdOf(r.Rule); threshold != nil {
+			if v.Threshold, err = format(threshold); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return marshal(v)
+}
+
+// UnmarshalJSON は MarshalJSON が出力した JSON を読み込みます。
+// UnmarshalJSON decodes the form written by MarshalJSON, parsing the rule
+// back into nodes. The threshold is not decoded as it is part of the rule.
+func (r *RuleResult) UnmarshalJSON(data []byte) error {
+	var v jsonRuleResult
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	rule, err := parser.ParseRule(v.Rule)
+	if err != nil {
+		return fmt.Errorf("eval: %w", err)
+	}
+	*r = RuleResult{
+		Rule:    rule,
+		Outcome: v.Outcome,
+		Message: v.Message,
+		Metrics: v.Metrics,
+		Samples: v.Samples,
+	}
+	return nil
+}
+
+// MarshalJSON はルールセットの評価結果を JSON に変換します。
+// MarshalJSON encodes the result as a JSON object with whether every rule
+// passed and the results of the rules, e.g. {"passed":true,"rules":[...]}.
+func (r *Result) MarshalJSON() ([]byte, error) {
+	rules := r.Rules
+	if rules == nil {
+		rules = []RuleResult{}
+	}
+	return marshal(struct {
+		Passed bool         `json:"passed"`
+		Rules  []RuleResult `json:"rules"`
+	}{r.Passed(), rules})
+}
+
+// UnmarshalJSON は MarshalJSON が出力した JSON を読み込みます。
+// UnmarshalJSON decodes the form written by MarshalJSON.
+func (r *Result) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Rules []RuleResult `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	r.Rules = v.Rules
+	return nil
+}
+
+// marshal is json.Marshal without escaping of HTML characters, which
+// would make the operators of rules unreadable. They are escaped again if
+// the caller of json.Marshal asks for it.
+func marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func format(node interface{}) (string, error) {
+	var buf bytes.Buffer
+	cfg := printer.Config{Mode: printer.OmitComments}
+	if err := cfg.Fprint(&buf, node); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// thresholdOf returns the expression the metric of rule is checked
+// against, or nil if there is none.
+func thresholdOf(decl ast.RuleDecl) ast.Expression {
+	r, ok := decl.(*ast.Rule)
+	if !ok || r.Expression == nil {
+		return nil
+	}
+	if x, ok := r.Expression.(*ast.WithThresholdExpression); ok {
+		return x.Threshold
+	}
+	if r.Type.Name == "ColumnValues" || r.Type.Name == "ColumnLength" {
+		// the expression applies to each value, not to the metric
+		return nil
+	}
+	return r.Expression
+}
@@ -0,0 +1,121 @@
+package eval
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/parser"
+)
+
+func TestResult__MarshalJSON(t *testing.T) {
+	ruleset, err := parser.ParseRuleset(`Rules = [
+	RowCount > 3,
+	Completeness "name" > 0.9,
+	ColumnValues "status" in ["active", "deleted"] with threshold >= 0.9,
+	ColumnCount between 1 and 3,
+	ColumnExists "email"
+]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(Evaluate(ruleset, testRows, WithNow(now))); err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "passed": false,
+  "rules": [
+    {
+      "rule": "RowCount > 3",
+      "outcome": "PASS",
+      "metrics": {
+        "Dataset.*.RowCount": 4
+      },
+      "threshold": "> 3"
+    },
+    {
+      "rule": "Completeness \"name\" > 0.9",
+      "outcome": "FAIL",
+      "message": "Value: 0.75 does not meet the constraint requirement",
+      "metrics": {
+        "Column.name.Completeness": 0.75
+      },
+      "threshold": "> 0.9"
+    },
+    {
+      "rule": "ColumnValues \"status\" in [\"active\", \"deleted\"] with threshold >= 0.9",
+      "outcome": "FAIL",
+      "message": "Value: 0.75 does not meet the constraint requirement",
+      "metrics": {
+        "Column.status.ColumnValues.Compliance": 0.75
+      },
+      "threshold": ">= 0.9",
+      "samples": [
+        "unknown"
+      ]
+    },
+    {
+      "rule": "ColumnCount between 1 and 3",
+      "outcome": "FAIL",
+      "message": "Value: 5 does not meet the constraint requirement",
+      "metrics": {
+        "Dataset.*.ColumnCount": 5
+      },
+      "threshold": "between 1 and 3"
+    },
+    {
+      "rule": "ColumnExists \"email\"",
+      "outcome": "FAIL",
+      "message": "column \"email\" does not exist",
+      "metrics": {}
+    }
+  ]
+}
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+}
+
+func TestResult__UnmarshalJSON(t *testing.T) {
+	rule, err := parser.ParseRule(`ColumnValues "status" in ["active"]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Result{Rules: []RuleResult{{
+		Rule:    rule,
+		Outcome: OutcomeFail,
+		Message: "2 values do not meet the constraint requirement",
+		Metrics: map[string]float64{"Column.status.ColumnValues.Compliance": 0.5},
+		Samples: []interface{}{"deleted", nil},
+	}}}
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &Result{}
+	if err := json.Unmarshal(data, got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got, cmp.Comparer(func(a, b ast.RuleDecl) bool {
+		return ast.Equal(a, b, ast.IgnorePositions)
+	})); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+}
+
+func TestOutcome__UnmarshalText(t *testing.T) {
+	var o Outcome
+	if err := o.UnmarshalText([]byte("SKIP")); err != nil || o != OutcomeSkip {
+		t.Errorf("got %v, %v, want SKIP", o, err)
+	}
+	if err := o.UnmarshalText([]byte("OK")); err == nil {
+		t.Error("got no error for an invalid outcome")
+	}
+}
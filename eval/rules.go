@@ -0,0 +1,406 @@
+package eval
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mashiike/go-dqdl/ast"
+)
+
+// evaluation is the evaluation of a single rule.
+type evaluation struct {
+	*config
+	rule     *ast.Rule
+	data     Dataset
+	metrics  map[string]float64
+	samples  []interface{}
+	patterns map[string]*regexp.Regexp // compiled patterns of matches
+}
+
+// ruleFunc evaluates a rule type. It returns whether the rule passed, the
+// reason if it did not, or an error if the rule can not be evaluated.
+type ruleFunc func(e *evaluation) (bool, string, error)
+
+var ruleFuncs = map[string]ruleFunc{
+	"RowCount":            evalRowCount,
+	"ColumnCount":         evalColumnCount,
+	"ColumnExists":        evalColumnExists,
+	"IsComplete":          evalIsComplete,
+	"Completeness":        evalCompleteness,
+	"IsUnique":            evalIsUnique,
+	"Uniqueness":          evalUniqueness,
+	"UniqueValueRatio":    evalUniqueValueRatio,
+	"DistinctValuesCount": evalDistinctValuesCount,
+	"IsPrimaryKey":        evalIsPrimaryKey,
+	"Mean":                evalStatistic("Mean", mean),
+	"Sum":                 evalStatistic("Sum", sum),
+	"StandardDeviation":   evalStatistic("StandardDeviation", standardDeviation),
+	"ColumnLength":        evalColumnLength,
+	"ColumnValues":        evalColumnValues,
+}
+
+func (e *evaluation) run() (Outcome, string) {
+	fn, ok := ruleFuncs[e.rule.Type.Name]
+	if !ok {
+		return OutcomeSkip, fmt.Sprintf("rule type %s is not supported", e.rule.Type.Name)
+	}
+	passed, reason, err := fn(e)
+	switch {
+	case err != nil:
+		return OutcomeError, err.Error()
+	case !passed:
+		return OutcomeFail, reason
+	}
+	return OutcomePass, ""
+}
+
+// column returns the name and the values of the column given by the i-th
+// parameter.
+func (e *evaluation) column(i int) (string, []interface{}, error) {
+	if i >= len(e.rule.Parameters) {
+		return "", nil, fmt.Errorf("%s requires a column", e.rule.Type.Name)
+	}
+	param, ok := e.rule.Parameters[i].(*ast.StringParameter)
+	if !ok {
+		return "", nil, fmt.Errorf("%s requires a column name", e.rule.Type.Name)
+	}
+	values, ok := e.data.Column(param.Value)
+	if !ok {
+		return param.Value, nil, fmt.Errorf("column %q does not exist", param.Value)
+	}
+	return param.Value, values, nil
+}
+
+// check records the metric and checks it against the expression of the
+// rule.
+func (e *evaluation) check(metric string, v float64) (bool, string, error) {
+	e.metrics[metric] = v
+	if e.rule.Expression == nil {
+		return false, "", fmt.Errorf("%s requires an expression", e.rule.Type.Name)
+	}
+	ok, err := e.match(e.rule.Expression, v)
+	if err != nil || ok {
+		return ok, "", err
+	}
+	return false, fmt.Sprintf("Value: %s does not meet the constraint requirement", formatFloat(v)), nil
+}
+
+// sample records a value that failed the rule.
+func (e *evaluation) sample(v interface{}) {
+	if len(e.samples) < MaxSamples {
+		e.samples = append(e.samples, v)
+	}
+}
+
+// is records the metric and checks that it is want, for rule types
+// without expression.
+func (e *evaluation) is(metric string, v, want float64) (bool, string, error) {
+	e.metrics[metric] = v
+	if v == want {
+		return true, "", nil
+	}
+	return false, fmt.Sprintf("Value: %s does not meet the constraint requirement", formatFloat(v)), nil
+}
+
+func evalRowCount(e *evaluation) (bool, string, error) {
+	return e.check("Dataset.*.RowCount", float64(e.data.Len()))
+}
+
+func evalColumnCount(e *evaluation) (bool, string, error) {
+	return e.check("Dataset.*.ColumnCount", float64(len(e.data.Columns())))
+}
+
+func evalColumnExists(e *evaluation) (bool, string, error) {
+	name, _, err := e.column(0)
+	if err != nil {
+		if name != "" {
+			return false, err.Error(), nil
+		}
+		return false, "", err
+	}
+	return true, "", nil
+}
+
+func completeness(values []interface{}) float64 {
+	if len(values) == 0 {
+		return 1
+	}
+	n := 0
+	for _, v := range values {
+		if v != nil {
+			n++
+		}
+	}
+	return float64(n) / float64(len(values))
+}
+
+func evalIsComplete(e *evaluation) (bool, string, error) {
+	name, values, err := e.column(0)
+	if err != nil {
+		return false, "", err
+	}
+	return e.is("Column."+name+".Completeness", completeness(values), 1)
+}
+
+func evalCompleteness(e *evaluation) (bool, string, error) {
+	name, values, err := e.column(0)
+	if err != nil {
+		return false, "", err
+	}
+	return e.check("Column."+name+".Completeness", completeness(values))
+}
+
+// counts returns the number of occurrences of each non-null value.
+func counts(values []interface{}) map[string]int {
+	m := make(map[string]int)
+	for _, v := range values {
+		if v != nil {
+			m[key(v)]++
+		}
+	}
+	return m
+}
+
+func key(v interface{}) string {
+	return fmt.Sprintf("%T:%v", v, v)
+}
+
+// uniqueness returns the fraction of rows whose value occurs only once.
+func uniqueness(values []interface{}) float64 {
+	if len(values) == 0 {
+		return 1
+	}
+	unique := 0
+	for _, n := range counts(values) {
+		if n == 1 {
+			unique++
+		}
+	}
+	return float64(unique) / float64(len(values))
+}
+
+func evalIsUnique(e *evaluation) (bool, string, error) {
+	name, values, err := e.column(0)
+	if err != nil {
+		return false, "", err
+	}
+	return e.is("Column."+name+".Uniqueness", uniqueness(values), 1)
+}
+
+func evalUniqueness(e *evaluation) (bool, string, error) {
+	name, values, err := e.column(0)
+	if err != nil {
+		return false, "", err
+	}
+	return e.check("Column."+name+".Uniqueness", uniqueness(values))
+}
+
+func evalUniqueValueRatio(e *evaluation) (bool, string, error) {
+	name, values, err := e.column(0)
+	if err != nil {
+		return false, "", err
+	}
+	c := counts(values)
+	ratio := 1.0
+	if len(c) > 0 {
+		unique := 0
+		for _, n := range c {
+			if n == 1 {
+				unique++
+			}
+		}
+		ratio = float64(unique) / float64(len(c))
+	}
+	return e.check("Column."+name+".UniqueValueRatio", ratio)
+}
+
+func evalDistinctValuesCount(e *evaluation) (bool, string, error) {
+	name, values, err := e.column(0)
+	if err != nil {
+		return false, "", err
+	}
+	return e.check("Column."+name+".DistinctValuesCount", float64(len(counts(values))))
+}
+
+func evalIsPrimaryKey(e *evaluation) (bool, string, error) {
+	if len(e.rule.Parameters) == 0 {
+		return false, "", fmt.Errorf("IsPrimaryKey requires a column")
+	}
+	names := make([]string, 0, len(e.rule.Parameters))
+	keys := make([]string, e.data.Len())
+	for i := range e.rule.Parameters {
+		name, values, err := e.column(i)
+		if err != nil {
+			return false, "", err
+		}
+		names = append(names, name)
+		for row, v := range values {
+			if v == nil {
+				return false, fmt.Sprintf("column %q has a null value", name), nil
+			}
+			keys[row] += key(v) + "\x00"
+		}
+	}
+	rows := make([]interface{}, len(keys))
+	for i, k := range keys {
+		rows[i] = k
+	}
+	return e.is("Column."+strings.Join(names, ",")+".Uniqueness", uniqueness(rows), 1)
+}
+
+// numbers returns the non-null values as numbers.
+func numbers(name string, values []interface{}) ([]float64, error) {
+	nums := make([]float64, 0, len(values))
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		f, ok := toFloat(v)
+		if !ok {
+			return nil, fmt.Errorf("column %q has a value that is not a number: %v", name, v)
+		}
+		nums = append(nums, f)
+	}
+	return nums, nil
+}
+
+func evalStatistic(metric string, fn func([]float64) float64) ruleFunc {
+	return func(e *evaluation) (bool, string, error) {
+		name, values, err := e.column(0)
+		if err != nil {
+			return false, "", err
+		}
+		nums, err := numbers(name, values)
+		if err != nil {
+			return false, "", err
+		}
+		if len(nums) == 0 {
+			return false, "", fmt.Errorf("column %q has no values", name)
+		}
+		return e.check("Column."+name+"."+metric, fn(nums))
+	}
+}
+
+func sum(nums []float64) float64 {
+	s := 0.0
+	for _, n := range nums {
+		s += n
+	}
+	return s
+}
+
+func mean(nums []float64) float64 {
+	return sum(nums) / float64(len(nums))
+}
+
+// standardDeviation returns the sample standard deviation.
+func standardDeviation(nums []float64) float64 {
+	if len(nums) < 2 {
+		return 0
+	}
+	m := mean(nums)
+	s := 0.0
+	for _, n := range nums {
+		s += (n - m) * (n - m)
+	}
+	return math.Sqrt(s / float64(len(nums)-1))
+}
+
+func evalColumnLength(e *evaluation) (bool, string, error) {
+	name, values, err := e.column(0)
+	if err != nil {
+		return false, "", err
+	}
+	if e.rule.Expression == nil {
+		return false, "", fmt.Errorf("ColumnLength requires an expression")
+	}
+	minLen, maxLen := -1, -1
+	var failed []interface{}
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		s, ok := v.(string)
+		if !ok {
+			return false, "", fmt.Errorf("column %q has a value that is not a string: %v", name, v)
+		}
+		n := utf8.RuneCountInString(s)
+		if minLen < 0 || n < minLen {
+			minLen = n
+		}
+		if n > maxLen {
+			maxLen = n
+		}
+		ok, err := e.match(e.rule.Expression, float64(n))
+		if err != nil {
+			return false, "", err
+		}
+		if !ok {
+			failed = append(failed, v)
+			e.sample(v)
+		}
+	}
+	if minLen >= 0 {
+		e.metrics["Column."+name+".MinimumLength"] = float64(minLen)
+		e.metrics["Column."+name+".MaximumLength"] = float64(maxLen)
+	}
+	if len(failed) > 0 {
+		return false, fmt.Sprintf("%d values do not meet the constraint requirement, e.g. %q", len(failed), failed[0]), nil
+	}
+	return true, "", nil
+}
+
+func evalColumnValues(e *evaluation) (bool, string, error) {
+	name, values, err := e.column(0)
+	if err != nil {
+		return false, "", err
+	}
+	expr := e.rule.Expression
+	var threshold *ast.WithThresholdExpression
+	if x, ok := expr.(*ast.WithThresholdExpression); ok {
+		expr, threshold = x.Target, x
+	}
+	if expr == nil {
+		return false, "", fmt.Errorf("ColumnValues requires an expression")
+	}
+	var failed []interface{}
+	for _, v := range values {
+		ok, err := e.match(expr, v)
+		if err != nil {
+			return false, "", err
+		}
+		if !ok {
+			failed = append(failed, v)
+			e.sample(v)
+		}
+	}
+	compliance := 1.0
+	if len(values) > 0 {
+		compliance = float64(len(values)-len(failed)) / float64(len(values))
+	}
+	e.metrics["Column."+name+".ColumnValues.Compliance"] = compliance
+	if threshold != nil {
+		ok, err := threshold.Eval(compliance)
+		if err != nil || ok {
+			return ok, "", err
+		}
+		return false, fmt.Sprintf("Value: %s does not meet the constraint requirement", formatFloat(compliance)), nil
+	}
+	if len(failed) > 0 {
+		return false, fmt.Sprintf("%d values do not meet the constraint requirement, e.g. %v", len(failed), formatValue(failed[0])), nil
+	}
+	return true, "", nil
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("%q", v)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,134 @@
+// Package sqleval はデータベース上でルールを評価します。
+// Package sqleval evaluates rules in a database through database/sql,
+// running the queries generated by the sqlgen package. The results are
+// reported with the types of the eval package, so that they can be handled
+// like the ones of an in-memory evaluation.
+//
+// The package does not depend on any database driver. To check a local
+// CSV or Parquet file, open a DuckDB database with a DuckDB driver and use
+// DuckDBTable as the table:
+//
+//	db, err := sql.Open("duckdb", "")
+//	table, err := sqleval.DuckDBTable("orders.parquet")
+//	result, err := sqleval.Evaluate(ctx, db, ruleset, table, sqlgen.DuckDB)
+package sqleval
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/eval"
+	"github.com/mashiike/go-dqdl/sqlgen"
+)
+
+// Evaluate はルールセットの全てのルールをデータベース上で評価します。
+// Evaluate evaluates every rule of ruleset against table in db. Rules that
+// sqlgen can not translate are skipped, and rules whose query fails are
+// reported with OutcomeError. An error is returned only if ctx is done.
+func Evaluate(ctx context.Context, db *sql.DB, ruleset *ast.Ruleset, table string, d sqlgen.Dialect) (*eval.Result, error) {
+	result := &eval.Result{Rules: make([]eval.RuleResult, 0, len(ruleset.Rules))}
+	for _, rule := range ruleset.Rules {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		result.Rules = append(result.Rules, EvaluateRule(ctx, db, rule, table, d))
+	}
+	return result, nil
+}
+
+// EvaluateRule はルールをデータベース上で評価します。
+// EvaluateRule evaluates rule against table in db. A rule with a
+// snooze-until directive is not run, see eval.Snooze.
+func EvaluateRule(ctx context.Context, db *sql.DB, rule ast.RuleDecl, table string, d sqlgen.Dialect) eval.RuleResult {
+	if result, ok := eval.Snooze(rule, time.Now()); ok {
+		return result
+	}
+	result := eval.RuleResult{Rule: rule, Metrics: make(map[string]float64)}
+	query, err := sqlgen.Rule(rule, table, d)
+	if err != nil {
+		result.Outcome = eval.OutcomeSkip
+		result.Message = strings.TrimPrefix(err.Error(), "sqlgen: ")
+		return result
+	}
+	var metric sql.NullFloat64
+	var passed sql.NullBool
+	if err := db.QueryRowContext(ctx, query).Scan(&metric, &passed); err != nil {
+		result.Outcome = eval.OutcomeError
+		result.Message = err.Error()
+		return result
+	}
+	if metric.Valid {
+		if name := metricName(rule); name != "" {
+			result.Metrics[name] = metric.Float64
+		}
+	}
+	switch {
+	case !passed.Valid:
+		result.Outcome = eval.OutcomeError
+		result.Message = "the metric can not be computed on the data"
+	case passed.Bool:
+		result.Outcome = eval.OutcomePass
+	default:
+		result.Outcome = eval.OutcomeFail
+		if metric.Valid {
+			result.Message = fmt.Sprintf("Value: %s does not meet the constraint requirement",
+				strconv.FormatFloat(metric.Float64, 'f', -1, 64))
+		}
+	}
+	return result
+}
+
+// metricName returns the name of the metric computed by the query of
+// rule, named like the ones of the eval package, or "" for a combined
+// rule.
+func metricName(decl ast.RuleDecl) string {
+	r, ok := decl.(*ast.Rule)
+	if !ok {
+		return ""
+	}
+	var columns []string
+	for _, param := range r.Parameters {
+		if p, ok := param.(*ast.StringParameter); ok {
+			columns = append(columns, p.Value)
+		}
+	}
+	if r.Type.Name == "RowCount" || len(columns) == 0 {
+		return "Dataset.*." + r.Type.Name
+	}
+	switch r.Type.Name {
+	case "IsComplete":
+		return "Column." + columns[0] + ".Completeness"
+	case "IsUnique":
+		return "Column." + columns[0] + ".Uniqueness"
+	case "IsPrimaryKey":
+		return "Column." + strings.Join(columns, ",") + ".Uniqueness"
+	case "ColumnValues", "ColumnLength":
+		return "Column." + columns[0] + "." + r.Type.Name + ".Compliance"
+	}
+	return "Column." + columns[0] + "." + r.Type.Name
+}
+
+// DuckDBTable はファイルを読み込む DuckDB のテーブル関数を返します。
+// DuckDBTable returns the DuckDB table function reading the file at path,
+// to be used as the table of Evaluate. The format is chosen by the
+// extension: .parquet for Parquet, and .csv or .tsv, optionally compressed
+// with .gz, for CSV.
+func DuckDBTable(path string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".gz" {
+		ext = strings.ToLower(filepath.Ext(strings.TrimSuffix(path, filepath.Ext(path))))
+	}
+	switch ext {
+	case ".parquet":
+		return "read_parquet(" + sqlgen.DuckDB.QuoteString(path) + ")", nil
+	case ".csv", ".tsv":
+		return "read_csv_auto(" + sqlgen.DuckDB.QuoteString(path) + ")", nil
+	}
+	return "", fmt.Errorf("sqleval: unsupported file format %q", path)
+}
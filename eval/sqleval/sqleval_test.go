@@ -0,0 +1,143 @@
+package sqleval
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/eval"
+	"github.com/mashiike/go-dqdl/parser"
+	"github.com/mashiike/go-dqdl/sqlgen"
+)
+
+// fakeDriver answers each query with the row of the first entry of rows
+// whose key is contained in the query.
+type fakeDriver struct {
+	rows []fakeRow
+}
+
+type fakeRow struct {
+	key string
+	row []driver.Value
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{d}, nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{c.d, query}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return 0 }
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	for _, r := range s.d.rows {
+		if strings.Contains(s.query, r.key) {
+			return &fakeRows{row: r.row}, nil
+		}
+	}
+	return nil, errors.New("no such table")
+}
+
+type fakeRows struct {
+	row  []driver.Value
+	done bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"metric", "passed"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.row)
+	return nil
+}
+
+func TestEvaluate(t *testing.T) {
+	sql.Register("sqleval-fake", &fakeDriver{rows: []fakeRow{
+		{`AS r1 CROSS JOIN`, []driver.Value{nil, true}},
+		{`COUNT(*) AS DOUBLE) AS metric`, []driver.Value{float64(4), true}},
+		{`COUNT("name")`, []driver.Value{0.75, false}},
+		{`AVG(`, []driver.Value{nil, nil}},
+	}})
+	db, err := sql.Open("sqleval-fake", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ruleset, err := parser.ParseRuleset(`Rules = [
+	RowCount > 3,
+	IsComplete "name",
+	Mean "price" > 0,
+	ColumnCount = 5,
+	IsComplete "email",
+	(RowCount > 0) and (RowCount < 10)
+]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Evaluate(context.Background(), db, ruleset, "orders", sqlgen.DuckDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type outcome struct {
+		Outcome eval.Outcome
+		Message string
+		Metrics map[string]float64
+	}
+	var got []outcome
+	for _, r := range result.Rules {
+		got = append(got, outcome{r.Outcome, r.Message, r.Metrics})
+	}
+	want := []outcome{
+		{eval.OutcomePass, "", map[string]float64{"Dataset.*.RowCount": 4}},
+		{eval.OutcomeFail, "Value: 0.75 does not meet the constraint requirement", map[string]float64{"Column.name.Completeness": 0.75}},
+		{eval.OutcomeError, "the metric can not be computed on the data", map[string]float64{}},
+		{eval.OutcomeSkip, "ColumnCount: rule type is not supported", map[string]float64{}},
+		{eval.OutcomeError, "no such table", map[string]float64{}},
+		{eval.OutcomePass, "", map[string]float64{}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+}
+
+func TestDuckDBTable(t *testing.T) {
+	cases := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "data/orders.parquet", want: `read_parquet('data/orders.parquet')`},
+		{path: "orders.CSV", want: `read_csv_auto('orders.CSV')`},
+		{path: "it's.tsv.gz", want: `read_csv_auto('it''s.tsv.gz')`},
+		{path: "orders.json", wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			got, err := DuckDBTable(c.path)
+			if (err != nil) != c.wantErr {
+				t.Fatalf("got error %v, want error %v", err, c.wantErr)
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
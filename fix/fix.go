@@ -0,0 +1,39 @@
+// Package fix はDQDLのソースを正規の形式に書き換えます。
+// Package fix rewrites DQDL sources into their canonical form.
+package fix
+
+import (
+	"bytes"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/parser"
+	"github.com/mashiike/go-dqdl/printer"
+)
+
+// WrapBareRules は `Rules = [ ... ]` で囲まれていない古い形式のルールの一覧を正規の形式に書き換えます。
+// WrapBareRules rewrites a legacy list of rules without the
+// `Rules = [ ... ]` wrapper (see parser.ParseBareRules) into a canonical
+// DQDL file using the printer. It reports whether src was rewritten; a
+// src that already is a valid DQDL file is returned unchanged.
+func WrapBareRules(src []byte, opts ...parser.Option) ([]byte, bool, error) {
+	if _, err := parser.ParseFile("", bytes.NewReader(src), opts...); err == nil {
+		return src, false, nil
+	}
+	ruleset, err := parser.ParseBareRules(string(src), opts...)
+	if err != nil {
+		return nil, false, err
+	}
+	file := &ast.File{Rulesets: []*ast.Ruleset{ruleset}}
+	// comments before the first rule, separated by a blank line, are
+	// comments of the file rather than of the ruleset.
+	if len(ruleset.InnerComments) > 0 && len(ruleset.Rules) > 0 &&
+		ruleset.InnerComments[0].Pos().Index < ruleset.Rules[0].Pos().Index {
+		file.CommentGroups = ruleset.InnerComments[:1]
+		ruleset.InnerComments = ruleset.InnerComments[1:]
+	}
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, file); err != nil {
+		return nil, false, err
+	}
+	return buf.Bytes(), true, nil
+}
@@ -0,0 +1,75 @@
+package fix
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWrapBareRules(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   string
+		want    string
+		changed bool
+		errStr  string
+	}{
+		{
+			name: "one rule per line",
+			input: `# legacy rules
+
+# unique id
+IsUnique "id"
+IsComplete "id"
+# combined
+(RowCount > 0) and (ColumnCount = 3)
+ColumnValues "status" in ["a", "b"],
+Mean "price"
+  between 1 and 10
+`,
+			want: `# legacy rules
+
+Rules = [
+	# unique id
+	IsUnique "id",
+	IsComplete "id",
+	# combined
+	(RowCount > 0) and (ColumnCount = 3),
+	ColumnValues "status" in ["a", "b"],
+	Mean "price" between 1 and 10
+]
+`,
+			changed: true,
+		},
+		{
+			name:  "already wrapped",
+			input: "Rules = [\n  IsUnique \"id\"\n]\n",
+			want:  "Rules = [\n  IsUnique \"id\"\n]\n",
+		},
+		{
+			name:   "broken",
+			input:  "IsUnique \"id\" >",
+			errStr: "1:16: syntax error near `>`, unexpected token `EOF`",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, changed, err := WrapBareRules([]byte(c.input))
+			if c.errStr != "" {
+				if err == nil || err.Error() != c.errStr {
+					t.Fatalf("got error %v, want %q", err, c.errStr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != c.changed {
+				t.Errorf("got changed %v, want %v", changed, c.changed)
+			}
+			if diff := cmp.Diff(c.want, string(got)); diff != "" {
+				t.Errorf("unexpected output (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+// Package generate はテーブルのスキーマから雛形となるルールセットを生成します。
+// Package generate builds starter rulesets from table schemas.
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mashiike/go-dqdl/ast"
+)
+
+// Schema はテーブルのスキーマを表します。
+// Schema describes a table.
+type Schema struct {
+	Database string   `json:"database,omitempty"`
+	Table    string   `json:"table"`
+	Columns  []Column `json:"columns"`
+	Keys     []string `json:"keys,omitempty"` // columns with unique values
+}
+
+// Column はテーブルのカラムを表します。
+// Column describes a column of a table.
+type Column struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"` // Glue/Hive type name, e.g. "varchar(32)"
+	Nullable bool   `json:"nullable"`
+}
+
+// ReadSchema は JSON 形式のスキーマを読み込みます。
+// ReadSchema decodes a JSON encoded Schema from r.
+func ReadSchema(r io.Reader) (*Schema, error) {
+	var schema Schema
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&schema); err != nil {
+		return nil, fmt.Errorf("decode schema: %w", err)
+	}
+	return &schema, nil
+}
+
+// Profile は生成するルールの種類を選択します。
+// A Profile selects the kinds of rules Ruleset generates.
+type Profile struct {
+	NonEmpty bool // RowCount > 0 for the table
+	Exists   bool // ColumnExists for every column
+	Complete bool // IsComplete for every non-nullable column
+	Unique   bool // IsUnique for every key column
+	DataType bool // ColumnDataType for columns of a type Glue can check
+	Length   bool // ColumnLength for string columns with a declared length
+	Range    bool // ColumnValues for numeric columns of a bounded type
+}
+
+var (
+	// DefaultProfile は Ruleset がデフォルトで用いるプロファイルです。
+	// DefaultProfile is the profile Ruleset uses by default.
+	DefaultProfile = Profile{Complete: true, Unique: true, Length: true, Range: true}
+
+	// StrictProfile は全ての種類のルールを生成するプロファイルです。
+	// StrictProfile generates every kind of rule.
+	StrictProfile = Profile{NonEmpty: true, Exists: true, Complete: true, Unique: true, DataType: true, Length: true, Range: true}
+)
+
+// Option は Ruleset の設定を変更します。
+// An Option configures Ruleset.
+type Option func(*config)
+
+type config struct {
+	profile Profile
+}
+
+// WithProfile は生成するルールの種類を指定します。
+// WithProfile sets the profile selecting the rules to generate.
+// The default is DefaultProfile.
+func WithProfile(p Profile) Option {
+	return func(c *config) {
+		c.profile = p
+	}
+}
+
+// Ruleset はスキーマから雛形となるルールセットを生成します。
+// Ruleset builds a starter ruleset for schema. With the default profile it
+// generates:
+//
+//   - IsComplete for every non-nullable column
+//   - IsUnique for every key column
+//   - ColumnLength for string columns with a declared length
+//   - ColumnValues for tinyint, smallint, int and decimal(p,s) columns,
+//     checking that the values fit the type. Only the upper bound is
+//     checked, since DQDL numbers can not be negative.
+//
+// Use WithProfile to select other rules. Every rule carries a description
+// comment marking it as generated, so that it is reviewed by a human
+// before use.
+func Ruleset(schema *Schema, opts ...Option) *ast.Ruleset {
+	cfg := &config{profile: DefaultProfile}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	profile := cfg.profile
+	ruleset := &ast.Ruleset{
+		Description: comments(fmt.Sprintf("# generated from the schema of %s: review before use", schema.qualifiedName())),
+	}
+	if profile.NonEmpty {
+		ruleset.Rules = append(ruleset.Rules, &ast.Rule{
+			Description: comments("# generated: table must not be empty"),
+			Type:        &ast.Ident{Name: "RowCount"},
+			Expression: &ast.ComparisonExpression{
+				Operator: ">",
+				Right:    &ast.NumberParameter{Value: "0"},
+			},
+		})
+	}
+	keys := make(map[string]bool, len(schema.Keys))
+	for _, key := range schema.Keys {
+		keys[key] = true
+	}
+	for _, col := range schema.Columns {
+		if profile.Exists {
+			ruleset.Rules = append(ruleset.Rules, generatedRule(col, "ColumnExists", "column is in the schema"))
+		}
+		if profile.Complete && !col.Nullable {
+			ruleset.Rules = append(ruleset.Rules, generatedRule(col, "IsComplete", "column is not nullable"))
+		}
+		if profile.Unique && keys[col.Name] {
+			ruleset.Rules = append(ruleset.Rules, generatedRule(col, "IsUnique", "column is a key"))
+		}
+		if typ, ok := dataType(col.Type); profile.DataType && ok {
+			rule := generatedRule(col, "ColumnDataType", fmt.Sprintf("column type is %s", col.Type))
+			rule.Expression = &ast.ComparisonExpression{
+				Operator: "=",
+				Right:    &ast.StringParameter{Value: typ},
+			}
+			ruleset.Rules = append(ruleset.Rules, rule)
+		}
+		if n, ok := stringLength(col.Type); profile.Length && ok {
+			rule := generatedRule(col, "ColumnLength", fmt.Sprintf("column type is %s", col.Type))
+			rule.Expression = &ast.ComparisonExpression{
+				Operator: "<=",
+				Right:    &ast.NumberParameter{Value: strconv.Itoa(n)},
+			}
+			ruleset.Rules = append(ruleset.Rules, rule)
+		}
+		if expr, ok := upperBound(col.Type); profile.Range && ok {
+			rule := generatedRule(col, "ColumnValues", fmt.Sprintf("column type is %s", col.Type))
+			rule.Expression = expr
+			ruleset.Rules = append(ruleset.Rules, rule)
+		}
+	}
+	return ruleset
+}
+
+func (s *Schema) qualifiedName() string {
+	if s.Database == "" {
+		return s.Table
+	}
+	return s.Database + "." + s.Table
+}
+
+func generatedRule(col Column, ruleType, reason string) *ast.Rule {
+	return &ast.Rule{
+		Description: comments(fmt.Sprintf("# generated: %s", reason)),
+		Type:        &ast.Ident{Name: ruleType},
+		Parameters: []ast.Parameter{
+			&ast.StringParameter{Value: col.Name},
+		},
+	}
+}
+
+func comments(texts ...string) ast.CommentGroup {
+	group := make(ast.CommentGroup, 0, len(texts))
+	for _, text := range texts {
+		group = append(group, &ast.Comment{Text: text})
+	}
+	return group
+}
+
+var stringTypePattern = regexp.MustCompile(`^(?:var)?char\((\d+)\)$`)
+
+// stringLength returns the declared length of a char(n) or varchar(n) type.
+func stringLength(typ string) (int, bool) {
+	m := stringTypePattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(typ)))
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// integerMax maps the integer types of Glue/Hive to their largest value.
+// bigint is left out, since no value of a column can exceed it.
+var integerMax = map[string]string{
+	"tinyint":  "127",
+	"smallint": "32767",
+	"int":      "2147483647",
+	"integer":  "2147483647",
+}
+
+var decimalTypePattern = regexp.MustCompile(`^decimal\((\d+),\s*(\d+)\)$`)
+
+// upperBound returns the comparison the values of a column of type typ
+// satisfy: at most the largest value of an integer type, or less than
+// 10^(p-s) for decimal(p,s).
+func upperBound(typ string) (*ast.ComparisonExpression, bool) {
+	typ = strings.ToLower(strings.TrimSpace(typ))
+	if largest, ok := integerMax[typ]; ok {
+		return &ast.ComparisonExpression{Operator: "<=", Right: &ast.NumberParameter{Value: largest}}, true
+	}
+	m := decimalTypePattern.FindStringSubmatch(typ)
+	if m == nil {
+		return nil, false
+	}
+	precision, err1 := strconv.Atoi(m[1])
+	scale, err2 := strconv.Atoi(m[2])
+	if err1 != nil || err2 != nil || scale > precision {
+		return nil, false
+	}
+	bound := "1" + strings.Repeat("0", precision-scale)
+	return &ast.ComparisonExpression{Operator: "<", Right: &ast.NumberParameter{Value: bound}}, true
+}
+
+// dataTypes maps Glue/Hive type names to the types ColumnDataType accepts.
+var dataTypes = map[string]string{
+	"boolean":   "BOOLEAN",
+	"date":      "DATE",
+	"timestamp": "TIMESTAMP",
+	"tinyint":   "INTEGER",
+	"smallint":  "INTEGER",
+	"int":       "INTEGER",
+	"integer":   "INTEGER",
+	"bigint":    "LONG",
+	"float":     "FLOAT",
+	"double":    "DOUBLE",
+}
+
+// dataType returns the type ColumnDataType checks for a column of type typ.
+func dataType(typ string) (string, bool) {
+	t, ok := dataTypes[strings.ToLower(strings.TrimSpace(typ))]
+	return t, ok
+}
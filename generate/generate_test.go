@@ -0,0 +1,146 @@
+package generate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/ast"
+)
+
+func TestRuleset(t *testing.T) {
+	schema, err := ReadSchema(strings.NewReader(`{
+		"database": "sales",
+		"table": "orders",
+		"keys": ["order_id"],
+		"columns": [
+			{"name": "order_id", "type": "bigint", "nullable": false},
+			{"name": "status", "type": "VARCHAR(16)", "nullable": false},
+			{"name": "note", "type": "string", "nullable": true},
+			{"name": "quantity", "type": "smallint", "nullable": true},
+			{"name": "price", "type": "decimal(10, 2)", "nullable": true}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ruleset := Ruleset(schema)
+	if got, want := ruleset.Description[0].Text, "# generated from the schema of sales.orders: review before use"; got != want {
+		t.Errorf("description = %q, want %q", got, want)
+	}
+	var got []string
+	for _, decl := range ruleset.Rules {
+		rule := decl.(*ast.Rule)
+		if len(rule.Description) == 0 || !strings.HasPrefix(rule.Description[0].Text, "# generated: ") {
+			t.Errorf("rule %s has no generated description", rule.Type.Name)
+		}
+		s := rule.Type.Name + " " + rule.Parameters[0].(*ast.StringParameter).Value
+		if expr, ok := rule.Expression.(*ast.ComparisonExpression); ok {
+			s += " " + expr.Operator + " " + expr.Right.(*ast.NumberParameter).Value
+		}
+		got = append(got, s)
+	}
+	want := []string{
+		"IsComplete order_id",
+		"IsUnique order_id",
+		"IsComplete status",
+		"ColumnLength status <= 16",
+		"ColumnValues quantity <= 32767",
+		"ColumnValues price < 100000000",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected rules (-want +got):\n%s", diff)
+	}
+}
+
+func TestRuleset__StrictProfile(t *testing.T) {
+	schema := &Schema{
+		Table: "orders",
+		Keys:  []string{"order_id"},
+		Columns: []Column{
+			{Name: "order_id", Type: "bigint"},
+			{Name: "status", Type: "varchar(16)", Nullable: true},
+			{Name: "rank", Type: "TINYINT", Nullable: true},
+		},
+	}
+	ruleset := Ruleset(schema, WithProfile(StrictProfile))
+	var got []string
+	for _, decl := range ruleset.Rules {
+		var buf strings.Builder
+		rule := decl.(*ast.Rule)
+		buf.WriteString(rule.Type.Name)
+		for _, param := range rule.Parameters {
+			buf.WriteString(" " + param.(*ast.StringParameter).Value)
+		}
+		if expr, ok := rule.Expression.(*ast.ComparisonExpression); ok {
+			buf.WriteString(" " + expr.Operator + " ")
+			switch v := expr.Right.(type) {
+			case *ast.NumberParameter:
+				buf.WriteString(v.Value)
+			case *ast.StringParameter:
+				buf.WriteString(v.Value)
+			}
+		}
+		got = append(got, buf.String())
+	}
+	want := []string{
+		"RowCount > 0",
+		"ColumnExists order_id",
+		"IsComplete order_id",
+		"IsUnique order_id",
+		"ColumnDataType order_id = LONG",
+		"ColumnExists status",
+		"ColumnLength status <= 16",
+		"ColumnExists rank",
+		"ColumnDataType rank = INTEGER",
+		"ColumnValues rank <= 127",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected rules (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadSchema__UnknownField(t *testing.T) {
+	_, err := ReadSchema(strings.NewReader(`{"table": "orders", "colums": []}`))
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestReadGlueTable(t *testing.T) {
+	input := `{
+		"Table": {
+			"Name": "orders",
+			"DatabaseName": "sales",
+			"StorageDescriptor": {
+				"Columns": [
+					{"Name": "order_id", "Type": "bigint"},
+					{"Name": "status", "Type": "varchar(16)"}
+				]
+			},
+			"PartitionKeys": [
+				{"Name": "dt", "Type": "string"}
+			]
+		}
+	}`
+	schema, err := ReadGlueTable(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Schema{
+		Database: "sales",
+		Table:    "orders",
+		Columns: []Column{
+			{Name: "order_id", Type: "bigint", Nullable: true},
+			{Name: "status", Type: "varchar(16)", Nullable: true},
+			{Name: "dt", Type: "string", Nullable: false},
+		},
+	}
+	if diff := cmp.Diff(want, schema); diff != "" {
+		t.Errorf("unexpected schema (-want +got):\n%s", diff)
+	}
+
+	if _, err := ReadGlueTable(strings.NewReader(`{"Table": {}}`)); err == nil {
+		t.Error("expected error for a table without name, got nil")
+	}
+}
@@ -0,0 +1,59 @@
+package generate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// glueTable is the subset of the Glue Data Catalog Table structure used to build a Schema.
+type glueTable struct {
+	Name              string `json:"Name"`
+	DatabaseName      string `json:"DatabaseName"`
+	StorageDescriptor struct {
+		Columns []glueColumn `json:"Columns"`
+	} `json:"StorageDescriptor"`
+	PartitionKeys []glueColumn `json:"PartitionKeys"`
+}
+
+type glueColumn struct {
+	Name string `json:"Name"`
+	Type string `json:"Type"`
+}
+
+// ReadGlueTable は Glue Data Catalog の GetTable API のレスポンスからスキーマを読み込みます。
+// ReadGlueTable decodes a Schema from the JSON response of the Glue Data
+// Catalog GetTable API, e.g. the output of `aws glue get-table`.
+// Both the whole response ({"Table": {...}}) and the bare Table object are accepted.
+//
+// The Data Catalog records neither nullability nor keys, so regular columns
+// are treated as nullable and partition keys, which always have a value,
+// as non-nullable.
+func ReadGlueTable(r io.Reader) (*Schema, error) {
+	var resp struct {
+		Table *glueTable `json:"Table"`
+		glueTable
+	}
+	if err := json.NewDecoder(r).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("decode glue table: %w", err)
+	}
+	table := resp.Table
+	if table == nil {
+		table = &resp.glueTable
+	}
+	if table.Name == "" {
+		return nil, errors.New("decode glue table: table name is missing")
+	}
+	schema := &Schema{
+		Database: table.DatabaseName,
+		Table:    table.Name,
+	}
+	for _, col := range table.StorageDescriptor.Columns {
+		schema.Columns = append(schema.Columns, Column{Name: col.Name, Type: col.Type, Nullable: true})
+	}
+	for _, col := range table.PartitionKeys {
+		schema.Columns = append(schema.Columns, Column{Name: col.Name, Type: col.Type, Nullable: false})
+	}
+	return schema, nil
+}
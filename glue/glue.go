@@ -0,0 +1,93 @@
+// Package glue は AWS Glue Data Quality の API が受け付けるルールセットの文字列との変換を行います。
+// Package glue converts rulesets to and from the strings used by the AWS
+// Glue Data Quality API. It does not depend on the AWS SDK: Format returns
+// the value of the Ruleset parameter of CreateDataQualityRuleset and
+// UpdateDataQualityRuleset, and Parse reads the Ruleset field of the
+// GetDataQualityRuleset response.
+package glue
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/diag"
+	"github.com/mashiike/go-dqdl/parser"
+	"github.com/mashiike/go-dqdl/printer"
+	"github.com/mashiike/go-dqdl/validate"
+)
+
+// MaxRulesetLength は API が受け付けるルールセットの最大の文字数です。
+// MaxRulesetLength is the maximum length in characters of a ruleset
+// accepted by the API.
+const MaxRulesetLength = 65536
+
+// ValidationError は Glue が受け付けないルールセットを表すエラーです。
+// A ValidationError reports a ruleset that Glue would reject.
+type ValidationError struct {
+	Diagnostics []diag.Diagnostic // the errors found, sorted by position
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Diagnostics))
+	for _, d := range e.Diagnostics {
+		msgs = append(msgs, d.String())
+	}
+	return "glue: invalid ruleset: " + strings.Join(msgs, "\n")
+}
+
+// Validate はルールセットが Glue の受け付ける文法に従っているかを検査します。
+// Validate reports whether Glue accepts ruleset. The rule types must be
+// the built-in ones, the parameters and expressions must match their
+// specs, and patterns of matches expressions must be valid Java regular
+// expressions. It returns a *ValidationError listing the errors found, or
+// nil. Warnings are not reported.
+func Validate(ruleset *ast.Ruleset) error {
+	var errs []diag.Diagnostic
+	if len(ruleset.Rules) == 0 {
+		errs = append(errs, diag.Diagnostic{
+			Code:     "empty-ruleset",
+			Severity: diag.SeverityError,
+			Message:  "ruleset has no rules",
+			Source:   "glue",
+			Pos:      ruleset.Pos(),
+			End:      ruleset.End(),
+		})
+	}
+	for _, d := range validate.Ruleset(ruleset, validate.WithRegexpDialect(validate.RegexpJava)) {
+		if d.Severity == diag.SeverityError {
+			errs = append(errs, d)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	diag.Sort(errs)
+	return &ValidationError{Diagnostics: errs}
+}
+
+// Format はルールセットを検査し、API に渡す文字列に変換します。
+// Format validates ruleset with Validate and returns it in the form
+// passed to CreateDataQualityRuleset and UpdateDataQualityRuleset.
+func Format(ruleset *ast.Ruleset) (string, error) {
+	if err := Validate(ruleset); err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, ruleset); err != nil {
+		return "", err
+	}
+	s := buf.String()
+	if n := utf8.RuneCountInString(s); n > MaxRulesetLength {
+		return "", fmt.Errorf("glue: ruleset is %d characters long, exceeding the limit of %d", n, MaxRulesetLength)
+	}
+	return s, nil
+}
+
+// Parse は GetDataQualityRuleset が返したルールセットの文字列を構文解析します。
+// Parse parses the Ruleset field of a GetDataQualityRuleset response.
+func Parse(ruleset string) (*ast.Ruleset, error) {
+	return parser.ParseRuleset(ruleset)
+}
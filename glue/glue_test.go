@@ -0,0 +1,91 @@
+package glue
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFormat(t *testing.T) {
+	const input = "Rules = [\n# the key\nIsComplete \"id\", ColumnValues \"price\" > 0 ]"
+	ruleset, err := Parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Format(ruleset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Rules = [\n\t# the key\n\tIsComplete \"id\",\n\tColumnValues \"price\" > 0\n]\n"
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+	back, err := Parse(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(back.Rules) != 2 {
+		t.Errorf("got %d rules, want 2", len(back.Rules))
+	}
+}
+
+func TestFormat__Invalid(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "custom rule type",
+			input: `Rules = [ IsComplete "id", MyCheck "id" ]`,
+			want:  []string{"unknown-rule-type"},
+		},
+		{
+			name:  "java regexp",
+			input: `Rules = [ ColumnValues "name" matches "\\pN+" ]`,
+			want:  []string{},
+		},
+		{
+			name:  "re2 only regexp",
+			input: `Rules = [ ColumnValues "name" matches "(?P<x>a)" ]`,
+			want:  []string{"regexp-compat"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ruleset, err := Parse(c.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = Format(ruleset)
+			got := []string{}
+			var verr *ValidationError
+			if errors.As(err, &verr) {
+				for _, d := range verr.Diagnostics {
+					got = append(got, d.Code)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFormat__TooLong(t *testing.T) {
+	rules := make([]string, 0, 3000)
+	for i := 0; i < cap(rules); i++ {
+		rules = append(rules, `IsComplete "a_rather_long_column_name"`)
+	}
+	ruleset, err := Parse("Rules = [" + strings.Join(rules, ",") + "]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Format(ruleset); err == nil || !strings.Contains(err.Error(), "exceeding the limit") {
+		t.Errorf("got %v, want a length error", err)
+	}
+}
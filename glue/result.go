@@ -0,0 +1,83 @@
+package glue
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/parser"
+	"github.com/mashiike/go-dqdl/printer"
+)
+
+// ResultRule は品質チェックの実行結果に含まれるルールの文字列を構文解析したものです。
+// A ResultRule is a parsed rule string of the results of a data quality
+// run, e.g. `Rule_1 ColumnValues "x" > 5`.
+type ResultRule struct {
+	Name string       // name Glue gave the rule, e.g. "Rule_1"; empty if none
+	Rule ast.RuleDecl // the rule without the name
+}
+
+// resultRuleName matches a rule name followed by a rule type or the "("
+// of a combined rule. A rule type is followed by a parameter or an
+// expression instead, so it is never taken for a name.
+var resultRuleName = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_]*)\s+[A-Za-z(]`)
+
+// ParseResultRule は名前の付いたルールの文字列を構文解析します。
+// ParseResultRule parses a rule string of the results of a data quality
+// run, capturing the rule name in front of it if any.
+func ParseResultRule(s string) (*ResultRule, error) {
+	result := &ResultRule{}
+	if m := resultRuleName.FindStringSubmatchIndex(s); m != nil {
+		result.Name = s[m[2]:m[3]]
+		s = s[m[3]:]
+	}
+	rule, err := parser.ParseRule(s)
+	if err != nil {
+		return nil, err
+	}
+	result.Rule = rule
+	return result, nil
+}
+
+// Correlate は実行結果のルールの文字列と、それが評価されたルールセット内のルールを対応付けます。
+// Correlate maps each rule string of the results of a data quality run to
+// the rule of ruleset it was evaluated from. Rules are compared ignoring
+// comments, layout and the formatting of numbers. Rule strings that match
+// no rule of ruleset are not in the map, and an error is returned if one
+// can not be parsed.
+func Correlate(ruleset *ast.Ruleset, results []string) (map[string]ast.RuleDecl, error) {
+	sources := make(map[string]ast.RuleDecl, len(ruleset.Rules))
+	for _, rule := range ruleset.Rules {
+		key, err := canonical(rule)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := sources[key]; !ok {
+			sources[key] = rule
+		}
+	}
+	m := make(map[string]ast.RuleDecl, len(results))
+	for _, s := range results {
+		result, err := ParseResultRule(s)
+		if err != nil {
+			return nil, err
+		}
+		key, err := canonical(result.Rule)
+		if err != nil {
+			return nil, err
+		}
+		if rule, ok := sources[key]; ok {
+			m[s] = rule
+		}
+	}
+	return m, nil
+}
+
+func canonical(rule ast.RuleDecl) (string, error) {
+	cfg := &printer.Config{Mode: printer.NormalizeNumbers | printer.OmitComments}
+	var buf bytes.Buffer
+	if err := cfg.Fprint(&buf, rule); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,59 @@
+package glue
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/printer"
+)
+
+func TestParseResultRule(t *testing.T) {
+	cases := []struct {
+		input    string
+		wantName string
+		wantRule string
+	}{
+		{input: `Rule_1 ColumnValues "x" > 5`, wantName: "Rule_1", wantRule: `ColumnValues "x" > 5`},
+		{input: `Rule_12 (IsComplete "a") and (IsUnique "a")`, wantName: "Rule_12", wantRule: `(IsComplete "a") and (IsUnique "a")`},
+		{input: `IsComplete "x"`, wantRule: `IsComplete "x"`},
+		{input: `RowCount > 5`, wantRule: `RowCount > 5`},
+	}
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			got, err := ParseResultRule(c.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := printer.Fprint(&buf, got.Rule); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff([]string{c.wantName, c.wantRule}, []string{got.Name, buf.String()}); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCorrelate(t *testing.T) {
+	ruleset, err := Parse("Rules = [\n  IsComplete \"id\",\n  # price\n  ColumnValues \"price\" > 5.0\n]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := []string{
+		`Rule_1 IsComplete "id"`,
+		`Rule_2 ColumnValues "price" > 5`,
+		`Rule_3 RowCount > 0`,
+	}
+	got, err := Correlate(ruleset, results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[results[0]] != ruleset.Rules[0] || got[results[1]] != ruleset.Rules[1] {
+		t.Errorf("got %v", got)
+	}
+	if _, err := Correlate(ruleset, []string{"Rule_1 ["}); err == nil {
+		t.Error("expected an error for an unparsable rule string")
+	}
+}
@@ -2,4 +2,9 @@ module github.com/mashiike/go-dqdl
 
 go 1.18
 
-require github.com/google/go-cmp v0.5.9
+require (
+	github.com/google/go-cmp v0.5.9
+	github.com/marcboeker/go-duckdb v1.5.6
+)
+
+require github.com/mitchellh/mapstructure v1.5.0 // indirect
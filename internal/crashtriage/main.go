@@ -0,0 +1,65 @@
+// Command crashtriage は構文解析器をクラッシュさせる入力を最小化し、分類します。
+// Command crashtriage minimizes the inputs that crash the parser,
+// classifies them by the site of the panic and writes one regression input
+// per site to parser/testdata/crashers, where TestCrashers parses them.
+//
+// Usage, from the root of the module:
+//
+//	go run ./internal/crashtriage [-out dir] [-n] [input...]
+//
+// The inputs are files, raw or in the corpus format of go test, or
+// directories of them. The default is the corpus of FuzzParseFile, where
+// go test -fuzz writes the failing inputs. An input is checked with the
+// calls of FuzzParseFile, and minimized by removing bytes as long as it
+// panics at the same site; the result does not depend on the order of the
+// inputs. Inputs that do not crash are ignored.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("crashtriage", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	out := fs.String("out", "parser/testdata/crashers", "`directory` to write the regression inputs to")
+	dryRun := fs.Bool("n", false, "report the crashes without writing")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: go run ./internal/crashtriage [-out dir] [-n] [input...]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"parser/testdata/fuzz/FuzzParseFile"}
+	}
+	inputs, err := readInputs(paths)
+	if err != nil {
+		fmt.Fprintf(stderr, "crashtriage: %v\n", err)
+		return 2
+	}
+	crashes := triage(inputs, check)
+	for _, c := range crashes {
+		line := fmt.Sprintf("%s: %d inputs, minimized to %d bytes", c.Site, c.Inputs, len(c.Input))
+		if !*dryRun {
+			path, err := c.write(*out)
+			if err != nil {
+				fmt.Fprintf(stderr, "crashtriage: %v\n", err)
+				return 2
+			}
+			line += ", " + path
+		}
+		fmt.Fprintln(stdout, line)
+	}
+	fmt.Fprintf(stdout, "%d inputs, %d crash sites\n", len(inputs), len(crashes))
+	return 0
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/parser"
+)
+
+// check returns the site of the panic of src, or "" if it does not crash
+// the parser. It makes the calls of checkInternal of the parser tests.
+func check(src string) string {
+	var file *ast.File
+	parses := []func() error{
+		func() (err error) {
+			file, err = parser.ParseFile("fuzz.dqdl", strings.NewReader(src))
+			return err
+		},
+		func() error {
+			_, err := parser.ParseRule(src)
+			return err
+		},
+		func() error {
+			_, err := parser.ParseBareRules(src)
+			return err
+		},
+		func() error {
+			parser.Complete(src, len(src)/2)
+			return nil
+		},
+		func() error {
+			if file == nil || len(src) == 0 {
+				return nil
+			}
+			_, err := parser.Reparse(file, parser.Range{}, src[:1])
+			return err
+		},
+	}
+	for _, parse := range parses {
+		var ie *parser.InternalError
+		if errors.As(parse(), &ie) {
+			return ie.Site
+		}
+	}
+	return ""
+}
+
+// crash is a minimized input crashing at a site.
+type crash struct {
+	Site   string
+	Input  string // the minimized input
+	Inputs int    // the number of inputs crashing at Site
+}
+
+// triage returns the crashes of inputs by site, sorted by site. check
+// returns the site of the panic of an input, or "" if it does not crash.
+// Each site has the shortest of its minimized inputs, the least if there
+// are several, so that the result does not depend on the order of inputs.
+func triage(inputs []string, check func(string) string) []crash {
+	bySite := make(map[string]*crash)
+	for _, input := range inputs {
+		site := check(input)
+		if site == "" {
+			continue
+		}
+		min := minimize(input, func(s string) bool { return check(s) == site })
+		c, ok := bySite[site]
+		if !ok {
+			bySite[site] = &crash{Site: site, Input: min, Inputs: 1}
+			continue
+		}
+		c.Inputs++
+		if len(min) < len(c.Input) || (len(min) == len(c.Input) && min < c.Input) {
+			c.Input = min
+		}
+	}
+	crashes := make([]crash, 0, len(bySite))
+	for _, c := range bySite {
+		crashes = append(crashes, *c)
+	}
+	sort.Slice(crashes, func(i, j int) bool { return crashes[i].Site < crashes[j].Site })
+	return crashes
+}
+
+// minimize returns a shortest input it finds by removing bytes of s while
+// keep holds, with the complements of delta debugging: s is split into n
+// chunks, a chunk is removed if keep holds without it, and n is doubled
+// when no chunk can be removed, until the chunks are single bytes.
+func minimize(s string, keep func(string) bool) string {
+	n := 2
+	for len(s) > 0 {
+		if n > len(s) {
+			n = len(s)
+		}
+		size := (len(s) + n - 1) / n
+		removed := false
+		for start := 0; start < len(s); start += size {
+			end := start + size
+			if end > len(s) {
+				end = len(s)
+			}
+			if t := s[:start] + s[end:]; keep(t) {
+				s, removed = t, true
+				break
+			}
+		}
+		switch {
+		case removed:
+			if n > 2 {
+				n--
+			}
+		case n == len(s):
+			return s
+		default:
+			n *= 2
+		}
+	}
+	return s
+}
+
+// nonAlnum matches the characters replaced in the name of the file of a
+// site.
+var nonAlnum = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// write writes the input of c to a file in dir named after its site, e.g.
+// parser-parser-parseRule-601.dqdl, and returns its path. An existing file
+// with another input is not overwritten; a number is added to the name.
+func (c crash) write(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	name := strings.Trim(nonAlnum.ReplaceAllString(c.Site, "-"), "-")
+	for i := 1; ; i++ {
+		path := filepath.Join(dir, name+".dqdl")
+		if i > 1 {
+			path = filepath.Join(dir, fmt.Sprintf("%s-%d.dqdl", name, i))
+		}
+		bs, err := os.ReadFile(path)
+		switch {
+		case err == nil && string(bs) == c.Input:
+			return path, nil
+		case err == nil:
+			continue
+		case !errors.Is(err, os.ErrNotExist):
+			return "", err
+		}
+		return path, os.WriteFile(path, []byte(c.Input), 0o644)
+	}
+}
+
+// readInputs reads the files of paths, and the files in the directories of
+// paths, sorted by name.
+func readInputs(paths []string) ([]string, error) {
+	var inputs []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		files := []string{path}
+		if info.IsDir() {
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return nil, err
+			}
+			files = files[:0]
+			for _, e := range entries {
+				if !e.IsDir() {
+					files = append(files, filepath.Join(path, e.Name()))
+				}
+			}
+		}
+		for _, file := range files {
+			bs, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			input, err := parseInput(bs)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			inputs = append(inputs, input)
+		}
+	}
+	return inputs, nil
+}
+
+// corpusHeader is the first line of a file of the corpus of go test.
+const corpusHeader = "go test fuzz v1\n"
+
+// parseInput returns the input of a file of the corpus of go test with a
+// single string or []byte value, or bs as it is for another file.
+func parseInput(bs []byte) (string, error) {
+	if !bytes.HasPrefix(bs, []byte(corpusHeader)) {
+		return string(bs), nil
+	}
+	value := strings.TrimSpace(string(bs[len(corpusHeader):]))
+	for _, prefix := range []string{"string(", "[]byte("} {
+		if strings.HasPrefix(value, prefix) && strings.HasSuffix(value, ")") {
+			s, err := strconv.Unquote(value[len(prefix) : len(value)-1])
+			if err != nil {
+				return "", fmt.Errorf("invalid corpus value %s: %w", value, err)
+			}
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported corpus value %s, want a string", value)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeCheck crashes at "parser.f:1" on inputs with an X followed by a Y,
+// and at "parser.(*parser).g:2" on inputs with a Z.
+func fakeCheck(s string) string {
+	if i := strings.Index(s, "X"); i >= 0 && strings.Contains(s[i:], "Y") {
+		return "parser.f:1"
+	}
+	if strings.Contains(s, "Z") {
+		return "parser.(*parser).g:2"
+	}
+	return ""
+}
+
+func TestTriage(t *testing.T) {
+	inputs := []string{
+		"Rules = [ IsComplete \"X\", IsUnique \"Y\" ]",
+		"Rules = [ RowCount > 0 ]",
+		"Rules = [ ColumnValues \"Z\" in [1] ]",
+		"XYXY",
+		"a Z b",
+	}
+	want := []crash{
+		{Site: "parser.(*parser).g:2", Input: "Z", Inputs: 2},
+		{Site: "parser.f:1", Input: "XY", Inputs: 2},
+	}
+	if diff := cmp.Diff(want, triage(inputs, fakeCheck)); diff != "" {
+		t.Errorf("unexpected crashes (-want +got):\n%s", diff)
+	}
+	reversed := make([]string, len(inputs))
+	for i, input := range inputs {
+		reversed[len(inputs)-1-i] = input
+	}
+	if diff := cmp.Diff(want, triage(reversed, fakeCheck)); diff != "" {
+		t.Errorf("unexpected crashes of the reversed inputs (-want +got):\n%s", diff)
+	}
+}
+
+func TestMinimize(t *testing.T) {
+	cases := []struct {
+		input string
+		keep  func(string) bool
+		want  string
+	}{
+		{
+			input: "Rules = [ IsComplete \"a\" ]",
+			keep:  func(s string) bool { return strings.Contains(s, "[") && strings.Contains(s, "]") },
+			want:  "[]",
+		},
+		{
+			input: "abc",
+			keep:  func(s string) bool { return true },
+			want:  "",
+		},
+		{
+			input: "abc",
+			keep:  func(s string) bool { return s == "abc" },
+			want:  "abc",
+		},
+	}
+	for _, c := range cases {
+		if diff := cmp.Diff(c.want, minimize(c.input, c.keep)); diff != "" {
+			t.Errorf("unexpected minimized input of %q (-want +got):\n%s", c.input, diff)
+		}
+	}
+}
+
+func TestParseInput(t *testing.T) {
+	cases := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "Rules = [ RowCount > 0 ]", want: "Rules = [ RowCount > 0 ]"},
+		{input: "go test fuzz v1\nstring(\"Rules = [ IsComplete \\\"col\")\n", want: "Rules = [ IsComplete \"col"},
+		{input: "go test fuzz v1\n[]byte(\"\\xff\")\n", want: "\xff"},
+		{input: "go test fuzz v1\nint(1)\n", wantErr: true},
+	}
+	for _, c := range cases {
+		got, err := parseInput([]byte(c.input))
+		if (err != nil) != c.wantErr {
+			t.Fatalf("unexpected error of %q: %v", c.input, err)
+		}
+		if diff := cmp.Diff(c.want, got); diff != "" {
+			t.Errorf("unexpected input of %q (-want +got):\n%s", c.input, diff)
+		}
+	}
+}
+
+func TestCrashWrite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "crashers")
+	var got []string
+	for _, c := range []crash{
+		{Site: "parser.(*parser).parseRule:601", Input: "a"},
+		{Site: "parser.(*parser).parseRule:601", Input: "a"},
+		{Site: "parser.(*parser).parseRule:601", Input: "b"},
+	} {
+		path, err := c.write(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, filepath.Base(path)+": "+string(bs))
+	}
+	want := []string{
+		"parser-parser-parseRule-601.dqdl: a",
+		"parser-parser-parseRule-601.dqdl: a",
+		"parser-parser-parseRule-601-2.dqdl: b",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected files (-want +got):\n%s", diff)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a"), []byte("go test fuzz v1\nstring(\"Rules = [\")\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.dqdl"), []byte("Rules = [ RowCount > 0 ]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-out", filepath.Join(dir, "crashers"), dir}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if diff := cmp.Diff("2 inputs, 0 crash sites\n", stdout.String()); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+}
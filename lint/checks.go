@@ -0,0 +1,246 @@
+package lint
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/diag"
+	"github.com/mashiike/go-dqdl/printer"
+)
+
+// ratioRuleTypes are the rule types whose value is a ratio between 0 and 1.
+var ratioRuleTypes = map[string]bool{
+	"Completeness":     true,
+	"Uniqueness":       true,
+	"UniqueValueRatio": true,
+}
+
+// ThresholdRange は0から1の範囲外の閾値を報告します。
+// ThresholdRange reports thresholds outside of [0, 1], both in
+// `with threshold` clauses and in expressions of rule types whose value
+// is a ratio, such as Completeness.
+type ThresholdRange struct{}
+
+// ID implements Check.
+func (c *ThresholdRange) ID() string { return "threshold-out-of-range" }
+
+// Check implements Check.
+func (c *ThresholdRange) Check(file *ast.File) []diag.Diagnostic {
+	var diags []diag.Diagnostic
+	report := func(expr ast.Expression) {
+		for _, param := range expressionParameters(expr) {
+			n, ok := param.(*ast.NumberParameter)
+			if !ok {
+				continue
+			}
+			v, err := n.Float64()
+			if err != nil || (0 <= v && v <= 1) {
+				continue
+			}
+			diags = append(diags, diag.Diagnostic{
+				Code:     c.ID(),
+				Severity: diag.SeverityError,
+				Message:  fmt.Sprintf("threshold %s is out of range [0, 1]", n.Value),
+				Pos:      n.Pos(),
+				End:      n.End(),
+			})
+		}
+	}
+	rules(file, func(_ *ast.Ruleset, rule *ast.Rule) {
+		switch expr := rule.Expression.(type) {
+		case nil:
+		case *ast.WithThresholdExpression:
+			report(expr.Threshold)
+		default:
+			if ratioRuleTypes[rule.Type.Name] {
+				report(expr)
+			}
+		}
+	})
+	return diags
+}
+
+// expressionParameters returns the parameters of a comparison or between
+// expression.
+func expressionParameters(expr ast.Expression) []ast.Parameter {
+	switch x := expr.(type) {
+	case *ast.ComparisonExpression:
+		return []ast.Parameter{x.Right}
+	case *ast.BetweenExpression:
+		return []ast.Parameter{x.Left, x.Right}
+	}
+	return nil
+}
+
+// DuplicateRules は同じルールセット内で重複したルールを報告します。
+// DuplicateRules reports rules that appear more than once in a ruleset.
+// Rules are compared in their printed form, ignoring comments and the
+// formatting of numbers.
+type DuplicateRules struct{}
+
+// ID implements Check.
+func (c *DuplicateRules) ID() string { return "duplicate-rule" }
+
+// Check implements Check.
+func (c *DuplicateRules) Check(file *ast.File) []diag.Diagnostic {
+	cfg := &printer.Config{Mode: printer.NormalizeNumbers | printer.OmitComments}
+	var diags []diag.Diagnostic
+	for _, ruleset := range file.Rulesets {
+		seen := make(map[string]ast.RuleDecl, len(ruleset.Rules))
+		for _, rule := range ruleset.Rules {
+			var buf bytes.Buffer
+			if err := cfg.Fprint(&buf, rule); err != nil {
+				continue
+			}
+			key := buf.String()
+			first, ok := seen[key]
+			if !ok {
+				seen[key] = rule
+				continue
+			}
+			diags = append(diags, diag.Diagnostic{
+				Code:     c.ID(),
+				Severity: diag.SeverityWarning,
+				Message:  fmt.Sprintf("duplicate rule `%s`", key),
+				Pos:      rule.Pos(),
+				End:      rule.End(),
+				Related: []diag.Related{{
+					Filename: file.Filename,
+					Pos:      first.Pos(),
+					End:      first.End(),
+					Message:  "first defined here",
+				}},
+			})
+		}
+	}
+	return diags
+}
+
+// MissingDescription は説明のコメントがないルールを報告します。
+// MissingDescription reports rules without a description comment.
+// Comments that are only directives, such as `#dqdl:disable`, do not
+// count as a description.
+type MissingDescription struct{}
+
+// ID implements Check.
+func (c *MissingDescription) ID() string { return "missing-description" }
+
+// Check implements Check.
+func (c *MissingDescription) Check(file *ast.File) []diag.Diagnostic {
+	var diags []diag.Diagnostic
+	for _, ruleset := range file.Rulesets {
+		for _, rule := range ruleset.Rules {
+			var description ast.CommentGroup
+			switch r := rule.(type) {
+			case *ast.Rule:
+				description = r.Description
+			case *ast.CombinedRule:
+				description = r.Description
+			}
+			if hasText(description) {
+				continue
+			}
+			diags = append(diags, diag.Diagnostic{
+				Code:     c.ID(),
+				Severity: diag.SeverityInfo,
+				Message:  "rule has no description comment",
+				Pos:      rule.Pos(),
+				End:      rule.End(),
+			})
+		}
+	}
+	return diags
+}
+
+// hasText reports whether g has a comment that is not a directive.
+func hasText(g ast.CommentGroup) bool {
+	for _, c := range g {
+		if _, _, ok := c.Directive(); !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultMaxInListValues は LongInList.Max のデフォルト値です。
+// DefaultMaxInListValues is the default of LongInList.Max.
+const DefaultMaxInListValues = 20
+
+// LongInList は値が多すぎる in 式を報告します。
+// LongInList reports in expressions with more values than Max.
+type LongInList struct {
+	Max int // maximum number of values; DefaultMaxInListValues if 0
+}
+
+// ID implements Check.
+func (c *LongInList) ID() string { return "long-in-list" }
+
+// Check implements Check.
+func (c *LongInList) Check(file *ast.File) []diag.Diagnostic {
+	max := c.Max
+	if max <= 0 {
+		max = DefaultMaxInListValues
+	}
+	var diags []diag.Diagnostic
+	rules(file, func(_ *ast.Ruleset, rule *ast.Rule) {
+		expr := rule.Expression
+		if x, ok := expr.(*ast.WithThresholdExpression); ok {
+			expr = x.Target
+		}
+		in, ok := expr.(*ast.InExpression)
+		if !ok || len(in.Values) <= max {
+			return
+		}
+		diags = append(diags, diag.Diagnostic{
+			Code:     c.ID(),
+			Severity: diag.SeverityWarning,
+			Message:  fmt.Sprintf("in-list has %d values (max %d)", len(in.Values), max),
+			Pos:      in.Pos(),
+			End:      in.End(),
+		})
+	})
+	return diags
+}
+
+// Snooze はスヌーズされたルールと期限切れのスヌーズを報告します。
+// Snooze reports the rules disabled by a snooze-until directive, see
+// ast.SnoozeUntil: an info while the rule is snoozed, and an error once
+// the date has passed or if it is invalid, so that a snooze is not
+// forgotten.
+type Snooze struct {
+	Now func() time.Time // current time; time.Now if nil
+}
+
+// ID implements Check.
+func (c *Snooze) ID() string { return "snooze" }
+
+// Check implements Check.
+func (c *Snooze) Check(file *ast.File) []diag.Diagnostic {
+	now := time.Now
+	if c.Now != nil {
+		now = c.Now
+	}
+	var diags []diag.Diagnostic
+	for _, ruleset := range file.Rulesets {
+		for _, rule := range ruleset.Rules {
+			until, ok, err := ast.SnoozeUntil(rule)
+			if !ok {
+				continue
+			}
+			d := diag.Diagnostic{Code: c.ID(), Pos: rule.Pos(), End: rule.End()}
+			switch snoozed, _ := ast.Snoozed(rule, now()); {
+			case err != nil:
+				d.Severity, d.Message = diag.SeverityError, strings.TrimPrefix(err.Error(), "ast: ")
+			case snoozed:
+				d.Severity, d.Message = diag.SeverityInfo, fmt.Sprintf("rule is snoozed until %s", until.Format("2006-01-02"))
+			default:
+				d.Severity, d.Message = diag.SeverityError, fmt.Sprintf("snooze expired on %s", until.Format("2006-01-02"))
+			}
+			diags = append(diags, d)
+		}
+	}
+	return diags
+}
@@ -0,0 +1,147 @@
+// Package lint はDQDLファイルの品質上の問題を検出します。
+// Package lint finds questionable but valid constructs in DQDL files.
+//
+// Each check implements the Check interface and reports diagnostics whose
+// Code is the stable ID of the check. A Linter runs a set of checks and
+// lets callers override the severity of a check or disable it.
+//
+// A check can also be disabled in the source with a `#dqdl:disable`
+// comment followed by the IDs of the checks, or by nothing to disable all
+// of them. The comment applies to the whole file if it is detached from
+// the rulesets, to a ruleset if it is in its description, and to a rule
+// if it is in its description or on its line:
+//
+//	Rules = [
+//		#dqdl:disable duplicate-rule
+//		IsUnique "id",
+//		IsUnique "id" #dqdl:disable missing-description
+//	]
+//
+// Suppressions that suppress nothing are reported as unused-suppression.
+package lint
+
+import (
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/diag"
+)
+
+const source = "lint"
+
+// Check はリンターのチェックが実装するインタフェースです。
+// Check is the interface implemented by lint checks.
+type Check interface {
+	// ID returns the stable identifier of the check, e.g. "duplicate-rule".
+	// It is used as the Code of the diagnostics the check reports.
+	ID() string
+	// Check returns the findings of the check in file.
+	Check(file *ast.File) []diag.Diagnostic
+}
+
+// Linter は複数のチェックを実行します。
+// A Linter runs a set of checks.
+type Linter struct {
+	checks     []Check
+	severities map[string]diag.Severity
+	disabled   map[string]bool
+}
+
+// New は指定したチェックを実行するLinterを作成します。チェックを指定しない場合は組み込みのチェックを実行します。
+// New returns a linter running the given checks, or the built-in checks
+// returned by Defaults if none are given.
+func New(checks ...Check) *Linter {
+	if len(checks) == 0 {
+		checks = Defaults()
+	}
+	return &Linter{
+		checks:     checks,
+		severities: make(map[string]diag.Severity),
+		disabled:   make(map[string]bool),
+	}
+}
+
+// Defaults は組み込みのチェックを返します。
+// Defaults returns a new set of the built-in checks.
+func Defaults() []Check {
+	return []Check{
+		&ThresholdRange{},
+		&DuplicateRules{},
+		&MissingDescription{},
+		&LongInList{},
+		&Snooze{},
+	}
+}
+
+// SetSeverity はチェックが報告する診断情報の重要度を上書きします。
+// SetSeverity overrides the severity of the diagnostics reported by the
+// check with the given ID.
+func (l *Linter) SetSeverity(id string, severity diag.Severity) {
+	l.severities[id] = severity
+}
+
+// Disable はチェックを無効にします。
+// Disable turns off the check with the given ID.
+func (l *Linter) Disable(id string) {
+	l.disabled[id] = true
+}
+
+// Lint は全ての有効なチェックを実行し、位置順に並べた診断情報を返します。
+// Lint runs all enabled checks on file and returns their diagnostics
+// sorted by position.
+func (l *Linter) Lint(file *ast.File) []diag.Diagnostic {
+	var diags []diag.Diagnostic
+	sups := suppressions(file)
+	for _, check := range l.checks {
+		id := check.ID()
+		if l.disabled[id] {
+			continue
+		}
+		for _, d := range check.Check(file) {
+			if d.Code == "" {
+				d.Code = id
+			}
+			if suppress(sups, d) {
+				continue
+			}
+			diags = append(diags, l.complete(file, id, d))
+		}
+	}
+	if !l.disabled[unusedSuppressionID] {
+		for _, d := range l.unusedSuppressions(sups) {
+			diags = append(diags, l.complete(file, unusedSuppressionID, d))
+		}
+	}
+	diag.Sort(diags)
+	return diags
+}
+
+// complete fills in the fields of d, reported by the check id, that the
+// check left empty, and applies the severity set for the check.
+func (l *Linter) complete(file *ast.File, id string, d diag.Diagnostic) diag.Diagnostic {
+	if d.Source == "" {
+		d.Source = source
+	}
+	if d.Filename == "" {
+		d.Filename = file.Filename
+	}
+	if s, ok := l.severities[id]; ok {
+		d.Severity = s
+	}
+	return d
+}
+
+// rules calls fn for every rule in file, including the rules of combined
+// rules.
+func rules(file *ast.File, fn func(ruleset *ast.Ruleset, rule *ast.Rule)) {
+	for _, ruleset := range file.Rulesets {
+		for _, decl := range ruleset.Rules {
+			switch r := decl.(type) {
+			case *ast.Rule:
+				fn(ruleset, r)
+			case *ast.CombinedRule:
+				for _, nested := range r.Rules {
+					fn(ruleset, nested)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package lint
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/diag"
+	"github.com/mashiike/go-dqdl/parser"
+)
+
+const testInput = `Rules = [
+	# completeness of id
+	Completeness "id" > 1.5,
+	# status values
+	ColumnValues "status" in ["a", "b", "c"] with threshold > 90,
+	# uniqueness
+	IsUnique "id",
+	IsUnique "id",
+	# mean
+	Mean "price" between 0.50 and 10,
+	# same mean
+	Mean "price" between 0.5 and 10.0
+]
+`
+
+func TestLinter(t *testing.T) {
+	file, err := parser.ParseFile("test.dqdl", strings.NewReader(testInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name  string
+		setup func(l *Linter)
+		want  []string
+	}{
+		{
+			name: "defaults",
+			want: []string{
+				"test.dqdl:3:22: error: threshold 1.5 is out of range [0, 1] [threshold-out-of-range]",
+				"test.dqdl:5:60: error: threshold 90 is out of range [0, 1] [threshold-out-of-range]",
+				"test.dqdl:8:2: warning: duplicate rule `IsUnique \"id\"` [duplicate-rule]",
+				"test.dqdl:8:2: info: rule has no description comment [missing-description]",
+				"test.dqdl:12:2: warning: duplicate rule `Mean \"price\" between 0.5 and 10` [duplicate-rule]",
+			},
+		},
+		{
+			name: "override",
+			setup: func(l *Linter) {
+				l.Disable("missing-description")
+				l.SetSeverity("threshold-out-of-range", diag.SeverityWarning)
+			},
+			want: []string{
+				"test.dqdl:3:22: warning: threshold 1.5 is out of range [0, 1] [threshold-out-of-range]",
+				"test.dqdl:5:60: warning: threshold 90 is out of range [0, 1] [threshold-out-of-range]",
+				"test.dqdl:8:2: warning: duplicate rule `IsUnique \"id\"` [duplicate-rule]",
+				"test.dqdl:12:2: warning: duplicate rule `Mean \"price\" between 0.5 and 10` [duplicate-rule]",
+			},
+		},
+		{
+			name: "long in list",
+			setup: func(l *Linter) {
+				*l = *New(&LongInList{Max: 2})
+			},
+			want: []string{
+				"test.dqdl:5:24: warning: in-list has 3 values (max 2) [long-in-list]",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			l := New()
+			if c.setup != nil {
+				c.setup(l)
+			}
+			var got []string
+			for _, d := range l.Lint(file) {
+				if d.Source != "lint" {
+					t.Errorf("unexpected source %q", d.Source)
+				}
+				got = append(got, d.String())
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("unexpected diagnostics (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+const suppressionInput = `#dqdl:disable long-in-list
+
+# orders
+#dqdl:disable threshold-out-of-range
+Rules = [
+	# completeness
+	Completeness "id" > 1.5,
+	#dqdl:disable duplicate-rule
+	IsUnique "id",
+	# unique again
+	IsUnique "id",
+	#dqdl:disable
+	Mean "price" > 0,
+	# sum
+	#dqdl:disable unknown-check, duplicate-rule
+	Sum "price" > 0,
+	StandardDeviation "price" > 0 #dqdl:disable missing-description
+]
+`
+
+func TestLinter__Suppression(t *testing.T) {
+	file, err := parser.ParseFile("test.dqdl", strings.NewReader(suppressionInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name  string
+		setup func(l *Linter)
+		want  []string
+	}{
+		{
+			name: "defaults",
+			want: []string{
+				"test.dqdl:1:1: warning: suppression of `long-in-list` is not used [unused-suppression]",
+				"test.dqdl:8:2: warning: suppression of `duplicate-rule` is not used [unused-suppression]",
+				"test.dqdl:9:2: info: rule has no description comment [missing-description]",
+				"test.dqdl:11:2: warning: duplicate rule `IsUnique \"id\"` [duplicate-rule]",
+				"test.dqdl:15:2: warning: suppression of unknown check `unknown-check` [unused-suppression]",
+				"test.dqdl:15:2: warning: suppression of `duplicate-rule` is not used [unused-suppression]",
+			},
+		},
+		{
+			name: "disabled",
+			setup: func(l *Linter) {
+				l.Disable("duplicate-rule")
+				l.Disable("unused-suppression")
+			},
+			want: []string{
+				"test.dqdl:9:2: info: rule has no description comment [missing-description]",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			l := New()
+			if c.setup != nil {
+				c.setup(l)
+			}
+			var got []string
+			for _, d := range l.Lint(file) {
+				got = append(got, d.String())
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("unexpected diagnostics (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSnooze(t *testing.T) {
+	input := `Rules = [
+	# incident 42
+	#dqdl:snooze-until 2024-04-01
+	IsComplete "id",
+	# @snooze-until: 2024-03-31
+	IsUnique "id",
+	#dqdl:snooze-until tomorrow
+	RowCount > 0,
+	#dqdl:disable snooze
+	#dqdl:snooze-until 2024-03-01
+	Mean "price" > 0
+]
+`
+	file, err := parser.ParseFile("test.dqdl", strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := func() time.Time { return time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC) }
+	var got []string
+	for _, d := range New(&Snooze{Now: now}).Lint(file) {
+		got = append(got, d.String())
+	}
+	want := []string{
+		"test.dqdl:4:2: info: rule is snoozed until 2024-04-01 [snooze]",
+		"test.dqdl:6:2: error: snooze expired on 2024-03-31 [snooze]",
+		`test.dqdl:8:2: error: invalid snooze-until date "tomorrow", want YYYY-MM-DD [snooze]`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected diagnostics (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,300 @@
+// Package sarif は診断情報を SARIF 2.1.0 形式に変換します。
+// Package sarif converts diagnostics into SARIF 2.1.0 logs, the format
+// consumed by GitHub code scanning and other static analysis tools.
+package sarif
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+
+	"github.com/mashiike/go-dqdl/diag"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+const (
+	// Version は出力する SARIF のバージョンです。
+	// Version is the SARIF version of the logs written by this package.
+	Version = "2.1.0"
+	// Schema は SARIF 2.1.0 の JSON スキーマの URI です。
+	// Schema is the URI of the JSON schema of SARIF 2.1.0.
+	Schema = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// Log は SARIF のログファイルのトップレベルのオブジェクトです。
+// Log is the top level object of a SARIF log file.
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run はツールの1回の実行結果です。
+// Run is the result of a single invocation of a tool.
+type Run struct {
+	Tool Tool `json:"tool"`
+	// ColumnKind is always "unicodeCodePoints", since token.Pos counts
+	// columns in runes.
+	ColumnKind string   `json:"columnKind"`
+	Results    []Result `json:"results"`
+}
+
+// Tool は診断情報を報告したツールです。
+// Tool describes the tool that reported the results.
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver はツールの本体とルールの一覧です。
+// Driver is the main component of the tool and the rules it reports.
+type Driver struct {
+	Name           string                `json:"name"`
+	Version        string                `json:"version,omitempty"`
+	InformationURI string                `json:"informationUri,omitempty"`
+	Rules          []ReportingDescriptor `json:"rules,omitempty"`
+}
+
+// ReportingDescriptor は診断情報のコードごとのルールです。
+// ReportingDescriptor describes one diagnostic code.
+type ReportingDescriptor struct {
+	ID string `json:"id"`
+}
+
+// Result は1つの診断情報です。
+// Result is a single diagnostic.
+type Result struct {
+	RuleID           string     `json:"ruleId,omitempty"`
+	RuleIndex        *int       `json:"ruleIndex,omitempty"`
+	Level            string     `json:"level"`
+	Message          Message    `json:"message"`
+	Locations        []Location `json:"locations,omitempty"`
+	RelatedLocations []Location `json:"relatedLocations,omitempty"`
+	Fixes            []Fix      `json:"fixes,omitempty"`
+}
+
+// Message はメッセージの本文です。
+// Message is the text of a message.
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Location はファイル上の位置です。
+// Location is a location in a file.
+type Location struct {
+	ID               *int             `json:"id,omitempty"`
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+	Message          *Message         `json:"message,omitempty"`
+}
+
+// PhysicalLocation はファイルとその中の範囲です。
+// PhysicalLocation is a file and a region in it.
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+// ArtifactLocation はファイルの URI です。
+// ArtifactLocation is the URI of a file.
+type ArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// Region はファイル中の範囲です。行と列は1始まりで、終了列は範囲に含まれません。
+// Region is a range in a file. Lines and columns are one-based and the
+// end column is exclusive.
+type Region struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// Fix は修正案です。
+// Fix is a suggested fix.
+type Fix struct {
+	Description     Message          `json:"description"`
+	ArtifactChanges []ArtifactChange `json:"artifactChanges"`
+}
+
+// ArtifactChange は1つのファイルに対する変更です。
+// ArtifactChange is a set of replacements in one file.
+type ArtifactChange struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Replacements     []Replacement    `json:"replacements"`
+}
+
+// Replacement は範囲の置き換えです。
+// Replacement replaces a region with new content.
+type Replacement struct {
+	DeletedRegion   Region           `json:"deletedRegion"`
+	InsertedContent *ArtifactContent `json:"insertedContent,omitempty"`
+}
+
+// ArtifactContent は挿入するテキストです。
+// ArtifactContent is the text to insert.
+type ArtifactContent struct {
+	Text string `json:"text"`
+}
+
+type config struct {
+	name           string
+	version        string
+	informationURI string
+}
+
+// Option は SARIF ログの生成方法を設定します。
+// Option configures how a SARIF log is built.
+type Option func(*config)
+
+// WithToolName はツールの名前を設定します。デフォルトは "go-dqdl" です。
+// WithToolName sets the name of the tool. The default is "go-dqdl".
+func WithToolName(name string) Option {
+	return func(c *config) {
+		c.name = name
+	}
+}
+
+// WithToolVersion はツールのバージョンを設定します。
+// WithToolVersion sets the version of the tool.
+func WithToolVersion(version string) Option {
+	return func(c *config) {
+		c.version = version
+	}
+}
+
+// WithInformationURI はツールの説明のURIを設定します。
+// WithInformationURI sets the URI of the documentation of the tool.
+func WithInformationURI(uri string) Option {
+	return func(c *config) {
+		c.informationURI = uri
+	}
+}
+
+// New は診断情報から1回の実行結果を含む SARIF ログを作成します。
+// New builds a SARIF log with a single run containing diags. Every
+// distinct Code becomes a rule of the driver, in order of appearance.
+func New(diags []diag.Diagnostic, opts ...Option) *Log {
+	cfg := &config{
+		name:           "go-dqdl",
+		informationURI: "https://github.com/mashiike/go-dqdl",
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	run := Run{
+		Tool: Tool{Driver: Driver{
+			Name:           cfg.name,
+			Version:        cfg.version,
+			InformationURI: cfg.informationURI,
+		}},
+		ColumnKind: "unicodeCodePoints",
+		Results:    make([]Result, 0, len(diags)),
+	}
+	ruleIndex := make(map[string]int)
+	for _, d := range diags {
+		result := Result{
+			Level:   level(d.Severity),
+			Message: Message{Text: d.Message},
+		}
+		if d.Code != "" {
+			i, ok := ruleIndex[d.Code]
+			if !ok {
+				i = len(run.Tool.Driver.Rules)
+				ruleIndex[d.Code] = i
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, ReportingDescriptor{ID: d.Code})
+			}
+			result.RuleID = d.Code
+			result.RuleIndex = &i
+		}
+		if d.Filename != "" {
+			result.Locations = []Location{{PhysicalLocation: physicalLocation(d.Filename, d.Pos, d.End)}}
+		}
+		for i, r := range d.Related {
+			filename := r.Filename
+			if filename == "" {
+				filename = d.Filename
+			}
+			if filename == "" {
+				continue
+			}
+			id := i
+			loc := Location{ID: &id, PhysicalLocation: physicalLocation(filename, r.Pos, r.End)}
+			if r.Message != "" {
+				loc.Message = &Message{Text: r.Message}
+			}
+			result.RelatedLocations = append(result.RelatedLocations, loc)
+		}
+		if d.Filename != "" {
+			for _, f := range d.Fixes {
+				result.Fixes = append(result.Fixes, fix(d.Filename, f))
+			}
+		}
+		run.Results = append(run.Results, result)
+	}
+	return &Log{
+		Schema:  Schema,
+		Version: Version,
+		Runs:    []Run{run},
+	}
+}
+
+// Write は診断情報を SARIF ログとして書き出します。
+// Write writes diags to w as an indented SARIF log.
+func Write(w io.Writer, diags []diag.Diagnostic, opts ...Option) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(New(diags, opts...))
+}
+
+// level returns the SARIF level of s. SARIF has no level below note.
+func level(s diag.Severity) string {
+	switch s {
+	case diag.SeverityError:
+		return "error"
+	case diag.SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func physicalLocation(filename string, pos, end token.Pos) PhysicalLocation {
+	loc := PhysicalLocation{
+		ArtifactLocation: ArtifactLocation{URI: filepath.ToSlash(filename)},
+	}
+	if pos.IsValid() {
+		r := region(pos, end)
+		loc.Region = &r
+	}
+	return loc
+}
+
+func region(pos, end token.Pos) Region {
+	r := Region{StartLine: pos.Line, StartColumn: pos.Column}
+	if end.IsValid() {
+		r.EndLine = end.Line
+		r.EndColumn = end.Column
+	}
+	return r
+}
+
+func fix(filename string, f diag.Fix) Fix {
+	change := ArtifactChange{
+		ArtifactLocation: ArtifactLocation{URI: filepath.ToSlash(filename)},
+	}
+	for _, e := range f.Edits {
+		end := e.End
+		if !end.IsValid() {
+			end = e.Pos
+		}
+		r := Replacement{DeletedRegion: region(e.Pos, end)}
+		if e.NewText != "" {
+			r.InsertedContent = &ArtifactContent{Text: e.NewText}
+		}
+		change.Replacements = append(change.Replacements, r)
+	}
+	return Fix{
+		Description:     Message{Text: f.Message},
+		ArtifactChanges: []ArtifactChange{change},
+	}
+}
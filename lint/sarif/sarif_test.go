@@ -0,0 +1,228 @@
+package sarif
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/diag"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+func TestWrite(t *testing.T) {
+	diags := []diag.Diagnostic{
+		{
+			Code:     "duplicate-rule",
+			Severity: diag.SeverityWarning,
+			Message:  "duplicate rule `IsUnique \"id\"`",
+			Source:   "lint",
+			Filename: "rules/a.dqdl",
+			Pos:      token.Pos{Index: 30, Line: 3, Column: 2},
+			End:      token.Pos{Index: 43, Line: 3, Column: 15},
+			Related: []diag.Related{{
+				Pos:     token.Pos{Index: 11, Line: 2, Column: 2},
+				End:     token.Pos{Index: 24, Line: 2, Column: 15},
+				Message: "first defined here",
+			}},
+		},
+		{
+			Code:     "unknown-rule-type",
+			Severity: diag.SeverityError,
+			Message:  "unknown rule type `IsUniq`",
+			Source:   "validate",
+			Filename: "rules/a.dqdl",
+			Pos:      token.Pos{Index: 46, Line: 4, Column: 2},
+			End:      token.Pos{Index: 52, Line: 4, Column: 8},
+			Fixes: []diag.Fix{{
+				Message: "replace with `IsUnique`",
+				Edits: []diag.Edit{{
+					Pos:     token.Pos{Index: 46, Line: 4, Column: 2},
+					End:     token.Pos{Index: 52, Line: 4, Column: 8},
+					NewText: "IsUnique",
+				}},
+			}},
+		},
+		{
+			Code:     "missing-description",
+			Severity: diag.SeverityInfo,
+			Message:  "rule has no description comment",
+			Filename: "rules/b.dqdl",
+			Pos:      token.Pos{Index: 10, Line: 2, Column: 2},
+		},
+		{
+			Code:     "duplicate-rule",
+			Severity: diag.SeverityWarning,
+			Message:  "no position",
+		},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, diags, WithToolVersion("v0.1.0")); err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+  "version": "2.1.0",
+  "runs": [
+    {
+      "tool": {
+        "driver": {
+          "name": "go-dqdl",
+          "version": "v0.1.0",
+          "informationUri": "https://github.com/mashiike/go-dqdl",
+          "rules": [
+            {
+              "id": "duplicate-rule"
+            },
+            {
+              "id": "unknown-rule-type"
+            },
+            {
+              "id": "missing-description"
+            }
+          ]
+        }
+      },
+      "columnKind": "unicodeCodePoints",
+      "results": [
+        {
+          "ruleId": "duplicate-rule",
+          "ruleIndex": 0,
+          "level": "warning",
+          "message": {
+            "text": "duplicate rule ` + "`IsUnique \\\"id\\\"`" + `"
+          },
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {
+                  "uri": "rules/a.dqdl"
+                },
+                "region": {
+                  "startLine": 3,
+                  "startColumn": 2,
+                  "endLine": 3,
+                  "endColumn": 15
+                }
+              }
+            }
+          ],
+          "relatedLocations": [
+            {
+              "id": 0,
+              "physicalLocation": {
+                "artifactLocation": {
+                  "uri": "rules/a.dqdl"
+                },
+                "region": {
+                  "startLine": 2,
+                  "startColumn": 2,
+                  "endLine": 2,
+                  "endColumn": 15
+                }
+              },
+              "message": {
+                "text": "first defined here"
+              }
+            }
+          ]
+        },
+        {
+          "ruleId": "unknown-rule-type",
+          "ruleIndex": 1,
+          "level": "error",
+          "message": {
+            "text": "unknown rule type ` + "`IsUniq`" + `"
+          },
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {
+                  "uri": "rules/a.dqdl"
+                },
+                "region": {
+                  "startLine": 4,
+                  "startColumn": 2,
+                  "endLine": 4,
+                  "endColumn": 8
+                }
+              }
+            }
+          ],
+          "fixes": [
+            {
+              "description": {
+                "text": "replace with ` + "`IsUnique`" + `"
+              },
+              "artifactChanges": [
+                {
+                  "artifactLocation": {
+                    "uri": "rules/a.dqdl"
+                  },
+                  "replacements": [
+                    {
+                      "deletedRegion": {
+                        "startLine": 4,
+                        "startColumn": 2,
+                        "endLine": 4,
+                        "endColumn": 8
+                      },
+                      "insertedContent": {
+                        "text": "IsUnique"
+                      }
+                    }
+                  ]
+                }
+              ]
+            }
+          ]
+        },
+        {
+          "ruleId": "missing-description",
+          "ruleIndex": 2,
+          "level": "note",
+          "message": {
+            "text": "rule has no description comment"
+          },
+          "locations": [
+            {
+              "physicalLocation": {
+                "artifactLocation": {
+                  "uri": "rules/b.dqdl"
+                },
+                "region": {
+                  "startLine": 2,
+                  "startColumn": 2
+                }
+              }
+            }
+          ]
+        },
+        {
+          "ruleId": "duplicate-rule",
+          "ruleIndex": 0,
+          "level": "warning",
+          "message": {
+            "text": "no position"
+          }
+        }
+      ]
+    }
+  ]
+}
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+}
+
+func TestWrite__Empty(t *testing.T) {
+	log := New(nil, WithToolName("dqdl-lint"))
+	if got := log.Runs[0].Tool.Driver.Name; got != "dqdl-lint" {
+		t.Errorf("got tool name %q, want %q", got, "dqdl-lint")
+	}
+	// results must be an empty array rather than null, meaning the tool
+	// ran and found nothing.
+	if log.Runs[0].Results == nil {
+		t.Error("results are nil")
+	}
+}
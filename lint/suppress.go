@@ -0,0 +1,140 @@
+package lint
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/diag"
+)
+
+// unusedSuppressionID is the Code of the diagnostics reporting suppression
+// comments that suppress nothing.
+const unusedSuppressionID = "unused-suppression"
+
+// suppression is a check disabled by a `#dqdl:disable` comment in the
+// range [start, end] of the source.
+type suppression struct {
+	comment    *ast.Comment
+	id         string // ID of the check, or "" for all checks
+	start, end int
+	used       bool
+}
+
+// suppressions returns the suppressions of file. A comment on the line
+// where a rule ends covers the rule, and so does a description of a rule.
+// Other comments of a ruleset, such as its description, cover the
+// ruleset, and comments detached from the rulesets cover the whole file.
+func suppressions(file *ast.File) []*suppression {
+	var sups []*suppression
+	add := func(c *ast.Comment, start, end int) {
+		key, value, ok := c.Directive()
+		if !ok || key != "disable" {
+			return
+		}
+		ids := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+		if len(ids) == 0 {
+			ids = []string{""}
+		}
+		for _, id := range ids {
+			sups = append(sups, &suppression{comment: c, id: id, start: start, end: end})
+		}
+	}
+	for _, g := range file.CommentGroups {
+		for _, c := range g {
+			add(c, 0, math.MaxInt)
+		}
+	}
+	for _, ruleset := range file.Rulesets {
+		for _, c := range ruleset.Description {
+			add(c, ruleset.Pos().Index, ruleset.End().Index)
+		}
+		// the parser attaches a comment after a comma to the next rule, so
+		// rules are looked up by the line of their end.
+		byEndLine := make(map[int]ast.RuleDecl, len(ruleset.Rules))
+		for _, decl := range ruleset.Rules {
+			byEndLine[decl.End().Line] = decl
+		}
+		seen := make(map[*ast.Comment]bool)
+		scope := func(g ast.CommentGroup, owner ast.RuleDecl) {
+			for _, c := range g {
+				if seen[c] {
+					continue
+				}
+				seen[c] = true
+				if decl, ok := byEndLine[c.Pos().Line]; ok && decl.End().Index <= c.Pos().Index {
+					owner = decl
+				}
+				if owner == nil {
+					add(c, ruleset.Pos().Index, ruleset.End().Index)
+				} else {
+					add(c, owner.Pos().Index, owner.End().Index)
+				}
+			}
+		}
+		for _, decl := range ruleset.Rules {
+			switch r := decl.(type) {
+			case *ast.Rule:
+				scope(r.Description, r)
+				scope(r.Type.Comments, r)
+				scope(r.Comments, r)
+			case *ast.CombinedRule:
+				scope(r.Description, r)
+				for _, nested := range r.Rules {
+					scope(nested.Type.Comments, r)
+					scope(nested.Comments, r)
+				}
+				scope(r.Comments, r)
+			}
+		}
+		for _, g := range ruleset.InnerComments {
+			scope(g, nil)
+		}
+		scope(ruleset.Comments, nil)
+	}
+	return sups
+}
+
+// suppress reports whether d is suppressed by one of sups, marking the
+// suppressions that apply as used.
+func suppress(sups []*suppression, d diag.Diagnostic) bool {
+	suppressed := false
+	for _, s := range sups {
+		if (s.id == "" || s.id == d.Code) && s.start <= d.Pos.Index && d.Pos.Index <= s.end {
+			s.used = true
+			suppressed = true
+		}
+	}
+	return suppressed
+}
+
+// unusedSuppressions reports the suppressions that suppressed nothing.
+// Suppressions of checks disabled in l are not reported.
+func (l *Linter) unusedSuppressions(sups []*suppression) []diag.Diagnostic {
+	known := make(map[string]bool, len(l.checks))
+	for _, check := range l.checks {
+		known[check.ID()] = true
+	}
+	var diags []diag.Diagnostic
+	for _, s := range sups {
+		if s.used || l.disabled[s.id] {
+			continue
+		}
+		msg := fmt.Sprintf("suppression of `%s` is not used", s.id)
+		switch {
+		case s.id == "":
+			msg = "suppression of all checks is not used"
+		case !known[s.id]:
+			msg = fmt.Sprintf("suppression of unknown check `%s`", s.id)
+		}
+		diags = append(diags, diag.Diagnostic{
+			Code:     unusedSuppressionID,
+			Severity: diag.SeverityWarning,
+			Message:  msg,
+			Pos:      s.comment.Pos(),
+			End:      s.comment.End(),
+		})
+	}
+	return diags
+}
@@ -0,0 +1,181 @@
+package dqdl
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/mashiike/go-dqdl/ast"
+)
+
+// Marshal は構造体をルールに変換します。
+// Marshal returns the rule represented by the struct v, or a pointer to
+// it. It is the inverse of Unmarshal: the rule type is the result of the
+// RuleType method or the name of the struct type, and the fields are
+// mapped to the parameters and the expression in the same way. A nil
+// ast.Expression or *Condition and a Condition without Operator mean a
+// rule without expression.
+//
+// Like RuleBuilder.Build, the rule is printed and parsed back, so the
+// nodes carry the positions of the printed form and an error is returned
+// for a rule that is not valid DQDL syntax.
+func Marshal(v interface{}) (ast.RuleDecl, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("dqdl: Marshal requires a struct, got %T", v)
+	}
+	ptr := reflect.New(rv.Type())
+	ptr.Elem().Set(rv)
+	name := ruleTypeOf(ptr)
+	if name == "" {
+		return nil, fmt.Errorf("dqdl: can not marshal %T without a rule type", v)
+	}
+	st := rv.Type()
+	fields, err := ruleFields(st)
+	if err != nil {
+		return nil, err
+	}
+	rule := &ast.Rule{Type: &ast.Ident{Name: name}}
+	for _, f := range fields {
+		fv := rv.Field(f.index)
+		switch {
+		case f.expression:
+			expr, err := expressionOf(fv)
+			if err != nil {
+				return nil, fmt.Errorf("dqdl: %s.%s: %w", st, f.name, err)
+			}
+			rule.Expression = expr
+		case f.variadic:
+			for i := 0; i < fv.Len(); i++ {
+				param, err := parameterOf(fv.Index(i))
+				if err != nil {
+					return nil, fmt.Errorf("dqdl: %s.%s: %w", st, f.name, err)
+				}
+				rule.Parameters = append(rule.Parameters, param)
+			}
+		default:
+			param, err := parameterOf(fv)
+			if err != nil {
+				return nil, fmt.Errorf("dqdl: %s.%s: %w", st, f.name, err)
+			}
+			rule.Parameters = append(rule.Parameters, param)
+		}
+	}
+	return reparseRule(rule)
+}
+
+func parameterOf(fv reflect.Value) (ast.Parameter, error) {
+	switch fv.Kind() {
+	case reflect.String:
+		return &ast.StringParameter{Value: fv.String()}, nil
+	case reflect.Bool:
+		return &ast.BoolParameter{Value: fv.Bool()}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &ast.NumberParameter{Value: strconv.FormatInt(fv.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &ast.NumberParameter{Value: strconv.FormatUint(fv.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		return &ast.NumberParameter{Value: strconv.FormatFloat(fv.Float(), 'f', -1, fv.Type().Bits())}, nil
+	}
+	if fv.IsNil() {
+		return nil, fmt.Errorf("missing parameter")
+	}
+	return fv.Interface().(ast.Parameter), nil
+}
+
+func expressionOf(fv reflect.Value) (ast.Expression, error) {
+	switch fv.Type() {
+	case expressionType:
+		if fv.IsNil() {
+			return nil, nil
+		}
+		return fv.Interface().(ast.Expression), nil
+	case conditionType:
+		cond := fv.Interface().(Condition)
+		if cond.Operator == "" {
+			return nil, nil
+		}
+		return cond.expression()
+	}
+	cond := fv.Interface().(*Condition)
+	if cond == nil {
+		return nil, nil
+	}
+	return cond.expression()
+}
+
+// Expression は条件を式のノードに変換します。
+// Expression converts the condition back to an expression node. See Value
+// for the accepted types of Values.
+func (c *Condition) Expression() (ast.Expression, error) {
+	expr, err := c.expression()
+	if err != nil {
+		return nil, fmt.Errorf("dqdl: %w", err)
+	}
+	return expr, nil
+}
+
+func (c *Condition) expression() (ast.Expression, error) {
+	params := make([]ast.Parameter, 0, len(c.Values))
+	for _, v := range c.Values {
+		param, err := value(v)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, param)
+	}
+	arity := func(n int) error {
+		if len(params) != n {
+			return fmt.Errorf("%s takes %d values, got %d", c.Operator, n, len(params))
+		}
+		return nil
+	}
+	var expr ast.Expression
+	switch c.Operator {
+	case ">", ">=", "<", "<=", "=":
+		if err := arity(1); err != nil {
+			return nil, err
+		}
+		expr = &ast.ComparisonExpression{Operator: c.Operator, Right: params[0]}
+	case "between":
+		if err := arity(2); err != nil {
+			return nil, err
+		}
+		expr = &ast.BetweenExpression{Left: params[0], Right: params[1]}
+	case "in":
+		if len(params) == 0 {
+			return nil, fmt.Errorf("in takes at least 1 value")
+		}
+		expr = &ast.InExpression{Values: params}
+	case "matches":
+		if err := arity(1); err != nil {
+			return nil, err
+		}
+		pattern, ok := c.Values[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("matches takes a string, got %T", c.Values[0])
+		}
+		expr = &ast.MatchesExpression{Value: pattern}
+	default:
+		return nil, fmt.Errorf("unknown operator %q", c.Operator)
+	}
+	if c.Threshold == nil {
+		return expr, nil
+	}
+	target, ok := expr.(ast.ThresholdTarget)
+	if !ok {
+		return nil, fmt.Errorf("with threshold requires an in or matches condition, got %s", c.Operator)
+	}
+	t, err := c.Threshold.expression()
+	if err != nil {
+		return nil, err
+	}
+	threshold, ok := t.(ast.ThresholdExpression)
+	if !ok || c.Threshold.Threshold != nil {
+		return nil, fmt.Errorf("threshold must be a comparison or between condition")
+	}
+	return &ast.WithThresholdExpression{Target: target, Threshold: threshold}, nil
+}
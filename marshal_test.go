@@ -0,0 +1,126 @@
+package dqdl
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/printer"
+)
+
+type freshness struct {
+	Column string
+	Expr   Condition
+}
+
+func (freshness) RuleType() string { return "DataFreshness" }
+
+func TestMarshal(t *testing.T) {
+	cases := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{
+			name: "no expression",
+			v:    IsUnique{Column: "id"},
+			want: `IsUnique "id"`,
+		},
+		{
+			name: "pointer",
+			v:    &Completeness{Column: "name", Condition: &Condition{Operator: ">=", Values: []interface{}{0.95}}},
+			want: `Completeness "name" >= 0.95`,
+		},
+		{
+			name: "in with threshold",
+			v: &ColumnValues{Column: "status", Condition: &Condition{
+				Operator:  "in",
+				Values:    []interface{}{"a", "b"},
+				Threshold: &Condition{Operator: ">", Values: []interface{}{0.8}},
+			}},
+			want: `ColumnValues "status" in ["a", "b"] with threshold > 0.8`,
+		},
+		{
+			name: "variadic",
+			v:    IsPrimaryKey{Columns: []string{"a", "b"}},
+			want: `IsPrimaryKey "a" "b"`,
+		},
+		{
+			name: "rule type method",
+			v:    freshness{Column: "updated_at", Expr: Condition{Operator: "<=", Values: []interface{}{Hours(24)}}},
+			want: `DataFreshness "updated_at" <= 24 hours`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rule, err := Marshal(c.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := printer.Fprint(&buf, rule); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, buf.String()); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+			if rule.Pos().Line != 1 {
+				t.Errorf("got position %v, want the one of the printed rule", rule.Pos())
+			}
+			back := reflect.New(reflect.Indirect(reflect.ValueOf(c.v)).Type())
+			if err := Unmarshal(rule, back.Interface()); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestMarshal__Error(t *testing.T) {
+	cases := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{
+			name: "anonymous struct",
+			v:    struct{ Column string }{"a"},
+			want: "dqdl: can not marshal struct { Column string } without a rule type",
+		},
+		{
+			name: "operator",
+			v:    RowCount{Condition: &Condition{Operator: "!=", Values: []interface{}{1}}},
+			want: `dqdl: dqdl.RowCount.Condition: unknown operator "!="`,
+		},
+		{
+			name: "arity",
+			v:    RowCount{Condition: &Condition{Operator: "between", Values: []interface{}{1}}},
+			want: "dqdl: dqdl.RowCount.Condition: between takes 2 values, got 1",
+		},
+		{
+			name: "threshold target",
+			v: RowCount{Condition: &Condition{
+				Operator:  ">",
+				Values:    []interface{}{1},
+				Threshold: &Condition{Operator: ">", Values: []interface{}{0.5}},
+			}},
+			want: "dqdl: dqdl.RowCount.Condition: with threshold requires an in or matches condition, got >",
+		},
+		{
+			name: "not a struct",
+			v:    "IsUnique",
+			want: "dqdl: Marshal requires a struct, got string",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := Marshal(c.v)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if diff := cmp.Diff(c.want, err.Error()); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
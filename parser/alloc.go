@@ -0,0 +1,40 @@
+package parser
+
+// slab hands out pointers to values allocated in chunks, so that parsing
+// a large input allocates the most frequent nodes in a few large blocks
+// instead of one by one. The chunks double in size up to maxSlabChunk, so
+// that small inputs do not pay for unused values.
+type slab[T any] struct {
+	chunk []T
+	used  int // values of chunk handed out
+	size  int
+}
+
+const maxSlabChunk = 256
+
+func (s *slab[T]) new() *T {
+	if s.used == len(s.chunk) {
+		if s.size < maxSlabChunk {
+			s.size = s.size*2 + 1
+		}
+		s.chunk, s.used = make([]T, s.size), 0
+	}
+	s.used++
+	return &s.chunk[s.used-1]
+}
+
+// appendReserving is append, but grows s to a capacity of n at first, and
+// doubles its capacity after that. append grows large slices by smaller
+// steps, which copy the rules and the commas of a large ruleset several
+// times.
+func appendReserving[T any](s []T, n int, v T) []T {
+	if len(s) == cap(s) {
+		if c := 2*len(s) + 1; n < c {
+			n = c
+		}
+		grown := make([]T, len(s), n)
+		copy(grown, s)
+		s = grown
+	}
+	return append(s, v)
+}
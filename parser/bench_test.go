@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// largeSource returns a ruleset of n rules of various types, with a
+// comment every ten rules.
+func largeSource(n int) string {
+	var b strings.Builder
+	b.WriteString("Rules = [\n")
+	for i := 0; i < n; i++ {
+		if i%10 == 0 {
+			fmt.Fprintf(&b, "\t# rules %d to %d\n", i, i+9)
+		}
+		switch i % 5 {
+		case 0:
+			fmt.Fprintf(&b, "\tIsComplete \"col_%d\"", i)
+		case 1:
+			fmt.Fprintf(&b, "\tColumnValues \"col_%d\" in [\"a\", \"b\", \"c\"] with threshold > 0.9", i)
+		case 2:
+			fmt.Fprintf(&b, "\tMean \"col_%d\" between 1.5 and 100", i)
+		case 3:
+			fmt.Fprintf(&b, "\t(IsUnique \"col_%d\") or (ColumnValues \"col_%d\" matches \"[a-z]+\")", i, i)
+		case 4:
+			fmt.Fprintf(&b, "\tColumnValues \"col_%d\" > (now() - 3 days)", i)
+		}
+		if i < n-1 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("]\n")
+	return b.String()
+}
+
+func BenchmarkParseFile__Large(b *testing.B) {
+	src := largeSource(10000)
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseFile("bench.dqdl", strings.NewReader(src)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseRuleset__Large(b *testing.B) {
+	src := largeSource(10000)
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseRuleset(src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseRule(b *testing.B) {
+	src := `ColumnValues "status" in ["a", "b", "c"] with threshold > 0.9`
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseRule(src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
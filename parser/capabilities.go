@@ -0,0 +1,83 @@
+package parser
+
+import "github.com/mashiike/go-dqdl/token"
+
+// 構文機能を表す名前です。
+// Names of syntax features and parser options reported by Capabilities.
+const (
+	FeatureComments         = "comments"           // `#` line comments, WithComments and WithoutComments
+	FeatureMultipleRulesets = "multiple-rulesets"  // several `Rules = [...]` blocks in one file
+	FeatureCombinedRules    = "combined-rules"     // `(rule) and (rule)`, `(rule) or (rule)`
+	FeatureWithThreshold    = "with-threshold"     // `... with threshold > 0.5`
+	FeatureDateArithmetic   = "date-arithmetic"    // `(now() - 3 days)`
+	FeatureParseOptions     = "parse-options"      // variadic Option arguments on the Parse functions
+	FeatureIdentifierDigits = "identifier-digits"  // digits and underscores in rule types, e.g. `CustomSql2`
+	FeatureDialects         = "dialects"           // WithDialect
+	FeatureStrictGlueCompat = "strict-glue-compat" // WithStrictGlueCompat
+	FeatureWarnings         = "warnings"           // WithWarnings
+	FeatureErrorRecovery    = "error-recovery"     // WithErrorRecovery
+	FeatureMaxErrors        = "max-errors"         // WithMaxErrors
+	FeatureFileSet          = "file-set"           // WithFileSet
+	FeatureParseDir         = "parse-dir"          // ParseDir, WithConcurrency and WithProgress
+)
+
+// CapabilitySet はこのパーサーがサポートする構文の一覧です。
+// CapabilitySet describes the syntax supported by this version of the parser.
+type CapabilitySet struct {
+	Keywords    []string // reserved words, as token.Keywords, e.g. "between", "now"
+	Operators   []string // comparison operators, e.g. ">="
+	Expressions []string // expression kinds, e.g. "between", "in"
+	Parameters  []string // parameter kinds, e.g. "string", "duration"
+	Units       []string // duration units, e.g. "days"
+	Features    []string // syntax features, see the Feature constants
+}
+
+// Capabilities はこのパーサーがサポートする構文の一覧を返します。
+// Capabilities returns a description of the syntax supported by this parser,
+// so that tools can detect features at runtime instead of checking versions.
+func Capabilities() *CapabilitySet {
+	return &CapabilitySet{
+		Keywords: token.Keywords(),
+		Operators: tokenStrings(
+			token.EQUAL, token.GREATER_THAN, token.GREATER_EQUAL, token.LESS_THAN, token.LESS_EQUAL,
+		),
+		Expressions: []string{"comparison", "between", "in", "matches", "with-threshold"},
+		Parameters:  []string{"string", "number", "bool", "duration", "date"},
+		Units:       tokenStrings(token.DAYS, token.HOURS),
+		Features: []string{
+			FeatureComments,
+			FeatureMultipleRulesets,
+			FeatureCombinedRules,
+			FeatureWithThreshold,
+			FeatureDateArithmetic,
+			FeatureParseOptions,
+			FeatureIdentifierDigits,
+			FeatureDialects,
+			FeatureStrictGlueCompat,
+			FeatureWarnings,
+			FeatureErrorRecovery,
+			FeatureMaxErrors,
+			FeatureFileSet,
+			FeatureParseDir,
+		},
+	}
+}
+
+// Supports は指定された構文機能がサポートされているかどうかを返します。
+// Supports reports whether the named syntax feature is supported.
+func (c *CapabilitySet) Supports(feature string) bool {
+	for _, f := range c.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+func tokenStrings(types ...token.TokenType) []string {
+	strs := make([]string, 0, len(types))
+	for _, t := range types {
+		strs = append(strs, t.String())
+	}
+	return strs
+}
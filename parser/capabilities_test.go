@@ -0,0 +1,78 @@
+package parser
+
+import (
+	goast "go/ast"
+	goparser "go/parser"
+	gotoken "go/token"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+func TestCapabilities(t *testing.T) {
+	c := Capabilities()
+	if !c.Supports(FeatureCombinedRules) {
+		t.Errorf("expected %q to be supported", FeatureCombinedRules)
+	}
+	if c.Supports("dynamic-rules") {
+		t.Error("expected dynamic-rules not to be supported")
+	}
+	if diff := cmp.Diff([]string{"=", ">", ">=", "<", "<="}, c.Operators); diff != "" {
+		t.Errorf("unexpected operators (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(token.Keywords(), c.Keywords); diff != "" {
+		t.Errorf("unexpected keywords (-want +got):\n%s", diff)
+	}
+	c.Features = nil
+	if !Capabilities().Supports(FeatureComments) {
+		t.Error("Capabilities must return a fresh value on every call")
+	}
+}
+
+// optionFeatures is the feature of each Option of the package. Add the
+// feature of a new option to Capabilities and here.
+var optionFeatures = map[string]string{
+	"WithComments":         FeatureComments,
+	"WithoutComments":      FeatureComments,
+	"WithDialect":          FeatureDialects,
+	"WithStrictGlueCompat": FeatureStrictGlueCompat,
+	"WithWarnings":         FeatureWarnings,
+	"WithErrorRecovery":    FeatureErrorRecovery,
+	"WithMaxErrors":        FeatureMaxErrors,
+	"WithFileSet":          FeatureFileSet,
+	"WithConcurrency":      FeatureParseDir,
+	"WithProgress":         FeatureParseDir,
+}
+
+func TestCapabilities__Options(t *testing.T) {
+	fset := gotoken.NewFileSet()
+	pkgs, err := goparser.ParseDir(fset, ".", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Capabilities()
+	for name, file := range pkgs["parser"].Files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*goast.FuncDecl)
+			if !ok || fn.Recv != nil || !fn.Name.IsExported() || fn.Type.Results == nil || len(fn.Type.Results.List) != 1 {
+				continue
+			}
+			if result, ok := fn.Type.Results.List[0].Type.(*goast.Ident); !ok || result.Name != "Option" {
+				continue
+			}
+			feature, ok := optionFeatures[fn.Name.Name]
+			if !ok {
+				t.Errorf("option %s has no feature", fn.Name.Name)
+				continue
+			}
+			if !c.Supports(feature) {
+				t.Errorf("feature %q of option %s is not in Capabilities", feature, fn.Name.Name)
+			}
+		}
+	}
+}
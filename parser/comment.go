@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// The comments read by the parser are collected in p.comments in source
+// order, as the lexer returns them, and attached to the nodes in a single
+// forward pass over that list: p.nextComment is the index of the comment
+// the next COMMENT token popped stands for, so that pushing a comment back
+// and popping it again refers to the same node. A comment group is a run
+// of the list sharing its array, with no spare capacity, so that attaching
+// a group allocates neither the comments nor the slice.
+
+// collect appends the node of the comment token t to the collected list.
+func (p *parser) collect(t token.Token) {
+	c := p.commentNodes.new()
+	c.SharpPos = t.Start
+	c.Text = t.Value
+	p.comments = append(p.comments, c)
+}
+
+// comment returns the node of the comment token popped last.
+func (p *parser) comment() *ast.Comment {
+	return p.comments[p.nextComment-1]
+}
+
+// extend returns group followed by the comment popped last. The result is
+// a run of the collected list if group is the run right before it.
+func (p *parser) extend(group ast.CommentGroup) ast.CommentGroup {
+	i, n := p.nextComment-1, len(group)
+	if n == 0 || (i >= n && p.comments[i-n] == group[0] && p.comments[i-1] == group[n-1]) {
+		return p.comments[i-n : i+1 : i+1]
+	}
+	return append(group[:n:n], p.comments[i])
+}
+
+// appendComments returns group followed by comments. Either is returned
+// as is if the other one is empty.
+func appendComments(group, comments ast.CommentGroup) ast.CommentGroup {
+	switch {
+	case len(comments) == 0:
+		return group
+	case len(group) == 0:
+		return comments
+	}
+	return append(group[:len(group):len(group)], comments...)
+}
+
+// commentGrouper collects comments on consecutive lines into groups.
+// A group is kept pending until it is known whether it leads the next
+// node or is detached from it.
+type commentGrouper struct {
+	pending ast.CommentGroup
+}
+
+// add appends the comment popped last by p to the pending group. If it
+// does not continue the pending group, the pending group is returned and
+// the comment starts a new one.
+func (g *commentGrouper) add(p *parser) ast.CommentGroup {
+	line := p.comment().Pos().Line
+	if n := len(g.pending); n == 0 || g.pending[n-1].Pos().Line+1 == line {
+		g.pending = p.extend(g.pending)
+		return nil
+	}
+	group := g.pending
+	g.pending = p.extend(nil)
+	return group
+}
+
+// take returns the pending group and resets it. lead reports whether the
+// group ends on the line before line, that is, whether it leads a node
+// starting on line.
+func (g *commentGrouper) take(line int) (group ast.CommentGroup, lead bool) {
+	group = g.pending
+	g.pending = nil
+	if n := len(group); n > 0 {
+		lead = group[n-1].Pos().Line+1 == line
+	}
+	return group, lead
+}
+
+// flush returns the pending group and resets it.
+func (g *commentGrouper) flush() ast.CommentGroup {
+	group, _ := g.take(0)
+	return group
+}
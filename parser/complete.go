@@ -0,0 +1,232 @@
+package parser
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// SuggestionKind は補完候補の種類です。
+// SuggestionKind is the kind of a completion suggestion.
+type SuggestionKind int
+
+const (
+	SuggestRuleType SuggestionKind = iota + 1 // a rule type, e.g. IsUnique
+	SuggestKeyword                            // a keyword, e.g. between
+)
+
+func (k SuggestionKind) String() string {
+	switch k {
+	case SuggestRuleType:
+		return "rule type"
+	case SuggestKeyword:
+		return "keyword"
+	}
+	return "unknown"
+}
+
+// Suggestion は補完候補です。
+// A Suggestion is a completion candidate. Text replaces input[Start:offset],
+// the part of the word already typed before the cursor.
+type Suggestion struct {
+	Text  string
+	Kind  SuggestionKind
+	Start int // byte offset of the word being completed
+}
+
+// sortedRuleTypes are the known rule types in alphabetical order.
+var sortedRuleTypes = func() []string {
+	names := make([]string, 0, len(detectRuleTypes))
+	for name := range detectRuleTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}()
+
+// Complete はカーソル位置で入力可能なルールタイプとキーワードを返します。
+// Complete returns the rule types and keywords that may follow input at
+// the byte offset, filtered by the word already typed before the cursor.
+// Punctuation and parameter values are not suggested, and nothing is
+// suggested inside a string or a comment.
+func Complete(input string, offset int) (suggestions []Suggestion) {
+	defer func() {
+		if r := recover(); r != nil {
+			suggestions = nil
+		}
+	}()
+	if offset < 0 || offset > len(input) {
+		return nil
+	}
+	start := offset
+	for start > 0 && isWordByte(input[start-1]) {
+		start--
+	}
+	tokens, ok := completionTokens(input[:start])
+	if !ok {
+		return nil
+	}
+	prefix := input[start:offset]
+	add := func(kind SuggestionKind, texts ...string) {
+		for _, text := range texts {
+			if len(text) >= len(prefix) && strings.EqualFold(text[:len(prefix)], prefix) {
+				suggestions = append(suggestions, Suggestion{Text: text, Kind: kind, Start: start})
+			}
+		}
+	}
+	c := completionState(tokens)
+	if c.ruleTypes {
+		add(SuggestRuleType, sortedRuleTypes...)
+	}
+	add(SuggestKeyword, c.keywords...)
+	return suggestions
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || '0' <= b && b <= '9' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
+
+// completionTokens returns the tokens of input without comments. It
+// returns false if input ends inside a string or a comment.
+func completionTokens(input string) ([]token.Token, bool) {
+	l := newLexer("complete", input)
+	var tokens []token.Token
+	var last token.Token
+	for {
+		t, ok := l.nextToken()
+		if !ok || t.Type == token.EOF {
+			break
+		}
+		if t.Type == token.ILLEGAL {
+			// e.g. the cursor is inside a string
+			return nil, false
+		}
+		last = t
+		if t.Type != token.COMMENT {
+			tokens = append(tokens, t)
+		}
+	}
+	if last.Type == token.COMMENT && !strings.Contains(input[last.End.Index:], "\n") {
+		return nil, false
+	}
+	return tokens, true
+}
+
+// bracket kinds tracked by completionState.
+const (
+	bracketRuleset = iota
+	bracketIn
+	bracketNested
+	bracketDate
+)
+
+type completion struct {
+	ruleTypes bool     // a rule type may follow
+	keywords  []string // keywords that may follow
+}
+
+var expressionKeywords = []string{"between", "in", "matches"}
+
+// completionState returns what may follow tokens.
+func completionState(tokens []token.Token) completion {
+	var stack []int
+	var expr token.TokenType // start of the expression of the current rule
+	var betweenAnd bool      // `and` of the between expression is seen
+	for i, t := range tokens {
+		switch t.Type {
+		case token.LEFT_BRACKET:
+			if i >= 2 && tokens[i-1].Type == token.EQUAL && tokens[i-2].Type == token.RULES {
+				stack = append(stack, bracketRuleset)
+				expr = 0
+			} else {
+				stack = append(stack, bracketIn)
+			}
+		case token.LEFT_PAREN:
+			if i >= 1 && tokens[i-1].Type.IsExpressionStart() {
+				stack = append(stack, bracketDate)
+			} else {
+				stack = append(stack, bracketNested)
+				expr = 0
+			}
+		case token.RIGHT_BRACKET, token.RIGHT_PAREN:
+			if len(stack) == 0 {
+				break
+			}
+			if stack[len(stack)-1] == bracketNested {
+				expr = 0
+			}
+			stack = stack[:len(stack)-1]
+		case token.COMMA:
+			if len(stack) > 0 && stack[len(stack)-1] == bracketRuleset {
+				expr = 0
+			}
+		case token.AND:
+			if expr == token.BETWEEN {
+				betweenAnd = true
+			}
+		default:
+			if t.Type.IsExpressionStart() && (len(stack) == 0 || stack[len(stack)-1] != bracketDate) {
+				expr = t.Type
+				betweenAnd = false
+			}
+		}
+	}
+	if len(tokens) == 0 {
+		return completion{keywords: []string{"Rules"}}
+	}
+	top := -1
+	if len(stack) > 0 {
+		top = stack[len(stack)-1]
+	}
+	last := tokens[len(tokens)-1]
+	switch last.Type {
+	case token.LEFT_BRACKET, token.COMMA:
+		if top == bracketRuleset {
+			return completion{ruleTypes: true}
+		}
+	case token.LEFT_PAREN:
+		if top == bracketNested {
+			return completion{ruleTypes: true}
+		}
+		return completion{keywords: []string{"now()"}}
+	case token.RIGHT_BRACKET:
+		if top == -1 {
+			return completion{keywords: []string{"Rules"}}
+		}
+		if expr == token.IN {
+			return completion{keywords: []string{"with"}}
+		}
+	case token.RIGHT_PAREN:
+		if top != bracketDate && expr == 0 {
+			return completion{keywords: []string{"and", "or"}}
+		}
+	case token.IDENT:
+		if expr == 0 {
+			return completion{keywords: expressionKeywords}
+		}
+	case token.EQUAL, token.GREATER_THAN, token.GREATER_EQUAL, token.LESS_THAN, token.LESS_EQUAL, token.BETWEEN:
+		if last.Type == token.EQUAL && len(tokens) >= 2 && tokens[len(tokens)-2].Type == token.RULES {
+			return completion{}
+		}
+		return completion{keywords: []string{"now()", "true", "false"}}
+	case token.WITH:
+		return completion{keywords: []string{"threshold"}}
+	case token.STRING, token.NUMBER, token.TRUE, token.FALSE, token.NOW:
+		var keywords []string
+		if last.Type == token.NUMBER {
+			keywords = append(keywords, "days", "hours")
+		}
+		switch {
+		case top == bracketIn || top == bracketDate:
+		case expr == 0:
+			keywords = append(keywords, expressionKeywords...)
+		case expr == token.BETWEEN && !betweenAnd:
+			keywords = append(keywords, "and")
+		case expr == token.MATCHES:
+			keywords = append(keywords, "with")
+		}
+		return completion{keywords: keywords}
+	}
+	return completion{}
+}
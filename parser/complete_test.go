@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestComplete(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string // the cursor is at `|`
+		want  []string
+	}{
+		{name: "empty", input: "|", want: []string{"Rules"}},
+		{name: "partial keyword", input: "Ru|", want: []string{"Rules"}},
+		{name: "partial rule type", input: "Rules = [\n\tIsU|", want: []string{"IsUnique"}},
+		{name: "rule type case insensitive", input: "Rules = [ datas|", want: []string{"DatasetMatch"}},
+		{name: "after comma", input: "Rules = [ IsUnique \"id\", Col|", want: []string{"ColumnCorrelation", "ColumnCount", "ColumnDataType", "ColumnExists", "ColumnLength", "ColumnNamesMatchPattern", "ColumnValues"}},
+		{name: "nested rule", input: "Rules = [ (IsUnique \"id\") or (Row|", want: []string{"RowCount", "RowCountMatch"}},
+		{name: "after nested rule", input: "Rules = [ (ColumnValues \"a\" > 1) |", want: []string{"and", "or"}},
+		{name: "after parameter", input: "Rules = [ ColumnValues \"a\" |", want: []string{"between", "in", "matches"}},
+		{name: "between", input: "Rules = [ Mean \"a\" between 1 |", want: []string{"days", "hours", "and"}},
+		{name: "between and", input: "Rules = [ Mean \"a\" between 1 and 2 |", want: []string{"days", "hours"}},
+		{name: "after in list", input: "Rules = [ ColumnValues \"a\" in [\"x\", \"y\"] |", want: []string{"with"}},
+		{name: "with", input: "Rules = [ ColumnValues \"a\" matches \"x\" with t|", want: []string{"threshold"}},
+		{name: "date", input: "Rules = [ ColumnValues \"a\" > (n|", want: []string{"now()"}},
+		{name: "duration", input: "Rules = [ ColumnValues \"a\" > (now() - 3 |", want: []string{"days", "hours"}},
+		{name: "after ruleset", input: "Rules = [ RowCount > 0 ]\n|", want: []string{"Rules"}},
+		{name: "in string", input: "Rules = [ IsUnique \"i|", want: nil},
+		{name: "in comment", input: "Rules = [ # IsU|", want: nil},
+		{name: "after comment", input: "Rules = [ # unique\n\tIsU|", want: []string{"IsUnique"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			offset := strings.Index(c.input, "|")
+			input := strings.Replace(c.input, "|", "", 1)
+			var got []string
+			for _, s := range Complete(input, offset) {
+				got = append(got, s.Text)
+				if s.Start > offset || !strings.EqualFold(s.Text[:offset-s.Start], input[s.Start:offset]) {
+					t.Errorf("suggestion %q does not replace the typed word: start %d", s.Text, s.Start)
+				}
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("unexpected suggestions (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"errors"
+
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// delimiters follows the brackets and parens read by a parser, so that a
+// syntax error caused by an unbalanced one can point at where it was
+// opened instead of where the parser gave up.
+type delimiters struct {
+	open     []delimiter // `[` and `(` not closed yet, innermost last
+	mismatch *delimiter  // first closing delimiter not matching the innermost open one
+	opener   delimiter   // the open delimiter mismatch was read against
+}
+
+// delimiter is a delimiter token without its value, which has no pointers
+// to store for each `[` and `(` read.
+type delimiter struct {
+	Type  token.TokenType
+	Start token.Pos
+}
+
+// observe records a token of type typ at start read by the parser. It
+// takes the fields it needs rather than the token, which is too large to
+// pass in registers.
+func (d *delimiters) observe(typ token.TokenType, start token.Pos) {
+	switch typ {
+	case token.LEFT_BRACKET, token.LEFT_PAREN:
+		d.open = append(d.open, delimiter{Type: typ, Start: start})
+	case token.RIGHT_BRACKET, token.RIGHT_PAREN:
+		if len(d.open) == 0 {
+			// reported by the parser as an unexpected token
+			return
+		}
+		top := d.open[len(d.open)-1]
+		if closer(top.Type) == typ {
+			d.open = d.open[:len(d.open)-1]
+		} else if d.mismatch == nil {
+			d.mismatch, d.opener = &delimiter{Type: typ, Start: start}, top
+		}
+	}
+}
+
+func closer(open token.TokenType) token.TokenType {
+	if open == token.LEFT_PAREN {
+		return token.RIGHT_PAREN
+	}
+	return token.RIGHT_BRACKET
+}
+
+// explainDelimiters returns the syntax error to report instead of err when err is
+// caused by an unbalanced delimiter: a closing delimiter that does not
+// match the open one, or the end of the input with delimiters still open.
+// Otherwise it returns err.
+func (p *parser) explainDelimiters(err error) error {
+	d := &p.delims
+	var perr *Error
+	if !errors.As(err, &perr) {
+		perr = nil
+	}
+	if d.mismatch != nil && (perr == nil || !perr.Pos.Before(d.mismatch.Start)) {
+		return p.errorf(d.mismatch.Start, "expected `%s` to close `%s` opened at %s but got `%s`",
+			closer(d.opener.Type), d.opener.Type, d.opener.Start, d.mismatch.Type)
+	}
+	if p.eof.IsValid() && len(d.open) > 0 && atEOF(err, perr, p.eof) {
+		open := d.open[len(d.open)-1]
+		return p.causef(errUnclosed, p.eof, "`%s` opened at %s is never closed", open.Type, open.Start)
+	}
+	return err
+}
+
+// atEOF reports whether err, perr if it is a syntax error, is about the
+// end of the input at eof or a missing closing delimiter.
+func atEOF(err error, perr *Error, eof token.Pos) bool {
+	if perr != nil && !perr.Pos.Before(eof) {
+		return true
+	}
+	return errors.Is(err, errUnexpectedEOF) || errors.Is(err, errUnclosed)
+}
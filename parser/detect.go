@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+// detectMaxLines is the number of lines Detect looks at.
+const detectMaxLines = 200
+
+// byteOrderMark is the UTF-8 byte order mark, which the scanner skips at
+// the start of the input.
+const byteOrderMark = "\uFEFF"
+
+var (
+	detectRulesBlock = regexp.MustCompile(`^Rules\s*=\s*\[`)
+	detectRuleLine   = regexp.MustCompile(`^\(?\s*([A-Z][A-Za-z0-9_]*[a-z][A-Za-z0-9_]*)(\s|\)|,|$)`)
+	detectCustomRule = regexp.MustCompile(`^\(?\s*[A-Z][A-Za-z0-9_]*[a-z][A-Za-z0-9_]*\s+"`)
+)
+
+// detectRuleTypes are the rule types of AWS Glue Data Quality.
+var detectRuleTypes = map[string]bool{
+	"AggregateMatch": true, "ColumnCorrelation": true, "ColumnCount": true,
+	"ColumnDataType": true, "ColumnExists": true, "ColumnLength": true,
+	"ColumnNamesMatchPattern": true, "ColumnValues": true, "Completeness": true,
+	"CustomSql": true, "DataFreshness": true, "DatasetMatch": true,
+	"DistinctValuesCount": true, "Entropy": true, "IsComplete": true,
+	"IsPrimaryKey": true, "IsUnique": true, "Mean": true,
+	"ReferentialIntegrity": true, "RowCount": true, "RowCountMatch": true,
+	"SchemaMatch": true, "StandardDeviation": true, "Sum": true,
+	"UniqueValueRatio": true, "Uniqueness": true,
+}
+
+// Detect はソースがDQDLらしいかどうかを構文解析せずに判定します。
+// Detect cheaply guesses whether src is DQDL without parsing it. It looks
+// at the first lines for a `Rules = [` block and for lines led by a rule
+// type, and returns a confidence between 0 and 1 with a short reason.
+// A confidence of 0.5 or more means src most likely is DQDL.
+func Detect(src []byte) (confidence float64, reason string) {
+	src = bytes.TrimPrefix(src, []byte(byteOrderMark))
+	if len(bytes.TrimSpace(src)) == 0 {
+		return 0, "empty input"
+	}
+	if bytes.IndexByte(src, 0) >= 0 || !utf8.Valid(src) {
+		return 0, "binary data"
+	}
+	var rulesBlocks, ruleLines, otherLines int
+	lines := bytes.SplitN(src, []byte("\n"), detectMaxLines+1)
+	if len(lines) > detectMaxLines {
+		lines = lines[:detectMaxLines]
+	}
+	for _, line := range lines {
+		line = bytes.TrimSpace(line)
+		switch {
+		case len(line) == 0, line[0] == '#':
+		case detectRulesBlock.Match(line):
+			rulesBlocks++
+		case bytes.Equal(line, []byte("]")):
+		case isRuleLine(line):
+			ruleLines++
+		default:
+			otherLines++
+		}
+	}
+	ratio := 0.0
+	if ruleLines+otherLines > 0 {
+		ratio = float64(ruleLines) / float64(ruleLines+otherLines)
+	}
+	switch {
+	case rulesBlocks > 0 && ratio >= 0.5:
+		return 0.9 + 0.1*ratio, fmt.Sprintf("%d Rules block(s) with %d rule line(s)", rulesBlocks, ruleLines)
+	case rulesBlocks > 0:
+		return 0.6, fmt.Sprintf("%d Rules block(s), but %d of %d line(s) do not start with a rule type", rulesBlocks, otherLines, ruleLines+otherLines)
+	case ruleLines > 0 && ratio >= 0.8:
+		return 0.5 + 0.2*ratio, fmt.Sprintf("%d rule line(s) without a Rules block", ruleLines)
+	case ruleLines > 0:
+		return 0.4 * ratio, fmt.Sprintf("only %d of %d line(s) start with a rule type", ruleLines, ruleLines+otherLines)
+	}
+	return 0, "no Rules block or rule type found"
+}
+
+// isRuleLine reports whether line starts with a known rule type, or with
+// a rule type like identifier followed by a string parameter.
+func isRuleLine(line []byte) bool {
+	if m := detectRuleLine.FindSubmatch(line); m != nil && detectRuleTypes[string(m[1])] {
+		return true
+	}
+	return detectCustomRule.Match(line)
+}
@@ -0,0 +1,44 @@
+package parser
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	sample, err := os.ReadFile("testdata/sample.dqdl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name   string
+		input  string
+		isDQDL bool
+	}{
+		{name: "sample", input: string(sample), isDQDL: true},
+		{name: "bare rules", input: "IsUnique \"id\",\nIsComplete \"id\",\n(RowCount > 0) and (Mean \"x\" > 1)\n", isDQDL: true},
+		{name: "custom rule", input: "Rules = [\n\tIsValidJAN \"code\"\n]", isDQDL: true},
+		{name: "custom rules with digits", input: "CustomSql2 \"select count(*) from primary\" > 0,\nIs_Valid_JAN13 \"code\"\n", isDQDL: true},
+		{name: "byte order mark", input: "\uFEFFRules = [\r\n\tIsComplete \"id\",\r\n\tIsUnique \"id\"\r\n]\r\n", isDQDL: true},
+		{name: "empty", input: " \n\t"},
+		{name: "sql", input: "SELECT count(*)\nFROM orders\nWHERE status = 'ok';\n"},
+		{name: "yaml", input: "rules:\n  - name: IsUnique\n    column: id\n"},
+		{name: "json", input: `{"Rules": ["IsUnique \"id\""]}`},
+		{name: "binary", input: "Rules = [\x00]"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			confidence, reason := Detect([]byte(c.input))
+			t.Logf("confidence: %.2f, reason: %s", confidence, reason)
+			if confidence < 0 || confidence > 1 {
+				t.Fatalf("confidence out of range: %f", confidence)
+			}
+			if got := confidence >= 0.5; got != c.isDQDL {
+				t.Errorf("got confidence %.2f (%s), want DQDL=%v", confidence, reason, c.isDQDL)
+			}
+			if reason == "" {
+				t.Error("reason is empty")
+			}
+		})
+	}
+}
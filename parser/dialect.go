@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// Dialect は受け付ける AWS Glue Data Quality の文法の版です。
+// A Dialect is a version of the DQDL grammar of AWS Glue Data Quality. A
+// parser given a dialect with WithDialect rejects the syntax features the
+// dialect does not have, so that a ruleset written for an older Glue
+// environment fails locally instead of in CreateDataQualityRuleset.
+type Dialect int
+
+const (
+	// DialectGlueLatest は最新の Glue の文法です。デフォルトです。
+	// DialectGlueLatest is the grammar of the latest Glue. It is the default.
+	DialectGlueLatest Dialect = iota
+	// DialectGlue2023 は2023年の Glue の文法です。with threshold 句がありません。
+	// DialectGlue2023 is the grammar of Glue as of 2023, which has no
+	// `with threshold` clause.
+	DialectGlue2023
+)
+
+var dialectNames = map[Dialect]string{
+	DialectGlueLatest: "glue-latest",
+	DialectGlue2023:   "glue-2023",
+}
+
+func (d Dialect) String() string {
+	if s, ok := dialectNames[d]; ok {
+		return s
+	}
+	return fmt.Sprintf("Dialect(%d)", int(d))
+}
+
+// dialectTable maps a dialect to its gated syntax features.
+type dialectTable map[Dialect]map[string]bool
+
+// dialectFeatures are the syntax features of each dialect.
+var dialectFeatures = dialectTable{
+	DialectGlueLatest: {
+		FeatureCombinedRules:  true,
+		FeatureWithThreshold:  true,
+		FeatureDateArithmetic: true,
+	},
+	DialectGlue2023: {
+		FeatureCombinedRules:  true,
+		FeatureDateArithmetic: true,
+	},
+}
+
+// supports reports whether d has feature in the table. An unknown
+// dialect has none of the gated features.
+func (t dialectTable) supports(d Dialect, feature string) bool {
+	switch feature {
+	case FeatureCombinedRules, FeatureWithThreshold, FeatureDateArithmetic:
+		return t[d][feature]
+	}
+	return true
+}
+
+// Supports は指定された構文機能がこの版にあるかどうかを返します。
+// Supports reports whether the dialect has the named syntax feature. Only
+// the features of the Glue grammar are gated by dialects; the others, such
+// as FeatureComments, are always supported. An unknown dialect has none of
+// the gated features.
+func (d Dialect) Supports(feature string) bool {
+	return dialectFeatures.supports(d, feature)
+}
+
+// WithDialect は受け付ける文法の版を指定します。デフォルトは DialectGlueLatest です。
+// WithDialect sets the dialect of the grammar accepted by the parser. The
+// default is DialectGlueLatest. Parsing with an unknown dialect fails.
+func WithDialect(d Dialect) Option {
+	return func(c *config) {
+		c.dialect = d
+	}
+}
+
+// require returns a syntax error at pos if the dialect of the parser does
+// not have feature.
+func (p *parser) require(feature string, pos token.Pos) error {
+	if p.cfg.dialects.supports(p.cfg.dialect, feature) {
+		return nil
+	}
+	return p.errorf(pos, "%s is not supported by the %s dialect", feature, p.cfg.dialect)
+}
+
+// checkDialect returns an error if the dialect of the parser is unknown.
+func (p *parser) checkDialect() error {
+	if _, ok := p.cfg.dialects[p.cfg.dialect]; !ok {
+		return fmt.Errorf("parser: unknown dialect %s", p.cfg.dialect)
+	}
+	return nil
+}
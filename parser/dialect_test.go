@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"testing"
+)
+
+// withDialects makes the parser use table for the features of the
+// dialects.
+func withDialects(table dialectTable) Option {
+	return func(c *config) {
+		c.dialects = table
+	}
+}
+
+func TestWithDialect(t *testing.T) {
+	// a dialect without the gated features, standing in for a Glue older
+	// than any of the known dialects.
+	const dialectBare Dialect = 100
+	table := dialectTable{dialectBare: {}}
+	for d, features := range dialectFeatures {
+		table[d] = features
+	}
+
+	cases := []struct {
+		name    string
+		input   string
+		dialect Dialect
+		errStr  string
+	}{
+		{name: "latest", input: `ColumnValues "a" in ["x"] with threshold > 0.9`, dialect: DialectGlueLatest},
+		{
+			name:    "glue 2023 with threshold",
+			input:   `ColumnValues "a" in ["x"] with threshold > 0.9`,
+			dialect: DialectGlue2023,
+			errStr:  "1:27: syntax error near ` with threshold > 0....`, with-threshold is not supported by the glue-2023 dialect",
+		},
+		{name: "glue 2023 combined rules", input: `(IsComplete "id") and (IsUnique "id")`, dialect: DialectGlue2023},
+		{
+			name:    "unknown dialect",
+			input:   `IsComplete "id"`,
+			dialect: Dialect(7),
+			errStr:  "parser: unknown dialect Dialect(7)",
+		},
+		{
+			name:    "combined rules",
+			input:   `(IsComplete "id") and (IsUnique "id")`,
+			dialect: dialectBare,
+			errStr:  "1:19: syntax error near ` and (IsUnique \"id\")`, combined-rules is not supported by the Dialect(100) dialect",
+		},
+		{
+			name:    "with threshold",
+			input:   `ColumnValues "a" in ["x"] with threshold > 0.9`,
+			dialect: dialectBare,
+			errStr:  "1:27: syntax error near ` with threshold > 0....`, with-threshold is not supported by the Dialect(100) dialect",
+		},
+		{
+			name:    "date arithmetic",
+			input:   `ColumnValues "a" > (now() - 3 days)`,
+			dialect: dialectBare,
+			errStr:  "1:20: syntax error near ` (now() - 3 days)`, date-arithmetic is not supported by the Dialect(100) dialect",
+		},
+		{name: "plain rule", input: `IsComplete "id"`, dialect: dialectBare},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := ParseRule(c.input, WithDialect(c.dialect), withDialects(table))
+			if c.errStr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || err.Error() != c.errStr {
+				t.Errorf("got error %v, want %s", err, c.errStr)
+			}
+		})
+	}
+}
+
+func TestDialect__Supports(t *testing.T) {
+	cases := []struct {
+		dialect Dialect
+		feature string
+		want    bool
+	}{
+		{dialect: DialectGlueLatest, feature: FeatureWithThreshold, want: true},
+		{dialect: DialectGlue2023, feature: FeatureWithThreshold, want: false},
+		{dialect: DialectGlue2023, feature: FeatureCombinedRules, want: true},
+		{dialect: Dialect(7), feature: FeatureCombinedRules, want: false},
+		{dialect: Dialect(7), feature: FeatureComments, want: true},
+	}
+	for _, c := range cases {
+		if got := c.dialect.Supports(c.feature); got != c.want {
+			t.Errorf("%s supports %s: got %v, want %v", c.dialect, c.feature, got, c.want)
+		}
+	}
+}
+
+func TestDialect__String(t *testing.T) {
+	for d, want := range map[Dialect]string{
+		DialectGlueLatest: "glue-latest",
+		DialectGlue2023:   "glue-2023",
+		Dialect(7):        "Dialect(7)",
+	} {
+		if got := d.String(); got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	}
+}
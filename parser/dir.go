@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"io/fs"
+	"path"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/diag"
+)
+
+// WithConcurrency は ParseDir が同時に構文解析するファイルの数を指定します。デフォルトは GOMAXPROCS です。
+// WithConcurrency sets the number of files ParseDir parses at once. The default is GOMAXPROCS.
+func WithConcurrency(n int) Option {
+	return func(c *config) {
+		c.concurrency = n
+	}
+}
+
+// Progress は ParseDir の進捗を表します。
+// Progress reports the progress of ParseDir.
+type Progress struct {
+	Filename string // file that has just been parsed
+	Parsed   int    // number of files parsed so far, including failed ones
+	Errors   int    // number of files that failed to parse so far
+	Total    int    // number of files to parse
+}
+
+// WithProgress は ParseDir がファイルを1つ構文解析するたびに呼び出す関数を指定します。
+// WithProgress sets a function that ParseDir calls each time it finishes a file.
+// Calls are serialized, so fn needs no locking of its own, but it should
+// return quickly because parsing of other files waits for it.
+func WithProgress(fn func(Progress)) Option {
+	return func(c *config) {
+		c.progress = fn
+	}
+}
+
+// ParseDir は dir 以下の全ての .dqdl ファイルについての構文解析を行います。
+// ParseDir walks the tree rooted at dir in fsys and parses every file with
+// the ".dqdl" extension. The returned map is keyed by the path of the file
+// within fsys. Files that fail to parse are omitted from the map, and their
+// errors are returned together as an ErrorList.
+func ParseDir(fsys fs.FS, dir string, opts ...Option) (map[string]*ast.File, error) {
+	var paths []string
+	err := fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && path.Ext(p) == ".dqdl" {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := newConfig(opts)
+	if cfg.warnings != nil {
+		var warnMu sync.Mutex
+		warn := cfg.warnings
+		opts = append(opts[:len(opts):len(opts)], WithWarnings(func(d diag.Diagnostic) {
+			warnMu.Lock()
+			defer warnMu.Unlock()
+			warn(d)
+		}))
+	}
+	if cfg.recovery != nil {
+		var recoveryMu sync.Mutex
+		recovery := cfg.recovery
+		opts = append(opts[:len(opts):len(opts)], WithErrorRecovery(func(d diag.Diagnostic) {
+			recoveryMu.Lock()
+			defer recoveryMu.Unlock()
+			recovery(d)
+		}))
+	}
+	concurrency := cfg.concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		files = make(map[string]*ast.File, len(paths))
+		errs  ErrorList
+		sem   = make(chan struct{}, concurrency)
+	)
+	for _, p := range paths {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(p string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			file, err := parseFS(fsys, p, opts)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, &FileError{Filename: p, Err: err})
+			} else {
+				files[p] = file
+			}
+			if cfg.progress != nil {
+				cfg.progress(Progress{
+					Filename: p,
+					Parsed:   len(files) + len(errs),
+					Errors:   len(errs),
+					Total:    len(paths),
+				})
+			}
+		}(p)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool {
+			return errs[i].Filename < errs[j].Filename
+		})
+		return files, errs
+	}
+	return files, nil
+}
+
+func parseFS(fsys fs.FS, name string, opts []Option) (*ast.File, error) {
+	fp, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+	return ParseFile(name, fp, opts...)
+}
@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/diag"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+func TestParseDir(t *testing.T) {
+	fsys := fstest.MapFS{
+		"rules/orders.dqdl":         {Data: []byte(`Rules = [ IsUnique "order-id" ]`)},
+		"rules/nested/users.dqdl":   {Data: []byte(`Rules = [ IsComplete "user-id" ]`)},
+		"rules/nested/broken.dqdl":  {Data: []byte(`Rules = [ IsComplete "user-id"`)},
+		"rules/README.md":           {Data: []byte(`# not a ruleset`)},
+		"other/ignored.dqdl":        {Data: []byte(`Rules = [ RowCount > 0 ]`)},
+		"rules/nested/broken2.dqdl": {Data: []byte(`Rules =`)},
+	}
+	for _, concurrency := range []int{0, 1} {
+		files, err := ParseDir(fsys, "rules", WithConcurrency(concurrency))
+		var errList ErrorList
+		if !errors.As(err, &errList) {
+			t.Fatalf("got error %v, want ErrorList", err)
+		}
+		var failed []string
+		for _, e := range errList {
+			failed = append(failed, e.Filename)
+		}
+		if diff := cmp.Diff([]string{"rules/nested/broken.dqdl", "rules/nested/broken2.dqdl"}, failed); diff != "" {
+			t.Errorf("unexpected failed files (-want +got):\n%s", diff)
+		}
+		var parsed []string
+		for name, file := range files {
+			parsed = append(parsed, name)
+			if file.Filename != name {
+				t.Errorf("file.Filename = %q, want %q", file.Filename, name)
+			}
+		}
+		sort.Strings(parsed)
+		if diff := cmp.Diff([]string{"rules/nested/users.dqdl", "rules/orders.dqdl"}, parsed); diff != "" {
+			t.Errorf("unexpected parsed files (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestParseDir__WithFileSet(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.dqdl": {Data: []byte(`Rules = [ IsUnique "order-id" ]`)},
+		"b.dqdl": {Data: []byte("Rules = [\n  IsComplete \"user-id\"\n]")},
+	}
+	fset := token.NewFileSet()
+	files, err := ParseDir(fsys, ".", WithFileSet(fset))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	fset.Iterate(func(f *token.File) bool {
+		rule := files[f.Name()].Rulesets[0].Rules[0]
+		got = append(got, fset.Position(f.FilePos(rule.Pos())).String())
+		return true
+	})
+	sort.Strings(got)
+	if diff := cmp.Diff([]string{"a.dqdl:1:11", "b.dqdl:2:3"}, got); diff != "" {
+		t.Errorf("unexpected positions (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseDir__WithProgress(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.dqdl": {Data: []byte(`Rules = [ IsUnique "order-id" ]`)},
+		"b.dqdl": {Data: []byte(`Rules = [`)},
+		"c.dqdl": {Data: []byte(`Rules = [ RowCount > 0 ]`)},
+	}
+	var reports []Progress
+	_, err := ParseDir(fsys, ".", WithConcurrency(2), WithProgress(func(p Progress) {
+		reports = append(reports, p)
+	}))
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if len(reports) != 3 {
+		t.Fatalf("got %d progress reports, want 3", len(reports))
+	}
+	for i, p := range reports {
+		if p.Parsed != i+1 || p.Total != 3 {
+			t.Errorf("report %d: got %d/%d, want %d/3", i, p.Parsed, p.Total, i+1)
+		}
+	}
+	if last := reports[len(reports)-1]; last.Errors != 1 {
+		t.Errorf("got %d errors in the last report, want 1", last.Errors)
+	}
+}
+
+func TestParseDir__ErrorRecovery(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for i := 0; i < 50; i++ {
+		fsys[fmt.Sprintf("rules/%02d.dqdl", i)] = &fstest.MapFile{Data: []byte("Rules = [\n\tIsComplete \"a\" >,\n\tIsUnique \"b\"\n]\n")}
+	}
+	var diags []diag.Diagnostic
+	files, err := ParseDir(fsys, "rules", WithConcurrency(8), WithErrorRecovery(func(d diag.Diagnostic) {
+		diags = append(diags, d)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 50 || len(diags) != 50 {
+		t.Errorf("got %d files and %d diagnostics, want 50 of each", len(files), len(diags))
+	}
+}
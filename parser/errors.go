@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mashiike/go-dqdl/diag"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// Error は構文エラーを表します。
+// An Error is a syntax error found while parsing.
+type Error struct {
+	Filename string    // name of the file, empty unless parsed by ParseFile
+	Pos      token.Pos // position of the error
+	Msg      string    // error message
+
+	cause error // classifies the error for the parser, e.g. errUnexpectedEOF
+}
+
+// Unwrap returns the cause of the error, if any.
+func (e *Error) Unwrap() error { return e.cause }
+
+// Causes of syntax errors, wrapped by the errors they classify, so that
+// the parser tells them apart regardless of the messages.
+var (
+	errUnexpectedEOF = errors.New("unexpected EOF")            // the input ends in the middle of a construct
+	errUnclosed      = errors.New("missing closing delimiter") // a `]` or `)` is missing
+)
+
+// Error returns a string in the form "filename:line:column: message",
+// or "line:column: message" if the error has no filename.
+func (e *Error) Error() string {
+	if e.Filename != "" {
+		return fmt.Sprintf("%s:%s: %s", e.Filename, e.Pos, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+// Diagnostic は構文エラーを診断情報に変換します。
+// Diagnostic converts the error to a diag.Diagnostic.
+func (e *Error) Diagnostic() diag.Diagnostic {
+	return diag.Diagnostic{
+		Code:     "syntax-error",
+		Severity: diag.SeverityError,
+		Message:  e.Msg,
+		Source:   "parser",
+		Filename: e.Filename,
+		Pos:      e.Pos,
+	}
+}
+
+// FileError はファイルの構文解析中に発生したエラーです。
+// A FileError is an error that occurred while parsing a file.
+type FileError struct {
+	Filename string
+	Err      error
+}
+
+func (e *FileError) Error() string {
+	var perr *Error
+	if errors.As(e.Err, &perr) && perr.Filename == e.Filename {
+		return e.Err.Error()
+	}
+	return e.Filename + ": " + e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorList はファイルごとのエラーの一覧です。
+// An ErrorList is a list of per-file errors, sorted by filename.
+type ErrorList []*FileError
+
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	msgs := make([]string, 0, len(l))
+	for _, e := range l {
+		msgs = append(msgs, e.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// FuzzParseFile checks that the parser never panics. Inputs ending in the
+// middle of a construct are kept in testdata/fuzz/FuzzParseFile. Run
+// internal/crashtriage on the failing inputs it finds.
+func FuzzParseFile(f *testing.F) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.dqdl"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, path := range paths {
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(bs))
+	}
+	f.Fuzz(checkInternal)
+}
+
+// TestCrashers parses the inputs that crashed the parser, written to
+// testdata/crashers by internal/crashtriage, as FuzzParseFile does.
+func TestCrashers(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "crashers", "*.dqdl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			checkInternal(t, string(bs))
+		})
+	}
+}
+
+// checkInternal fails t if src panics in the parser. internal/crashtriage
+// makes the same calls.
+func checkInternal(t *testing.T, src string) {
+	file, err := ParseFile("fuzz.dqdl", strings.NewReader(src))
+	if errors.Is(err, ErrInternal) {
+		t.Fatal(err)
+	}
+	if _, err := ParseRule(src); errors.Is(err, ErrInternal) {
+		t.Fatal(err)
+	}
+	if _, err := ParseBareRules(src); errors.Is(err, ErrInternal) {
+		t.Fatal(err)
+	}
+	Complete(src, len(src)/2)
+	if file != nil && len(src) > 0 {
+		if _, err := Reparse(file, Range{}, src[:1]); errors.Is(err, ErrInternal) {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRecoverInternal(t *testing.T) {
+	err := func() (err error) {
+		defer recoverInternal("rule", &err)
+		var s []int
+		_ = s[1]
+		return nil
+	}()
+	if !errors.Is(err, ErrInternal) {
+		t.Fatalf("got %v, want ErrInternal", err)
+	}
+	want := "parser: internal error parsing rule: runtime error: index out of range [1] with length 0"
+	if err.Error() != want {
+		t.Errorf("got %q, want %q", err.Error(), want)
+	}
+	var ie *InternalError
+	if !errors.As(err, &ie) {
+		t.Fatalf("got %T, want *InternalError", err)
+	}
+	if !strings.HasPrefix(ie.Site, "parser.TestRecoverInternal.func1:") {
+		t.Errorf("got site %q, want the function that panicked", ie.Site)
+	}
+}
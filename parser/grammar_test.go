@@ -0,0 +1,389 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"text/scanner"
+	"unicode"
+
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// This file checks grammar.ebnf against the lexer and the parser. The
+// grammar is read by a small EBNF interpreter, which matches the syntactic
+// productions against the tokens produced by the lexer. Lexical
+// productions are not interpreted: the ones below stand for token classes.
+var lexicalTokens = map[string]func(token.Token) bool{
+	"ident":  func(t token.Token) bool { return t.Type == token.IDENT },
+	"number": func(t token.Token) bool { return t.Type == token.NUMBER },
+	"string": func(t token.Token) bool { return t.Type == token.STRING },
+	"integer": func(t token.Token) bool {
+		return t.Type == token.NUMBER && !strings.ContainsRune(t.Value, '.')
+	},
+}
+
+type ebnfExpr interface{}
+
+type (
+	ebnfAlternative []ebnfExpr
+	ebnfSequence    []ebnfExpr
+	ebnfName        string
+	ebnfTerminal    string
+	ebnfOption      struct{ body ebnfExpr }
+	ebnfRepetition  struct{ body ebnfExpr }
+	ebnfRange       struct{ begin, end string }
+)
+
+type ebnfParser struct {
+	s   scanner.Scanner
+	tok rune
+	err error
+}
+
+func parseEBNF(src string) (map[string]ebnfExpr, error) {
+	p := &ebnfParser{}
+	p.s.Init(strings.NewReader(src))
+	p.s.Error = func(s *scanner.Scanner, msg string) {
+		p.err = fmt.Errorf("%s: %s", s.Pos(), msg)
+	}
+	p.next()
+	grammar := make(map[string]ebnfExpr)
+	for p.tok != scanner.EOF && p.err == nil {
+		if p.tok != scanner.Ident {
+			return nil, fmt.Errorf("%s: production name expected", p.s.Pos())
+		}
+		name := p.s.TokenText()
+		p.next()
+		p.expect('=')
+		grammar[name] = p.expression()
+		p.expect('.')
+	}
+	return grammar, p.err
+}
+
+func (p *ebnfParser) next() {
+	p.tok = p.s.Scan()
+}
+
+func (p *ebnfParser) expect(tok rune) {
+	if p.tok != tok && p.err == nil {
+		p.err = fmt.Errorf("%s: expected %q, got %q", p.s.Pos(), tok, p.s.TokenText())
+	}
+	p.next()
+}
+
+func (p *ebnfParser) expression() ebnfExpr {
+	alt := ebnfAlternative{p.sequence()}
+	for p.tok == '|' {
+		p.next()
+		alt = append(alt, p.sequence())
+	}
+	if len(alt) == 1 {
+		return alt[0]
+	}
+	return alt
+}
+
+func (p *ebnfParser) sequence() ebnfExpr {
+	var seq ebnfSequence
+	for p.err == nil {
+		x := p.term()
+		if x == nil {
+			break
+		}
+		seq = append(seq, x)
+	}
+	if len(seq) == 1 {
+		return seq[0]
+	}
+	return seq
+}
+
+func (p *ebnfParser) term() ebnfExpr {
+	switch p.tok {
+	case scanner.Ident:
+		name := ebnfName(p.s.TokenText())
+		p.next()
+		return name
+	case scanner.String, scanner.RawString:
+		lit, err := strconv.Unquote(p.s.TokenText())
+		if err != nil {
+			p.err = err
+		}
+		p.next()
+		if p.tok == '…' {
+			p.next()
+			end, err := strconv.Unquote(p.s.TokenText())
+			if err != nil {
+				p.err = err
+			}
+			p.next()
+			return ebnfRange{begin: lit, end: end}
+		}
+		return ebnfTerminal(lit)
+	case '(':
+		p.next()
+		x := p.expression()
+		p.expect(')')
+		return x
+	case '[':
+		p.next()
+		x := p.expression()
+		p.expect(']')
+		return ebnfOption{body: x}
+	case '{':
+		p.next()
+		x := p.expression()
+		p.expect('}')
+		return ebnfRepetition{body: x}
+	}
+	return nil
+}
+
+// ebnfMatcher matches syntactic productions against a list of tokens.
+type ebnfMatcher struct {
+	grammar map[string]ebnfExpr
+	tokens  []token.Token
+}
+
+// match returns all the positions where a match of x starting at i can end.
+func (m *ebnfMatcher) match(x ebnfExpr, i int) []int {
+	switch x := x.(type) {
+	case ebnfName:
+		if accept, ok := lexicalTokens[string(x)]; ok {
+			if i < len(m.tokens) && accept(m.tokens[i]) {
+				return []int{i + 1}
+			}
+			return nil
+		}
+		return m.match(m.grammar[string(x)], i)
+	case ebnfTerminal:
+		if i < len(m.tokens) && m.tokens[i].Type.String() == string(x) {
+			return []int{i + 1}
+		}
+		return nil
+	case ebnfAlternative:
+		var ends []int
+		for _, alt := range x {
+			ends = appendUnique(ends, m.match(alt, i)...)
+		}
+		return ends
+	case ebnfSequence:
+		ends := []int{i}
+		for _, elem := range x {
+			var next []int
+			for _, end := range ends {
+				next = appendUnique(next, m.match(elem, end)...)
+			}
+			ends = next
+		}
+		return ends
+	case ebnfOption:
+		return appendUnique([]int{i}, m.match(x.body, i)...)
+	case ebnfRepetition:
+		ends := []int{i}
+		for frontier := []int{i}; len(frontier) > 0; {
+			var next []int
+			for _, end := range frontier {
+				for _, e := range m.match(x.body, end) {
+					if e > end && !containsInt(ends, e) {
+						ends = append(ends, e)
+						next = append(next, e)
+					}
+				}
+			}
+			frontier = next
+		}
+		return ends
+	}
+	panic(fmt.Sprintf("unexpected expression %T", x))
+}
+
+func appendUnique(s []int, values ...int) []int {
+	for _, v := range values {
+		if !containsInt(s, v) {
+			s = append(s, v)
+		}
+	}
+	return s
+}
+
+func containsInt(s []int, v int) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// terminals returns all terminals used by syntactic productions.
+func terminals(x ebnfExpr, set map[string]bool) {
+	switch x := x.(type) {
+	case ebnfTerminal:
+		set[string(x)] = true
+	case ebnfAlternative:
+		for _, e := range x {
+			terminals(e, set)
+		}
+	case ebnfSequence:
+		for _, e := range x {
+			terminals(e, set)
+		}
+	case ebnfOption:
+		terminals(x.body, set)
+	case ebnfRepetition:
+		terminals(x.body, set)
+	}
+}
+
+func loadGrammar(t *testing.T) map[string]ebnfExpr {
+	t.Helper()
+	bs, err := os.ReadFile("grammar.ebnf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grammar, err := parseEBNF(string(bs))
+	if err != nil {
+		t.Fatalf("grammar.ebnf: %s", err)
+	}
+	return grammar
+}
+
+// grammarAccepts reports whether the tokens of input match the production.
+func grammarAccepts(t *testing.T, grammar map[string]ebnfExpr, production, input string) bool {
+	t.Helper()
+	r := NewTokenReader(input)
+	var tokens []token.Token
+	for tok, ok := r.Next(); ok; tok, ok = r.Next() {
+		switch tok.Type {
+		case token.COMMENT, token.EOF:
+		case token.ILLEGAL:
+			return false
+		default:
+			tokens = append(tokens, tok)
+		}
+	}
+	m := &ebnfMatcher{grammar: grammar, tokens: tokens}
+	return containsInt(m.match(ebnfName(production), 0), len(tokens))
+}
+
+func TestGrammar__Productions(t *testing.T) {
+	grammar := loadGrammar(t)
+	var check func(x ebnfExpr)
+	check = func(x ebnfExpr) {
+		switch x := x.(type) {
+		case ebnfName:
+			if _, ok := grammar[string(x)]; !ok {
+				t.Errorf("production %s is not defined", x)
+			}
+		case ebnfAlternative:
+			for _, e := range x {
+				check(e)
+			}
+		case ebnfSequence:
+			for _, e := range x {
+				check(e)
+			}
+		case ebnfOption:
+			check(x.body)
+		case ebnfRepetition:
+			check(x.body)
+		}
+	}
+	for _, x := range grammar {
+		check(x)
+	}
+}
+
+func TestGrammar__Corpus(t *testing.T) {
+	grammar := loadGrammar(t)
+	paths, err := filepath.Glob("testdata/*.dqdl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no corpus found")
+	}
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			bs, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ParseFile(path, strings.NewReader(string(bs))); err != nil {
+				t.Fatalf("parser rejects the corpus: %s", err)
+			}
+			if !grammarAccepts(t, grammar, "File", string(bs)) {
+				t.Error("grammar rejects the corpus")
+			}
+		})
+	}
+}
+
+func TestGrammar__AgreesWithParser(t *testing.T) {
+	grammar := loadGrammar(t)
+	cases := []string{
+		`Rules = []`,
+		`Rules = [ IsUnique "a", ]`,
+		`Rules = [ , ]`,
+		`Rules = [ IsUnique "a" IsComplete "a" ]`,
+		`Rules = [ IsUnique "a" ] Rules = [ IsComplete "a" ]`,
+		`Rules = [ ColumnValues "a" > (now() - 3 days) ]`,
+		`Rules = [ ColumnValues "a" > (now() - 3) ]`,
+		`Rules = [ ColumnValues "a" between (now() - 3 days) and 3 ]`,
+		`Rules = [ ColumnValues "a" in [] ]`,
+		`Rules = [ ColumnValues "a" in ["a", 1, true, 3 days] with threshold between 0.1 and 0.2 ]`,
+		`Rules = [ ColumnValues "a" matches 1 ]`,
+		`Rules = [ ColumnValues "a" > 1 with threshold > 0.5 ]`,
+		`Rules = [ (IsUnique "a") and (IsComplete "a"), RowCount > 0 ]`,
+		`Rules = [ (IsUnique "a") and (IsComplete "a") or (RowCount > 0), RowCount > 0 ]`,
+		`Rules = [ ((IsUnique "a")) and (IsComplete "a"), RowCount > 0 ]`,
+		`Rules = [ IsUnique "a" matches "b" with threshold in ["c"] ]`,
+		`Rules = [ DataFreshness "a" <= 1.5 hours ]`,
+		`Rules = [ CustomSql2 "a", Custom_Rule_3 > 0 ]`,
+		`Rules = [ _Custom "a" ]`,
+		`Rules = [ 2Custom "a" ]`,
+	}
+	for _, input := range cases {
+		t.Run(input, func(t *testing.T) {
+			_, err := ParseFile("test.dqdl", strings.NewReader(input))
+			parsed := err == nil
+			if accepted := grammarAccepts(t, grammar, "File", input); accepted != parsed {
+				t.Errorf("grammar accepts: %v, parser accepts: %v (%v)", accepted, parsed, err)
+			}
+		})
+	}
+}
+
+func TestGrammar__Tokens(t *testing.T) {
+	grammar := loadGrammar(t)
+	used := make(map[string]bool)
+	for name, x := range grammar {
+		if unicode.IsUpper(rune(name[0])) {
+			terminals(x, used)
+		}
+	}
+	for typ := token.ILLEGAL; typ <= token.COMMENT; typ++ {
+		str := typ.String()
+		if str == "unknown token" || strings.ToUpper(str) == str && unicode.IsLetter(rune(str[0])) {
+			// token classes and tokens without a spelling.
+			continue
+		}
+		if typ == token.PLUS || typ == token.MULTIPLY || typ == token.DIVIDE {
+			// scanned, but not accepted by the parser.
+			continue
+		}
+		if !used[str] {
+			t.Errorf("token %q is not used in grammar.ebnf", str)
+		}
+		delete(used, str)
+	}
+	for str := range used {
+		t.Errorf("terminal %q in grammar.ebnf is not a token", str)
+	}
+}
@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// LazyFile は ScanFile の結果です。ルールセットの中身は最初にアクセスされた時に構文解析されます。
+// A LazyFile is the result of ScanFile. It knows where each ruleset is,
+// but the rules of a ruleset are parsed only when it is first accessed.
+type LazyFile struct {
+	Filename string
+	Source   string
+	Rulesets []*LazyRuleset
+}
+
+// LazyRuleset は構文解析を遅延したルールセットです。
+// A LazyRuleset is a ruleset whose rules have not been parsed yet.
+type LazyRuleset struct {
+	DeclPos         token.Pos // position of "Rules" keyword
+	RightBracketPos token.Pos // position of the matching "]"
+
+	filename string
+	input    string    // source up to the end of the ruleset line
+	start    token.Pos // where to start parsing, including the description
+	opts     []Option
+
+	once    sync.Once
+	ruleset *ast.Ruleset
+	err     error
+}
+
+// Text はルールセットのソースを返します。
+// Text returns the source text from "Rules" to the matching "]".
+func (r *LazyRuleset) Text() string {
+	return r.input[r.DeclPos.Index : r.RightBracketPos.Index+1]
+}
+
+// Ruleset はルールセットの構文解析を行い、その結果を返します。構文解析は初回の呼び出し時にのみ行われます。
+// Ruleset parses the ruleset on the first call and returns the result.
+// Later calls return the same result. It is safe for concurrent use.
+func (r *LazyRuleset) Ruleset() (*ast.Ruleset, error) {
+	r.once.Do(func() {
+		p := newParser(r.filename, r.input, r.opts)
+		p.filename = r.filename
+		p.scan(newLexerAt(r.filename, r.input, r.start))
+		r.err = p.run(context.Background(), func() (err error) {
+			r.ruleset, err = p.parseRuleset()
+			return err
+		})
+	})
+	return r.ruleset, r.err
+}
+
+// ScanFile はルールセットの位置のみを高速に走査します。
+// ScanFile finds the rulesets of a DQDL file by matching brackets, without
+// parsing the rules. This is much cheaper than ParseFile when only the
+// number or location of the rulesets is needed. Syntax errors inside a
+// ruleset are reported by LazyRuleset.Ruleset; ScanFile itself only fails
+// on unbalanced brackets or unterminated strings.
+func ScanFile(filename string, reader io.Reader, opts ...Option) (*LazyFile, error) {
+	input, err := readSource(reader)
+	if err != nil {
+		return nil, err
+	}
+	file := &LazyFile{
+		Filename: filename,
+		Source:   input,
+	}
+	pos := token.Pos{Index: 0, Line: 1, Column: 1}
+	if strings.HasPrefix(input, byteOrderMark) {
+		// the first column follows the byte order mark, as in the scanner.
+		pos.Index = len(byteOrderMark)
+	}
+	// next parsing starts where the previous ruleset ends, so that the
+	// comments before "Rules" become the description of the ruleset.
+	start := pos
+	var declPos, openPos token.Pos
+	depth := 0
+	for pos.Index < len(input) {
+		c := input[pos.Index]
+		switch {
+		case c == '#':
+			end := strings.IndexByte(input[pos.Index:], '\n')
+			if end < 0 {
+				end = len(input) - pos.Index
+			}
+			pos = advance(input, pos, end)
+			continue
+		case c == '"':
+			end := strings.IndexByte(input[pos.Index+1:], '"')
+			if end < 0 {
+				return nil, &Error{Filename: filename, Pos: pos, Msg: "unterminated string"}
+			}
+			pos = advance(input, pos, end+2)
+			continue
+		case c == '[':
+			if depth == 0 {
+				openPos = pos
+			}
+			depth++
+		case c == ']':
+			if depth == 0 {
+				return nil, &Error{Filename: filename, Pos: pos, Msg: "unexpected `]`"}
+			}
+			depth--
+			if depth == 0 && declPos.IsValid() {
+				end := pos.Index + 1
+				if i := strings.IndexByte(input[end:], '\n'); i >= 0 {
+					end += i
+				} else {
+					end = len(input)
+				}
+				file.Rulesets = append(file.Rulesets, &LazyRuleset{
+					DeclPos:         declPos,
+					RightBracketPos: pos,
+					filename:        filename,
+					input:           input[:end],
+					start:           start,
+					opts:            opts,
+				})
+				declPos = token.NoPos
+				pos = advance(input, pos, end-pos.Index)
+				start = pos
+				continue
+			}
+		case depth == 0 && strings.HasPrefix(input[pos.Index:], "Rules"):
+			declPos = pos
+			pos = advance(input, pos, len("Rules"))
+			continue
+		}
+		pos = advance(input, pos, 1)
+	}
+	if depth > 0 {
+		return nil, &Error{Filename: filename, Pos: pos, Msg: fmt.Sprintf("`[` opened at %s is never closed", openPos)}
+	}
+	return file, nil
+}
+
+// advance returns the position n bytes after pos.
+func advance(input string, pos token.Pos, n int) token.Pos {
+	return pos.Advance(input[pos.Index : pos.Index+n])
+}
@@ -1,7 +1,6 @@
 package parser
 
 import (
-	"context"
 	"testing"
 
 	"github.com/mashiike/go-dqdl/token"
@@ -240,22 +239,54 @@ func TestLexer(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			t.Logf("input: %s", c.input)
-			ctx, cancel := context.WithCancel(context.Background())
-			defer cancel()
+			r := NewTokenReader(c.input)
+			for i, expected := range c.tokens {
+				peeked, ok := r.Peek()
+				if !ok {
+					t.Fatalf("expected %d token is %s, got the end of tokens", i, expected)
+				}
+				assertToken(t, expected, peeked)
+				actual, _ := r.Next()
+				assertToken(t, expected, actual)
+				// a token pushed back is read again.
+				r.Push(actual)
+				actual, _ = r.Next()
+				assertToken(t, expected, actual)
+			}
+			if actual, ok := r.Next(); ok {
+				t.Error("unexpected token:", actual)
+			}
+		})
+		t.Run(c.name+"/nextToken", func(t *testing.T) {
 			l := newLexer(c.name, c.input)
-			cleanup := l.run(ctx)
-			defer cleanup()
 			for i, expected := range c.tokens {
-				actual, ok := <-l.tokens
+				actual, ok := l.nextToken()
 				if !ok {
-					t.Fatalf("expected %d token is %s, got closed channel", i, expected)
+					t.Fatalf("expected %d token is %s, got the end of tokens", i, expected)
 				}
 				assertToken(t, expected, actual)
-
 			}
-			for actual := range l.tokens {
+			if actual, ok := l.nextToken(); ok {
 				t.Error("unexpected token:", actual)
 			}
 		})
 	}
 }
+
+func TestLexer__PositionBeforeNewline(t *testing.T) {
+	// the number ends before the newline, which the lexer backs up over.
+	l := newLexer("position", "RowCount > 1\nIsUnique \"id\"")
+	var number token.Token
+	for {
+		tok, ok := l.nextToken()
+		if !ok || tok.Type == token.EOF {
+			break
+		}
+		if tok.Type == token.NUMBER {
+			number = tok
+		}
+	}
+	if want := (token.Pos{Index: 12, Line: 1, Column: 13}); number.End != want {
+		t.Errorf("got end %v, want %v", number.End, want)
+	}
+}
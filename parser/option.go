@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"github.com/mashiike/go-dqdl/diag"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// Option は構文解析の挙動を変更するためのオプションです。
+// Option configures the behavior of the parser.
+type Option func(*config)
+
+type config struct {
+	comments    bool         // attach comments to the syntax tree
+	concurrency int          // number of files parsed at once by ParseDir
+	dialect     Dialect      // grammar accepted, see WithDialect
+	dialects    dialectTable // features of the dialects, dialectFeatures but in tests
+	strict      bool         // reject what Glue does not accept, see WithStrictGlueCompat
+	fileSet     *token.FileSet
+	progress    func(Progress)
+	warnings    func(diag.Diagnostic) // see WithWarnings
+	recovery    func(diag.Diagnostic) // see WithErrorRecovery
+	maxErrors   int                   // syntax errors recovered from at most, see WithMaxErrors
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{
+		comments: true,
+		dialects: dialectFeatures,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithComments はコメントを構文木に含めるかどうかを指定します。デフォルトは true です。
+// WithComments sets whether comments are attached to the syntax tree. The default is true.
+func WithComments(enabled bool) Option {
+	return func(c *config) {
+		c.comments = enabled
+	}
+}
+
+// WithoutComments はコメントを構文木に含めません。WithComments(false) と同じです。
+// WithoutComments drops comments while parsing, which saves the work and
+// the memory of attaching them when the tree is only validated. The
+// positions of the nodes are the same as with comments. It is the same as
+// WithComments(false).
+func WithoutComments() Option {
+	return WithComments(false)
+}
+
+// WithFileSet は構文解析したファイルを fset に登録します。
+// WithFileSet registers every file parsed by ParseFile and ParseDir in fset,
+// so positions of the resulting trees can be stored as token.FilePos values.
+func WithFileSet(fset *token.FileSet) Option {
+	return func(c *config) {
+		c.fileSet = fset
+	}
+}
@@ -5,48 +5,225 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"runtime"
 	"strings"
 
 	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/diag"
 	"github.com/mashiike/go-dqdl/token"
 )
 
 type parser struct {
-	input                string
-	lexer                *lexer
-	stack                []token.Token
+	ctx                  context.Context
+	done                 <-chan struct{} // ctx.Done(), read for each token
+	cfg                  *config
+	filename             string
+	lexer                *lexer         // source of the input, for error messages
+	tokens               TokenReader    // the tokens given by the caller, nil to read lexer
+	stack                []token.Token  // tokens pushed back and not read again, read before tokens
+	comments             []*ast.Comment // comments read, see collect
+	nextComment          int            // index in comments of the next comment popped
 	fileCommentGroups    []ast.CommentGroup
 	rulesetCommentGroups []ast.CommentGroup
+	bare                 bool // a rule type on a new line starts a new rule
+	strict               strictChecker
+	delims               delimiters
+	commas               []token.Pos       // commas after the rules of the current ruleset
+	ruleCapacity         int               // rules and commas reserved for the current ruleset
+	eof                  token.Pos         // position of the EOF token, once read
+	warnings             []diag.Diagnostic // reported by run if the parse succeeds
+	syntaxErrors         []diag.Diagnostic // recovered from, reported by run if the parse succeeds
+
+	// allocators of the most frequent nodes
+	rules          slab[ast.Rule]
+	idents         slab[ast.Ident]
+	stringParams   slab[ast.StringParameter]
+	numberParams   slab[ast.NumberParameter]
+	commentNodes   slab[ast.Comment]
+	paramLists     slab[[1]ast.Parameter]
+	positions      slab[token.Pos] // see posPtr
+	comparisons    slab[ast.ComparisonExpression]
+	ins            slab[ast.InExpression]
+	betweens       slab[ast.BetweenExpression]
+	matches        slab[ast.MatchesExpression]
+	thresholds     slab[ast.WithThresholdExpression]
+	combinedRules  slab[ast.CombinedRule]
+	durationParams slab[ast.DurationParameter]
+	dateParams     slab[ast.DateParameter]
+
+	// buffers of the values and commas of an in-list
+	inValues []ast.Parameter
+	inCommas []token.Pos
 }
 
-func ParseFile(filename string, reader io.Reader) (*ast.File, error) {
-	bs, err := io.ReadAll(reader)
+func newParser(name, input string, opts []Option) *parser {
+	p := &parser{
+		ctx: context.Background(),
+		cfg: newConfig(opts),
+	}
+	p.scan(newLexer(name, input))
+	return p
+}
+
+// scan makes p read the tokens of l.
+func (p *parser) scan(l *lexer) {
+	p.lexer, p.tokens = l, nil
+}
+
+// ParseFile はファイル全体についての構文解析を行います。
+// ParseFile parses the source of a DQDL file.
+func ParseFile(filename string, reader io.Reader, opts ...Option) (*ast.File, error) {
+	return ParseFileContext(context.Background(), filename, reader, opts...)
+}
+
+// ParseFileContext はコンテキストを指定してファイル全体についての構文解析を行います。
+// ParseFileContext is like ParseFile but aborts with ctx.Err() when ctx is done.
+func ParseFileContext(ctx context.Context, filename string, reader io.Reader, opts ...Option) (*ast.File, error) {
+	input, err := readSource(reader)
 	if err != nil {
 		return nil, err
 	}
-	input := string(bs)
-	p := &parser{
-		input: input,
-		lexer: newLexer(filename, input),
+	p := newParser(filename, input, opts)
+	p.filename = filename
+	var file *ast.File
+	err = p.run(ctx, func() (err error) {
+		file, err = p.parseFile()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	file.Filename = filename
+	file.Source = input
+	if p.cfg.fileSet != nil {
+		p.cfg.fileSet.AddFile(filename, input)
+	}
+	return file, nil
+}
+
+// readSource reads the whole input of r. It reads into the string
+// returned, rather than into bytes to copy, and reserves the length of r
+// if known, e.g. of a strings.Reader.
+func readSource(r io.Reader) (string, error) {
+	var b strings.Builder
+	if l, ok := r.(interface{ Len() int }); ok {
+		b.Grow(l.Len())
+	}
+	if _, err := io.Copy(&b, r); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// ParseReader は r から読み込みながらファイル全体についての構文解析を行います。
+// ParseReader is like ParseFile but reads r incrementally instead of
+// loading it into memory at once, for very large generated files. Only a
+// window of the input is kept while parsing, so the returned file has no
+// Source and is not registered in the FileSet given by WithFileSet. An
+// error reading r is returned as it is.
+func ParseReader(filename string, r io.Reader, opts ...Option) (*ast.File, error) {
+	return ParseReaderContext(context.Background(), filename, r, opts...)
+}
+
+// ParseReaderContext はコンテキストを指定して r から読み込みながら構文解析を行います。
+// ParseReaderContext is like ParseReader but aborts with ctx.Err() when ctx
+// is done.
+func ParseReaderContext(ctx context.Context, filename string, r io.Reader, opts ...Option) (*ast.File, error) {
+	p := newParser(filename, "", opts)
+	p.filename = filename
+	p.scan(newReaderLexer(filename, r))
+	var file *ast.File
+	err := p.run(ctx, func() (err error) {
+		file, err = p.parseFile()
+		return err
+	})
+	if readErr := p.lexer.scanner.Err(); readErr != nil {
+		return nil, readErr
 	}
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	waiter := p.lexer.run(ctx)
-	file, err := p.parseFile()
 	if err != nil {
-		cancel()
-		p.discardUntilToken(token.EOF)
-		waiter()
 		return nil, err
 	}
 	file.Filename = filename
-	p.discardUntilToken(token.EOF)
-	waiter()
 	return file, nil
 }
 
+// run は構文解析を実行します。字句解析は構文解析と同じゴルーチンで必要に応じて行われます。
+// run calls parse. The lexer runs on demand in the same goroutine, as pop
+// pulls the tokens. If ctx is done before parsing completes, ctx.Err() is
+// returned. A panic in parse is returned as an error wrapping
+// ErrInternal, so that hostile input never crashes the caller.
+func (p *parser) run(ctx context.Context, parse func() error) (err error) {
+	p.ctx, p.done = ctx, ctx.Done()
+	defer recoverInternal(p.lexer.name, &err)
+	if err := p.checkDialect(); err != nil {
+		return err
+	}
+	err = parse()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if err != nil {
+		return p.explainDelimiters(err)
+	}
+	if p.strict.err != nil {
+		return p.strict.err
+	}
+	for _, d := range p.syntaxErrors {
+		p.cfg.recovery(d)
+	}
+	for _, w := range p.warnings {
+		p.cfg.warnings(w)
+	}
+	return nil
+}
+
 var errNoRulesFound = errors.New("no rules found")
 
+// ErrInternal は構文解析器の内部エラーを表します。
+// ErrInternal is wrapped by the error returned when the parser panics on
+// an input. It indicates a bug in the parser; please report the input.
+var ErrInternal = errors.New("parser: internal error")
+
+// InternalError は構文解析器のパニックから変換されたエラーです。
+// An InternalError is the error returned when the parser panics on an
+// input. It wraps ErrInternal.
+type InternalError struct {
+	Name  string      // name of the input
+	Value interface{} // value passed to panic
+	Site  string      // function and line of the panic, e.g. "parser.(*parser).parseRule:601"
+}
+
+func (e *InternalError) Error() string {
+	return fmt.Sprintf("%v parsing %s: %v", ErrInternal, e.Name, e.Value)
+}
+
+func (e *InternalError) Unwrap() error { return ErrInternal }
+
+// recoverInternal converts a panic while parsing the input named name
+// into an *InternalError stored in *err. It must be deferred directly.
+func recoverInternal(name string, err *error) {
+	if r := recover(); r != nil {
+		*err = &InternalError{Name: name, Value: r, Site: panicSite()}
+	}
+}
+
+// panicSite returns the function and line that panicked, the first frame
+// of the stack of a deferred call that is not in the runtime nor
+// recoverInternal.
+func panicSite() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, "runtime.") {
+			return fmt.Sprintf("%s:%d", f.Function[strings.LastIndex(f.Function, "/")+1:], f.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
 func (p *parser) parseFile() (*ast.File, error) {
 	p.fileCommentGroups = nil
 	file := &ast.File{}
@@ -69,123 +246,131 @@ func (p *parser) parseFile() (*ast.File, error) {
 		p.push(t)
 	}
 	file.CommentGroups = p.fileCommentGroups
+	file.EOF = p.eof
 	return file, nil
 }
 
-// PaserRuleset はルールセットについての構文解析を行います。
+// ParseRuleset はルールセットについての構文解析を行います。
 // ParseRuleset parses a ruleset.
-func ParseRuleset(rulesetStr string) (*ast.Ruleset, error) {
-	p := &parser{
-		input: rulesetStr,
-		lexer: newLexer("ruleset", rulesetStr),
-	}
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	waiter := p.lexer.run(ctx)
-	ruleset, err := p.parseRuleset()
+func ParseRuleset(rulesetStr string, opts ...Option) (*ast.Ruleset, error) {
+	return ParseRulesetContext(context.Background(), rulesetStr, opts...)
+}
+
+// ParseRulesetContext はコンテキストを指定してルールセットについての構文解析を行います。
+// ParseRulesetContext is like ParseRuleset but aborts with ctx.Err() when ctx is done.
+func ParseRulesetContext(ctx context.Context, rulesetStr string, opts ...Option) (*ast.Ruleset, error) {
+	p := newParser("ruleset", rulesetStr, opts)
+	var ruleset *ast.Ruleset
+	err := p.run(ctx, func() (err error) {
+		ruleset, err = p.parseRuleset()
+		return err
+	})
 	if err != nil {
-		cancel()
-		p.discardUntilToken(token.EOF)
-		waiter()
 		return nil, err
 	}
-	p.discardUntilToken(token.EOF)
-	waiter()
 	return ruleset, nil
 }
 
 func (p *parser) parseRuleset() (*ast.Ruleset, error) {
 	ruleset := &ast.Ruleset{}
 	var rulesFound bool
-	var storedComments ast.CommentGroup
-	var lastCommentPos token.Pos
+	var comments commentGrouper
 	for {
 		t, ok := p.pop()
 		if !ok {
-			return nil, errors.New("unexpected EOF")
+			if rulesFound && p.cfg.recovery != nil && p.eof.IsValid() {
+				// the last rule ended at the EOF.
+				return p.unclosedRuleset(ruleset, token.Token{Type: token.EOF, Start: p.eof, End: p.eof})
+			}
+			return nil, errUnexpectedEOF
 		}
 		switch t.Type {
 		case token.EOF:
 			if !rulesFound {
 				return nil, errNoRulesFound
 			}
-			return nil, fmt.Errorf("syntax error near %s `%s`, missing `]`", t.Start, p.nearString(t.Start))
+			if p.cfg.recovery != nil {
+				return p.unclosedRuleset(ruleset, t)
+			}
+			return nil, p.causef(errUnclosed, t.Start, "missing `]`")
 		case token.RIGHT_BRACKET:
 			if !rulesFound {
-				return nil, fmt.Errorf("syntax error near %s `%s`, unexpected `]`", t.Start, p.nearString(t.Start))
+				return nil, p.errorf(t.Start, "unexpected `]`")
 			}
 			ruleset.RightBracketPos = t.Start
+			ruleset.CommaPositions = p.commas
 			lc, err := p.parseLineComments(t.Start)
 			if err != nil {
 				return nil, err
 			}
-			ruleset.Comments = append(ruleset.Comments, lc...)
+			ruleset.Comments = appendComments(ruleset.Comments, lc)
 			return ruleset, nil
 		case token.RULES:
+			if rulesFound && p.cfg.recovery != nil {
+				return p.unclosedRuleset(ruleset, t)
+			}
 			ruleset.DeclPos = t.Start
 			expetedEqual, ok := p.pop()
 			if !ok {
-				return nil, fmt.Errorf("syntax error near %s `%s`, unexpected EOF", t.Start, p.nearString(t.Start))
+				return nil, p.causef(errUnexpectedEOF, t.Start, "unexpected EOF")
 			}
 			if expetedEqual.Type != token.EQUAL {
-				return nil, fmt.Errorf("syntax error near %s `%s`, must equal after Rules", t.Start, p.nearString(t.Start))
+				return nil, p.errorf(t.Start, "must equal after Rules")
 			}
+			ruleset.EqualPos = expetedEqual.Start
 			expectedLeftBracket, lc, ok := p.popWithLineComment()
 			if !ok {
-				return nil, fmt.Errorf("syntax error near %s `%s`, unexpected EOF", t.Start, p.nearString(t.Start))
+				return nil, p.causef(errUnexpectedEOF, t.Start, "unexpected EOF")
 			}
 			if expectedLeftBracket.Type != token.LEFT_BRACKET {
-				return nil, fmt.Errorf("syntax error near %s `%s`, missing `[`", t.Start, p.nearString(t.Start))
+				return nil, p.errorf(t.Start, "missing `[`")
 			}
 			ruleset.LeftBracketPos = expectedLeftBracket.Start
+			p.commas = nil
+			p.ruleCapacity = ruleCapacity(p.lexer.scanner.Source(expectedLeftBracket.End.Index))
 			ruleset.Comments = lc
-			if lastCommentPos.IsValid() && lastCommentPos.Line+1 == t.Start.Line {
-				ruleset.Description = storedComments
-				storedComments = nil
-				lastCommentPos = token.NoPos
+			if group, lead := comments.take(t.Start.Line); lead {
+				ruleset.Description = group
 			}
 			rulesFound = true
 		case token.COMMENT:
 			if rulesFound {
 				p.push(t)
 				p.rulesetCommentGroups = nil
+				cp := p.checkpoint(t.Start)
 				rule, err := p.parseRule(true, false)
 				if err != nil {
-					return nil, err
+					if rule, err = p.badRule(cp, err); err != nil {
+						return nil, err
+					}
+				}
+				if rule != nil {
+					ruleset.Rules = appendReserving(ruleset.Rules, p.ruleCapacity, rule)
 				}
-				ruleset.Rules = append(ruleset.Rules, rule)
 				if len(p.rulesetCommentGroups) > 0 {
 					ruleset.InnerComments = p.rulesetCommentGroups
 				}
 				continue
 			}
-			if !lastCommentPos.IsValid() || lastCommentPos.Line+1 == t.Start.Line {
-				storedComments = append(storedComments, &ast.Comment{
-					SharpPos: t.Start,
-					Text:     t.Value,
-				})
-				lastCommentPos = t.Start
-				continue
-			}
-			p.fileCommentGroups = append(p.fileCommentGroups, storedComments)
-			storedComments = []*ast.Comment{
-				{
-					SharpPos: t.Start,
-					Text:     t.Value,
-				},
+			if group := comments.add(p); len(group) > 0 {
+				p.fileCommentGroups = append(p.fileCommentGroups, group)
 			}
-			lastCommentPos = t.Start
 		default:
 			if !rulesFound {
-				return nil, fmt.Errorf("syntax error near %s `%s`, unexpected `%s`", t.Start, p.nearString(t.Start), t.Type)
+				return nil, p.errorf(t.Start, "unexpected `%s`", t.Type)
 			}
 			p.push(t)
 			p.rulesetCommentGroups = nil
+			cp := p.checkpoint(t.Start)
 			rule, err := p.parseRule(true, false)
 			if err != nil {
-				return nil, err
+				if rule, err = p.badRule(cp, err); err != nil {
+					return nil, err
+				}
+			}
+			if rule != nil {
+				ruleset.Rules = appendReserving(ruleset.Rules, p.ruleCapacity, rule)
 			}
-			ruleset.Rules = append(ruleset.Rules, rule)
 			if len(p.rulesetCommentGroups) > 0 {
 				ruleset.InnerComments = p.rulesetCommentGroups
 			}
@@ -193,65 +378,318 @@ func (p *parser) parseRuleset() (*ast.Ruleset, error) {
 	}
 }
 
+// ruleCapacity estimates the number of rules of a ruleset from src, the
+// input after its `[`, by the commas separating them. As the commas of
+// in-lists count too, it is at most a rule in 16 bytes.
+func ruleCapacity(src string) int {
+	n := strings.Count(src, ",") + 1
+	if max := len(src)/16 + 1; n > max {
+		n = max
+	}
+	return n
+}
+
 // ParseRule は単一のルールについての構文解析を行います。
 // ParseRule parses a single rule.
-func ParseRule(ruleStr string) (ast.RuleDecl, error) {
-	p := &parser{
-		input: ruleStr,
-		lexer: newLexer("rule", ruleStr),
-	}
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	waiter := p.lexer.run(ctx)
-	rule, err := p.parseRule(false, false)
+func ParseRule(ruleStr string, opts ...Option) (ast.RuleDecl, error) {
+	return ParseRuleContext(context.Background(), ruleStr, opts...)
+}
+
+// ParseRuleContext はコンテキストを指定して単一のルールについての構文解析を行います。
+// ParseRuleContext is like ParseRule but aborts with ctx.Err() when ctx is done.
+func ParseRuleContext(ctx context.Context, ruleStr string, opts ...Option) (ast.RuleDecl, error) {
+	p := newParser("rule", ruleStr, opts)
+	var rule ast.RuleDecl
+	err := p.run(ctx, func() (err error) {
+		rule, err = p.parseRule(false, false)
+		return err
+	})
 	if err != nil {
-		cancel()
-		p.discardUntilToken(token.EOF)
-		waiter()
 		return nil, err
 	}
-	p.discardUntilToken(token.EOF)
-	waiter()
 	return rule, nil
 }
 
-func (p *parser) discardUntilToken(tokenType token.TokenType) token.Token {
+// ParseRules はカンマ区切りのルールの一覧についての構文解析を行います。ex: `IsUnique "a", IsComplete "a"`
+// ParseRules parses a bare comma-separated list of rules, without the
+// `Rules = [ ... ]` wrapper. A trailing comma is allowed.
+// It returns no rules and no error for an empty input.
+func ParseRules(rulesStr string, opts ...Option) ([]ast.RuleDecl, error) {
+	return ParseRulesContext(context.Background(), rulesStr, opts...)
+}
+
+// ParseRulesContext はコンテキストを指定してカンマ区切りのルールの一覧についての構文解析を行います。
+// ParseRulesContext is like ParseRules but aborts with ctx.Err() when ctx is done.
+func ParseRulesContext(ctx context.Context, rulesStr string, opts ...Option) ([]ast.RuleDecl, error) {
+	p := newParser("rules", rulesStr, opts)
+	var rules []ast.RuleDecl
+	err := p.run(ctx, func() (err error) {
+		rules, err = p.parseRules()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (p *parser) parseRules() ([]ast.RuleDecl, error) {
+	var rules []ast.RuleDecl
 	for {
 		t, ok := p.pop()
-		if !ok {
-			return token.Token{Type: token.ILLEGAL}
+		if !ok || t.Type == token.EOF {
+			return rules, nil
 		}
-		if t.Type == tokenType {
-			return t
+		if t.Type == token.RIGHT_BRACKET {
+			return nil, p.errorf(t.Start, "unexpected `]`")
+		}
+		p.push(t)
+		cp := p.checkpoint(t.Start)
+		rule, err := p.parseRule(true, false)
+		if err != nil {
+			if rule, err = p.badRule(cp, err); err != nil {
+				return nil, err
+			}
+		}
+		if rule != nil {
+			rules = append(rules, rule)
+		}
+	}
+}
+
+// ParseBareRules は `Rules = [ ... ]` で囲まれていない古い形式のルールの一覧についての構文解析を行います。
+// ParseBareRules parses a legacy list of rules that is not wrapped in
+// `Rules = [ ... ]`. Rules are separated by commas or by starting the next
+// rule on a new line. The rules are returned in a synthetic ruleset whose
+// Legacy field is set and whose bracket positions are invalid.
+func ParseBareRules(src string, opts ...Option) (*ast.Ruleset, error) {
+	return ParseBareRulesContext(context.Background(), src, opts...)
+}
+
+// ParseBareRulesContext はコンテキストを指定して古い形式のルールの一覧についての構文解析を行います。
+// ParseBareRulesContext is like ParseBareRules but aborts with ctx.Err() when ctx is done.
+func ParseBareRulesContext(ctx context.Context, src string, opts ...Option) (*ast.Ruleset, error) {
+	p := newParser("rules", src, opts)
+	if p.cfg.strict {
+		return nil, p.errorf(token.Pos{Line: 1, Column: 1}, "Glue does not accept rules without `Rules = [...]`")
+	}
+	p.bare = true
+	var rules []ast.RuleDecl
+	err := p.run(ctx, func() (err error) {
+		rules, err = p.parseRules()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, errNoRulesFound
+	}
+	return &ast.Ruleset{
+		Rules:          rules,
+		CommaPositions: p.commas,
+		InnerComments:  p.rulesetCommentGroups,
+		Legacy:         true,
+	}, nil
+}
+
+// ParseExpression は単一の表現についての構文解析を行います。ex: `between 1 and 5`
+// ParseExpression parses a single expression such as `> 0.5` or
+// `in ["a", "b"] with threshold > 0.9`, without a rule type or parameters.
+// Comments following parts of the expression are attached to the expression nodes.
+func ParseExpression(exprStr string, opts ...Option) (ast.Expression, error) {
+	return ParseExpressionContext(context.Background(), exprStr, opts...)
+}
+
+// ParseExpressionContext はコンテキストを指定して単一の表現についての構文解析を行います。
+// ParseExpressionContext is like ParseExpression but aborts with ctx.Err() when ctx is done.
+func ParseExpressionContext(ctx context.Context, exprStr string, opts ...Option) (ast.Expression, error) {
+	p := newParser("expression", exprStr, opts)
+	var expr ast.Expression
+	err := p.run(ctx, func() (err error) {
+		expr, err = p.parseSingleExpression()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return expr, nil
+}
+
+func (p *parser) parseSingleExpression() (ast.Expression, error) {
+	t, ok := p.popSkipComments()
+	if !ok {
+		return nil, errUnexpectedEOF
+	}
+	switch {
+	case t.Type == token.ILLEGAL:
+		return nil, p.errorf(t.Start, "%s", t.Value)
+	case t.Type == token.EOF:
+		return nil, p.causef(errUnexpectedEOF, t.Start, "expression is required: unexpected EOF")
+	case !t.Type.IsExpressionStart():
+		return nil, p.errorf(t.Start, "expression is required: unexpected token `%s`", t.Type)
+	}
+	// NoPos never shares a line with the expression, so that all comments
+	// are attached to the expression nodes instead of being returned.
+	expr, _, err := p.parseExpression(t, token.NoPos, false)
+	if err != nil {
+		return nil, err
+	}
+	t, ok = p.popSkipComments()
+	if !ok {
+		return nil, errUnexpectedEOF
+	}
+	switch t.Type {
+	case token.EOF:
+		return expr, nil
+	case token.ILLEGAL:
+		return nil, p.errorf(t.Start, "%s", t.Value)
+	default:
+		return nil, p.errorf(t.Start, "unexpected token `%s` after expression", t.Type)
+	}
+}
+
+// popSkipComments pops the next token which is not a comment.
+func (p *parser) popSkipComments() (token.Token, bool) {
+	for {
+		t, ok := p.pop()
+		if !ok || t.Type != token.COMMENT {
+			return t, ok
 		}
 	}
 }
 
-func (p *parser) pop() (token.Token, bool) {
+// pop reads the next token. Tokens read for the first time are observed
+// by the checks of the parser; comments are dropped if they are not
+// attached to the tree. ok is false if there is no token, as the token
+// reader has ended or ctx is done.
+func (p *parser) pop() (t token.Token, ok bool) {
+	// A token and a bool do not fit in the result registers, a token
+	// alone does: read returns noToken for none, and pop is inlined.
+	t = p.read()
+	return t, t.Type != noToken
+}
+
+// noToken is the type of the token read returns if there is none.
+const noToken token.TokenType = -1
+
+// read is pop, returning a token of type noToken if there is none.
+func (p *parser) read() token.Token {
 	if len(p.stack) == 0 {
-		t, ok := <-p.lexer.TokenChan()
-		return t, ok
+		for {
+			if p.done != nil {
+				select {
+				case <-p.done:
+					return token.Token{Type: noToken}
+				default:
+				}
+			}
+			var t token.Token
+			if p.tokens != nil {
+				next, ok := p.tokens.Next()
+				if !ok {
+					return token.Token{Type: noToken}
+				}
+				t = next
+			} else if l := p.lexer; !l.done {
+				// as nextToken, without copying the token once more
+				l.scanner.Scan(&t)
+				l.advance(&t)
+			} else {
+				return token.Token{Type: noToken}
+			}
+			if p.cfg.strict {
+				p.strict.observe(p, t)
+			}
+			p.delims.observe(t.Type, t.Start)
+			if t.Type == token.EOF {
+				p.eof = t.Start
+			}
+			if t.Type == token.COMMENT {
+				if !p.cfg.comments {
+					continue
+				}
+				p.collect(t)
+				p.nextComment++
+			}
+			return t
+		}
 	}
 	t := p.stack[len(p.stack)-1]
 	p.stack = p.stack[:len(p.stack)-1]
-	return t, true
+	if t.Type == token.COMMENT {
+		p.nextComment++
+	}
+	return t
+}
+
+// mayPopComment reports whether pop may return a comment next. It is
+// false if the next token is known to be another token without reading
+// it, as it would be pushed back.
+func (p *parser) mayPopComment() bool {
+	if n := len(p.stack); n > 0 {
+		return p.stack[n-1].Type == token.COMMENT
+	}
+	return p.tokens != nil || (p.cfg.comments && p.lexer.mayComment())
+}
+
+// posPtr returns a pointer to pos, for the optional positions of nodes.
+func (p *parser) posPtr(pos token.Pos) *token.Pos {
+	ptr := p.positions.new()
+	*ptr = pos
+	return ptr
 }
 
+// push pushes t back, so that pop returns it again.
 func (p *parser) push(t token.Token) {
+	if t.Type == token.COMMENT {
+		p.nextComment--
+	}
 	p.stack = append(p.stack, t)
 }
 
+// pushBack pushes t and the comments before it back, so that the comments
+// are popped first.
+func (p *parser) pushBack(t token.Token, comments ast.CommentGroup) {
+	p.push(t)
+	for i := len(comments) - 1; i >= 0; i-- {
+		c := comments[i]
+		p.push(token.Token{
+			Type:  token.COMMENT,
+			Value: c.Text,
+			Start: c.Pos(),
+			End:   c.End(),
+		})
+	}
+}
+
+// errorf は指定された位置の構文エラーを返します。
+// errorf returns a syntax error at pos.
+func (p *parser) errorf(pos token.Pos, format string, args ...interface{}) error {
+	return &Error{
+		Filename: p.filename,
+		Pos:      pos,
+		Msg:      fmt.Sprintf("syntax error near `%s`, ", p.nearString(pos)) + fmt.Sprintf(format, args...),
+	}
+}
+
+// causef is like errorf, and the error wraps cause.
+func (p *parser) causef(cause error, pos token.Pos, format string, args ...interface{}) error {
+	err := p.errorf(pos, format, args...).(*Error)
+	err.cause = cause
+	return err
+}
+
 // nearString は指定された位置のトークンから20文字分の文字列を返します。
 // nearString returns a string of 20 characters from the specified position of the token.
 func (p *parser) nearString(pos token.Pos) string {
-	var str string
-	if pos.Index-1 < 0 {
-		str = p.input
-	} else {
-		str = p.input[pos.Index-1:]
+	offset := pos.Index - 1
+	if offset < 0 {
+		offset = 0
 	}
+	str := p.lexer.scanner.Source(offset)
 	if strings.ContainsRune(str, '\n') {
-		str = str[:strings.IndexRune(str, '\n')]
+		str = strings.TrimSuffix(str[:strings.IndexRune(str, '\n')], "\r")
 	}
 	if len(str) > 20 {
 		str = str[:20] + "..."
@@ -260,30 +698,28 @@ func (p *parser) nearString(pos token.Pos) string {
 }
 
 func (p *parser) parseRule(modeRuleset bool, nested bool) (ast.RuleDecl, error) {
-	rule := &ast.Rule{}
+	rule := p.rules.new()
 	var ruleTypeFound, expressionFound bool
-	var storedComments []*ast.Comment
-	var lastCommentPos token.Pos
+	var comments commentGrouper
 	for {
 		t, ok := p.pop()
 		if !ok {
-			return nil, errors.New("unexpected EOF")
+			return nil, errUnexpectedEOF
 		}
 		switch t.Type {
 		case token.LEFT_PAREN:
 			if nested {
 				// 2 or more nested rules are not allowed
-				return nil, fmt.Errorf("syntax error near %s: `%s`, deep nested rule is not allowed", t.Start, p.nearString(t.Start))
+				return nil, p.errorf(t.Start, "deep nested rule is not allowed")
 			}
-			if len(storedComments) > 0 {
-				if lastCommentPos.IsValid() && lastCommentPos.Line+1 == t.Start.Line {
-					rule.Description = append(rule.Description, storedComments...)
-				} else {
-					p.rulesetCommentGroups = append(p.rulesetCommentGroups, storedComments)
+			if ruleTypeFound {
+				if p.bare && t.Start.Line > rule.Type.Pos().Line {
+					p.pushBack(t, comments.flush())
+					return rule, nil
 				}
+				return nil, p.errorf(t.Start, "unexpected `(`")
 			}
-			storedComments = nil
-			lastCommentPos = token.NoPos
+			p.attachDescription(rule, &comments, t.Start.Line)
 			p.push(t)
 			r, err := p.parseCombinedRule(rule, modeRuleset)
 			if err != nil {
@@ -291,82 +727,76 @@ func (p *parser) parseRule(modeRuleset bool, nested bool) (ast.RuleDecl, error)
 			}
 			return r, nil
 		case token.ILLEGAL:
-			return nil, fmt.Errorf("syntax error near %s `%s`, %s", t.Start, p.nearString(t.Start), t.Value)
+			return nil, p.errorf(t.Start, "%s", t.Value)
 		case token.EOF:
-			if len(storedComments) > 0 {
-				p.rulesetCommentGroups = append(p.rulesetCommentGroups, storedComments)
+			if group := comments.flush(); len(group) > 0 {
+				p.rulesetCommentGroups = append(p.rulesetCommentGroups, group)
 			}
 			if !ruleTypeFound {
-				return nil, fmt.Errorf("syntax error near %s `%s`, RuleType is required: unexpexted EOF", t.Start, p.nearString(t.Start))
+				return nil, p.causef(errUnexpectedEOF, t.Start, "RuleType is required: unexpexted EOF")
 			}
 			return rule, nil
 		case token.COMMA, token.RIGHT_BRACKET:
-			if len(storedComments) > 0 {
-				p.rulesetCommentGroups = append(p.rulesetCommentGroups, storedComments)
+			if group := comments.flush(); len(group) > 0 {
+				p.rulesetCommentGroups = append(p.rulesetCommentGroups, group)
 			}
 			if !ruleTypeFound {
-				return nil, fmt.Errorf("syntax error near %s `%s`, RuleType is required: unexpected `,`", t.Start, p.nearString(t.Start))
+				return nil, p.errorf(t.Start, "RuleType is required: unexpected `,`")
 			}
 			if !modeRuleset {
-				return nil, fmt.Errorf("syntax error near %s: `%s`, parse mode is single rule", t.Start, p.nearString(t.Start))
+				return nil, p.errorf(t.Start, "parse mode is single rule")
 			}
 			if t.Type == token.RIGHT_BRACKET {
 				p.push(t)
+			} else {
+				p.commas = appendReserving(p.commas, p.ruleCapacity, t.Start)
 			}
 			return rule, nil
 		case token.IDENT:
 			if ruleTypeFound {
-				return nil, fmt.Errorf("syntax error near %s: `%s`, RuleType is already defined", t.Start, p.nearString(t.Start))
-			}
-			rule.Type = &ast.Ident{
-				NamePos: t.Start,
-				Name:    t.Value,
-			}
-			ruleTypeFound = true
-			if len(storedComments) > 0 {
-				if lastCommentPos.IsValid() && lastCommentPos.Line+1 == t.Start.Line {
-					rule.Description = append(rule.Description, storedComments...)
-				} else {
-					p.rulesetCommentGroups = append(p.rulesetCommentGroups, storedComments)
+				if p.bare && !nested && t.Start.Line > rule.Type.Pos().Line {
+					p.pushBack(t, comments.flush())
+					return rule, nil
 				}
+				return nil, p.errorf(t.Start, "RuleType is already defined")
 			}
-			storedComments = nil
-			lastCommentPos = token.NoPos
+			rule.Type = p.idents.new()
+			rule.Type.NamePos = t.Start
+			rule.Type.Name = t.Value
+			ruleTypeFound = true
+			p.attachDescription(rule, &comments, t.Start.Line)
 			lineComments, err := p.parseLineComments(t.Start)
 			if err != nil {
 				return nil, err
 			}
 			rule.Type.Comments = lineComments
 		case token.COMMENT:
-			if !lastCommentPos.IsValid() || lastCommentPos.Line+1 == t.Start.Line {
-				storedComments = append(storedComments, &ast.Comment{
-					SharpPos: t.Start,
-					Text:     t.Value,
-				})
-				lastCommentPos = t.Start
-				continue
+			if group := comments.add(p); len(group) > 0 {
+				p.rulesetCommentGroups = append(p.rulesetCommentGroups, group)
 			}
-			p.rulesetCommentGroups = append(p.rulesetCommentGroups, storedComments)
-			storedComments = []*ast.Comment{
-				{
-					SharpPos: t.Start,
-					Text:     t.Value,
-				},
-			}
-			lastCommentPos = t.Start
 		case token.RIGHT_PAREN:
 			if nested {
 				p.push(t)
 				return rule, nil
 			}
-			return nil, fmt.Errorf("syntax error near %s: `%s`, unexpected `)`", t.Start, p.nearString(t.Start))
+			return nil, p.errorf(t.Start, "unexpected `)`")
+		case token.RULES:
+			if p.cfg.recovery != nil && ruleTypeFound && modeRuleset && !nested {
+				// the ruleset misses its `]`, reported by parseRuleset.
+				if group := comments.flush(); len(group) > 0 {
+					p.rulesetCommentGroups = append(p.rulesetCommentGroups, group)
+				}
+				p.push(t)
+				return rule, nil
+			}
+			return nil, p.errorf(t.Start, "unexpected token `%s`", t.Type)
 		default:
 			if t.Type.IsParameterAcceptable() {
 				if !ruleTypeFound {
-					return nil, fmt.Errorf("syntax error near %s `%s`, RuleType is required: unexpected <Parameter>", t.Start, p.nearString(t.Start))
+					return nil, p.errorf(t.Start, "RuleType is required: unexpected <Parameter>")
 				}
 				if expressionFound {
-					return nil, fmt.Errorf("syntax error near %s `%s`, parameters must be before expression", t.Start, p.nearString(t.Start))
+					return nil, p.errorf(t.Start, "parameters must be before expression")
 				}
 				param, lineComments, err := p.parseParameter(t, rule.Type.Pos())
 				if err != nil {
@@ -376,38 +806,62 @@ func (p *parser) parseRule(modeRuleset bool, nested bool) (ast.RuleDecl, error)
 					if len(rule.Type.Comments) > 0 {
 						rule.Comments = rule.Type.Comments
 					}
-					rule.Comments = append(rule.Comments, lineComments...)
+					rule.Comments = appendComments(rule.Comments, lineComments)
+				}
+				if rule.Parameters == nil {
+					// most rules have a single parameter
+					params := p.paramLists.new()
+					params[0] = param
+					rule.Parameters = params[:]
+				} else {
+					rule.Parameters = append(rule.Parameters, param)
 				}
-				rule.Parameters = append(rule.Parameters, param)
 				continue
 			}
 			if t.Type.IsExpressionStart() {
 				if !ruleTypeFound {
-					return nil, fmt.Errorf("syntax error near %s `%s`, RuleType is required: unexpected <Expression>", t.Start, p.nearString(t.Start))
+					return nil, p.errorf(t.Start, "RuleType is required: unexpected <Expression>")
 				}
+				cp := p.checkpoint(t.Start)
 				expr, lc, err := p.parseExpression(t, rule.Pos(), modeRuleset)
 				if err != nil {
-					return nil, err
+					if expr, lc, err = p.badExpr(cp, err); err != nil {
+						return nil, err
+					}
 				}
 				rule.Expression = expr
-				rule.Comments = append(rule.Comments, lc...)
+				rule.Comments = appendComments(rule.Comments, lc)
 				expressionFound = true
 				continue
 			}
-			return nil, fmt.Errorf("syntax error near %s: `%s`, unexpected token `%s`", t.Start, p.nearString(t.Start), t.Type)
+			return nil, p.errorf(t.Start, "unexpected token `%s`", t.Type)
 		}
 	}
 }
 
+// attachDescription attaches the pending comments to rule as its
+// description if they end on the line before line, and to the ruleset as
+// a detached group otherwise.
+func (p *parser) attachDescription(rule *ast.Rule, comments *commentGrouper, line int) {
+	group, lead := comments.take(line)
+	switch {
+	case lead:
+		rule.Description = appendComments(rule.Description, group)
+	case len(group) > 0:
+		p.rulesetCommentGroups = append(p.rulesetCommentGroups, group)
+	}
+}
+
 func (p *parser) parseCombinedRule(firstRule *ast.Rule, modeRuleset bool) (ast.RuleDecl, error) {
-	combined := &ast.CombinedRule{
+	combined := p.combinedRules.new()
+	*combined = ast.CombinedRule{
 		Description: firstRule.Description,
 	}
 	firstRule.Description = nil
 	for {
 		t, ok := p.pop()
 		if !ok {
-			return nil, errors.New("unexpected EOF")
+			return nil, errUnexpectedEOF
 		}
 		switch t.Type {
 		case token.LEFT_PAREN:
@@ -420,40 +874,53 @@ func (p *parser) parseCombinedRule(firstRule *ast.Rule, modeRuleset bool) (ast.R
 			}
 			r, ok := rule.(*ast.Rule)
 			if !ok {
-				return nil, fmt.Errorf("syntax error near %s: `%s`, nested rule must be single rule", t.Start, p.nearString(t.Start))
+				return nil, p.errorf(t.Start, "nested rule must be single rule")
+			}
+			if combined.Rules == nil {
+				// a combined rule has two rules or more
+				combined.Rules = make([]*ast.Rule, 0, 2)
 			}
 			combined.Rules = append(combined.Rules, r)
 			n, ok := p.pop()
 			if !ok {
-				return nil, fmt.Errorf("syntax error near %s: `%s`, unexpected EOF", r.End(), p.nearString(r.End()))
+				return nil, p.causef(errUnexpectedEOF, r.End(), "unexpected EOF")
 			}
 			if n.Type != token.RIGHT_PAREN {
-				return nil, fmt.Errorf("syntax error near %s: `%s`, must close `)`", n.Start, p.nearString(n.Start))
+				return nil, p.causef(errUnclosed, n.Start, "must close `)`")
 			}
 			combined.LastRParenPos = n.Start
 		case token.AND, token.OR:
 			if len(combined.Rules) == 0 {
-				return nil, fmt.Errorf("syntax error near %s: `%s`, unexpected `%s`", t.Start, p.nearString(t.Start), t.Value)
+				return nil, p.errorf(t.Start, "unexpected `%s`", t.Value)
+			}
+			if err := p.require(FeatureCombinedRules, t.Start); err != nil {
+				return nil, err
 			}
 			if combined.Operator != "" && combined.Operator != t.Value {
-				return nil, fmt.Errorf("syntax error near %s: `%s`, can not mixed `%s` and `%s`", t.Start, p.nearString(t.Start), combined.Operator, t.Value)
+				return nil, p.errorf(t.Start, "can not mixed `%s` and `%s`", combined.Operator, t.Value)
 			}
 			combined.Operator = t.Value
+			combined.OperatorPositions = append(combined.OperatorPositions, t.Start)
 		case token.EOF:
 			if len(combined.Rules) == 0 {
-				return nil, fmt.Errorf("syntax error near %s: `%s`, unexpected EOF", t.Start, p.nearString(t.Start))
+				return nil, p.causef(errUnexpectedEOF, t.Start, "unexpected EOF")
 			}
 			if len(combined.Rules) == 1 {
 				combined.Rules[0].Description = combined.Description
 				return combined.Rules[0], nil
 			}
 			return combined, nil
-		case token.COMMA:
+		case token.COMMA, token.RIGHT_BRACKET:
 			if len(combined.Rules) == 0 {
-				return nil, fmt.Errorf("syntax error near %s: `%s`, unexpected `,`", t.Start, p.nearString(t.Start))
+				return nil, p.errorf(t.Start, "unexpected `%s`", t.Type)
 			}
 			if !modeRuleset {
-				return nil, fmt.Errorf("syntax error near %s: `%s`, parse mode is single rule", t.Start, p.nearString(t.Start))
+				return nil, p.errorf(t.Start, "parse mode is single rule")
+			}
+			if t.Type == token.RIGHT_BRACKET {
+				p.push(t)
+			} else {
+				p.commas = appendReserving(p.commas, p.ruleCapacity, t.Start)
 			}
 			if len(combined.Rules) == 1 {
 				combined.Rules[0].Description = combined.Description
@@ -461,23 +928,39 @@ func (p *parser) parseCombinedRule(firstRule *ast.Rule, modeRuleset bool) (ast.R
 			}
 			return combined, nil
 		case token.COMMENT:
-			comment := &ast.Comment{
-				SharpPos: t.Start,
-				Text:     t.Value,
+			combined.Comments = p.extend(combined.Comments)
+		case token.IDENT:
+			if !p.bare || len(combined.Rules) == 0 || t.Start.Line <= combined.LastRParenPos.Line {
+				return nil, p.errorf(t.Start, "unexpected token `%s`", t.Type)
+			}
+			// comments on their own lines belong to the next rule.
+			var next ast.CommentGroup
+			for len(combined.Comments) > 0 && combined.Comments[len(combined.Comments)-1].Pos().Line > combined.LastRParenPos.Line {
+				next = append(ast.CommentGroup{combined.Comments[len(combined.Comments)-1]}, next...)
+				combined.Comments = combined.Comments[: len(combined.Comments)-1 : len(combined.Comments)-1]
 			}
-			combined.Comments = append(combined.Comments, comment)
+			p.pushBack(t, next)
+			if len(combined.Rules) == 1 {
+				combined.Rules[0].Description = combined.Description
+				return combined.Rules[0], nil
+			}
+			return combined, nil
 		default:
-			return nil, fmt.Errorf("syntax error near %s: `%s`, unexpected token `%s`", t.Start, p.nearString(t.Start), t.Type)
+			return nil, p.errorf(t.Start, "unexpected token `%s`", t.Type)
 		}
 	}
 }
 func (p *parser) parseParameter(current token.Token, rulePos token.Pos) (ast.Parameter, ast.CommentGroup, error) {
 	switch current.Type {
 	case token.STRING:
-		param := &ast.StringParameter{
-			LeftQuotePos:  current.Start,
-			Value:         strings.Trim(current.Value, `"`),
-			RightQuotePos: current.Start.AddColumn(len(current.Value) - 1),
+		param := p.stringParams.new()
+		param.LeftQuotePos = current.Start
+		param.Value = strings.Trim(current.Value, `"`)
+		if end := current.End; end.Index-current.Start.Index == len(current.Value) {
+			// the closing quote, a column before the end of the token
+			param.RightQuotePos = token.Pos{Index: end.Index - 1, Line: end.Line, Column: end.Column - 1}
+		} else {
+			param.RightQuotePos = current.Start.Advance(current.Value[:len(current.Value)-1])
 		}
 		lineComments, err := p.parseLineComments(current.Start)
 		if err != nil {
@@ -494,9 +977,10 @@ func (p *parser) parseParameter(current token.Token, rulePos token.Pos) (ast.Par
 			switch next.Type {
 			case token.DAYS, token.HOURS:
 				if strings.ContainsRune(current.Value, '.') {
-					return nil, nil, fmt.Errorf("syntax error near %s: `%s`, duration parameter can not be float", current.Start, p.nearString(current.Start))
+					return nil, nil, p.errorf(current.Start, "duration parameter can not be float")
 				}
-				param := &ast.DurationParameter{
+				param := p.durationParams.new()
+				*param = ast.DurationParameter{
 					NumberPos: current.Start,
 					UnitPos:   next.Start,
 					Value:     current.Value + " " + next.Value,
@@ -516,10 +1000,9 @@ func (p *parser) parseParameter(current token.Token, rulePos token.Pos) (ast.Par
 				p.push(next)
 			}
 		}
-		param := &ast.NumberParameter{
-			NumberPos: current.Start,
-			Value:     current.Value,
-		}
+		param := p.numberParams.new()
+		param.NumberPos = current.Start
+		param.Value = current.Value
 		lineComments, err := p.parseLineComments(current.Start)
 		if err != nil {
 			return nil, nil, err
@@ -544,7 +1027,8 @@ func (p *parser) parseParameter(current token.Token, rulePos token.Pos) (ast.Par
 		param.Comments = lineComments
 		return param, nil, nil
 	case token.NOW:
-		param := &ast.DateParamter{
+		param := p.dateParams.new()
+		*param = ast.DateParameter{
 			NowPos: current.Start,
 		}
 		lineComments, err := p.parseLineComments(current.Start)
@@ -557,7 +1041,7 @@ func (p *parser) parseParameter(current token.Token, rulePos token.Pos) (ast.Par
 		param.Comments = lineComments
 		return param, nil, nil
 	default:
-		return nil, nil, fmt.Errorf("syntax error near %s: `%s`, no parameter", current.Start, p.nearString(current.Start))
+		return nil, nil, p.errorf(current.Start, "no parameter")
 	}
 }
 
@@ -573,17 +1057,28 @@ func (p *parser) popWithLineComment() (token.Token, ast.CommentGroup, bool) {
 	return t, lc, true
 }
 
+// appendParameterComments appends comments to the line comments of param.
+// It returns false if param can not have comments.
+func appendParameterComments(param ast.Parameter, comments ast.CommentGroup) bool {
+	x, ok := param.(ast.Commented)
+	if ok {
+		x.SetLineComments(appendComments(x.LineComments(), comments))
+	}
+	return ok
+}
+
 func (p *parser) parseExpression(current token.Token, rulePos token.Pos, modeRuleset bool) (ast.Expression, ast.CommentGroup, error) {
 	var lineComments ast.CommentGroup
 	switch current.Type {
 	case token.GREATER_EQUAL, token.GREATER_THAN, token.LESS_EQUAL, token.LESS_THAN, token.EQUAL:
-		expr := &ast.ComparisonExpression{
+		expr := p.comparisons.new()
+		*expr = ast.ComparisonExpression{
 			ExprPos:  current.Start,
 			Operator: current.Value,
 		}
 		t, lc, ok := p.popWithLineComment()
 		if !ok {
-			return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected EOF", current.Start, p.nearString(current.Start))
+			return nil, nil, p.causef(errUnexpectedEOF, current.Start, "unexpected EOF")
 		}
 		if rulePos.Line == current.Start.Line {
 			lineComments = lc
@@ -596,42 +1091,46 @@ func (p *parser) parseExpression(current token.Token, rulePos token.Pos, modeRul
 			if err != nil {
 				return nil, nil, err
 			}
-			lineComments = lc
+			lineComments = appendComments(lineComments, lc)
 			expr.Right = param
 		case t.Type == token.LEFT_PAREN:
 			// parse date expression as `(now() - 1 days)`
-			param := &ast.DateParamter{
-				LeftParenPos: t.Start.Ptr(),
+			if err := p.require(FeatureDateArithmetic, t.Start); err != nil {
+				return nil, nil, err
+			}
+			param := p.dateParams.new()
+			*param = ast.DateParameter{
+				LeftParenPos: p.posPtr(t.Start),
 			}
 			t, lc, ok := p.popWithLineComment()
 			if !ok {
-				return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected EOF", current.Start, p.nearString(current.Start))
+				return nil, nil, p.causef(errUnexpectedEOF, current.Start, "unexpected EOF")
 			}
 			if t.Type != token.NOW {
-				return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected token `%s`", t.Start, p.nearString(t.Start), t.Type)
+				return nil, nil, p.errorf(t.Start, "unexpected token `%s`", t.Type)
 			}
 			if rulePos.Line == t.Start.Line {
-				lineComments = append(lineComments, lc...)
+				lineComments = appendComments(lineComments, lc)
 			} else {
-				param.Comments = append(param.Comments, lc...)
+				param.Comments = appendComments(param.Comments, lc)
 			}
 			param.NowPos = t.Start
 			t, lc, ok = p.popWithLineComment()
 			if !ok {
-				return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected EOF", current.Start, p.nearString(current.Start))
+				return nil, nil, p.causef(errUnexpectedEOF, current.Start, "unexpected EOF")
 			}
 			if t.Type != token.MINUS {
-				return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected token `%s`", t.Start, p.nearString(t.Start), t.Type)
+				return nil, nil, p.errorf(t.Start, "unexpected token `%s`", t.Type)
 			}
 			if rulePos.Line == t.Start.Line {
-				lineComments = append(lineComments, lc...)
+				lineComments = appendComments(lineComments, lc)
 			} else {
-				param.Comments = append(param.Comments, lc...)
+				param.Comments = appendComments(param.Comments, lc)
 			}
-			param.MinusPos = t.Start.Ptr()
+			param.MinusPos = p.posPtr(t.Start)
 			t, ok = p.pop()
 			if !ok {
-				return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected EOF", current.Start, p.nearString(current.Start))
+				return nil, nil, p.causef(errUnexpectedEOF, current.Start, "unexpected EOF")
 			}
 			dp, lc, err := p.parseParameter(t, rulePos)
 			if err != nil {
@@ -639,47 +1138,48 @@ func (p *parser) parseExpression(current token.Token, rulePos token.Pos, modeRul
 			}
 			durationParam, ok := dp.(*ast.DurationParameter)
 			if !ok {
-				return nil, nil, fmt.Errorf("syntax error near %s: `%s`, expected duration parameter", t.Start, p.nearString(t.Start))
+				return nil, nil, p.errorf(t.Start, "expected duration parameter")
 			}
 			param.Duration = durationParam
-			lineComments = append(lineComments, lc...)
+			lineComments = appendComments(lineComments, lc)
 			t, lc, ok = p.popWithLineComment()
 			if !ok {
-				return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected EOF", current.Start, p.nearString(current.Start))
+				return nil, nil, p.causef(errUnexpectedEOF, current.Start, "unexpected EOF")
 			}
 			if t.Type != token.RIGHT_PAREN {
-				return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected token `%s`", t.Start, p.nearString(t.Start), t.Type)
+				return nil, nil, p.errorf(t.Start, "unexpected token `%s`", t.Type)
 			}
 			if rulePos.Line == t.Start.Line {
-				lineComments = append(lineComments, lc...)
+				lineComments = appendComments(lineComments, lc)
 			} else {
-				param.Comments = append(param.Comments, lc...)
+				param.Comments = appendComments(param.Comments, lc)
 			}
-			param.RightParenPos = t.Start.Ptr()
+			param.RightParenPos = p.posPtr(t.Start)
 			expr.Right = param
 			lc, err = p.parseLineComments(t.Start)
 			if err != nil {
 				return nil, nil, err
 			}
 			if rulePos.Line == current.Start.Line {
-				lineComments = append(lineComments, lc...)
+				lineComments = appendComments(lineComments, lc)
 			} else {
-				expr.Comments = append(expr.Comments, lc...)
+				expr.Comments = appendComments(expr.Comments, lc)
 			}
 		default:
-			return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected token `%s`", t.Start, p.nearString(t.Start), t.Type)
+			return nil, nil, p.errorf(t.Start, "unexpected token `%s`", t.Type)
 		}
 		return expr, lineComments, nil
 	case token.BETWEEN:
-		expr := &ast.BetweenExpression{
+		expr := p.betweens.new()
+		*expr = ast.BetweenExpression{
 			ExprPos: current.Start,
 		}
 		left, ok := p.pop()
 		if !ok {
-			return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected EOF", current.Start, p.nearString(current.Start))
+			return nil, nil, p.causef(errUnexpectedEOF, current.Start, "unexpected EOF")
 		}
 		if !left.Type.IsParameterAcceptable() {
-			return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected token `%s`", left.Start, p.nearString(left.Start), left.Type)
+			return nil, nil, p.errorf(left.Start, "unexpected token `%s`", left.Type)
 		}
 		leftParam, lc, err := p.parseParameter(left, rulePos)
 		if err != nil {
@@ -687,28 +1187,28 @@ func (p *parser) parseExpression(current token.Token, rulePos token.Pos, modeRul
 		}
 		expr.Left = leftParam
 		if rulePos.Line == left.Start.Line {
-			lineComments = append(lineComments, lc...)
+			lineComments = appendComments(lineComments, lc)
 		} else {
-			expr.Comments = append(expr.Comments, lc...)
+			expr.Comments = appendComments(expr.Comments, lc)
 		}
 		and, lc, ok := p.popWithLineComment()
 		if !ok {
-			return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected EOF", current.Start, p.nearString(current.Start))
+			return nil, nil, p.causef(errUnexpectedEOF, current.Start, "unexpected EOF")
 		}
 		if and.Type != token.AND {
-			return nil, nil, fmt.Errorf("syntax error near %s: `%s`, expected `and` but got `%s`", and.Start, p.nearString(and.Start), and.Value)
+			return nil, nil, p.errorf(and.Start, "expected `and` but got `%s`", and.Value)
 		}
 		if rulePos.Line == and.Start.Line {
-			lineComments = append(lineComments, lc...)
+			lineComments = appendComments(lineComments, lc)
 		} else {
-			expr.Comments = append(expr.Comments, lc...)
+			expr.Comments = appendComments(expr.Comments, lc)
 		}
 		right, ok := p.pop()
 		if !ok {
-			return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected EOF", current.Start, p.nearString(current.Start))
+			return nil, nil, p.causef(errUnexpectedEOF, current.Start, "unexpected EOF")
 		}
 		if !right.Type.IsParameterAcceptable() {
-			return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected token `%s`", right.Start, p.nearString(right.Start), right.Type)
+			return nil, nil, p.errorf(right.Start, "unexpected token `%s`", right.Type)
 		}
 		rightParam, lc, err := p.parseParameter(right, rulePos)
 		if err != nil {
@@ -716,84 +1216,99 @@ func (p *parser) parseExpression(current token.Token, rulePos token.Pos, modeRul
 		}
 		expr.Right = rightParam
 		if rulePos.Line == right.Start.Line {
-			lineComments = append(lineComments, lc...)
+			lineComments = appendComments(lineComments, lc)
 		} else {
-			expr.Comments = append(expr.Comments, lc...)
+			expr.Comments = appendComments(expr.Comments, lc)
 		}
 		return expr, lineComments, err
 	case token.IN:
-		expr := &ast.InExpression{
+		expr := p.ins.new()
+		*expr = ast.InExpression{
 			ExprPos: current.Start,
 		}
 		left, lc, ok := p.popWithLineComment()
 		if !ok {
-			return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected EOF", current.Start, p.nearString(current.Start))
+			return nil, nil, p.causef(errUnexpectedEOF, current.Start, "unexpected EOF")
 		}
 		if left.Type != token.LEFT_BRACKET {
-			return nil, nil, fmt.Errorf("syntax error near %s: `%s`, expected `[` but got `%s`", left.Start, p.nearString(left.Start), left.Value)
+			return nil, nil, p.errorf(left.Start, "expected `[` but got `%s`", left.Value)
 		}
 		expr.LeftBracketPos = left.Start
 		if rulePos.Line == left.Start.Line {
-			lineComments = append(lineComments, lc...)
+			lineComments = appendComments(lineComments, lc)
 		} else {
-			expr.Comments = append(expr.Comments, lc...)
+			expr.Comments = appendComments(expr.Comments, lc)
 		}
+		// the values are collected in buffers of p, and copied to their
+		// length at the end
+		values, commas := p.inValues[:0], p.inCommas[:0]
 		for {
 			t, ok := p.pop()
 			if !ok {
-				return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected EOF", current.Start, p.nearString(current.Start))
+				return nil, nil, p.causef(errUnexpectedEOF, current.Start, "unexpected EOF")
 			}
 			if !t.Type.IsParameterAcceptable() {
-				return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected token `%s`", t.Start, p.nearString(t.Start), t.Type)
+				return nil, nil, p.errorf(t.Start, "unexpected token `%s`", t.Type)
 			}
 			param, lc, err := p.parseParameter(t, rulePos)
 			if err != nil {
 				return nil, nil, err
 			}
 			if rulePos.Line == t.Start.Line {
-				lineComments = append(lineComments, lc...)
+				lineComments = appendComments(lineComments, lc)
 			} else {
-				expr.Comments = append(expr.Comments, lc...)
+				expr.Comments = appendComments(expr.Comments, lc)
 			}
-			expr.Values = append(expr.Values, param)
+			values = append(values, param)
 			t, lc, ok = p.popWithLineComment()
 			if !ok {
-				return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected EOF", current.Start, p.nearString(current.Start))
+				return nil, nil, p.causef(errUnexpectedEOF, current.Start, "unexpected EOF")
 			}
-			if rulePos.Line == t.Start.Line {
-				lineComments = append(lineComments, lc...)
-			} else {
-				expr.Comments = append(expr.Comments, lc...)
+			switch {
+			case rulePos.Line == t.Start.Line:
+				lineComments = appendComments(lineComments, lc)
+			case t.Type == token.COMMA && param.Pos().Line == t.Start.Line && appendParameterComments(param, lc):
+				// comments after the comma of a value on a line of its own
+			default:
+				expr.Comments = appendComments(expr.Comments, lc)
 			}
 			if t.Type == token.RIGHT_BRACKET {
 				expr.RightBracketPos = t.Start
 				break
 			}
 			if t.Type != token.COMMA {
-				return nil, nil, fmt.Errorf("syntax error near %s: `%s`, expected `,` but got `%s`", t.Start, p.nearString(t.Start), t.Value)
+				return nil, nil, p.errorf(t.Start, "expected `,` but got `%s`", t.Value)
 			}
+			commas = append(commas, t.Start)
 		}
+		expr.Values = append([]ast.Parameter(nil), values...)
+		if len(commas) > 0 {
+			expr.CommaPositions = append([]token.Pos(nil), commas...)
+		}
+		p.inValues, p.inCommas = values, commas
+		p.checkInValues(expr)
 		withThresholdExpr, lc, err := p.parseWithThreshold(expr, rulePos, modeRuleset)
 		if err != nil {
 			return nil, nil, err
 		}
-		lineComments = append(lineComments, lc...)
+		lineComments = appendComments(lineComments, lc)
 		return withThresholdExpr, lineComments, err
 	case token.MATCHES:
-		expr := &ast.MatchesExpression{
+		expr := p.matches.new()
+		*expr = ast.MatchesExpression{
 			ExprPos: current.Start,
 		}
 		regexpValue, lc, ok := p.popWithLineComment()
 		if !ok {
-			return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected EOF", current.Start, p.nearString(current.Start))
+			return nil, nil, p.causef(errUnexpectedEOF, current.Start, "unexpected EOF")
 		}
 		if regexpValue.Type != token.STRING {
-			return nil, nil, fmt.Errorf("syntax error near %s: `%s`, expected string but got `%s`", regexpValue.Start, p.nearString(regexpValue.Start), regexpValue.Value)
+			return nil, nil, p.errorf(regexpValue.Start, "expected string but got `%s`", regexpValue.Value)
 		}
 		if rulePos.Line == regexpValue.Start.Line {
-			lineComments = append(lineComments, lc...)
+			lineComments = appendComments(lineComments, lc)
 		} else {
-			expr.Comments = append(expr.Comments, lc...)
+			expr.Comments = appendComments(expr.Comments, lc)
 		}
 		expr.RegexpPos = regexpValue.Start
 		expr.Value = strings.Trim(regexpValue.Value, `"`)
@@ -801,10 +1316,10 @@ func (p *parser) parseExpression(current token.Token, rulePos token.Pos, modeRul
 		if err != nil {
 			return nil, nil, err
 		}
-		lineComments = append(lineComments, lc...)
+		lineComments = appendComments(lineComments, lc)
 		return withThresholdExpr, lineComments, err
 	default:
-		return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected token `%s`", current.Start, p.nearString(current.Start), current.Type)
+		return nil, nil, p.errorf(current.Start, "unexpected token `%s`", current.Type)
 	}
 }
 
@@ -817,19 +1332,22 @@ func (p *parser) parseWithThreshold(expr ast.ThresholdTarget, rulePos token.Pos,
 		p.push(with)
 		return expr, nil, nil
 	}
+	if err := p.require(FeatureWithThreshold, with.Start); err != nil {
+		return nil, nil, err
+	}
 	thresholdKeywords, lineComments, ok := p.popWithLineComment()
 	if !ok {
-		return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected EOF", with.Start, p.nearString(with.Start))
+		return nil, nil, p.causef(errUnexpectedEOF, with.Start, "unexpected EOF")
 	}
 	if thresholdKeywords.Type != token.THRESHOLD {
-		return nil, nil, fmt.Errorf("syntax error near %s: `%s`, expected `threshold` but got `%s`", thresholdKeywords.Start, p.nearString(thresholdKeywords.Start), thresholdKeywords.Value)
+		return nil, nil, p.errorf(thresholdKeywords.Start, "expected `threshold` but got `%s`", thresholdKeywords.Value)
 	}
 	thresholdValue, ok := p.pop()
 	if !ok {
-		return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected EOF", thresholdKeywords.Start, p.nearString(thresholdKeywords.Start))
+		return nil, nil, p.causef(errUnexpectedEOF, thresholdKeywords.Start, "unexpected EOF")
 	}
 	if !thresholdValue.Type.IsExpressionStart() {
-		return nil, nil, fmt.Errorf("syntax error near %s: `%s`, unexpected token `%s`", thresholdValue.Start, p.nearString(thresholdValue.Start), thresholdValue.Type)
+		return nil, nil, p.errorf(thresholdValue.Start, "unexpected token `%s`", thresholdValue.Type)
 	}
 	tExpr, lc, err := p.parseExpression(thresholdValue, rulePos, modeRuleset)
 	if err != nil {
@@ -837,10 +1355,12 @@ func (p *parser) parseWithThreshold(expr ast.ThresholdTarget, rulePos token.Pos,
 	}
 	threshold, ok := tExpr.(ast.ThresholdExpression)
 	if !ok {
-		return nil, nil, fmt.Errorf("syntax error near %s: `%s`, expected threshold expression but got `%s`", thresholdValue.Start, p.nearString(thresholdValue.Start), thresholdValue.Type)
+		return nil, nil, p.errorf(thresholdValue.Start, "expected threshold expression but got `%s`", thresholdValue.Type)
 	}
-	lineComments = append(lineComments, lc...)
-	withThresholdExpr := &ast.WithThresholdExpression{
+	lineComments = appendComments(lineComments, lc)
+	p.checkThreshold(threshold)
+	withThresholdExpr := p.thresholds.new()
+	*withThresholdExpr = ast.WithThresholdExpression{
 		ExprPos:   with.Start,
 		Target:    expr,
 		Threshold: threshold,
@@ -848,7 +1368,7 @@ func (p *parser) parseWithThreshold(expr ast.ThresholdTarget, rulePos token.Pos,
 	if rulePos.Line == with.Start.Line {
 		return withThresholdExpr, lineComments, nil
 	}
-	withThresholdExpr.Comments = append(withThresholdExpr.Comments, lineComments...)
+	withThresholdExpr.Comments = appendComments(withThresholdExpr.Comments, lineComments)
 	return withThresholdExpr, nil, nil
 }
 
@@ -857,9 +1377,9 @@ func (p *parser) parseWithThreshold(expr ast.ThresholdTarget, rulePos token.Pos,
 // parseLineComments is parse comments after given position.
 // If there is no comment, return nil.
 func (p *parser) parseLineComments(pos token.Pos) (ast.CommentGroup, error) {
-	comments := ast.CommentGroup{}
+	var comments ast.CommentGroup
 	var lastCommentPos token.Pos
-	for {
+	for p.mayPopComment() {
 		comment, ok := p.pop()
 		if !ok {
 			break
@@ -882,14 +1402,8 @@ func (p *parser) parseLineComments(pos token.Pos) (ast.CommentGroup, error) {
 				break
 			}
 		}
-		comments = append(comments, &ast.Comment{
-			SharpPos: comment.Start,
-			Text:     comment.Value,
-		})
+		comments = p.extend(comments)
 		lastCommentPos = comment.Start
 	}
-	if len(comments) == 0 {
-		return nil, nil
-	}
 	return comments, nil
 }
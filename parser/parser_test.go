@@ -1,11 +1,19 @@
 package parser
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"unicode/utf8"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/mashiike/go-dqdl/ast"
@@ -22,22 +30,22 @@ func TestParseRule(t *testing.T) {
 		{
 			name:   "empty",
 			input:  "",
-			errStr: "syntax error near 1:1 ``, RuleType is required: unexpexted EOF",
+			errStr: "1:1: syntax error near ``, RuleType is required: unexpexted EOF",
 		},
 		{
 			name:   "lexer error",
 			input:  `\" IsUnique "col-A"`,
-			errStr: "syntax error near 1:1 `\\\" IsUnique \"col-A\"`, unrecognized character: U+005C '\\'",
+			errStr: "1:1: syntax error near `\\\" IsUnique \"col-A\"`, unrecognized character: U+005C '\\'",
 		},
 		{
 			name:   "parameter only",
 			input:  `"cal-A"`,
-			errStr: "syntax error near 1:1 `\"cal-A\"`, RuleType is required: unexpected <Parameter>",
+			errStr: "1:1: syntax error near `\"cal-A\"`, RuleType is required: unexpected <Parameter>",
 		},
 		{
 			name:   "expression only",
 			input:  `between 1 and 5`,
-			errStr: "syntax error near 1:1 `between 1 and 5`, RuleType is required: unexpected <Expression>",
+			errStr: "1:1: syntax error near `between 1 and 5`, RuleType is required: unexpected <Expression>",
 		},
 		{
 			name:  "is_unique",
@@ -150,6 +158,27 @@ func TestParseRule(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "comparison with line comment",
+			input: `RowCount > 0 # not empty`,
+			want: &ast.Rule{
+				Type: &ast.Ident{
+					NamePos: token.Pos{Index: 0, Line: 1, Column: 1},
+					Name:    "RowCount",
+				},
+				Expression: &ast.ComparisonExpression{
+					ExprPos:  token.Pos{Index: 9, Line: 1, Column: 10},
+					Operator: ">",
+					Right: &ast.NumberParameter{
+						NumberPos: token.Pos{Index: 11, Line: 1, Column: 12},
+						Value:     "0",
+					},
+				},
+				Comments: ast.CommentGroup{
+					{SharpPos: token.Pos{Index: 13, Line: 1, Column: 14}, Text: "# not empty"},
+				},
+			},
+		},
 		{
 			name: "is_unique_before_comment_and_line_comment",
 			input: `# comment
@@ -261,6 +290,7 @@ func TestParseRule(t *testing.T) {
 					ExprPos:         token.Pos{Index: 20, Line: 1, Column: 21},
 					LeftBracketPos:  token.Pos{Index: 23, Line: 1, Column: 24},
 					RightBracketPos: token.Pos{Index: 39, Line: 1, Column: 40},
+					CommaPositions:  []token.Pos{token.Pos{Index: 28, Line: 1, Column: 29}, token.Pos{Index: 33, Line: 1, Column: 34}},
 					Values: []ast.Parameter{
 						&ast.StringParameter{
 							LeftQuotePos:  token.Pos{Index: 25, Line: 1, Column: 26},
@@ -321,7 +351,7 @@ func TestParseRule(t *testing.T) {
 				Expression: &ast.ComparisonExpression{
 					ExprPos:  token.Pos{Index: 25, Line: 1, Column: 26},
 					Operator: ">",
-					Right: &ast.DateParamter{
+					Right: &ast.DateParameter{
 						LeftParenPos:  &token.Pos{Index: 27, Line: 1, Column: 28},
 						RightParenPos: &token.Pos{Index: 42, Line: 1, Column: 43},
 						NowPos:        token.Pos{Index: 28, Line: 1, Column: 29},
@@ -355,7 +385,7 @@ func TestParseRule(t *testing.T) {
 				Expression: &ast.ComparisonExpression{
 					ExprPos:  token.Pos{Index: 25, Line: 1, Column: 26},
 					Operator: ">",
-					Right: &ast.DateParamter{
+					Right: &ast.DateParameter{
 						LeftParenPos:  &token.Pos{Index: 27, Line: 1, Column: 28},
 						RightParenPos: &token.Pos{Index: 42, Line: 1, Column: 43},
 						NowPos:        token.Pos{Index: 28, Line: 1, Column: 29},
@@ -395,7 +425,7 @@ func TestParseRule(t *testing.T) {
 				Expression: &ast.ComparisonExpression{
 					ExprPos:  token.Pos{Index: 25, Line: 1, Column: 26},
 					Operator: "<=",
-					Right: &ast.DateParamter{
+					Right: &ast.DateParameter{
 						NowPos: token.Pos{Index: 28, Line: 1, Column: 29},
 					},
 				},
@@ -458,6 +488,7 @@ func TestParseRule(t *testing.T) {
 						ExprPos:         token.Pos{Index: 20, Line: 1, Column: 21},
 						LeftBracketPos:  token.Pos{Index: 23, Line: 1, Column: 24},
 						RightBracketPos: token.Pos{Index: 32, Line: 1, Column: 33},
+						CommaPositions:  []token.Pos{token.Pos{Index: 27, Line: 1, Column: 28}},
 						Values: []ast.Parameter{
 							&ast.StringParameter{
 								LeftQuotePos:  token.Pos{Index: 24, Line: 1, Column: 25},
@@ -485,15 +516,16 @@ func TestParseRule(t *testing.T) {
 		{
 			name:   "combined mix rule",
 			input:  `(IsUnique "col-A") and (IsPrimaryKey "col-A") or (IsUnique "col-B") and (IsPrimaryKey "col-B")`,
-			errStr: "syntax error near 1:47: ` or (IsUnique \"col-B...`, can not mixed `and` and `or`",
+			errStr: "1:47: syntax error near ` or (IsUnique \"col-B...`, can not mixed `and` and `or`",
 		},
 		{
 			name:  "combined and rule",
 			input: `(IsUnique "col-A") and (IsUnique "col-B")`,
 			want: &ast.CombinedRule{
-				FirstLParenPos: token.Pos{Index: 0, Line: 1, Column: 1},
-				LastRParenPos:  token.Pos{Index: 40, Line: 1, Column: 41},
-				Operator:       "and",
+				FirstLParenPos:    token.Pos{Index: 0, Line: 1, Column: 1},
+				LastRParenPos:     token.Pos{Index: 40, Line: 1, Column: 41},
+				Operator:          "and",
+				OperatorPositions: []token.Pos{token.Pos{Index: 19, Line: 1, Column: 20}},
 				Rules: []*ast.Rule{
 					{
 						Type: &ast.Ident{
@@ -528,9 +560,10 @@ func TestParseRule(t *testing.T) {
 			name:  "combined or rule",
 			input: `(IsUnique "col-A") or (IsPrimaryKey "col-A")`,
 			want: &ast.CombinedRule{
-				FirstLParenPos: token.Pos{Index: 0, Line: 1, Column: 1},
-				LastRParenPos:  token.Pos{Index: 43, Line: 1, Column: 44},
-				Operator:       "or",
+				FirstLParenPos:    token.Pos{Index: 0, Line: 1, Column: 1},
+				LastRParenPos:     token.Pos{Index: 43, Line: 1, Column: 44},
+				Operator:          "or",
+				OperatorPositions: []token.Pos{token.Pos{Index: 19, Line: 1, Column: 20}},
 				Rules: []*ast.Rule{
 					{
 						Type: &ast.Ident{
@@ -577,9 +610,10 @@ func TestParseRule(t *testing.T) {
 						Text:     "# comment 2",
 					},
 				},
-				FirstLParenPos: token.Pos{Index: 28, Line: 3, Column: 4},
-				LastRParenPos:  token.Pos{Index: 119, Line: 3, Column: 95},
-				Operator:       "or",
+				FirstLParenPos:    token.Pos{Index: 28, Line: 3, Column: 4},
+				LastRParenPos:     token.Pos{Index: 119, Line: 3, Column: 95},
+				Operator:          "or",
+				OperatorPositions: []token.Pos{token.Pos{Index: 47, Line: 3, Column: 23}, token.Pos{Index: 73, Line: 3, Column: 49}, token.Pos{Index: 95, Line: 3, Column: 71}},
 				Rules: []*ast.Rule{
 					{
 						Type: &ast.Ident{
@@ -723,12 +757,12 @@ func TestParseRuleset__Invalid(t *testing.T) {
 		{
 			name:   "missing left bracket",
 			input:  `Rules =`,
-			errStr: "syntax error near 1:1 `Rules =`, missing `[`",
+			errStr: "1:1: syntax error near `Rules =`, missing `[`",
 		},
 		{
 			name:   "missing right bracket",
 			input:  `Rules = [`,
-			errStr: "syntax error near 1:10 `[`, missing `]`",
+			errStr: "1:10: syntax error near `[`, `[` opened at 1:9 is never closed",
 		},
 	}
 	for _, c := range cases {
@@ -743,6 +777,7 @@ Rules = [
 ]`
 	want := &ast.Ruleset{
 		DeclPos:         token.Pos{Index: 1, Line: 2, Column: 1},
+		EqualPos:        token.Pos{Index: 7, Line: 2, Column: 7},
 		LeftBracketPos:  token.Pos{Index: 9, Line: 2, Column: 9},
 		RightBracketPos: token.Pos{Index: 12, Line: 4, Column: 1},
 	}
@@ -761,6 +796,7 @@ Rules = [
 ]`
 	want := &ast.Ruleset{
 		DeclPos:         token.Pos{Index: 1, Line: 2, Column: 1},
+		EqualPos:        token.Pos{Index: 7, Line: 2, Column: 7},
 		LeftBracketPos:  token.Pos{Index: 9, Line: 2, Column: 9},
 		RightBracketPos: token.Pos{Index: 29, Line: 4, Column: 1},
 		Rules: []ast.RuleDecl{
@@ -794,8 +830,10 @@ Rules = [
 ]`
 	want := &ast.Ruleset{
 		DeclPos:         token.Pos{Index: 1, Line: 2, Column: 1},
+		EqualPos:        token.Pos{Index: 7, Line: 2, Column: 7},
 		LeftBracketPos:  token.Pos{Index: 9, Line: 2, Column: 9},
 		RightBracketPos: token.Pos{Index: 56, Line: 5, Column: 1},
+		CommaPositions:  []token.Pos{token.Pos{Index: 33, Line: 3, Column: 23}},
 		Rules: []ast.RuleDecl{
 			&ast.Rule{
 				Type: &ast.Ident{
@@ -832,6 +870,23 @@ Rules = [
 	c.Run(t)
 }
 
+func TestParseRuleset__CombinedRuleLast(t *testing.T) {
+	ruleset, err := ParseRuleset("Rules = [\n\tIsUnique \"id\",\n\t(RowCount > 0) or (ColumnCount = 3)\n]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ruleset.Rules) != 2 {
+		t.Fatalf("got %d rules, want 2", len(ruleset.Rules))
+	}
+	combined, ok := ruleset.Rules[1].(*ast.CombinedRule)
+	if !ok || combined.Operator != "or" || len(combined.Rules) != 2 {
+		t.Errorf("unexpected last rule: %#v", ruleset.Rules[1])
+	}
+	if want := (token.Pos{Index: 63, Line: 4, Column: 1}); ruleset.RightBracketPos != want {
+		t.Errorf("got RightBracketPos %v, want %v", ruleset.RightBracketPos, want)
+	}
+}
+
 func TestParseRuleset__RulesetWithComment(t *testing.T) {
 	input := `# This is a file comment
 
@@ -856,8 +911,10 @@ Rules = [
 			},
 		},
 		DeclPos:         token.Pos{Index: 58, Line: 4, Column: 1},
+		EqualPos:        token.Pos{Index: 64, Line: 4, Column: 7},
 		LeftBracketPos:  token.Pos{Index: 66, Line: 4, Column: 9},
 		RightBracketPos: token.Pos{Index: 228, Line: 12, Column: 1},
+		CommaPositions:  []token.Pos{token.Pos{Index: 131, Line: 6, Column: 23}},
 		Rules: []ast.RuleDecl{
 			&ast.Rule{
 				Description: ast.CommentGroup{
@@ -953,3 +1010,790 @@ func TestParseFile(t *testing.T) {
 		t.Errorf("ParseFile(%s) mismatch (-want +got):\n%s", filename, diff)
 	}
 }
+
+func TestFileSnippet(t *testing.T) {
+	input := `Rules = [
+	IsComplete "order-id",
+	DataFreshness "load-date"   <= 24  hours,
+	ColumnValues "load-date" > (now() - 3 days)
+]`
+	file, err := ParseFile("snippet.dqdl", strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := file.Rulesets[0].Rules
+	cases := []struct {
+		node ast.Node
+		want string
+	}{
+		{node: rules[0], want: `IsComplete "order-id"`},
+		{node: rules[0].(*ast.Rule).Parameters[0], want: `"order-id"`},
+		{node: rules[1], want: `DataFreshness "load-date"   <= 24  hours`},
+		{node: rules[1].(*ast.Rule).Expression.(*ast.ComparisonExpression).Right, want: `24  hours`},
+		{node: rules[2].(*ast.Rule).Expression, want: `> (now() - 3 days)`},
+		{node: file.Rulesets[0], want: input},
+	}
+	for _, c := range cases {
+		if got := file.Snippet(c.node); got != c.want {
+			t.Errorf("Snippet() = %q, want %q", got, c.want)
+		}
+	}
+}
+
+func TestParseFile__WithoutComments(t *testing.T) {
+	fp, err := os.Open("testdata/sample.dqdl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+
+	astFile, err := ParseFile("testdata/sample.dqdl", fp, WithoutComments())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fp.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	withComments, err := ParseFile("testdata/sample.dqdl", fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(astFile.CommentGroups) != 0 {
+		t.Errorf("got %d comment groups, want 0", len(astFile.CommentGroups))
+	}
+	if len(astFile.Rulesets) != 2 {
+		t.Fatalf("got %d rulesets, want 2", len(astFile.Rulesets))
+	}
+	for _, ruleset := range astFile.Rulesets {
+		if len(ruleset.Description) != 0 {
+			t.Errorf("got ruleset description %v, want empty", ruleset.Description)
+		}
+		if len(ruleset.Rules) != 2 {
+			t.Errorf("got %d rules, want 2", len(ruleset.Rules))
+		}
+	}
+	for i, ruleset := range withComments.Rulesets {
+		if i < len(astFile.Rulesets) && !ast.Equal(ruleset, astFile.Rulesets[i], ast.IgnoreComments) {
+			t.Errorf("ruleset %d differs from the one parsed with comments", i)
+		}
+	}
+}
+
+func TestParseFile__Span(t *testing.T) {
+	input := "Rules = [ IsComplete \"a\" ]\n# trailing\n"
+	file, err := ParseFile("test.dqdl", strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := token.Span{
+		Start: token.Pos{Index: 0, Line: 1, Column: 1},
+		End:   token.Pos{Index: len(input), Line: 3, Column: 1},
+	}
+	if diff := cmp.Diff(want, ast.SpanOf(file)); diff != "" {
+		t.Errorf("unexpected span (-want +got):\n%s", diff)
+	}
+	if got := file.Snippet(file); got != input {
+		t.Errorf("got snippet %q, want the whole input", got)
+	}
+	if got, want := file.Rulesets[0].EqualPos, (token.Pos{Index: 6, Line: 1, Column: 7}); got != want {
+		t.Errorf("got EqualPos %s, want %s", got, want)
+	}
+}
+
+func TestParseFile__LineEndings(t *testing.T) {
+	lf, err := os.ReadFile("testdata/sample.dqdl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ParseFile("testdata/sample.dqdl", bytes.NewReader(lf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	windows := "\uFEFF" + strings.ReplaceAll(string(lf), "\n", "\r\n")
+	got, err := ParseFile("testdata/sample.dqdl", strings.NewReader(windows))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Rulesets) != len(want.Rulesets) {
+		t.Fatalf("got %d rulesets, want %d", len(got.Rulesets), len(want.Rulesets))
+	}
+	for i, ruleset := range want.Rulesets {
+		// comments must not keep the carriage returns.
+		if !ast.Equal(ruleset, got.Rulesets[i], ast.IgnorePositions, ast.IgnoreSource) {
+			t.Errorf("ruleset %d differs from the one with LF line endings", i)
+		}
+		// lines and columns must not count the byte order mark or the
+		// carriage returns.
+		wantPos, gotPos := ruleset.Rules[1].Pos(), got.Rulesets[i].Rules[1].Pos()
+		if wantPos.Line != gotPos.Line || wantPos.Column != gotPos.Column {
+			t.Errorf("ruleset %d: got rule at %s, want %s", i, gotPos, wantPos)
+		}
+	}
+}
+
+func TestParseFile__UnclosedDelimiters(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		errStr string
+		cause  error
+	}{
+		{
+			name:   "ruleset",
+			input:  "Rules = [\n\tIsComplete \"a\"",
+			errStr: "test.dqdl:2:16: syntax error near `\"`, `[` opened at 1:9 is never closed",
+			cause:  errUnclosed,
+		},
+		{
+			name:   "combined rule",
+			input:  "Rules = [\n\t(IsComplete \"a\") and (IsUnique \"b\"\n",
+			errStr: "test.dqdl:3:1: syntax error near ``, `(` opened at 2:23 is never closed",
+			cause:  errUnclosed,
+		},
+		{
+			name:   "in-list",
+			input:  "Rules = [\n\tColumnValues \"a\" in [1, 2\n",
+			errStr: "test.dqdl:3:1: syntax error near ``, `[` opened at 2:22 is never closed",
+			cause:  errUnclosed,
+		},
+		{
+			name:   "in-list closing the ruleset",
+			input:  "Rules = [\n\tColumnValues \"a\" in [1, 2\n]",
+			errStr: "test.dqdl:3:2: syntax error near `]`, `[` opened at 1:9 is never closed",
+			cause:  errUnclosed,
+		},
+		{
+			name:   "mismatch",
+			input:  "Rules = [\n\t(IsUnique \"b\"\n]",
+			errStr: "test.dqdl:3:1: syntax error near ``, expected `)` to close `(` opened at 2:2 but got `]`",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := ParseFile("test.dqdl", strings.NewReader(c.input))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if err.Error() != c.errStr {
+				t.Errorf("got error %q, want %q", err.Error(), c.errStr)
+			}
+			if c.cause != nil && !errors.Is(err, c.cause) {
+				t.Errorf("got error %v, want one wrapping %v", err, c.cause)
+			}
+		})
+	}
+}
+
+func TestAtEOF(t *testing.T) {
+	eof := token.Pos{Index: 20, Line: 2, Column: 5}
+	before := token.Pos{Index: 10, Line: 1, Column: 11}
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "at the EOF", err: &Error{Pos: eof, Msg: "unexpected `EOF`"}, want: true},
+		{name: "unexpected EOF", err: &Error{Pos: before, Msg: "reworded", cause: errUnexpectedEOF}, want: true},
+		{name: "unclosed", err: &Error{Pos: before, Msg: "reworded", cause: errUnclosed}, want: true},
+		{name: "bare unexpected EOF", err: errUnexpectedEOF, want: true},
+		{name: "other error", err: &Error{Pos: before, Msg: "unexpected EOF"}, want: false},
+		{name: "other bare error", err: errors.New("unexpected EOF"), want: false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var perr *Error
+			if !errors.As(c.err, &perr) {
+				perr = nil
+			}
+			if got := atEOF(c.err, perr, eof); got != c.want {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestParseContext__Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	input := `Rules = [
+	IsComplete "order-id",
+	IsUnique "order-id"
+]`
+	if _, err := ParseFileContext(ctx, "canceled.dqdl", strings.NewReader(input)); err != context.Canceled {
+		t.Errorf("ParseFileContext: got error %v, want %v", err, context.Canceled)
+	}
+	if _, err := ParseRulesetContext(ctx, input); err != context.Canceled {
+		t.Errorf("ParseRulesetContext: got error %v, want %v", err, context.Canceled)
+	}
+	if _, err := ParseRuleContext(ctx, `IsUnique "order-id"`); err != context.Canceled {
+		t.Errorf("ParseRuleContext: got error %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestParseFile__UnicodeColumns(t *testing.T) {
+	src := "Rules = [\n\t# 説明\n\tColumnValues \"名前\" matches \"[あ-ん]+\" # 末尾\n]"
+	file, err := ParseFile("test.dqdl", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := file.Rulesets[0].Rules[0].(*ast.Rule)
+	nodes := []ast.Node{
+		rule,
+		rule.Type,
+		rule.Parameters[0],
+		rule.Expression,
+		rule.Description[0],
+		rule.Comments[0],
+	}
+	// every position must agree with its byte offset, counting columns in
+	// runes.
+	check := func(pos token.Pos) {
+		t.Helper()
+		lineStart := strings.LastIndexByte(src[:pos.Index], '\n') + 1
+		want := token.Pos{
+			Index:  pos.Index,
+			Line:   strings.Count(src[:pos.Index], "\n") + 1,
+			Column: utf8.RuneCountInString(src[lineStart:pos.Index]) + 1,
+		}
+		if pos != want {
+			t.Errorf("got %#v, want %#v", pos, want)
+		}
+	}
+	for _, n := range nodes {
+		check(n.Pos())
+		check(n.End())
+	}
+	if got := file.Snippet(rule.Parameters[0]); got != `"名前"` {
+		t.Errorf("got snippet %q, want %q", got, `"名前"`)
+	}
+	if got := file.Snippet(rule.Comments[0]); got != "# 末尾" {
+		t.Errorf("got snippet %q, want %q", got, "# 末尾")
+	}
+}
+
+func TestParseFile__ErrorWithFilename(t *testing.T) {
+	input := `Rules = [
+	IsComplete "order-id",
+	IsUnique "order-id" matches 5
+]`
+	_, err := ParseFile("path/to/file.dqdl", strings.NewReader(input))
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	want := "path/to/file.dqdl:3:30: syntax error near ` 5`, expected string but got `5`"
+	if err.Error() != want {
+		t.Errorf("got error %q, want %q", err.Error(), want)
+	}
+	var perr *Error
+	if !errors.As(err, &perr) {
+		t.Fatalf("got error %T, want *Error", err)
+	}
+	if perr.Filename != "path/to/file.dqdl" || perr.Pos.Line != 3 || perr.Pos.Column != 30 {
+		t.Errorf("unexpected error position %s:%s", perr.Filename, perr.Pos)
+	}
+	if got := perr.Diagnostic().String(); got != "path/to/file.dqdl:3:30: error: syntax error near ` 5`, expected string but got `5` [syntax-error]" {
+		t.Errorf("unexpected diagnostic %q", got)
+	}
+}
+
+func TestParseExpression(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		want   ast.Expression
+		errStr string
+	}{
+		{
+			name:  "comparison",
+			input: `> 0.5`,
+			want: &ast.ComparisonExpression{
+				ExprPos:  token.Pos{Index: 0, Line: 1, Column: 1},
+				Operator: ">",
+				Right: &ast.NumberParameter{
+					NumberPos: token.Pos{Index: 2, Line: 1, Column: 3},
+					Value:     "0.5",
+				},
+			},
+		},
+		{
+			name: "in with threshold and comment",
+			input: `in ["a"] # allowed values
+			with threshold >= 0.9`,
+			want: &ast.WithThresholdExpression{
+				ExprPos: token.Pos{Index: 29, Line: 2, Column: 4},
+				Target: &ast.InExpression{
+					ExprPos:         token.Pos{Index: 0, Line: 1, Column: 1},
+					LeftBracketPos:  token.Pos{Index: 3, Line: 1, Column: 4},
+					RightBracketPos: token.Pos{Index: 7, Line: 1, Column: 8},
+					Values: []ast.Parameter{
+						&ast.StringParameter{
+							LeftQuotePos:  token.Pos{Index: 4, Line: 1, Column: 5},
+							RightQuotePos: token.Pos{Index: 6, Line: 1, Column: 7},
+							Value:         "a",
+						},
+					},
+					Comments: ast.CommentGroup{
+						&ast.Comment{
+							SharpPos: token.Pos{Index: 9, Line: 1, Column: 10},
+							Text:     "# allowed values",
+						},
+					},
+				},
+				Threshold: &ast.ComparisonExpression{
+					ExprPos:  token.Pos{Index: 44, Line: 2, Column: 19},
+					Operator: ">=",
+					Right: &ast.NumberParameter{
+						NumberPos: token.Pos{Index: 47, Line: 2, Column: 22},
+						Value:     "0.9",
+					},
+				},
+			},
+		},
+		{
+			name:  "in with comments on values",
+			input: "in [\n\"a\", # first\n\"b\" # second\n]",
+			want: &ast.InExpression{
+				ExprPos:         token.Pos{Index: 0, Line: 1, Column: 1},
+				LeftBracketPos:  token.Pos{Index: 3, Line: 1, Column: 4},
+				RightBracketPos: token.Pos{Index: 31, Line: 4, Column: 1},
+				Values: []ast.Parameter{
+					&ast.StringParameter{
+						LeftQuotePos:  token.Pos{Index: 5, Line: 2, Column: 1},
+						RightQuotePos: token.Pos{Index: 7, Line: 2, Column: 3},
+						Value:         "a",
+						Comments: ast.CommentGroup{
+							&ast.Comment{SharpPos: token.Pos{Index: 10, Line: 2, Column: 6}, Text: "# first"},
+						},
+					},
+					&ast.StringParameter{
+						LeftQuotePos:  token.Pos{Index: 18, Line: 3, Column: 1},
+						RightQuotePos: token.Pos{Index: 20, Line: 3, Column: 3},
+						Value:         "b",
+						Comments: ast.CommentGroup{
+							&ast.Comment{SharpPos: token.Pos{Index: 22, Line: 3, Column: 5}, Text: "# second"},
+						},
+					},
+				},
+				CommaPositions: []token.Pos{{Index: 8, Line: 2, Column: 4}},
+			},
+		},
+		{
+			name:   "rule",
+			input:  `IsUnique "col-A"`,
+			errStr: "1:1: syntax error near `IsUnique \"col-A\"`, expression is required: unexpected token `IDENT`",
+		},
+		{
+			name:   "trailing token",
+			input:  `between 1 and 5 6`,
+			errStr: "1:17: syntax error near ` 6`, unexpected token `NUMBER` after expression",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := ParseExpression(c.input)
+			if c.errStr != "" {
+				if err == nil {
+					t.Fatalf("expected error %q, got nil", c.errStr)
+				}
+				if err.Error() != c.errStr {
+					t.Errorf("got error %q, want %q", err.Error(), c.errStr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(got, c.want); diff != "" {
+				t.Errorf("unexpected result (-got +want):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		want   []string
+		errStr string
+	}{
+		{name: "empty", input: ``},
+		{name: "single", input: `IsUnique "a"`, want: []string{"IsUnique"}},
+		{
+			name: "list",
+			input: `IsUnique "a", # unique
+			# completeness
+			IsComplete "a",
+			(RowCount > 0) and (ColumnCount = 3),`,
+			want: []string{"IsUnique", "IsComplete", "and"},
+		},
+		{
+			name:   "missing comma",
+			input:  `IsUnique "a" IsComplete "a"`,
+			errStr: "1:14: syntax error near ` IsComplete \"a\"`, RuleType is already defined",
+		},
+		{
+			name:   "wrapped",
+			input:  `IsUnique "a"]`,
+			errStr: "1:13: syntax error near `\"]`, unexpected `]`",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rules, err := ParseRules(c.input)
+			if c.errStr != "" {
+				if err == nil {
+					t.Fatalf("expected error %q, got nil", c.errStr)
+				}
+				if err.Error() != c.errStr {
+					t.Errorf("got error %q, want %q", err.Error(), c.errStr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			var got []string
+			for _, rule := range rules {
+				switch r := rule.(type) {
+				case *ast.Rule:
+					got = append(got, r.Type.Name)
+				case *ast.CombinedRule:
+					got = append(got, r.Operator)
+				}
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("unexpected rules (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseFile__JSONRoundTrip(t *testing.T) {
+	input := `# round trip
+Rules = [
+	IsComplete "order-id", # line comment
+	ColumnValues "status" in ["a", "b"] with threshold > 0.9,
+	ColumnValues "name" matches "[a-z]+",
+	Mean "price" between 1 and 100.5,
+	ColumnValues "load_date" > (now() - 3 days),
+	DataFreshness "load_date" <= 24 hours,
+	(IsUnique "id") or (IsPrimaryKey "id"),
+	CustomSql "select count(*) from primary" = true
+]
+`
+	want, err := ParseFile("roundtrip.dqdl", strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got ast.File
+	if err := json.Unmarshal(bs, &got); err != nil {
+		t.Fatal(err)
+	}
+	want.Source = ""
+	if diff := cmp.Diff(want, &got); diff != "" {
+		t.Errorf("JSON round-trip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestParseFile__JSONSchema checks that the JSON representation of a parsed
+// file only uses the kinds and keys described in ast.Schema.
+func TestParseFile__JSONSchema(t *testing.T) {
+	var schema struct {
+		Defs map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+			Required   []string                   `json:"required"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(ast.Schema(), &schema); err != nil {
+		t.Fatal(err)
+	}
+	golden, err := os.ReadFile("testdata/TestParseFile.golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(golden, &doc); err != nil {
+		t.Fatal(err)
+	}
+	var check func(v interface{})
+	check = func(v interface{}) {
+		switch v := v.(type) {
+		case []interface{}:
+			for _, e := range v {
+				check(e)
+			}
+		case map[string]interface{}:
+			if kind, ok := v["Kind"].(string); ok {
+				def, ok := schema.Defs[kind]
+				if !ok {
+					t.Errorf("kind %q is not described in the schema", kind)
+				}
+				for key := range v {
+					if _, ok := def.Properties[key]; !ok {
+						t.Errorf("%s.%s is not described in the schema", kind, key)
+					}
+				}
+				for _, key := range def.Required {
+					if _, ok := v[key]; !ok {
+						t.Errorf("%s.%s is required by the schema", kind, key)
+					}
+				}
+			}
+			for _, e := range v {
+				check(e)
+			}
+		}
+	}
+	check(doc)
+}
+
+func TestScanFile(t *testing.T) {
+	fp, err := os.Open("testdata/sample.dqdl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+	lazy, err := ScanFile("testdata/sample.dqdl", fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ParseFile("testdata/sample.dqdl", strings.NewReader(lazy.Source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lazy.Rulesets) != len(want.Rulesets) {
+		t.Fatalf("got %d rulesets, want %d", len(lazy.Rulesets), len(want.Rulesets))
+	}
+	for i, r := range lazy.Rulesets {
+		if r.DeclPos != want.Rulesets[i].DeclPos {
+			t.Errorf("ruleset[%d]: got DeclPos %s, want %s", i, r.DeclPos, want.Rulesets[i].DeclPos)
+		}
+		got, err := r.Ruleset()
+		if err != nil {
+			t.Fatalf("ruleset[%d]: %s", i, err)
+		}
+		if diff := cmp.Diff(want.Rulesets[i], got); diff != "" {
+			t.Errorf("ruleset[%d] mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestScanFile__ByteOrderMark(t *testing.T) {
+	input := "\uFEFFRules = [\r\n\tIsUnique \"a\"\r\n]\r\n"
+	lazy, err := ScanFile("test.dqdl", strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ParseFile("test.dqdl", strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lazy.Rulesets) != 1 {
+		t.Fatalf("got %d rulesets, want 1", len(lazy.Rulesets))
+	}
+	if got := lazy.Rulesets[0].DeclPos; got != want.Rulesets[0].DeclPos {
+		t.Errorf("got DeclPos %s, want %s", got, want.Rulesets[0].DeclPos)
+	}
+	got, err := lazy.Rulesets[0].Ruleset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want.Rulesets[0], got); diff != "" {
+		t.Errorf("ruleset mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestScanFile__Error(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		errStr string
+	}{
+		{name: "unclosed", input: "Rules = [\n\tIsUnique \"a\"\n", errStr: "test.dqdl:3:1: `[` opened at 1:9 is never closed"},
+		{name: "unexpected close", input: "Rules = [\n]\n]", errStr: "test.dqdl:3:1: unexpected `]`"},
+		{name: "unterminated string", input: "Rules = [\n\tIsUnique \"a\n]", errStr: "test.dqdl:2:11: unterminated string"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := ScanFile("test.dqdl", strings.NewReader(c.input))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if err.Error() != c.errStr {
+				t.Errorf("got error %q, want %q", err.Error(), c.errStr)
+			}
+		})
+	}
+}
+
+func TestScanFile__DeferredSyntaxError(t *testing.T) {
+	input := `Rules = [
+	IsUnique "a"
+]
+
+# broken ruleset
+Rules = [
+	IsUnique "a" "b" >
+]`
+	lazy, err := ScanFile("test.dqdl", strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lazy.Rulesets) != 2 {
+		t.Fatalf("got %d rulesets, want 2", len(lazy.Rulesets))
+	}
+	if _, err := lazy.Rulesets[0].Ruleset(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	_, err = lazy.Rulesets[1].Ruleset()
+	if err == nil {
+		t.Fatal("expected syntax error")
+	}
+	if !strings.HasPrefix(err.Error(), "test.dqdl:8:1: ") {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if got, want := lazy.Rulesets[1].Text(), "Rules = [\n\tIsUnique \"a\" \"b\" >\n]"; got != want {
+		t.Errorf("got text %q, want %q", got, want)
+	}
+}
+
+func TestParseBareRules(t *testing.T) {
+	input := `# unique id
+IsUnique "id"
+IsComplete "id", ColumnCount > 3
+(RowCount > 0) and (ColumnCount = 3)
+Mean "price"
+	between 1 and 10`
+	ruleset, err := ParseBareRules(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ruleset.Legacy {
+		t.Error("ruleset is not flagged as legacy")
+	}
+	var got []string
+	for _, rule := range ruleset.Rules {
+		switch r := rule.(type) {
+		case *ast.Rule:
+			got = append(got, r.Type.Name)
+		case *ast.CombinedRule:
+			got = append(got, r.Operator)
+		}
+	}
+	want := []string{"IsUnique", "IsComplete", "ColumnCount", "and", "Mean"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected rules (-want +got):\n%s", diff)
+	}
+	if d := ruleset.Rules[0].(*ast.Rule).Description; len(d) != 1 || d[0].Text != "# unique id" {
+		t.Errorf("unexpected description: %v", d)
+	}
+
+	// a rule type on a new line is not a new rule outside of bare rules.
+	_, err = ParseRuleset("Rules = [\n\tIsUnique \"id\"\n\t(RowCount > 0) and (ColumnCount = 3)\n]")
+	if err == nil || err.Error() != "3:2: syntax error near `\t(RowCount > 0) and ...`, unexpected `(`" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestParseFile__CommentGroupsDoNotAlias(t *testing.T) {
+	// the comment groups share the list of the comments read, so that
+	// appending to one must not overwrite the next. The leading comments
+	// grow the list so that the groups of the rule share its array.
+	input := `# 1
+# 2
+# 3
+# 4
+
+Rules = [
+	# description
+	IsComplete "a" # a
+	               # aligned
+]
+`
+	f, err := ParseFile("test.dqdl", strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := f.Rulesets[0].Rules[0].(*ast.Rule)
+	rule.Description = append(rule.Description, &ast.Comment{Text: "# appended"})
+	var got []string
+	for _, c := range rule.Comments {
+		got = append(got, c.Text)
+	}
+	if diff := cmp.Diff([]string{"# a", "# aligned"}, got); diff != "" {
+		t.Errorf("unexpected line comments (-want +got):\n%s", diff)
+	}
+}
+
+// commentedSource returns a ruleset of n rules in which every rule has a
+// description, trailing comments and a detached comment group before it.
+func commentedSource(n int) string {
+	var b strings.Builder
+	b.WriteString("# file comment\n# spanning two lines\n\n# ruleset description\nRules = [ # open\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "\t# detached comment %d\n\n", i)
+		fmt.Fprintf(&b, "\t# description of rule %d\n\t# continued\n", i)
+		fmt.Fprintf(&b, "\tColumnValues \"col-%d\" between 1 and 10 # trailing\n", i)
+		b.WriteString("\t                                        # aligned\n")
+		if i < n-1 {
+			b.WriteString("\t,\n")
+		}
+	}
+	b.WriteString("] # close\n")
+	return b.String()
+}
+
+func BenchmarkParseFile__Commented(b *testing.B) {
+	src := commentedSource(500)
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseFile("bench.dqdl", strings.NewReader(src)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParseReader(t *testing.T) {
+	for _, filename := range []string{"testdata/sample.dqdl", "testdata/corpus.dqdl"} {
+		t.Run(filename, func(t *testing.T) {
+			src, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := ParseFile(filename, bytes.NewReader(src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want.Source = ""
+			got, err := ParseReader(filename, iotest.OneByteReader(bytes.NewReader(src)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseReader__Error(t *testing.T) {
+	// the error is far beyond the window kept by the scanner
+	src := largeSource(2000) + "Rules = [ IsUnique \"order-id\" matches 5 ]\n"
+	_, err := ParseReader("large.dqdl", strings.NewReader(src))
+	want := "large.dqdl:2203:39: syntax error near ` 5 ]`, expected string but got `5`"
+	if err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+
+	readErr := errors.New("read failed")
+	r := io.MultiReader(strings.NewReader("Rules = [ RowCount > 0,"), iotest.ErrReader(readErr))
+	if _, err := ParseReader("broken.dqdl", r); err != readErr {
+		t.Errorf("got error %v, want %v", err, readErr)
+	}
+}
@@ -0,0 +1,282 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/diag"
+	"github.com/mashiike/go-dqdl/scanner"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// WithErrorRecovery は構文エラーを fn に報告し、エラーの箇所を飛ばして構文解析を続けます。
+// WithErrorRecovery makes the parser tolerant of syntax errors, for
+// editors that need the structure of a file while it is being typed. A
+// rule that can not be parsed is replaced by an ast.BadRule and an
+// expression by an ast.BadExpr, holding the source text up to the next
+// `,`, `]` or `)` outside of the brackets opened in it, and parsing goes
+// on from there. A ruleset missing its `]` ends at the next `Rules` or at
+// the end of the input. Each error recovered from is reported to fn as a
+// diagnostic of severity error instead of being returned, in source order
+// once the input parses. Other syntax errors, such as text outside of a
+// ruleset, are still returned. ParseDir serializes the calls.
+func WithErrorRecovery(fn func(diag.Diagnostic)) Option {
+	return func(c *config) {
+		c.recovery = fn
+	}
+}
+
+// WithMaxErrors は WithErrorRecovery で回復する構文エラーの数の上限を指定します。
+// WithMaxErrors limits the number of syntax errors WithErrorRecovery
+// recovers from in an input to n, so that the parser gives up early on
+// input that is not DQDL. The syntax error after the first n is returned,
+// as without WithErrorRecovery, and none is reported. The default, or n
+// of zero or less, is no limit.
+func WithMaxErrors(n int) Option {
+	return func(c *config) {
+		c.maxErrors = n
+	}
+}
+
+// checkpoint is where the parser starts to skip the tokens of a bad node.
+type checkpoint struct {
+	from   token.Pos   // start of the bad node
+	open   []delimiter // delimiters open at from
+	groups int         // number of the ruleset comment groups at from
+}
+
+// checkpoint returns the checkpoint at from, the position of the next
+// token, or nil if the parser does not recover from errors.
+func (p *parser) checkpoint(from token.Pos) *checkpoint {
+	if p.cfg.recovery == nil {
+		return nil
+	}
+	return &checkpoint{from: from, open: p.openDelimiters(), groups: len(p.rulesetCommentGroups)}
+}
+
+// openDelimiters returns the delimiters open before the tokens pushed back
+// on the stack, which have been read by the lexer already.
+func (p *parser) openDelimiters() []delimiter {
+	open := append([]delimiter(nil), p.delims.open...)
+	for _, t := range p.stack {
+		switch t.Type {
+		case token.LEFT_BRACKET, token.LEFT_PAREN:
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		case token.RIGHT_BRACKET:
+			open = append(open, delimiter{Type: token.LEFT_BRACKET})
+		case token.RIGHT_PAREN:
+			open = append(open, delimiter{Type: token.LEFT_PAREN})
+		}
+	}
+	return open
+}
+
+// recovers reports whether the parser recovers from err, and records err
+// for run to report if so.
+func (p *parser) recovers(err error) bool {
+	var perr *Error
+	if p.cfg.recovery == nil || !errors.As(err, &perr) || p.ctx.Err() != nil {
+		return false
+	}
+	if p.cfg.maxErrors > 0 && len(p.syntaxErrors) >= p.cfg.maxErrors {
+		return false
+	}
+	p.syntaxErrors = append(p.syntaxErrors, perr.Diagnostic())
+	return true
+}
+
+// unclosedRuleset ends ruleset before t, the end of the input or the next
+// `Rules`, when the parser recovers from its missing `]`.
+func (p *parser) unclosedRuleset(ruleset *ast.Ruleset, t token.Token) (*ast.Ruleset, error) {
+	if !p.recovers(p.errorf(t.Start, "`[` opened at %s is never closed", ruleset.LeftBracketPos)) {
+		return nil, p.causef(errUnclosed, t.Start, "missing `]`")
+	}
+	p.push(t)
+	ruleset.CommaPositions = p.commas
+	return ruleset, nil
+}
+
+// badRule returns an ast.BadRule in place of the rule starting at cp that
+// failed with err, or err if the parser does not recover from it. A comma
+// after the bad rule is read, as after a rule. It returns nil if the rule
+// is empty, as between two commas.
+func (p *parser) badRule(cp *checkpoint, err error) (ast.RuleDecl, error) {
+	if cp == nil || !p.recovers(err) {
+		return nil, err
+	}
+	stop, ok := p.skip(cp, err)
+	if !ok {
+		return nil, err
+	}
+	rule := &ast.BadRule{From: cp.from}
+	rule.To, rule.Text, rule.Comments = p.badText(cp, stop)
+	empty := rule.Text == "" && len(rule.Comments) == 0
+	switch {
+	case stop.Type != token.COMMA:
+		p.push(stop)
+	case !empty:
+		p.commas = append(p.commas, stop.Start)
+	}
+	// the comments of the rule are in its text.
+	p.rulesetCommentGroups = p.rulesetCommentGroups[:cp.groups]
+	if empty {
+		return nil, nil
+	}
+	return rule, nil
+}
+
+// badExpr returns an ast.BadExpr in place of the expression starting at
+// cp that failed with err, or err if the parser does not recover from it.
+func (p *parser) badExpr(cp *checkpoint, err error) (ast.Expression, ast.CommentGroup, error) {
+	if cp == nil || !p.recovers(err) {
+		return nil, nil, err
+	}
+	stop, ok := p.skip(cp, err)
+	if !ok {
+		return nil, nil, err
+	}
+	expr := &ast.BadExpr{From: cp.from}
+	expr.To, expr.Text, expr.Comments = p.badText(cp, stop)
+	p.push(stop)
+	return expr, nil, nil
+}
+
+// skip skips the tokens of the bad node starting at cp, where the parse
+// failed with err, and returns the token after them: a `,` or a closing
+// delimiter outside of the delimiters opened in the node, the next
+// `Rules` in a ruleset, the end of the input or, in a bare list of rules,
+// a rule type on a later line. The delimiters are left as if the bad node
+// had been parsed. It returns false if ctx is done.
+func (p *parser) skip(cp *checkpoint, err error) (token.Token, bool) {
+	// the token the parse failed at may end the node, so it is read again.
+	var perr *Error
+	if errors.As(err, &perr) && (len(p.stack) == 0 || p.stack[len(p.stack)-1].Start != perr.Pos) {
+		if t, ok := p.tokenAt(perr.Pos); ok {
+			switch t.Type {
+			case token.COMMA, token.RIGHT_BRACKET, token.RIGHT_PAREN, token.RULES, token.EOF, token.ILLEGAL:
+				p.push(t)
+			case token.IDENT:
+				if p.bare && t.Start.Line > cp.from.Line {
+					p.push(t)
+				}
+			}
+		}
+	}
+	var nested []token.TokenType
+	if open := p.openDelimiters(); len(open) > len(cp.open) {
+		for _, t := range open[len(cp.open):] {
+			nested = append(nested, t.Type)
+		}
+	}
+	stop, ok := p.skipNested(cp, nested)
+	if !ok {
+		return stop, false
+	}
+	if m := p.delims.mismatch; m != nil && !m.Start.Before(cp.from) {
+		p.delims.mismatch = nil
+	}
+	p.delims.open = cp.open
+	p.delims.observe(stop.Type, stop.Start)
+	return stop, true
+}
+
+// skipNested skips tokens until the end of the bad node starting at cp,
+// where nested are the delimiters opened in the node and not closed yet.
+func (p *parser) skipNested(cp *checkpoint, nested []token.TokenType) (token.Token, bool) {
+	for {
+		t, ok := p.pop()
+		if !ok {
+			// the lexer stopped at the EOF read before.
+			if p.ctx.Err() != nil || !p.eof.IsValid() {
+				return t, false
+			}
+			return token.Token{Type: token.EOF, Start: p.eof, End: p.eof}, true
+		}
+		switch t.Type {
+		case token.EOF:
+			return t, true
+		case token.RULES:
+			// a new ruleset, unless the node is not in one.
+			if len(cp.open) > 0 {
+				return t, true
+			}
+		case token.ILLEGAL:
+			if p.lexer.skipIllegal(t) {
+				continue
+			}
+			// the rest of the input can not be scanned.
+			end := t.Start.Advance(p.lexer.scanner.Source(t.Start.Index))
+			p.eof = end
+			return token.Token{Type: token.EOF, Start: end, End: end}, true
+		case token.LEFT_BRACKET, token.LEFT_PAREN:
+			nested = append(nested, t.Type)
+		case token.RIGHT_BRACKET, token.RIGHT_PAREN:
+			// a closing delimiter also closes the ones left open in the
+			// delimiters it closes.
+			i := len(nested) - 1
+			for i >= 0 && closer(nested[i]) != t.Type {
+				i--
+			}
+			if i < 0 {
+				if t.Start == cp.from {
+					// no rule starts with it, so skip it.
+					continue
+				}
+				return t, true
+			}
+			nested = nested[:i]
+		case token.COMMA:
+			if len(nested) == 0 {
+				return t, true
+			}
+		case token.IDENT:
+			if p.bare && len(nested) == 0 && t.Start.Line > cp.from.Line {
+				return t, true
+			}
+		}
+	}
+}
+
+// tokenAt scans the token at pos again.
+func (p *parser) tokenAt(pos token.Pos) (token.Token, bool) {
+	src := p.lexer.scanner.Source(pos.Index)
+	if src == "" && pos != p.eof {
+		return token.Token{}, false
+	}
+	t := scanner.New(src).Next()
+	t.Start, t.End = pos, pos.Advance(src[:t.End.Index])
+	return t, true
+}
+
+// badText returns the end and the text of the bad node starting at cp and
+// ending before stop. Comments at the end of the text are left out of it
+// and returned as line comments.
+func (p *parser) badText(cp *checkpoint, stop token.Token) (token.Pos, string, ast.CommentGroup) {
+	src := p.lexer.scanner.Source(cp.from.Index)
+	if n := stop.Start.Index - cp.from.Index; n >= 0 && n <= len(src) {
+		src = src[:n]
+	} else {
+		src = ""
+	}
+	var end int
+	var comments ast.CommentGroup
+	s := scanner.New(src)
+	for t := s.Next(); t.Type != token.EOF; t = s.Next() {
+		if t.Type == token.ILLEGAL {
+			end, comments = len(strings.TrimRight(src, " \t\r\n")), nil
+			break
+		}
+		if t.Type == token.COMMENT {
+			if p.cfg.comments {
+				comments = append(comments, &ast.Comment{SharpPos: cp.from.Advance(src[:t.Start.Index]), Text: t.Value})
+			}
+			continue
+		}
+		end, comments = t.End.Index, nil
+	}
+	text := src[:end]
+	return cp.from.Advance(text), text, comments
+}
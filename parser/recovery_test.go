@@ -0,0 +1,213 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/diag"
+)
+
+func TestWithErrorRecovery(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		rules []string
+		diags []string
+	}{
+		{
+			name:  "bad expression",
+			input: "Rules = [\n\tCompleteness \"a\" > ,\n\tIsComplete \"b\"\n]",
+			rules: []string{"Completeness BadExpr 2:19-2:20 `>`", "IsComplete"},
+			diags: []string{"rules.dqdl:2:21: error: syntax error near ` ,`, unexpected token `,` [syntax-error]"},
+		},
+		{
+			name:  "bad expression at the end",
+			input: "Rules = [\n\tCompleteness \"a\" between 0.5 # note\n]",
+			rules: []string{"Completeness BadExpr 2:19-2:30 `between 0.5` # note"},
+			diags: []string{"rules.dqdl:3:1: error: syntax error near ``, expected `and` but got `]` [syntax-error]"},
+		},
+		{
+			name:  "bad rule",
+			input: "Rules = [\n\tIsComplete \"a\" \"b\" extra,\n\tIsComplete \"c\"\n]",
+			rules: []string{"BadRule 2:2-2:26 `IsComplete \"a\" \"b\" extra`", "IsComplete"},
+			diags: []string{"rules.dqdl:2:21: error: syntax error near ` extra,`, RuleType is already defined [syntax-error]"},
+		},
+		{
+			name:  "bad value in a list",
+			input: "Rules = [\n\tColumnValues \"a\" in [\"x\", , \"y\"],\n\tIsComplete \"c\"\n]",
+			rules: []string{"ColumnValues BadExpr 2:19-2:34 `in [\"x\", , \"y\"]`", "IsComplete"},
+			diags: []string{"rules.dqdl:2:28: error: syntax error near ` , \"y\"],`, unexpected token `,` [syntax-error]"},
+		},
+		{
+			name:  "bad nested rule",
+			input: "Rules = [\n\t(IsComplete \"a\") and (Completeness \"b\" > ),\n\tIsComplete \"c\"\n]",
+			rules: []string{"CombinedRule", "IsComplete"},
+			diags: []string{"rules.dqdl:2:43: error: syntax error near ` ),`, unexpected token `)` [syntax-error]"},
+		},
+		{
+			name:  "unclosed paren",
+			input: "Rules = [\n\tIsComplete \"a\" (\"x\",\n\tIsComplete \"b\"\n]\nRules = [ IsComplete \"c\" ]",
+			rules: []string{"BadRule 2:2-3:16 `IsComplete \"a\" (\"x\",\n\tIsComplete \"b\"`", "IsComplete"},
+			diags: []string{"rules.dqdl:2:17: error: syntax error near ` (\"x\",`, unexpected `(` [syntax-error]"},
+		},
+		{
+			name:  "missing right bracket",
+			input: "Rules = [\n\tIsComplete \"a\",\n\tCompleteness \"b\" > 0.5,\n",
+			rules: []string{"IsComplete", "Completeness"},
+			diags: []string{"rules.dqdl:4:1: error: syntax error near ``, `[` opened at 1:9 is never closed [syntax-error]"},
+		},
+		{
+			name:  "missing right bracket without trailing comma",
+			input: "Rules = [\n\tIsComplete \"a\",\n\tIsUnique \"b\"\n",
+			rules: []string{"IsComplete", "IsUnique"},
+			diags: []string{"rules.dqdl:4:1: error: syntax error near ``, `[` opened at 1:9 is never closed [syntax-error]"},
+		},
+		{
+			name:  "missing right bracket after a single rule",
+			input: "Rules = [ IsComplete \"a\" ",
+			rules: []string{"IsComplete"},
+			diags: []string{"rules.dqdl:1:26: error: syntax error near ` `, `[` opened at 1:9 is never closed [syntax-error]"},
+		},
+		{
+			name:  "missing right bracket before ruleset",
+			input: "Rules = [\n\tIsComplete \"a\"\n# b\nRules = [ IsComplete \"b\" ]",
+			rules: []string{"IsComplete", "IsComplete"},
+			diags: []string{"rules.dqdl:4:1: error: syntax error near ``, `[` opened at 1:9 is never closed [syntax-error]"},
+		},
+		{
+			name:  "unrecognized character",
+			input: "Rules = [\n\tIsComplete \"a\" @@,\n\tIsComplete \"b\"\n]",
+			rules: []string{"BadRule 2:2-2:19 `IsComplete \"a\" @@`", "IsComplete"},
+			diags: []string{"rules.dqdl:2:17: error: syntax error near ` @@,`, unrecognized character: U+0040 '@' [syntax-error]"},
+		},
+		{
+			name:  "empty rule",
+			input: "Rules = [ , IsComplete \"a\" ]",
+			rules: []string{"IsComplete"},
+			diags: []string{"rules.dqdl:1:11: error: syntax error near ` , IsComplete \"a\" ]`, RuleType is required: unexpected `,` [syntax-error]"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var diags []string
+			file, err := ParseFile("rules.dqdl", strings.NewReader(c.input), WithErrorRecovery(func(d diag.Diagnostic) {
+				diags = append(diags, d.String())
+			}))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var rules []string
+			for _, ruleset := range file.Rulesets {
+				for _, rule := range ruleset.Rules {
+					rules = append(rules, describeRecovered(rule))
+				}
+			}
+			if diff := cmp.Diff(c.rules, rules); diff != "" {
+				t.Errorf("rules (-want, +got)\n%s", diff)
+			}
+			if diff := cmp.Diff(c.diags, diags); diff != "" {
+				t.Errorf("diagnostics (-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+// describeRecovered returns the type of rule, or the range and the text
+// of the bad node in it.
+func describeRecovered(rule ast.RuleDecl) string {
+	bad := func(from, to fmt.Stringer, text string, comments ast.CommentGroup) string {
+		s := fmt.Sprintf("%s-%s `%s`", from, to, text)
+		for _, c := range comments {
+			s += " " + c.Text
+		}
+		return s
+	}
+	switch r := rule.(type) {
+	case *ast.BadRule:
+		return "BadRule " + bad(r.From, r.To, r.Text, r.Comments)
+	case *ast.Rule:
+		if x, ok := r.Expression.(*ast.BadExpr); ok {
+			return r.Type.Name + " BadExpr " + bad(x.From, x.To, x.Text, x.Comments)
+		}
+		return r.Type.Name
+	}
+	return fmt.Sprintf("%T", rule)[len("*ast."):]
+}
+
+func TestWithErrorRecovery__Bare(t *testing.T) {
+	var diags []string
+	ruleset, err := ParseBareRules("IsComplete \"a\" >\nColumnValues \"b\" in [1,\nIsComplete \"c\"", WithErrorRecovery(func(d diag.Diagnostic) {
+		diags = append(diags, d.String())
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var rules []string
+	for _, rule := range ruleset.Rules {
+		rules = append(rules, describeRecovered(rule))
+	}
+	want := []string{"IsComplete BadExpr 1:16-1:17 `>`", "ColumnValues BadExpr 2:18-3:15 `in [1,\nIsComplete \"c\"`"}
+	if diff := cmp.Diff(want, rules); diff != "" {
+		t.Errorf("rules (-want, +got)\n%s", diff)
+	}
+	if len(diags) != 2 {
+		t.Errorf("got %d diagnostics, want 2: %q", len(diags), diags)
+	}
+}
+
+func TestWithErrorRecovery__Unrecoverable(t *testing.T) {
+	var diags []diag.Diagnostic
+	_, err := ParseFile("rules.dqdl", strings.NewReader("Foo\nRules = [ IsComplete \"a\" > ]"), WithErrorRecovery(func(d diag.Diagnostic) {
+		diags = append(diags, d)
+	}))
+	if err == nil {
+		t.Fatal("expected an error for text outside of a ruleset")
+	}
+	if len(diags) != 0 {
+		t.Errorf("diagnostics reported for a failed parse: %v", diags)
+	}
+}
+
+func TestWithMaxErrors(t *testing.T) {
+	const input = "Rules = [\n\tCompleteness \"a\" > ,\n\tIsComplete \"b\" \"c\" x,\n\tRowCount between 1\n]"
+	cases := []struct {
+		max     int
+		rules   int
+		diags   int
+		wantErr string
+	}{
+		{max: 0, rules: 3, diags: 3},
+		{max: 3, rules: 3, diags: 3},
+		{max: 2, wantErr: "rules.dqdl:5:1: syntax error near ``, expected `and` but got `]`"},
+		{max: 1, wantErr: "rules.dqdl:3:21: syntax error near ` x,`, RuleType is already defined"},
+	}
+	for _, c := range cases {
+		t.Run(fmt.Sprint(c.max), func(t *testing.T) {
+			var diags []diag.Diagnostic
+			file, err := ParseFile("rules.dqdl", strings.NewReader(input), WithMaxErrors(c.max), WithErrorRecovery(func(d diag.Diagnostic) {
+				diags = append(diags, d)
+			}))
+			if c.wantErr != "" {
+				if err == nil || err.Error() != c.wantErr {
+					t.Fatalf("got error %v, want %s", err, c.wantErr)
+				}
+				if len(diags) != 0 {
+					t.Errorf("diagnostics reported for a failed parse: %v", diags)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := len(file.Rulesets[0].Rules); got != c.rules {
+				t.Errorf("got %d rules, want %d", got, c.rules)
+			}
+			if len(diags) != c.diags {
+				t.Errorf("got %d diagnostics, want %d: %v", len(diags), c.diags, diags)
+			}
+		})
+	}
+}
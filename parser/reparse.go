@@ -0,0 +1,383 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/scanner"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// Range はソース中の範囲を表します。
+// A Range is the part of a source from Start up to, but not including,
+// End. Only the Index of the positions is used.
+type Range struct {
+	Start token.Pos
+	End   token.Pos
+}
+
+// Reparse は編集された範囲のルールのみを再度構文解析します。
+// Reparse returns the file resulting from replacing the text of edit in
+// old.Source with newText. Only the rules touched by the edit are parsed
+// again: the others are reused with their positions updated, which keeps
+// reparsing cheap on large files. Edits that can not be handled locally,
+// such as ones changing the brackets of a ruleset or its first or last
+// rule, fall back to parsing the whole new source with ParseFile, so the
+// result and the errors are always the same as ParseFile's.
+//
+// The nodes of old are reused and may be modified, so old must not be used
+// after Reparse. old must have been parsed with the same options as opts.
+func Reparse(old *ast.File, edit Range, newText string, opts ...Option) (*ast.File, error) {
+	start, end := edit.Start.Index, edit.End.Index
+	if old.Source == "" {
+		return nil, fmt.Errorf("parser: Reparse requires the source of %s", old.Filename)
+	}
+	if start < 0 || start > end || end > len(old.Source) {
+		return nil, fmt.Errorf("parser: invalid edit range [%d, %d) of %s", start, end, old.Filename)
+	}
+	src := old.Source[:start] + newText + old.Source[end:]
+	if file, ok := reparse(old, start, end, src, opts); ok {
+		return file, nil
+	}
+	return ParseFile(old.Filename, strings.NewReader(src), opts...)
+}
+
+// reparse parses the rules between the commas around the edit of
+// old.Source[start:end], which turned it into src. It returns false if the
+// edit is not between two commas of a ruleset, or if the rules between
+// them can not be parsed on their own. A panic also returns false, leaving
+// the input to ParseFile.
+func reparse(old *ast.File, start, end int, src string, opts []Option) (file *ast.File, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			file, ok = nil, false
+		}
+	}()
+	if newConfig(opts).strict {
+		// the strict checks need every token of the file.
+		return nil, false
+	}
+	i, rs := rulesetAround(old, start, end)
+	if rs == nil || rs.Legacy || len(rs.InnerComments) > 0 {
+		return nil, false
+	}
+	rules := rs.Rules
+	// left is the last rule whose comma ends before the edit.
+	left := sort.Search(len(rules), func(k int) bool { return rules[k].End().Index > start }) - 1
+	var leftComma token.Token
+	for ; left >= 0; left-- {
+		c, ok := commaAfter(old.Source, rules[left])
+		if !ok {
+			return nil, false
+		}
+		if c.End.Index <= start {
+			leftComma = c
+			break
+		}
+	}
+	if left < 0 {
+		return nil, false
+	}
+	// right is the first rule whose preceding comma starts after the edit.
+	right := sort.Search(len(rules), func(k int) bool { return rules[k].Pos().Index >= end })
+	if right <= left {
+		right = left + 1
+	}
+	var rightComma token.Token
+	for ; right < len(rules); right++ {
+		c, ok := commaAfter(old.Source, rules[right-1])
+		if !ok {
+			return nil, false
+		}
+		if c.Start.Index >= end {
+			rightComma = c
+			break
+		}
+	}
+	if right >= len(rules) {
+		return nil, false
+	}
+
+	delta := len(src) - len(old.Source)
+	regionEnd := rightComma.Start.Index + delta
+	p := newParser(old.Filename, "", opts)
+	p.filename = old.Filename
+	p.scan(newLexerAt(old.Filename, src[:regionEnd], positionAt(src, leftComma.End.Index)))
+	region, ok := p.parseRegion()
+	if !ok {
+		return nil, false
+	}
+
+	oldEnd, newEnd := positionAt(old.Source, end), positionAt(src, end+delta)
+	s := &shifter{
+		from:     rightComma.Start.Index,
+		delta:    delta,
+		line:     oldEnd.Line,
+		lines:    newEnd.Line - oldEnd.Line,
+		columns:  newEnd.Column - oldEnd.Column,
+		comments: make(map[*ast.Comment]bool),
+	}
+	newRules := make([]ast.RuleDecl, 0, left+1+len(region)+len(rules)-right)
+	newRules = append(newRules, rules[:left+1]...)
+	newRules = append(newRules, region...)
+	for _, rule := range rules[right:] {
+		s.rule(rule)
+		newRules = append(newRules, rule)
+	}
+	newRS := *rs
+	newRS.Rules = newRules
+	newRS.CommaPositions = nil
+	for _, c := range rs.CommaPositions {
+		if c.Index <= leftComma.Start.Index {
+			newRS.CommaPositions = append(newRS.CommaPositions, c)
+		}
+	}
+	newRS.CommaPositions = append(newRS.CommaPositions, p.commas...)
+	for _, c := range rs.CommaPositions {
+		if c.Index >= rightComma.Start.Index {
+			s.pos(&c)
+			newRS.CommaPositions = append(newRS.CommaPositions, c)
+		}
+	}
+	s.pos(&newRS.RightBracketPos)
+	s.group(newRS.Comments)
+
+	newFile := *old
+	newFile.Source = src
+	newFile.Rulesets = append([]*ast.Ruleset(nil), old.Rulesets...)
+	newFile.Rulesets[i] = &newRS
+	for _, later := range newFile.Rulesets[i+1:] {
+		s.ruleset(later)
+	}
+	for _, group := range newFile.CommentGroups {
+		s.group(group)
+	}
+	s.pos(&newFile.EOF)
+	if p.cfg.fileSet != nil {
+		p.cfg.fileSet.AddFile(newFile.Filename, src)
+	}
+	return &newFile, true
+}
+
+// rulesetAround returns the ruleset whose brackets enclose the range
+// [start, end) and its index, or nil.
+func rulesetAround(file *ast.File, start, end int) (int, *ast.Ruleset) {
+	for i, rs := range file.Rulesets {
+		if rs.LeftBracketPos.Index < start && end <= rs.RightBracketPos.Index {
+			return i, rs
+		}
+	}
+	return -1, nil
+}
+
+// commaAfter returns the comma following rule in src, skipping comments.
+func commaAfter(src string, rule ast.RuleDecl) (token.Token, bool) {
+	s := scanner.New(src, scanner.WithStart(positionAt(src, rule.End().Index)))
+	for {
+		t := s.Next()
+		if t.Type != token.COMMENT {
+			return t, t.Type == token.COMMA
+		}
+	}
+}
+
+// parseRegion parses the rules of a part of a ruleset between two commas.
+// It returns false if the part is not a non-empty list of rules, or if it
+// has comments detached from the rules.
+func (p *parser) parseRegion() ([]ast.RuleDecl, bool) {
+	var rules []ast.RuleDecl
+	for {
+		t, ok := p.pop()
+		if !ok {
+			// the last rule ended at EOF.
+			break
+		}
+		if t.Type == token.EOF || t.Type == token.RIGHT_BRACKET {
+			// an empty rule, a trailing comma or the end of the ruleset.
+			return nil, false
+		}
+		p.push(t)
+		p.rulesetCommentGroups = nil
+		rule, err := p.parseRule(true, false)
+		if err != nil || len(p.rulesetCommentGroups) > 0 {
+			return nil, false
+		}
+		rules = append(rules, rule)
+	}
+	return rules, len(rules) > 0
+}
+
+// positionAt returns the position of the byte offset i of src.
+func positionAt(src string, i int) token.Pos {
+	lineStart := strings.LastIndexByte(src[:i], '\n') + 1
+	return token.Pos{
+		Index:  i,
+		Line:   strings.Count(src[:lineStart], "\n") + 1,
+		Column: utf8.RuneCountInString(src[lineStart:i]) + 1,
+	}
+}
+
+// shifter moves the positions after an edit to where they are in the
+// edited source.
+type shifter struct {
+	from     int // index of the first position to move
+	delta    int // change of the indexes
+	line     int // line of the end of the edit
+	lines    int // change of the lines
+	columns  int // change of the columns on the line of the end of the edit
+	comments map[*ast.Comment]bool
+}
+
+func (s *shifter) pos(pos *token.Pos) {
+	if pos == nil || !pos.IsValid() || pos.Index < s.from {
+		return
+	}
+	if pos.Line == s.line {
+		pos.Column += s.columns
+	}
+	pos.Line += s.lines
+	pos.Index += s.delta
+}
+
+func (s *shifter) positions(list []token.Pos) {
+	for i := range list {
+		s.pos(&list[i])
+	}
+}
+
+// group moves the comments of g. A comment may be shared by several
+// groups, so each one is moved only once.
+func (s *shifter) group(g ast.CommentGroup) {
+	for _, c := range g {
+		if !s.comments[c] {
+			s.comments[c] = true
+			s.pos(&c.SharpPos)
+		}
+	}
+}
+
+func (s *shifter) ruleset(rs *ast.Ruleset) {
+	s.group(rs.Description)
+	s.pos(&rs.DeclPos)
+	s.pos(&rs.EqualPos)
+	s.pos(&rs.LeftBracketPos)
+	for _, rule := range rs.Rules {
+		s.rule(rule)
+	}
+	s.positions(rs.CommaPositions)
+	for _, group := range rs.InnerComments {
+		s.group(group)
+	}
+	s.pos(&rs.RightBracketPos)
+	s.group(rs.Comments)
+}
+
+func (s *shifter) rule(rule ast.RuleDecl) {
+	switch r := rule.(type) {
+	case *ast.Rule:
+		s.group(r.Description)
+		s.ident(r.Type)
+		for _, param := range r.Parameters {
+			s.parameter(param)
+		}
+		s.expression(r.Expression)
+		s.group(r.Comments)
+	case *ast.CombinedRule:
+		s.group(r.Description)
+		s.pos(&r.FirstLParenPos)
+		for _, nested := range r.Rules {
+			s.rule(nested)
+		}
+		s.positions(r.OperatorPositions)
+		s.pos(&r.LastRParenPos)
+		s.group(r.Comments)
+	case *ast.BadRule:
+		s.pos(&r.From)
+		s.pos(&r.To)
+		s.group(r.Comments)
+	}
+}
+
+func (s *shifter) ident(x *ast.Ident) {
+	if x == nil {
+		return
+	}
+	s.pos(&x.NamePos)
+	s.group(x.Comments)
+}
+
+func (s *shifter) parameter(param ast.Parameter) {
+	switch x := param.(type) {
+	case *ast.StringParameter:
+		s.pos(&x.LeftQuotePos)
+		s.pos(&x.RightQuotePos)
+		s.group(x.Comments)
+	case *ast.NumberParameter:
+		s.pos(&x.NumberPos)
+		s.group(x.Comments)
+	case *ast.BoolParameter:
+		s.pos(&x.BoolPos)
+		s.group(x.Comments)
+	case *ast.DurationParameter:
+		s.duration(x)
+	case *ast.DateParameter:
+		s.pos(x.LeftParenPos)
+		s.pos(&x.NowPos)
+		s.pos(x.MinusPos)
+		s.duration(x.Duration)
+		s.pos(x.RightParenPos)
+		s.group(x.Comments)
+	}
+}
+
+func (s *shifter) duration(x *ast.DurationParameter) {
+	if x == nil {
+		return
+	}
+	s.pos(&x.NumberPos)
+	s.pos(&x.UnitPos)
+	s.group(x.Comments)
+}
+
+func (s *shifter) expression(expr ast.Expression) {
+	switch x := expr.(type) {
+	case *ast.ComparisonExpression:
+		s.pos(&x.ExprPos)
+		s.parameter(x.Right)
+		s.group(x.Comments)
+	case *ast.BetweenExpression:
+		s.pos(&x.ExprPos)
+		s.parameter(x.Left)
+		s.parameter(x.Right)
+		s.group(x.Comments)
+	case *ast.InExpression:
+		s.pos(&x.ExprPos)
+		s.pos(&x.LeftBracketPos)
+		for _, v := range x.Values {
+			s.parameter(v)
+		}
+		s.positions(x.CommaPositions)
+		s.pos(&x.RightBracketPos)
+		s.group(x.Comments)
+	case *ast.MatchesExpression:
+		s.pos(&x.ExprPos)
+		s.pos(&x.RegexpPos)
+		s.group(x.Comments)
+	case *ast.WithThresholdExpression:
+		s.pos(&x.ExprPos)
+		if x.Target != nil {
+			s.expression(x.Target)
+		}
+		if x.Threshold != nil {
+			s.expression(x.Threshold)
+		}
+		s.group(x.Comments)
+	case *ast.BadExpr:
+		s.pos(&x.From)
+		s.pos(&x.To)
+		s.group(x.Comments)
+	}
+}
@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+func TestReparse(t *testing.T) {
+	src := `# checks of orders
+Rules = [
+	RowCount > 0,
+	# the id
+	IsComplete "id", # required
+	ColumnValues "status" in ["a", "b"],
+	(IsUnique "id") and (ColumnLength "id" = 8),
+	Mean "price" between 1 and 10 # avg
+]
+
+Rules = [
+	ColumnValues "created_at" > (now() - 3 days),
+	IsComplete "x", IsComplete "y", IsComplete "z", IsComplete "w",
+	RowCount > 10
+]
+# end
+`
+	cases := []struct {
+		name    string
+		old     string // text of the edit in src
+		new     string
+		reused  bool // the first rule is reused
+		wantErr string
+	}{
+		{name: "in a rule", old: `"id", # required`, new: `"order_id", # required`, reused: true},
+		{name: "add lines", old: `"status" in`, new: "\"status\"\n\t\tin", reused: true},
+		{name: "add a rule", old: `"a", "b"],`, new: `"a", "b"],` + "\n\tIsUnique \"status\",", reused: true},
+		{name: "remove a rule", old: "ColumnValues \"status\" in [\"a\", \"b\"],\n\t", new: ``, reused: true},
+		{name: "edit a description", old: `# the id`, new: "# the primary key\n\t# of orders", reused: true},
+		{name: "first rule", old: `RowCount > 0`, new: `RowCount > 1`},
+		{name: "last rule", old: `Mean "price"`, new: `Sum "price"`},
+		{name: "second ruleset", old: `3 days`, new: `30 days`},
+		{name: "same line", old: `"y"`, new: `"yé"`, reused: true},
+		{name: "remove a comma", old: `"id", # required`, new: `"id" # required`, wantErr: "orders.dqdl:6:2: syntax error near `\tColumnValues \"statu...`, RuleType is already defined"},
+		{name: "syntax error", old: `ColumnValues "status" in`, new: `ColumnValues "status" inn`, wantErr: "orders.dqdl:6:24: syntax error near ` inn [\"a\", \"b\"],`, RuleType is already defined"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			old, err := ParseFile("orders.dqdl", strings.NewReader(src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			first := old.Rulesets[0].Rules[0]
+			start := strings.Index(src, c.old)
+			if start < 0 {
+				t.Fatalf("%q not found", c.old)
+			}
+			edit := Range{Start: token.Pos{Index: start}, End: token.Pos{Index: start + len(c.old)}}
+			newSrc := src[:start] + c.new + src[start+len(c.old):]
+			want, wantErr := ParseFile("orders.dqdl", strings.NewReader(newSrc))
+			got, err := Reparse(old, edit, c.new)
+			if c.wantErr != "" {
+				if err == nil || err.Error() != c.wantErr || wantErr == nil || wantErr.Error() != c.wantErr {
+					t.Fatalf("got error %v, ParseFile %v, want %s", err, wantErr, c.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+			if reused := got.Rulesets[0].Rules[0] == first; reused != c.reused {
+				t.Errorf("first rule reused %v, want %v", reused, c.reused)
+			}
+		})
+	}
+}
+
+func TestReparse__Invalid(t *testing.T) {
+	old, err := ParseFile("orders.dqdl", strings.NewReader(`Rules = [ RowCount > 0 ]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Reparse(old, Range{Start: token.Pos{Index: 10}, End: token.Pos{Index: 100}}, ""); err == nil {
+		t.Error("expected an error for a range out of the source")
+	}
+	old.Source = ""
+	if _, err := Reparse(old, Range{}, ""); err == nil {
+		t.Error("expected an error for a file without source")
+	}
+}
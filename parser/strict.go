@@ -0,0 +1,90 @@
+package parser
+
+import "github.com/mashiike/go-dqdl/token"
+
+// WithStrictGlueCompat は AWS Glue が受け付けない構文をエラーにするかどうかを指定します。デフォルトは false です。
+// WithStrictGlueCompat sets whether the parser rejects the syntax it
+// tolerates but AWS Glue Data Quality does not, so that a ruleset that
+// parses locally does not fail in CreateDataQualityRuleset. In strict mode
+// the following are syntax errors:
+//
+//   - more than one ruleset in a file
+//   - an empty ruleset, `Rules = []`
+//   - a trailing comma after the last rule
+//   - a comment between the tokens of a rule
+//   - a bare list of rules, see ParseBareRules
+//
+// Comments on their own lines between rules and at the end of a line after
+// a rule are accepted. The default is false.
+func WithStrictGlueCompat(enabled bool) Option {
+	return func(c *config) {
+		c.strict = enabled
+	}
+}
+
+// strictChecker follows the tokens read by a parser in strict mode and
+// records the first one Glue does not accept. It only looks at the tokens
+// themselves, so it works the same in every parse mode.
+type strictChecker struct {
+	err      error
+	rulesets int          // number of `Rules` seen
+	open     bool         // inside the brackets of a ruleset
+	depth    int          // nesting of parens and of brackets other than the ruleset's
+	inRule   bool         // a rule has started
+	comment  *token.Token // comment inside a rule, reported unless the rule ends next
+	last     token.Token  // last token that is not a comment
+}
+
+// observe checks the token t read by p.
+func (s *strictChecker) observe(p *parser, t token.Token) {
+	if s.err != nil {
+		return
+	}
+	if t.Type == token.COMMENT {
+		if s.inRule && s.comment == nil {
+			s.comment = &t
+		}
+		return
+	}
+	ends := s.depth == 0 && (t.Type == token.COMMA || t.Type == token.EOF || (s.open && t.Type == token.RIGHT_BRACKET))
+	if s.comment != nil && !ends {
+		s.err = p.errorf(s.comment.Start, "Glue does not accept a comment inside a rule")
+		return
+	}
+	s.comment = nil
+	switch {
+	case t.Type == token.RULES:
+		s.rulesets++
+		if s.rulesets > 1 {
+			s.err = p.errorf(t.Start, "Glue accepts only one ruleset")
+			return
+		}
+	case t.Type == token.LEFT_BRACKET && !s.open && s.last.Type == token.EQUAL:
+		s.open, s.inRule = true, false
+	case t.Type == token.RIGHT_BRACKET && s.open && s.depth == 0:
+		switch s.last.Type {
+		case token.LEFT_BRACKET:
+			s.err = p.errorf(t.Start, "Glue does not accept an empty ruleset")
+			return
+		case token.COMMA:
+			s.err = p.errorf(s.last.Start, "Glue does not accept a trailing comma")
+			return
+		}
+		s.open, s.inRule = false, false
+	case t.Type == token.EOF:
+		if s.last.Type == token.COMMA {
+			s.err = p.errorf(s.last.Start, "Glue does not accept a trailing comma")
+			return
+		}
+	case t.Type == token.COMMA && s.depth == 0:
+		s.inRule = false
+	case t.Type == token.LEFT_PAREN || t.Type == token.LEFT_BRACKET:
+		s.depth++
+		s.inRule = true
+	case t.Type == token.RIGHT_PAREN || t.Type == token.RIGHT_BRACKET:
+		s.depth--
+	default:
+		s.inRule = true
+	}
+	s.last = t
+}
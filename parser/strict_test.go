@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithStrictGlueCompat(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		errStr string
+	}{
+		{
+			name: "accepted",
+			input: `# orders
+Rules = [ # rules
+	# row count
+	RowCount > 0, # not empty
+	IsComplete "id" # trailing
+	,
+	(IsComplete "a") and (ColumnValues "b" in ["x", "y"] with threshold > 0.5)
+] # end
+`,
+		},
+		{
+			name:   "multiple rulesets",
+			input:  "Rules = [ RowCount > 0 ]\nRules = [ IsComplete \"id\" ]",
+			errStr: "2:1: syntax error near ``, Glue accepts only one ruleset",
+		},
+		{
+			name:   "empty ruleset",
+			input:  "Rules = [ ]",
+			errStr: "1:11: syntax error near ` ]`, Glue does not accept an empty ruleset",
+		},
+		{
+			name:   "trailing comma",
+			input:  "Rules = [\n\tRowCount > 0,\n]",
+			errStr: "2:14: syntax error near `0,`, Glue does not accept a trailing comma",
+		},
+		{
+			name:   "comment inside a rule",
+			input:  "Rules = [\n\tColumnValues \"a\" # column\n\t\tin [\"x\"]\n]",
+			errStr: "2:19: syntax error near ` # column`, Glue does not accept a comment inside a rule",
+		},
+		{
+			name:   "comment inside an in-list",
+			input:  "Rules = [\n\tColumnValues \"a\" in [\n\t\t\"x\", # x\n\t\t\"y\"\n\t]\n]",
+			errStr: "3:8: syntax error near ` # x`, Glue does not accept a comment inside a rule",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := ParseFile("", strings.NewReader(c.input), WithStrictGlueCompat(true))
+			if c.errStr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || err.Error() != c.errStr {
+				t.Errorf("got error %v, want %s", err, c.errStr)
+			}
+			if _, err := ParseFile("", strings.NewReader(c.input)); err != nil {
+				t.Errorf("not strict: %v", err)
+			}
+		})
+	}
+}
+
+func TestWithStrictGlueCompat__Rule(t *testing.T) {
+	if _, err := ParseRule(`IsComplete "id" # trailing`, WithStrictGlueCompat(true)); err != nil {
+		t.Error(err)
+	}
+	if _, err := ParseRule("IsComplete # type\n \"id\"", WithStrictGlueCompat(true)); err == nil {
+		t.Error("expected an error for a comment inside a rule")
+	}
+	if _, err := ParseBareRules("IsComplete \"id\"\nRowCount > 0", WithStrictGlueCompat(true)); err == nil {
+		t.Error("expected an error for bare rules")
+	}
+}
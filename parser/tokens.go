@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"context"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// TokenReader は構文解析器が読み込むトークンの列です。
+// A TokenReader is a stream of tokens read by the parser. Callers may
+// supply their own with ParseTokens, e.g. a preprocessor or a macro
+// expander wrapping the reader returned by NewTokenReader, or a
+// synthetic stream in tests. The stream ends with a token.EOF token, or a
+// token.ILLEGAL token for a lexical error, as the tokens of the scanner.
+type TokenReader interface {
+	// Next returns the next token, or false after the end of the stream.
+	Next() (token.Token, bool)
+	// Peek returns the token Next returns, without reading it.
+	Peek() (token.Token, bool)
+	// Push pushes t back, so that Next returns it. Tokens pushed back are
+	// returned last in, first out, before the rest of the stream.
+	Push(t token.Token)
+}
+
+// NewTokenReader は input のトークンの列を返します。
+// NewTokenReader returns a TokenReader of the tokens of input, including
+// the comments, as read by ParseFile.
+func NewTokenReader(input string) TokenReader {
+	return newLexer("", input)
+}
+
+// ParseTokens はトークンの列についての構文解析を行います。
+// ParseTokens parses the tokens read from r as a DQDL file. The positions
+// of the nodes are those of the tokens. Since the parser does not see the
+// source text, syntax errors do not quote it, the nodes of WithErrorRecovery
+// have no text and the returned file has no Source.
+func ParseTokens(filename string, r TokenReader, opts ...Option) (*ast.File, error) {
+	return ParseTokensContext(context.Background(), filename, r, opts...)
+}
+
+// ParseTokensContext はコンテキストを指定してトークンの列についての構文解析を行います。
+// ParseTokensContext is like ParseTokens but aborts with ctx.Err() when
+// ctx is done.
+func ParseTokensContext(ctx context.Context, filename string, r TokenReader, opts ...Option) (*ast.File, error) {
+	p := newParser(filename, "", opts)
+	p.filename = filename
+	p.tokens = r
+	var file *ast.File
+	err := p.run(ctx, func() (err error) {
+		file, err = p.parseFile()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	file.Filename = filename
+	return file, nil
+}
@@ -0,0 +1,158 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// macroReader expands the rule type IsKey into IsComplete and IsUnique of
+// the same column.
+type macroReader struct {
+	src     TokenReader
+	pending []token.Token // read before src, first to last
+}
+
+func (r *macroReader) Next() (token.Token, bool) {
+	if len(r.pending) > 0 {
+		t := r.pending[0]
+		r.pending = r.pending[1:]
+		return t, true
+	}
+	t, ok := r.src.Next()
+	if !ok || t.Type != token.IDENT || t.Value != "IsKey" {
+		return t, ok
+	}
+	column, ok := r.src.Next()
+	if !ok {
+		return t, true
+	}
+	ident := func(name string) token.Token {
+		return token.Token{Type: token.IDENT, Value: name, Start: t.Start, End: t.End}
+	}
+	comma := token.Token{Type: token.COMMA, Value: ",", Start: column.End, End: column.End}
+	r.pending = append(r.pending, column, comma, ident("IsUnique"), column)
+	return ident("IsComplete"), true
+}
+
+func (r *macroReader) Peek() (token.Token, bool) {
+	t, ok := r.Next()
+	if ok {
+		r.Push(t)
+	}
+	return t, ok
+}
+
+func (r *macroReader) Push(t token.Token) {
+	r.pending = append([]token.Token{t}, r.pending...)
+}
+
+func TestParseTokens(t *testing.T) {
+	src := `# orders
+Rules = [
+	IsComplete "id", # required
+	(ColumnValues "status" in ["a", "b"]) or (RowCount > 0)
+]
+`
+	want, err := ParseFile("orders.dqdl", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.Source = ""
+	got, err := ParseTokens("orders.dqdl", NewTokenReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected file (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseTokens__Macro(t *testing.T) {
+	src := "Rules = [\n\tIsKey \"id\",\n\tRowCount > 0\n]\n"
+	file, err := ParseTokens("macro.dqdl", &macroReader{src: NewTokenReader(src)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, decl := range file.Rulesets[0].Rules {
+		r := decl.(*ast.Rule)
+		name := r.Type.Name
+		for _, p := range r.Parameters {
+			name += " " + p.(*ast.StringParameter).Value
+		}
+		got = append(got, name)
+	}
+	want := []string{"IsComplete id", "IsUnique id", "RowCount"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected rules (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseTokens__Synthetic(t *testing.T) {
+	at := func(col int) token.Pos {
+		return token.Pos{Index: col - 1, Line: 1, Column: col}
+	}
+	tok := func(typ token.TokenType, value string, col int) token.Token {
+		return token.Token{Type: typ, Value: value, Start: at(col), End: at(col + len(value))}
+	}
+	rules := []token.Token{
+		tok(token.RULES, "Rules", 1),
+		tok(token.EQUAL, "=", 7),
+		tok(token.LEFT_BRACKET, "[", 9),
+		tok(token.IDENT, "IsComplete", 11),
+		tok(token.STRING, `"id"`, 22),
+	}
+	cases := []struct {
+		name    string
+		tokens  []token.Token
+		want    string // the rules, or the error
+		wantErr bool
+	}{
+		{
+			name:   "ruleset",
+			tokens: append(rules[:5:5], tok(token.RIGHT_BRACKET, "]", 27), tok(token.EOF, "", 28)),
+			want:   "IsComplete at 1:11",
+		},
+		{
+			name:   "without the EOF",
+			tokens: append(rules[:5:5], tok(token.RIGHT_BRACKET, "]", 27)),
+			want:   "IsComplete at 1:11",
+		},
+		{
+			name:    "lexical error",
+			tokens:  append(rules[:5:5], tok(token.ILLEGAL, "unterminated string", 27)),
+			want:    "synthetic.dqdl:1:27: syntax error near ``, unterminated string",
+			wantErr: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := NewTokenReader("")
+			r.Next() // the EOF of the empty input
+			for i := len(c.tokens) - 1; i >= 0; i-- {
+				r.Push(c.tokens[i])
+			}
+			file, err := ParseTokens("synthetic.dqdl", r)
+			var got string
+			if err != nil {
+				got = err.Error()
+			} else {
+				var names []string
+				for _, decl := range file.Rulesets[0].Rules {
+					names = append(names, decl.(*ast.Rule).Type.Name+" at "+decl.Pos().String())
+				}
+				got = strings.Join(names, ", ")
+			}
+			if (err != nil) != c.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
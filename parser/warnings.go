@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/diag"
+)
+
+// 構文解析中に報告される警告のコードです。
+// Codes of the warnings reported to the function given by WithWarnings.
+const (
+	WarnThresholdOutOfRange = "threshold-out-of-range" // `with threshold` bound outside of [0, 1]
+	WarnDuplicateInValue    = "duplicate-in-value"     // value listed twice in `in [...]`
+)
+
+// WithWarnings は構文としては正しいが疑わしい箇所を報告する関数を指定します。
+// WithWarnings sets a function called with a diagnostic of severity warning
+// for each construct that parses but is likely a mistake, such as
+// `with threshold > 1.5` or a value listed twice in an in-list. The codes
+// of the diagnostics are the Warn constants. Warnings are reported in
+// source order once the input parses; an input with a syntax error reports
+// none. ParseDir serializes the calls.
+func WithWarnings(fn func(diag.Diagnostic)) Option {
+	return func(c *config) {
+		c.warnings = fn
+	}
+}
+
+// warn records a warning about node, reported when the parse succeeds.
+func (p *parser) warn(node ast.Node, code, format string, args ...interface{}) {
+	if p.cfg.warnings == nil {
+		return
+	}
+	p.warnings = append(p.warnings, diag.Diagnostic{
+		Code:     code,
+		Severity: diag.SeverityWarning,
+		Message:  fmt.Sprintf(format, args...),
+		Source:   "parser",
+		Filename: p.filename,
+		Pos:      node.Pos(),
+		End:      node.End(),
+	})
+}
+
+// checkThreshold warns about the bounds of a `with threshold` clause that
+// are not ratios.
+func (p *parser) checkThreshold(threshold ast.ThresholdExpression) {
+	var params []ast.Parameter
+	switch x := threshold.(type) {
+	case *ast.ComparisonExpression:
+		params = []ast.Parameter{x.Right}
+	case *ast.BetweenExpression:
+		params = []ast.Parameter{x.Left, x.Right}
+	}
+	for _, param := range params {
+		n, ok := param.(*ast.NumberParameter)
+		if !ok {
+			continue
+		}
+		if v, err := n.Float64(); err == nil && (v < 0 || v > 1) {
+			p.warn(n, WarnThresholdOutOfRange, "threshold %s is out of range [0, 1]", n.Value)
+		}
+	}
+}
+
+// checkInValues warns about the values of an in-list that are listed
+// before. Numbers are compared by value, so 1 and 1.0 are the same.
+func (p *parser) checkInValues(x *ast.InExpression) {
+	type key struct {
+		kind  byte
+		value string
+	}
+	// the values of a short list are searched rather than hashed
+	var short [8]key
+	keys := short[:0]
+	var seen map[key]bool
+	if len(x.Values) > len(short) {
+		seen = make(map[key]bool, len(x.Values))
+	}
+	for _, param := range x.Values {
+		var k key
+		switch v := param.(type) {
+		case *ast.StringParameter:
+			k = key{'s', v.Value}
+		case *ast.NumberParameter:
+			f, err := v.Float64()
+			if err != nil {
+				continue
+			}
+			k = key{'n', strconv.FormatFloat(f, 'g', -1, 64)}
+		case *ast.BoolParameter:
+			k = key{'b', strconv.FormatBool(v.Value)}
+		default:
+			continue
+		}
+		listed := seen[k]
+		if seen == nil {
+			for _, l := range keys {
+				listed = listed || l == k
+			}
+			keys = append(keys, k)
+		}
+		if listed {
+			p.warn(param, WarnDuplicateInValue, "duplicate value %s in the list", inValueText(param))
+			continue
+		}
+		if seen != nil {
+			seen[k] = true
+		}
+	}
+}
+
+// inValueText returns the text of a value of an in-list in a warning.
+func inValueText(param ast.Parameter) string {
+	switch v := param.(type) {
+	case *ast.StringParameter:
+		return strconv.Quote(v.Value)
+	case *ast.NumberParameter:
+		return v.Value
+	case *ast.BoolParameter:
+		return strconv.FormatBool(v.Value)
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/diag"
+)
+
+func TestWithWarnings(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "none",
+			input: `Rules = [ ColumnValues "a" in ["x", "y"] with threshold between 0 and 1 ]`,
+		},
+		{
+			name:  "threshold",
+			input: "Rules = [\n\tColumnValues \"a\" in [1] with threshold > 1.5,\n\tColumnValues \"b\" matches \"x\" with threshold between 0.5 and 2\n]",
+			want: []string{
+				"rules.dqdl:2:43: warning: threshold 1.5 is out of range [0, 1] [threshold-out-of-range]",
+				"rules.dqdl:3:62: warning: threshold 2 is out of range [0, 1] [threshold-out-of-range]",
+			},
+		},
+		{
+			name:  "duplicate in values",
+			input: `Rules = [ ColumnValues "a" in ["x", 1, "x", 1.0, true, "y", true] ]`,
+			want: []string{
+				`rules.dqdl:1:40: warning: duplicate value "x" in the list [duplicate-in-value]`,
+				`rules.dqdl:1:45: warning: duplicate value 1.0 in the list [duplicate-in-value]`,
+				`rules.dqdl:1:61: warning: duplicate value true in the list [duplicate-in-value]`,
+			},
+		},
+		{
+			name:  "duplicate in long values",
+			input: `Rules = [ ColumnValues "a" in [1, 2, 3, 4, 5, 6, 7, 8, 9, 2.0] ]`,
+			want: []string{
+				`rules.dqdl:1:59: warning: duplicate value 2.0 in the list [duplicate-in-value]`,
+			},
+		},
+		{
+			name:  "syntax error",
+			input: `Rules = [ ColumnValues "a" in ["x", "x"] with threshold > 2`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var got []string
+			ParseFile("rules.dqdl", strings.NewReader(c.input), WithWarnings(func(d diag.Diagnostic) {
+				got = append(got, d.String())
+			}))
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
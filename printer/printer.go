@@ -0,0 +1,595 @@
+// Package printer implements printing of DQDL AST nodes.
+//
+// The printer does not reproduce the original layout of the source. It
+// lays out every node in a canonical form: one rule per line, rulesets
+// separated by blank lines, and comments attached to the node they
+// belong to. Comments that are attached to inner parts of a rule (for
+// example a parameter written on its own line) are printed as trailing
+// comments of the rule.
+//
+// Rulesets and rules are written in the order of their slices, so a tree
+// edited by inserting nodes without positions prints as expected. Comment
+// groups are placed by position relative to them. Relayout updates the
+// positions of a tree to the ones of its printed form.
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// Mode は Config.Fprint の出力を制御するフラグです。
+// A Mode value is a set of flags (or 0). They control printing.
+type Mode uint
+
+const (
+	// NormalizeNumbers は数値リテラルを正規形で出力します。(例: 0.50 → 0.5, 1.0 → 1)
+	// NormalizeNumbers prints number literals in their normal form (see NormalizeNumber).
+	NormalizeNumbers Mode = 1 << iota
+	// OmitComments はコメントを出力しません。
+	// OmitComments omits all comments.
+	OmitComments
+)
+
+// Config は出力の設定です。
+// A Config node controls the output of Fprint.
+type Config struct {
+	Mode   Mode   // default: 0
+	Indent string // indentation of rules in a ruleset; default: "\t"
+}
+
+// Fprint はデフォルトの設定でノードを出力します。
+// Fprint "pretty-prints" an AST node to w with the default config.
+//
+// The node type must be *ast.File, *ast.Ruleset, ast.RuleDecl,
+// ast.Expression, ast.Parameter, *ast.Ident or ast.CommentGroup.
+func Fprint(w io.Writer, node interface{}) error {
+	return (&Config{}).Fprint(w, node)
+}
+
+// Fprint は設定に従ってノードを出力します。
+// Fprint "pretty-prints" an AST node to w according to the config.
+func (cfg *Config) Fprint(w io.Writer, node interface{}) error {
+	p := &printer{cfg: cfg, indent: cfg.Indent}
+	if p.indent == "" {
+		p.indent = "\t"
+	}
+	if err := p.node(node); err != nil {
+		return err
+	}
+	_, err := w.Write(p.buf.Bytes())
+	return err
+}
+
+// Relayout はノードの位置をデフォルトの設定で出力した時の位置に置き換えます。
+// Relayout sets the positions of node and of all nodes below it to the
+// positions they have in the output of Fprint. Use it after building or
+// editing a tree, when positions are zero or stale. The positions of a
+// node other than *ast.File are relative to the output of that node.
+// Relayout accepts the same node types as Fprint.
+func Relayout(node interface{}) error {
+	p := &printer{cfg: &Config{}, indent: "\t", relayout: true}
+	return p.node(node)
+}
+
+// NormalizeNumber は数値リテラルを正規形に変換します。
+// NormalizeNumber returns the normal form of a number literal: leading
+// zeros of the integer part and trailing zeros of the fraction are
+// removed, and a fraction that becomes empty is dropped together with
+// its dot. So "0.50" becomes "0.5", "1.0" becomes "1" and "007" becomes
+// "7". Literals that are not made of digits and at most one dot are
+// returned unchanged.
+//
+// Semantically identical numbers have the same normal form, so it is
+// what hashing and canonicalization use to compare rules.
+func NormalizeNumber(lit string) string {
+	intPart, fracPart := lit, ""
+	if i := strings.IndexByte(lit, '.'); i >= 0 {
+		intPart, fracPart = lit[:i], lit[i+1:]
+	}
+	if intPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return lit
+	}
+	intPart = strings.TrimLeft(intPart, "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+	fracPart = strings.TrimRight(fracPart, "0")
+	if fracPart == "" {
+		return intPart
+	}
+	return intPart + "." + fracPart
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+type printer struct {
+	cfg      *Config
+	indent   string
+	buf      bytes.Buffer
+	relayout bool // set the positions of nodes to their output positions
+
+	// position of the end of buf, scanned up to scanned
+	scanned, line, lineStart int
+}
+
+// pos returns the position of the next byte written to buf.
+func (p *printer) pos() token.Pos {
+	b := p.buf.Bytes()
+	for ; p.scanned < len(b); p.scanned++ {
+		if b[p.scanned] == '\n' {
+			p.line++
+			p.lineStart = p.scanned + 1
+		}
+	}
+	return token.Pos{Index: len(b), Line: p.line + 1, Column: utf8.RuneCount(b[p.lineStart:]) + 1}
+}
+
+// mark sets *pos to the position of the next byte written in relayout mode.
+func (p *printer) mark(pos *token.Pos) {
+	if p.relayout {
+		*pos = p.pos()
+	}
+}
+
+// markPtr is like mark for optional positions.
+func (p *printer) markPtr(pos **token.Pos) {
+	if p.relayout {
+		*pos = p.pos().Ptr()
+	}
+}
+
+// markNext appends to *list the position of the next byte written in
+// relayout mode. Lists of separator positions are reset by resetMarks
+// before their node is written.
+func (p *printer) markNext(list *[]token.Pos) {
+	if p.relayout {
+		*list = append(*list, p.pos())
+	}
+}
+
+// resetMarks empties *list in relayout mode.
+func (p *printer) resetMarks(list *[]token.Pos) {
+	if p.relayout {
+		*list = nil
+	}
+}
+
+func (p *printer) node(node interface{}) error {
+	switch n := node.(type) {
+	case *ast.File:
+		p.file(n)
+	case *ast.Ruleset:
+		p.ruleset(n)
+	case ast.RuleDecl:
+		p.ruleDecl(n, "", nil)
+	case ast.Expression:
+		p.expression(n)
+	case ast.Parameter:
+		p.parameter(n)
+	case *ast.Ident:
+		p.buf.WriteString(n.Name)
+	case ast.CommentGroup:
+		p.commentGroup(n, "")
+	default:
+		return fmt.Errorf("printer: unsupported node type %T", node)
+	}
+	return nil
+}
+
+func (p *printer) file(f *ast.File) {
+	// Rulesets are written in order. File level comment groups are placed
+	// before the first ruleset that follows them in the source; groups
+	// without a position come first.
+	groups := f.CommentGroups
+	if p.cfg.Mode&OmitComments != 0 {
+		groups = nil
+	}
+	groups = sortGroups(groups, -1)
+	type item struct {
+		group   ast.CommentGroup
+		ruleset *ast.Ruleset
+	}
+	items := make([]item, 0, len(groups)+len(f.Rulesets))
+	for _, r := range f.Rulesets {
+		pos := r.Pos()
+		if !pos.IsValid() && len(r.Rules) > 0 {
+			// a synthetic ruleset, e.g. of bare rules, is placed at its first rule.
+			pos = ruleDeclPos(r.Rules[0])
+		}
+		if pos.IsValid() {
+			for len(groups) > 0 && groupIndex(groups[0], -1) < pos.Index {
+				items = append(items, item{group: groups[0]})
+				groups = groups[1:]
+			}
+		}
+		items = append(items, item{ruleset: r})
+	}
+	for _, g := range groups {
+		items = append(items, item{group: g})
+	}
+	for i, it := range items {
+		if i > 0 {
+			p.buf.WriteString("\n")
+		}
+		if it.ruleset != nil {
+			p.ruleset(it.ruleset)
+		} else {
+			p.commentGroup(it.group, "")
+		}
+	}
+}
+
+// groupIndex returns the byte offset of g, or invalid if g has no position.
+func groupIndex(g ast.CommentGroup, invalid int) int {
+	if pos := g.Pos(); pos.IsValid() {
+		return pos.Index
+	}
+	return invalid
+}
+
+// sortGroups returns a copy of groups sorted by position. Groups without
+// a position are placed at invalid.
+func sortGroups(groups []ast.CommentGroup, invalid int) []ast.CommentGroup {
+	sorted := append([]ast.CommentGroup(nil), groups...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return groupIndex(sorted[i], invalid) < groupIndex(sorted[j], invalid)
+	})
+	return sorted
+}
+
+func (p *printer) ruleset(r *ast.Ruleset) {
+	p.commentGroup(r.Description, "")
+	// Comments of a ruleset are written either after "[" or after "]".
+	var open, close ast.CommentGroup
+	for _, c := range r.Comments {
+		if r.LeftBracketPos.IsValid() && c.Pos().Line == r.LeftBracketPos.Line {
+			open = append(open, c)
+		} else {
+			close = append(close, c)
+		}
+	}
+	p.mark(&r.DeclPos)
+	p.buf.WriteString("Rules ")
+	p.mark(&r.EqualPos)
+	p.buf.WriteString("= ")
+	p.mark(&r.LeftBracketPos)
+	p.buf.WriteString("[")
+	p.resetMarks(&r.CommaPositions)
+	p.trailingComments(open, "")
+	p.buf.WriteString("\n")
+
+	// Rules are written in order. Inner comment groups are placed before
+	// the first rule that follows them in the source; groups without a
+	// position are placed at the end.
+	groups := r.InnerComments
+	if p.cfg.Mode&OmitComments != 0 {
+		groups = nil
+	}
+	const end = int(^uint(0) >> 1)
+	groups = sortGroups(groups, end)
+	type item struct {
+		group ast.CommentGroup
+		rule  ast.RuleDecl
+	}
+	items := make([]item, 0, len(r.Rules)+len(groups))
+	for _, rule := range r.Rules {
+		if pos := ruleDeclPos(rule); pos.IsValid() {
+			for len(groups) > 0 && groupIndex(groups[0], end) < pos.Index {
+				items = append(items, item{group: groups[0]})
+				groups = groups[1:]
+			}
+		}
+		items = append(items, item{rule: rule})
+	}
+	for _, g := range groups {
+		items = append(items, item{group: g})
+	}
+	rules := 0
+	for i, it := range items {
+		if it.rule == nil {
+			if i > 0 {
+				p.buf.WriteString("\n")
+			}
+			p.commentGroup(it.group, p.indent)
+			if i < len(items)-1 {
+				p.buf.WriteString("\n")
+			}
+			continue
+		}
+		rules++
+		var commas *[]token.Pos
+		if rules < len(r.Rules) {
+			commas = &r.CommaPositions
+		}
+		p.ruleDecl(it.rule, p.indent, commas)
+		p.buf.WriteString("\n")
+	}
+	p.mark(&r.RightBracketPos)
+	p.buf.WriteString("]")
+	p.trailingComments(close, "")
+	p.buf.WriteString("\n")
+}
+
+// ruleDeclPos returns the position of the rule declaration, or NoPos for
+// a rule without a type.
+func ruleDeclPos(rule ast.RuleDecl) token.Pos {
+	if r, ok := rule.(*ast.Rule); ok && r.Type == nil {
+		return token.NoPos
+	}
+	return rule.Pos()
+}
+
+// ruleDecl writes rule followed by a comma if commas is not nil, where the
+// position of the comma is added in relayout mode.
+func (p *printer) ruleDecl(rule ast.RuleDecl, indent string, commas *[]token.Pos) {
+	var comments ast.CommentGroup
+	switch r := rule.(type) {
+	case *ast.Rule:
+		p.commentGroup(r.Description, indent)
+		p.buf.WriteString(indent)
+		p.rule(r)
+		comments = ruleComments(r, nil)
+	case *ast.CombinedRule:
+		p.commentGroup(r.Description, indent)
+		p.buf.WriteString(indent)
+		p.resetMarks(&r.OperatorPositions)
+		for i, nested := range r.Rules {
+			if i > 0 {
+				p.buf.WriteString(" ")
+				p.markNext(&r.OperatorPositions)
+				p.buf.WriteString(r.Operator + " ")
+			} else {
+				p.mark(&r.FirstLParenPos)
+			}
+			p.buf.WriteString("(")
+			p.rule(nested)
+			p.mark(&r.LastRParenPos)
+			p.buf.WriteString(")")
+			comments = append(comments, nested.Description...)
+			comments = ruleComments(nested, comments)
+		}
+		comments = append(comments, r.Comments...)
+	case *ast.BadRule:
+		p.buf.WriteString(indent)
+		p.mark(&r.From)
+		p.buf.WriteString(r.Text)
+		p.mark(&r.To)
+		comments = r.Comments
+	}
+	if commas != nil {
+		p.markNext(commas)
+		p.buf.WriteString(",")
+	}
+	p.trailingComments(comments, indent)
+}
+
+// ruleComments appends all comments attached to the rule and its parts
+// to comments, skipping duplicates.
+func ruleComments(r *ast.Rule, comments ast.CommentGroup) ast.CommentGroup {
+	seen := make(map[*ast.Comment]bool, len(comments))
+	for _, c := range comments {
+		seen[c] = true
+	}
+	add := func(g ast.CommentGroup) {
+		for _, c := range g {
+			if !seen[c] {
+				seen[c] = true
+				comments = append(comments, c)
+			}
+		}
+	}
+	if r.Type != nil {
+		add(r.Type.Comments)
+	}
+	for _, param := range r.Parameters {
+		add(parameterComments(param))
+	}
+	add(expressionComments(r.Expression))
+	add(r.Comments)
+	return comments
+}
+
+func parameterComments(param ast.Parameter) ast.CommentGroup {
+	switch x := param.(type) {
+	case *ast.StringParameter:
+		return x.Comments
+	case *ast.NumberParameter:
+		return x.Comments
+	case *ast.BoolParameter:
+		return x.Comments
+	case *ast.DurationParameter:
+		return x.Comments
+	case *ast.DateParameter:
+		if x.Duration != nil {
+			return append(append(ast.CommentGroup{}, x.Duration.Comments...), x.Comments...)
+		}
+		return x.Comments
+	}
+	return nil
+}
+
+func expressionComments(expr ast.Expression) ast.CommentGroup {
+	var comments ast.CommentGroup
+	switch x := expr.(type) {
+	case *ast.ComparisonExpression:
+		comments = append(comments, parameterComments(x.Right)...)
+		comments = append(comments, x.Comments...)
+	case *ast.BetweenExpression:
+		comments = append(comments, parameterComments(x.Left)...)
+		comments = append(comments, parameterComments(x.Right)...)
+		comments = append(comments, x.Comments...)
+	case *ast.InExpression:
+		for _, v := range x.Values {
+			comments = append(comments, parameterComments(v)...)
+		}
+		comments = append(comments, x.Comments...)
+	case *ast.MatchesExpression:
+		comments = append(comments, x.Comments...)
+	case *ast.WithThresholdExpression:
+		comments = append(comments, expressionComments(x.Target)...)
+		comments = append(comments, expressionComments(x.Threshold)...)
+		comments = append(comments, x.Comments...)
+	case *ast.BadExpr:
+		comments = append(comments, x.Comments...)
+	}
+	return comments
+}
+
+func (p *printer) rule(r *ast.Rule) {
+	if r.Type != nil {
+		p.mark(&r.Type.NamePos)
+		p.buf.WriteString(r.Type.Name)
+	}
+	for _, param := range r.Parameters {
+		p.buf.WriteString(" ")
+		p.parameter(param)
+	}
+	if r.Expression != nil {
+		p.buf.WriteString(" ")
+		p.expression(r.Expression)
+	}
+}
+
+func (p *printer) parameter(param ast.Parameter) {
+	switch x := param.(type) {
+	case *ast.StringParameter:
+		p.mark(&x.LeftQuotePos)
+		p.buf.WriteString(`"` + x.Value + `"`)
+		if p.relayout {
+			x.RightQuotePos = x.LeftQuotePos.Advance(`"` + x.Value)
+		}
+	case *ast.NumberParameter:
+		p.mark(&x.NumberPos)
+		p.buf.WriteString(p.number(x.Value))
+	case *ast.BoolParameter:
+		p.mark(&x.BoolPos)
+		if x.Value {
+			p.buf.WriteString("true")
+		} else {
+			p.buf.WriteString("false")
+		}
+	case *ast.DurationParameter:
+		p.mark(&x.NumberPos)
+		p.buf.WriteString(p.number(x.Number) + " ")
+		p.mark(&x.UnitPos)
+		p.buf.WriteString(x.Unit)
+	case *ast.DateParameter:
+		if x.Duration == nil {
+			if p.relayout {
+				x.LeftParenPos, x.MinusPos, x.RightParenPos = nil, nil, nil
+			}
+			p.mark(&x.NowPos)
+			p.buf.WriteString("now()")
+			return
+		}
+		p.markPtr(&x.LeftParenPos)
+		p.buf.WriteString("(")
+		p.mark(&x.NowPos)
+		p.buf.WriteString("now() ")
+		p.markPtr(&x.MinusPos)
+		p.buf.WriteString("- ")
+		p.parameter(x.Duration)
+		p.markPtr(&x.RightParenPos)
+		p.buf.WriteString(")")
+	}
+}
+
+func (p *printer) number(lit string) string {
+	if p.cfg.Mode&NormalizeNumbers != 0 {
+		return NormalizeNumber(lit)
+	}
+	return lit
+}
+
+func (p *printer) expression(expr ast.Expression) {
+	switch x := expr.(type) {
+	case *ast.ComparisonExpression:
+		p.mark(&x.ExprPos)
+		p.buf.WriteString(x.Operator + " ")
+		p.parameter(x.Right)
+	case *ast.BetweenExpression:
+		p.mark(&x.ExprPos)
+		p.buf.WriteString("between ")
+		p.parameter(x.Left)
+		p.buf.WriteString(" and ")
+		p.parameter(x.Right)
+	case *ast.InExpression:
+		p.mark(&x.ExprPos)
+		p.buf.WriteString("in ")
+		p.mark(&x.LeftBracketPos)
+		p.buf.WriteString("[")
+		p.resetMarks(&x.CommaPositions)
+		for i, v := range x.Values {
+			if i > 0 {
+				p.markNext(&x.CommaPositions)
+				p.buf.WriteString(", ")
+			}
+			p.parameter(v)
+		}
+		p.mark(&x.RightBracketPos)
+		p.buf.WriteString("]")
+	case *ast.MatchesExpression:
+		p.mark(&x.ExprPos)
+		p.buf.WriteString("matches ")
+		p.mark(&x.RegexpPos)
+		p.buf.WriteString(`"` + x.Value + `"`)
+	case *ast.WithThresholdExpression:
+		p.expression(x.Target)
+		p.buf.WriteString(" ")
+		p.mark(&x.ExprPos)
+		p.buf.WriteString("with threshold ")
+		p.expression(x.Threshold)
+	case *ast.BadExpr:
+		p.mark(&x.From)
+		p.buf.WriteString(x.Text)
+		p.mark(&x.To)
+	}
+}
+
+// commentGroup writes each comment of g on its own line.
+func (p *printer) commentGroup(g ast.CommentGroup, indent string) {
+	if p.cfg.Mode&OmitComments != 0 {
+		return
+	}
+	for _, c := range g {
+		p.buf.WriteString(indent)
+		p.mark(&c.SharpPos)
+		p.buf.WriteString(c.Text + "\n")
+	}
+}
+
+// trailingComments writes g after the current line. The second and later
+// comments are written on their own lines, aligned with the first one so
+// that they are read back as a single group.
+func (p *printer) trailingComments(g ast.CommentGroup, indent string) {
+	if len(g) == 0 || p.cfg.Mode&OmitComments != 0 {
+		return
+	}
+	b := p.buf.Bytes()
+	line := b[bytes.LastIndexByte(b, '\n')+1:]
+	width := utf8.RuneCount(line) + 1
+	for i, c := range g {
+		if i > 0 {
+			p.buf.WriteString("\n" + indent + strings.Repeat(" ", width-utf8.RuneCountInString(indent)))
+		} else {
+			p.buf.WriteString(" ")
+		}
+		p.mark(&c.SharpPos)
+		p.buf.WriteString(c.Text)
+	}
+}
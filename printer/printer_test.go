@@ -0,0 +1,313 @@
+package printer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/diag"
+	"github.com/mashiike/go-dqdl/parser"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+func TestFprint(t *testing.T) {
+	cases := []struct {
+		name  string
+		mode  Mode
+		input string
+		want  string
+	}{
+		{
+			name: "layout",
+			input: `# file comment
+
+# ruleset description
+Rules = [ # open
+  # rule description
+  IsComplete   "order-id",
+
+  # inner comment
+
+  ColumnValues "status" in ["a","b"] with threshold > 0.9,
+  Mean "price" between 1 and 100.50,
+  ColumnValues "load_date" > (now() - 3 days),
+  DataFreshness "load_date" <= 24 hours,
+  (IsUnique "id") or (IsPrimaryKey "id"),
+  CustomSql "select count(*) from primary" = true,
+  ColumnValues "name" matches "[a-z]+" # trailing
+                                       # comment
+] # close
+`,
+			want: `# file comment
+
+# ruleset description
+Rules = [ # open
+	# rule description
+	IsComplete "order-id",
+
+	# inner comment
+
+	ColumnValues "status" in ["a", "b"] with threshold > 0.9,
+	Mean "price" between 1 and 100.50,
+	ColumnValues "load_date" > (now() - 3 days),
+	DataFreshness "load_date" <= 24 hours,
+	(IsUnique "id") or (IsPrimaryKey "id"),
+	CustomSql "select count(*) from primary" = true,
+	ColumnValues "name" matches "[a-z]+" # trailing
+	                                     # comment
+] # close
+`,
+		},
+		{
+			name: "parameter comments",
+			input: `Rules = [
+	IsUnique # type
+	    "col-A" # column
+]`,
+			want: `Rules = [
+	IsUnique "col-A" # type
+	                 # column
+]
+`,
+		},
+		{
+			name: "preserve numbers",
+			input: `Rules = [
+	Mean "a" between 0.50 and 007,
+	ColumnLength "b" = 1.0
+]`,
+			want: `Rules = [
+	Mean "a" between 0.50 and 007,
+	ColumnLength "b" = 1.0
+]
+`,
+		},
+		{
+			name: "normalize numbers",
+			mode: NormalizeNumbers,
+			input: `Rules = [
+	Mean "a" between 0.50 and 007,
+	ColumnLength "b" = 1.0,
+	DataFreshness "c" <= 024 hours,
+	ColumnValues "d" in [10.000, 0.0]
+]`,
+			want: `Rules = [
+	Mean "a" between 0.5 and 7,
+	ColumnLength "b" = 1,
+	DataFreshness "c" <= 24 hours,
+	ColumnValues "d" in [10, 0]
+]
+`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f, err := parser.ParseFile("test.dqdl", strings.NewReader(c.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			cfg := &Config{Mode: c.mode}
+			if err := cfg.Fprint(&buf, f); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, buf.String()); diff != "" {
+				t.Errorf("unexpected output (-want +got):\n%s", diff)
+			}
+			// the output must be parsed to the same output again.
+			f, err = parser.ParseFile("test.dqdl", strings.NewReader(buf.String()))
+			if err != nil {
+				t.Fatalf("reparse: %s", err)
+			}
+			var again bytes.Buffer
+			if err := cfg.Fprint(&again, f); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(buf.String(), again.String()); diff != "" {
+				t.Errorf("output is not stable (-first +second):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFprint__OmitComments(t *testing.T) {
+	input := `# file comment
+
+# description
+Rules = [ # open
+	# rule description
+	IsComplete "order-id", # trailing
+
+	# inner comment
+
+	IsUnique "order-id"
+] # close
+`
+	f, err := parser.ParseFile("test.dqdl", strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := (&Config{Mode: OmitComments}).Fprint(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+	want := `Rules = [
+	IsComplete "order-id",
+	IsUnique "order-id"
+]
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+}
+
+func TestFprint__Rule(t *testing.T) {
+	rule, err := parser.ParseRule(`ColumnValues "x" between 1.50 and 2 # comment`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := (&Config{Mode: NormalizeNumbers}).Fprint(&buf, rule); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), `ColumnValues "x" between 1.5 and 2 # comment`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFprint__BadNodes(t *testing.T) {
+	input := `Rules = [
+  Completeness   "a" between 0.5 # note
+  ,   IsComplete "b" "c" extra,
+  IsComplete   "d"
+]
+`
+	f, err := parser.ParseFile("test.dqdl", strings.NewReader(input), parser.WithErrorRecovery(func(diag.Diagnostic) {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Fprint(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+	want := `Rules = [
+	Completeness "a" between 0.5, # note
+	IsComplete "b" "c" extra,
+	IsComplete "d"
+]
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+}
+
+func TestNormalizeNumber(t *testing.T) {
+	cases := map[string]string{
+		"0":       "0",
+		"10":      "10",
+		"007":     "7",
+		"0.50":    "0.5",
+		"1.0":     "1",
+		"1.":      "1",
+		"00.000":  "0",
+		"100.250": "100.25",
+		"abc":     "abc",
+		"1.2.3":   "1.2.3",
+		"":        "",
+	}
+	for input, want := range cases {
+		if got := NormalizeNumber(input); got != want {
+			t.Errorf("NormalizeNumber(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestRelayout(t *testing.T) {
+	input := `# file comment
+
+# ruleset description
+Rules = [ # open
+	# rule description
+	IsComplete "order-id",
+
+	# inner comment
+
+	ColumnValues "status" in ["a", "b"] with threshold > 0.9,
+	ColumnValues "load_date" > (now() - 3 days)
+] # close
+`
+	f, err := parser.ParseFile("test.dqdl", strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a rule without positions is inserted, and a rule with the stale
+	// positions of another source is appended.
+	inserted, err := parser.ParseRule(`(IsUnique "id") or (IsPrimaryKey "id")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Relayout(inserted); err != nil {
+		t.Fatal(err)
+	}
+	inserted.(*ast.CombinedRule).FirstLParenPos = token.NoPos
+	stale, err := parser.ParseRule(`Mean "price" between 1 and 100 # stale`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := f.Rulesets[0]
+	rs.Rules = append(rs.Rules[:1], append([]ast.RuleDecl{inserted}, rs.Rules[1:]...)...)
+	rs.Rules = append(rs.Rules, stale)
+
+	if err := Relayout(f); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := Fprint(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+	want := `# file comment
+
+# ruleset description
+Rules = [ # open
+	# rule description
+	IsComplete "order-id",
+	(IsUnique "id") or (IsPrimaryKey "id"),
+
+	# inner comment
+
+	ColumnValues "status" in ["a", "b"] with threshold > 0.9,
+	ColumnValues "load_date" > (now() - 3 days),
+	Mean "price" between 1 and 100 # stale
+] # close
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+	// the positions are the ones of the printed source.
+	reparsed, err := parser.ParseFile("test.dqdl", strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt := cmpopts.IgnoreFields(ast.File{}, "Source")
+	if diff := cmp.Diff(reparsed.Rulesets[0].Rules, f.Rulesets[0].Rules, opt); diff != "" {
+		t.Errorf("positions differ from the reparsed output (-reparsed +relayout):\n%s", diff)
+	}
+	if diff := cmp.Diff(reparsed.CommentGroups, f.CommentGroups); diff != "" {
+		t.Errorf("positions differ from the reparsed output (-reparsed +relayout):\n%s", diff)
+	}
+	for _, field := range []struct {
+		name      string
+		got, want token.Pos
+	}{
+		{"DeclPos", f.Rulesets[0].DeclPos, reparsed.Rulesets[0].DeclPos},
+		{"LeftBracketPos", f.Rulesets[0].LeftBracketPos, reparsed.Rulesets[0].LeftBracketPos},
+		{"RightBracketPos", f.Rulesets[0].RightBracketPos, reparsed.Rulesets[0].RightBracketPos},
+	} {
+		if field.got != field.want {
+			t.Errorf("got %s %v, want %v", field.name, field.got, field.want)
+		}
+	}
+}
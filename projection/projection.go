@@ -0,0 +1,151 @@
+// Package projection は読み取り専用のメタデータ利用者向けに、閾値やリテラルを除いた縮小版のルールを作成します。
+// Package projection produces a reduced view of DQDL rules for read-only
+// metadata consumers such as catalog UIs. A projection keeps the rule
+// types, the column names and the descriptions, and drops expressions and
+// all other literals, so threshold values and allow-lists are not exposed.
+package projection
+
+import (
+	"strings"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/validate"
+)
+
+// File はファイルの射影です。
+// File is the projection of an ast.File.
+type File struct {
+	Filename string    `json:"filename,omitempty"`
+	Rulesets []Ruleset `json:"rulesets"`
+}
+
+// Ruleset はルールセットの射影です。
+// Ruleset is the projection of an ast.Ruleset.
+type Ruleset struct {
+	Description []string `json:"description,omitempty"`
+	Rules       []Rule   `json:"rules"`
+}
+
+// Rule はルールの射影です。結合されたルールは Operator と Rules を持ちます。
+// Rule is the projection of a rule. A combined rule has an Operator and
+// the projections of its rules, but no Type or Columns.
+type Rule struct {
+	Type        string   `json:"type,omitempty"`
+	Columns     []string `json:"columns,omitempty"`
+	Operator    string   `json:"operator,omitempty"`
+	Rules       []Rule   `json:"rules,omitempty"`
+	Description []string `json:"description,omitempty"`
+}
+
+// Option は射影の設定を変更します。
+// An Option configures projection.
+type Option func(*config)
+
+type config struct {
+	registry *validate.Registry
+}
+
+// WithRegistry はルールタイプの仕様を調べるRegistryを指定します。デフォルトは組み込みのルールタイプのみです。
+// WithRegistry sets the registry to look up rule specs in, so that the
+// columns of custom rule types are recognized. By default only the
+// built-in rule types are known.
+func WithRegistry(r *validate.Registry) Option {
+	return func(c *config) {
+		c.registry = r
+	}
+}
+
+func (cfg *config) lookup(name string) (*validate.RuleSpec, bool) {
+	if cfg.registry != nil {
+		return cfg.registry.Lookup(name)
+	}
+	return validate.Lookup(name)
+}
+
+// Project はファイルの射影を返します。
+// Project returns the projection of file.
+func Project(file *ast.File, opts ...Option) *File {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	f := &File{
+		Filename: file.Filename,
+		Rulesets: make([]Ruleset, 0, len(file.Rulesets)),
+	}
+	for _, ruleset := range file.Rulesets {
+		f.Rulesets = append(f.Rulesets, cfg.ruleset(ruleset))
+	}
+	return f
+}
+
+// ProjectRuleset はルールセットの射影を返します。
+// ProjectRuleset returns the projection of ruleset.
+func ProjectRuleset(ruleset *ast.Ruleset, opts ...Option) Ruleset {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg.ruleset(ruleset)
+}
+
+func (cfg *config) ruleset(ruleset *ast.Ruleset) Ruleset {
+	r := Ruleset{
+		Description: commentText(ruleset.Description),
+		Rules:       make([]Rule, 0, len(ruleset.Rules)),
+	}
+	for _, decl := range ruleset.Rules {
+		switch x := decl.(type) {
+		case *ast.Rule:
+			r.Rules = append(r.Rules, cfg.rule(x))
+		case *ast.CombinedRule:
+			combined := Rule{
+				Operator:    x.Operator,
+				Description: commentText(x.Description),
+			}
+			for _, nested := range x.Rules {
+				combined.Rules = append(combined.Rules, cfg.rule(nested))
+			}
+			r.Rules = append(r.Rules, combined)
+		}
+	}
+	return r
+}
+
+func (cfg *config) rule(rule *ast.Rule) Rule {
+	r := Rule{Description: commentText(rule.Description)}
+	if rule.Type == nil {
+		return r
+	}
+	r.Type = rule.Type.Name
+	// only parameters known to be column names are kept; the parameters of
+	// unknown rule types may be anything, e.g. SQL with literals.
+	spec, ok := cfg.lookup(rule.Type.Name)
+	if !ok {
+		return r
+	}
+	for i, param := range rule.Parameters {
+		s, ok := param.(*ast.StringParameter)
+		if !ok {
+			continue
+		}
+		p, ok := spec.Param(i)
+		if ok && (p.Name == "column" || p.Name == "columns") {
+			r.Columns = append(r.Columns, s.Value)
+		}
+	}
+	return r
+}
+
+// commentText returns the text of the comments without "#" and the
+// following spaces.
+func commentText(g ast.CommentGroup) []string {
+	if len(g) == 0 {
+		return nil
+	}
+	lines := make([]string, 0, len(g))
+	for _, c := range g {
+		lines = append(lines, strings.TrimSpace(strings.TrimPrefix(c.Text, "#")))
+	}
+	return lines
+}
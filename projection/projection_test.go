@@ -0,0 +1,72 @@
+package projection
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/parser"
+	"github.com/mashiike/go-dqdl/validate"
+)
+
+func TestProject(t *testing.T) {
+	input := `# orders
+Rules = [
+	# status must be known
+	ColumnValues "status" in ["secret-a", "secret-b"] with threshold > 0.93,
+	CustomSql "select count(*) from primary where price > 1234" > 0,
+	IsPrimaryKey "id" "sub_id",
+	(Completeness "price" > 0.95) and (Mean "price" between 10 and 20),
+	IsValidJAN "code" = true,
+	RowCount > 100
+]`
+	file, err := parser.ParseFile("orders.dqdl", strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Project(file)
+	want := &File{
+		Filename: "orders.dqdl",
+		Rulesets: []Ruleset{
+			{
+				Description: []string{"orders"},
+				Rules: []Rule{
+					{Type: "ColumnValues", Columns: []string{"status"}, Description: []string{"status must be known"}},
+					{Type: "CustomSql"},
+					{Type: "IsPrimaryKey", Columns: []string{"id", "sub_id"}},
+					{Operator: "and", Rules: []Rule{
+						{Type: "Completeness", Columns: []string{"price"}},
+						{Type: "Mean", Columns: []string{"price"}},
+					}},
+					{Type: "IsValidJAN"},
+					{Type: "RowCount"},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected projection (-want +got):\n%s", diff)
+	}
+	bs, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"secret-a", "0.93", "1234", "0.95", "100"} {
+		if strings.Contains(string(bs), secret) {
+			t.Errorf("projection exposes %q: %s", secret, bs)
+		}
+	}
+
+	registry := validate.NewRegistry()
+	if err := registry.Register(&validate.RuleSpec{
+		Name:   "IsValidJAN",
+		Params: []validate.Param{{Name: "column", Type: validate.ParamString}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	got = Project(file, WithRegistry(registry))
+	if diff := cmp.Diff([]string{"code"}, got.Rulesets[0].Rules[4].Columns); diff != "" {
+		t.Errorf("unexpected columns of custom rule type (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,286 @@
+// Package regexcompat は matches 式の正規表現について、Go の RE2 と AWS Glue が用いる Java の正規表現との互換性を検査します。
+// Package regexcompat checks regular expressions used in matches expressions
+// for constructs whose behavior differs between Go's RE2 syntax and the
+// Java regular expressions used by AWS Glue.
+package regexcompat
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind は互換性の問題の種類を表します。
+// Kind represents the kind of a compatibility issue.
+type Kind int
+
+const (
+	// JavaOnly は Java では受け付けられるが RE2 では受け付けられない構文です。
+	// JavaOnly is a construct accepted by Java but rejected by RE2.
+	JavaOnly Kind = iota + 1
+	// RE2Only は RE2 では受け付けられるが Java では受け付けられない構文です。
+	// RE2Only is a construct accepted by RE2 but rejected by Java.
+	RE2Only
+	// Different は両方で受け付けられるが意味が異なる構文です。
+	// Different is a construct accepted by both with different semantics.
+	Different
+)
+
+var kindStrings = map[Kind]string{
+	JavaOnly:  "java-only",
+	RE2Only:   "re2-only",
+	Different: "different",
+}
+
+// String returns the string representation of the kind.
+func (k Kind) String() string {
+	if s, ok := kindStrings[k]; ok {
+		return s
+	}
+	return "unknown kind"
+}
+
+// Issue は正規表現中の互換性の問題を表します。
+// An Issue describes a construct in a pattern with a compatibility problem.
+type Issue struct {
+	Offset  int    // byte offset of the construct in the pattern
+	Text    string // the construct, e.g. "*+"
+	Kind    Kind   // kind of the issue
+	Message string // human readable description
+}
+
+// String returns a string in the form "offset: kind: message".
+func (i Issue) String() string {
+	return fmt.Sprintf("%d: %s: %s", i.Offset, i.Kind, i.Message)
+}
+
+// Check は正規表現を解析し、RE2 と Java の間で互換性のない構文を返します。
+// Check scans pattern and returns the constructs whose behavior differs
+// between RE2 and Java regular expressions, in the order they appear.
+// Check does not report syntax errors; compile the pattern for that.
+func Check(pattern string) []Issue {
+	s := &scanner{pattern: pattern}
+	s.scan()
+	return s.issues
+}
+
+type scanner struct {
+	pattern string
+	issues  []Issue
+}
+
+func (s *scanner) report(offset, end int, kind Kind, format string, args ...interface{}) {
+	if end > len(s.pattern) {
+		end = len(s.pattern)
+	}
+	s.issues = append(s.issues, Issue{
+		Offset:  offset,
+		Text:    s.pattern[offset:end],
+		Kind:    kind,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (s *scanner) scan() {
+	p := s.pattern
+	for i := 0; i < len(p); {
+		switch c := p[i]; c {
+		case '\\':
+			i = s.scanEscape(i, false)
+		case '[':
+			i = s.scanClass(i)
+		case '(':
+			i = s.scanGroup(i)
+		case '*', '+', '?':
+			i = s.scanPossessive(i, i+1)
+		case '{':
+			end := repetitionEnd(p, i)
+			if end < 0 {
+				i++
+				continue
+			}
+			i = s.scanPossessive(i, end)
+		default:
+			i++
+		}
+	}
+}
+
+// scanPossessive reports a possessive quantifier if the quantifier
+// p[start:end] is followed by '+', and returns the next offset to scan.
+func (s *scanner) scanPossessive(start, end int) int {
+	if end < len(s.pattern) && s.pattern[end] == '+' {
+		s.report(start, end+1, JavaOnly, "possessive quantifier %q is not supported by RE2", s.pattern[start:end+1])
+		return end + 1
+	}
+	if end < len(s.pattern) && s.pattern[end] == '?' {
+		return end + 1 // lazy quantifier, supported by both
+	}
+	return end
+}
+
+// repetitionEnd returns the offset after a `{n}`, `{n,}` or `{n,m}`
+// repetition starting at p[i], or -1 if p[i] does not start one.
+func repetitionEnd(p string, i int) int {
+	j := i + 1
+	digits := func() int {
+		n := 0
+		for j < len(p) && '0' <= p[j] && p[j] <= '9' {
+			j++
+			n++
+		}
+		return n
+	}
+	if digits() == 0 {
+		return -1
+	}
+	if j < len(p) && p[j] == ',' {
+		j++
+		digits()
+	}
+	if j < len(p) && p[j] == '}' {
+		return j + 1
+	}
+	return -1
+}
+
+// scanEscape handles the escape sequence starting at p[i] and returns the next offset to scan.
+func (s *scanner) scanEscape(i int, inClass bool) int {
+	p := s.pattern
+	if i+1 >= len(p) {
+		return i + 1
+	}
+	switch e := p[i+1]; e {
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		if !inClass {
+			s.report(i, i+2, JavaOnly, "backreference %q is not supported by RE2", p[i:i+2])
+		}
+	case 'k':
+		if i+2 < len(p) && p[i+2] == '<' {
+			end := strings.IndexByte(p[i:], '>')
+			if end < 0 {
+				end = len(p) - i - 1
+			}
+			s.report(i, i+end+1, JavaOnly, "named backreference %q is not supported by RE2", p[i:i+end+1])
+			return i + end + 1
+		}
+	case 'p', 'P':
+		name, end := propertyName(p, i+2)
+		if !isGeneralCategory(name) {
+			s.report(i, end, Different, "character property %q is resolved differently by RE2 and Java; prefer a general category such as \\p{L} or an explicit class", p[i:end])
+		}
+		return end
+	case 'Z', 'h', 'H', 'R', 'X', 'G':
+		s.report(i, i+2, JavaOnly, "escape %q is not supported by RE2", p[i:i+2])
+	case 'v':
+		s.report(i, i+2, Different, "%q is a vertical tab in RE2 but any vertical whitespace in Java", p[i:i+2])
+	case 'Q':
+		end := strings.Index(p[i+2:], `\E`)
+		if end < 0 {
+			return len(p)
+		}
+		return i + 2 + end + 2
+	}
+	return i + 2
+}
+
+// propertyName returns the name of the character property starting at p[i]
+// (either a single letter or a braced name) and the offset after it.
+func propertyName(p string, i int) (string, int) {
+	if i >= len(p) {
+		return "", i
+	}
+	if p[i] != '{' {
+		return p[i : i+1], i + 1
+	}
+	end := strings.IndexByte(p[i:], '}')
+	if end < 0 {
+		return p[i+1:], len(p)
+	}
+	return p[i+1 : i+end], i + end + 1
+}
+
+// isGeneralCategory reports whether name is a Unicode general category
+// like "L" or "Lu", which both RE2 and Java interpret the same way.
+func isGeneralCategory(name string) bool {
+	name = strings.TrimPrefix(name, "^")
+	switch len(name) {
+	case 1:
+		return strings.ContainsAny(name, "CLMNPSZ")
+	case 2:
+		return strings.ContainsAny(name[:1], "CLMNPSZ") && 'a' <= name[1] && name[1] <= 'z'
+	}
+	return false
+}
+
+// scanClass handles the character class starting at p[i] and returns the next offset to scan.
+func (s *scanner) scanClass(i int) int {
+	p := s.pattern
+	j := i + 1
+	if j < len(p) && p[j] == '^' {
+		j++
+	}
+	if j < len(p) && p[j] == ']' {
+		j++ // a leading ']' is a literal in both dialects
+	}
+	for j < len(p) {
+		switch {
+		case p[j] == '\\':
+			j = s.scanEscape(j, true)
+		case p[j] == ']':
+			return j + 1
+		case strings.HasPrefix(p[j:], "[:"):
+			end := strings.Index(p[j:], ":]")
+			if end < 0 {
+				j++
+				continue
+			}
+			s.report(j, j+end+2, RE2Only, "POSIX class %q is not supported by Java; use \\p{...} instead", p[j:j+end+2])
+			j += end + 2
+		case p[j] == '[':
+			s.report(j, j+1, Different, "nested character class is a union in Java but a literal '[' in RE2")
+			j++
+		case strings.HasPrefix(p[j:], "&&"):
+			s.report(j, j+2, Different, "%q is a class intersection in Java but literal characters in RE2", "&&")
+			j += 2
+		default:
+			j++
+		}
+	}
+	return j
+}
+
+// scanGroup handles the group starting at p[i] and returns the next offset to scan.
+func (s *scanner) scanGroup(i int) int {
+	p := s.pattern
+	if !strings.HasPrefix(p[i:], "(?") {
+		return i + 1
+	}
+	rest := p[i+2:]
+	switch {
+	case strings.HasPrefix(rest, "="), strings.HasPrefix(rest, "!"):
+		s.report(i, i+3, JavaOnly, "lookahead %q is not supported by RE2", p[i:i+3])
+		return i + 3
+	case strings.HasPrefix(rest, "<="), strings.HasPrefix(rest, "<!"):
+		s.report(i, i+4, JavaOnly, "lookbehind %q is not supported by RE2", p[i:i+4])
+		return i + 4
+	case strings.HasPrefix(rest, ">"):
+		s.report(i, i+3, JavaOnly, "atomic group %q is not supported by RE2", p[i:i+3])
+		return i + 3
+	case strings.HasPrefix(rest, "P<"):
+		s.report(i, i+4, RE2Only, "named group %q is not supported by Java; use (?<name>...) instead", p[i:i+4])
+		return i + 4
+	case strings.HasPrefix(rest, "<"):
+		return i + 3
+	}
+	j := i + 2
+	for j < len(p) && p[j] != ')' && p[j] != ':' {
+		switch p[j] {
+		case 'U':
+			s.report(j, j+1, Different, "flag 'U' means ungreedy in RE2 but Unicode character classes in Java")
+		case 'x', 'd', 'u', 'c':
+			s.report(j, j+1, JavaOnly, "flag %q is not supported by RE2", p[j:j+1])
+		}
+		j++
+	}
+	return j
+}
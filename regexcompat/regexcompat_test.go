@@ -0,0 +1,73 @@
+package regexcompat
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheck(t *testing.T) {
+	cases := []struct {
+		name    string
+		pattern string
+		want    []Issue
+	}{
+		{name: "compatible", pattern: `^[a-zA-Z0-9_]+@\w+\.(com|net){1,2}$`},
+		{name: "lazy quantifier", pattern: `a+?b*?c{2,3}?`},
+		{name: "literal escapes", pattern: `\Q(?=a)\E\d+`},
+		{name: "general category", pattern: `\p{Lu}\pL\P{N}`},
+		{
+			name:    "possessive quantifiers",
+			pattern: `a++b*+c{2}+`,
+			want: []Issue{
+				{Offset: 1, Text: "++", Kind: JavaOnly, Message: `possessive quantifier "++" is not supported by RE2`},
+				{Offset: 4, Text: "*+", Kind: JavaOnly, Message: `possessive quantifier "*+" is not supported by RE2`},
+				{Offset: 7, Text: "{2}+", Kind: JavaOnly, Message: `possessive quantifier "{2}+" is not supported by RE2`},
+			},
+		},
+		{
+			name:    "backreferences",
+			pattern: `(a)\1(?<x>b)\k<x>`,
+			want: []Issue{
+				{Offset: 3, Text: `\1`, Kind: JavaOnly, Message: `backreference "\\1" is not supported by RE2`},
+				{Offset: 12, Text: `\k<x>`, Kind: JavaOnly, Message: `named backreference "\\k<x>" is not supported by RE2`},
+			},
+		},
+		{
+			name:    "lookaround and atomic group",
+			pattern: `(?=a)(?<!b)(?>c)`,
+			want: []Issue{
+				{Offset: 0, Text: "(?=", Kind: JavaOnly, Message: `lookahead "(?=" is not supported by RE2`},
+				{Offset: 5, Text: "(?<!", Kind: JavaOnly, Message: `lookbehind "(?<!" is not supported by RE2`},
+				{Offset: 11, Text: "(?>", Kind: JavaOnly, Message: `atomic group "(?>" is not supported by RE2`},
+			},
+		},
+		{
+			name:    "re2 only",
+			pattern: `(?P<n>[[:alpha:]])`,
+			want: []Issue{
+				{Offset: 0, Text: "(?P<", Kind: RE2Only, Message: `named group "(?P<" is not supported by Java; use (?<name>...) instead`},
+				{Offset: 7, Text: "[:alpha:]", Kind: RE2Only, Message: `POSIX class "[:alpha:]" is not supported by Java; use \p{...} instead`},
+			},
+		},
+		{
+			name:    "different semantics",
+			pattern: `(?iU)[a-z&&[^aeiou]]\v\p{Lower}`,
+			want: []Issue{
+				{Offset: 3, Text: "U", Kind: Different, Message: "flag 'U' means ungreedy in RE2 but Unicode character classes in Java"},
+				{Offset: 9, Text: "&&", Kind: Different, Message: `"&&" is a class intersection in Java but literal characters in RE2`},
+				{Offset: 11, Text: "[", Kind: Different, Message: "nested character class is a union in Java but a literal '[' in RE2"},
+				{Offset: 20, Text: `\v`, Kind: Different, Message: `"\\v" is a vertical tab in RE2 but any vertical whitespace in Java`},
+				{Offset: 22, Text: `\p{Lower}`, Kind: Different, Message: `character property "\\p{Lower}" is resolved differently by RE2 and Java; prefer a general category such as \p{L} or an explicit class`},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := Check(c.pattern)
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("Check(%q) mismatch (-want +got):\n%s", c.pattern, diff)
+			}
+		})
+	}
+}
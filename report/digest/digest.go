@@ -0,0 +1,308 @@
+// Package digest は評価結果を通知用の短い要約にします。
+// Package digest renders an evaluation result as a short summary, as
+// plain text or HTML, for the body of an email or another notification.
+//
+// The digest has the counts of the outcomes, the rules failing since a
+// baseline result, if given, the first failing rules and a list of links,
+// such as to the full report. Failing means an outcome of FAIL or ERROR.
+package digest
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/eval"
+	"github.com/mashiike/go-dqdl/printer"
+)
+
+// DefaultMaxFailures は既定で載せる失敗したルールの数です。
+// DefaultMaxFailures is the number of failing rules listed by default.
+const DefaultMaxFailures = 5
+
+// Option は要約の内容を変更します。
+// An Option configures a digest.
+type Option func(*config)
+
+type config struct {
+	title       string
+	baseline    *eval.Result
+	maxFailures int
+	links       []Link
+}
+
+// Link は要約に載せるリンクです。
+// A Link is a link listed at the end of a digest.
+type Link struct {
+	Text string
+	URL  string
+}
+
+// WithTitle は要約の題名を指定します。既定では "Data quality digest" です。
+// WithTitle sets the title of the digest. It defaults to
+// "Data quality digest".
+func WithTitle(title string) Option {
+	return func(c *config) {
+		c.title = title
+	}
+}
+
+// WithBaseline は比較の基準となる評価結果を指定します。
+// WithBaseline sets the result of a previous evaluation, such as the last
+// run, to compare with. The rules are matched by their DQDL text without
+// comments, so that the baseline may come from another parse of the
+// ruleset. A failing rule that was not failing in the baseline, or was
+// not in it, is newly failing.
+func WithBaseline(baseline *eval.Result) Option {
+	return func(c *config) {
+		c.baseline = baseline
+	}
+}
+
+// WithMaxFailures は載せる失敗したルールの最大数を指定します。
+// WithMaxFailures sets the maximum number of failing rules listed, and of
+// newly failing rules. The others are only counted. It defaults to
+// DefaultMaxFailures; zero or less lists none.
+func WithMaxFailures(n int) Option {
+	return func(c *config) {
+		c.maxFailures = n
+	}
+}
+
+// WithLink は要約の最後に載せるリンクを追加します。
+// WithLink adds a link to the end of the digest, e.g. to the full report
+// or to the ruleset in the repository.
+func WithLink(text, url string) Option {
+	return func(c *config) {
+		c.links = append(c.links, Link{Text: text, URL: url})
+	}
+}
+
+// Subject は要約の件名を返します。
+// Subject returns a one line summary of result for the subject of an
+// email, e.g. "[FAILED] Data quality digest: 2 of 5 rules failing".
+func Subject(result *eval.Result, opts ...Option) string {
+	d := newDigest(result, opts)
+	if d.Passed {
+		return fmt.Sprintf("[PASSED] %s: %s", d.Title, plural(d.Total, "rule"))
+	}
+	return fmt.Sprintf("[FAILED] %s: %d of %s failing", d.Title, d.Failing, plural(d.Total, "rule"))
+}
+
+// Text は要約をテキストとして書き出します。
+// Text writes the digest of result to w as plain text.
+func Text(w io.Writer, result *eval.Result, opts ...Option) error {
+	d := newDigest(result, opts)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n%s\n", d.Title, d.Summary())
+	list := func(heading string, l failureList) {
+		if l.Empty() {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s:\n", heading)
+		for _, r := range l.Rules {
+			fmt.Fprintf(&b, "  %s\n", r.Line())
+		}
+		if l.More > 0 {
+			fmt.Fprintf(&b, "  and %d more\n", l.More)
+		}
+	}
+	list("Newly failing", d.New)
+	list("Failures", d.Failures)
+	if len(d.Links) > 0 {
+		b.WriteString("\nLinks:\n")
+		for _, l := range d.Links {
+			fmt.Fprintf(&b, "  %s: %s\n", l.Text, l.URL)
+		}
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("digest: %w", err)
+	}
+	return nil
+}
+
+// HTML は要約を HTML として書き出します。
+// HTML writes the digest of result to w as an HTML document with inline
+// styles, as mail clients ignore style sheets.
+func HTML(w io.Writer, result *eval.Result, opts ...Option) error {
+	if err := htmlTemplate.Execute(w, newDigest(result, opts)); err != nil {
+		return fmt.Errorf("digest: %w", err)
+	}
+	return nil
+}
+
+// digest is the content of a digest, shared by the renderers.
+type digest struct {
+	Title    string
+	Passed   bool
+	Total    int
+	Failing  int
+	Counts   []count
+	New      failureList // the rules failing since the baseline
+	Failures failureList
+	Links    []Link
+}
+
+type count struct {
+	Outcome eval.Outcome
+	N       int
+}
+
+// failureList is a list of failing rules, cut at the maximum.
+type failureList struct {
+	Rules []failure
+	More  int // the number of the rules cut
+}
+
+// add appends f to l, or counts it if l has max rules.
+func (l *failureList) add(f failure, max int) {
+	if len(l.Rules) < max {
+		l.Rules = append(l.Rules, f)
+	} else {
+		l.More++
+	}
+}
+
+// Empty reports whether l has no rules.
+func (l failureList) Empty() bool {
+	return len(l.Rules) == 0 && l.More == 0
+}
+
+type failure struct {
+	Outcome eval.Outcome
+	Text    string
+	Message string
+}
+
+// Failed reports whether the rule failed, rather than not being evaluated.
+func (f failure) Failed() bool {
+	return f.Outcome == eval.OutcomeFail
+}
+
+// Line returns the failure as a line of text, e.g.
+// `FAIL IsComplete "id": 1 of 3 values are null`.
+func (f failure) Line() string {
+	line := f.Outcome.String() + " " + f.Text
+	if f.Message != "" {
+		line += ": " + f.Message
+	}
+	return line
+}
+
+// Summary returns the line of the counts, e.g.
+// "FAILED: 5 rules, 3 PASS, 1 FAIL, 1 ERROR".
+func (d *digest) Summary() string {
+	status := "PASSED"
+	if !d.Passed {
+		status = "FAILED"
+	}
+	parts := []string{plural(d.Total, "rule")}
+	for _, c := range d.Counts {
+		parts = append(parts, fmt.Sprintf("%d %s", c.N, c.Outcome))
+	}
+	return status + ": " + strings.Join(parts, ", ")
+}
+
+// outcomes is the order of the counts of a digest.
+var outcomes = []eval.Outcome{eval.OutcomePass, eval.OutcomeFail, eval.OutcomeError, eval.OutcomeSkip, eval.OutcomeSnoozed}
+
+func newDigest(result *eval.Result, opts []Option) *digest {
+	cfg := &config{title: "Data quality digest", maxFailures: DefaultMaxFailures}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	d := &digest{Title: cfg.title, Passed: result.Passed(), Total: len(result.Rules), Links: cfg.links}
+	n := make(map[eval.Outcome]int)
+	for _, r := range result.Rules {
+		n[r.Outcome]++
+	}
+	for _, o := range outcomes {
+		if n[o] > 0 {
+			d.Counts = append(d.Counts, count{Outcome: o, N: n[o]})
+		}
+	}
+	baseline := make(map[string]bool) // whether a rule was failing, by text
+	if cfg.baseline != nil {
+		for _, r := range cfg.baseline.Rules {
+			baseline[ruleText(r.Rule)] = failing(r.Outcome)
+		}
+	}
+	for _, r := range result.Rules {
+		if !failing(r.Outcome) {
+			continue
+		}
+		d.Failing++
+		f := failure{Outcome: r.Outcome, Text: ruleText(r.Rule), Message: r.Message}
+		d.Failures.add(f, cfg.maxFailures)
+		if cfg.baseline != nil && !baseline[f.Text] {
+			d.New.add(f, cfg.maxFailures)
+		}
+	}
+	return d
+}
+
+func failing(o eval.Outcome) bool {
+	return o == eval.OutcomeFail || o == eval.OutcomeError
+}
+
+// ruleText returns the DQDL text of rule without comments.
+func ruleText(rule ast.RuleDecl) string {
+	if rule == nil {
+		return ""
+	}
+	var b strings.Builder
+	cfg := printer.Config{Mode: printer.OmitComments}
+	if err := cfg.Fprint(&b, rule); err != nil {
+		return ""
+	}
+	return b.String()
+}
+
+// plural returns n and noun, with an "s" unless n is 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+var htmlTemplate = template.Must(template.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body style="font-family: sans-serif; color: #24292f;">
+<h1 style="font-size: 1.4em;">{{.Title}}</h1>
+<p style="font-weight: bold; color: {{if .Passed}}#1a7f37{{else}}#cf222e{{end}};">{{.Summary}}</p>
+{{- define "failures"}}
+<ul>
+{{- range .Rules}}
+<li><span style="color: {{if .Failed}}#cf222e{{else}}#9a6700{{end}};">{{.Outcome}}</span> <code>{{.Text}}</code>{{if .Message}}: {{.Message}}{{end}}</li>
+{{- end}}
+{{- if .More}}
+<li>and {{.More}} more</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if not .New.Empty}}
+<h2 style="font-size: 1.1em;">Newly failing</h2>
+{{- template "failures" .New}}
+{{- end}}
+{{- if not .Failures.Empty}}
+<h2 style="font-size: 1.1em;">Failures</h2>
+{{- template "failures" .Failures}}
+{{- end}}
+{{- if .Links}}
+<h2 style="font-size: 1.1em;">Links</h2>
+<ul>
+{{- range .Links}}
+<li><a href="{{.URL}}">{{.Text}}</a></li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
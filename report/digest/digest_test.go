@@ -0,0 +1,159 @@
+package digest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/eval"
+	"github.com/mashiike/go-dqdl/parser"
+)
+
+func testResults(t *testing.T) (result, baseline *eval.Result) {
+	t.Helper()
+	src := `RowCount > 10, IsComplete "id", Completeness "name" > 0.9, IsUnique "id", ColumnExists "ts"`
+	rules, err := parser.ParseRules(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result = &eval.Result{Rules: []eval.RuleResult{
+		{Rule: rules[0], Outcome: eval.OutcomePass},
+		{Rule: rules[1], Outcome: eval.OutcomeFail, Message: "1 of 3 values are null"},
+		{Rule: rules[2], Outcome: eval.OutcomeError, Message: `column "name" not found`},
+		{Rule: rules[3], Outcome: eval.OutcomeFail, Message: "2 of 3 values are unique"},
+		{Rule: rules[4], Outcome: eval.OutcomeSnoozed, Message: "snoozed until 2024-04-01"},
+	}}
+	// The baseline is another parse, with comments, where only IsUnique
+	// failed and Completeness did not exist yet.
+	old, err := parser.ParseRules("RowCount > 10, IsComplete \"id\", # unique ids\nIsUnique \"id\"")
+	if err != nil {
+		t.Fatal(err)
+	}
+	baseline = &eval.Result{Rules: []eval.RuleResult{
+		{Rule: old[0], Outcome: eval.OutcomePass},
+		{Rule: old[1], Outcome: eval.OutcomePass},
+		{Rule: old[2], Outcome: eval.OutcomeFail},
+	}}
+	return result, baseline
+}
+
+func TestText(t *testing.T) {
+	result, baseline := testResults(t)
+	cases := []struct {
+		name   string
+		result *eval.Result
+		opts   []Option
+		want   string
+	}{
+		{
+			name:   "default",
+			result: result,
+			want: `Data quality digest
+
+FAILED: 5 rules, 1 PASS, 2 FAIL, 1 ERROR, 1 SNOOZED
+
+Failures:
+  FAIL IsComplete "id": 1 of 3 values are null
+  ERROR Completeness "name" > 0.9: column "name" not found
+  FAIL IsUnique "id": 2 of 3 values are unique
+`,
+		},
+		{
+			name:   "baseline and links",
+			result: result,
+			opts: []Option{
+				WithTitle("orders"),
+				WithBaseline(baseline),
+				WithMaxFailures(1),
+				WithLink("Report", "https://example.com/orders.html"),
+			},
+			want: `orders
+
+FAILED: 5 rules, 1 PASS, 2 FAIL, 1 ERROR, 1 SNOOZED
+
+Newly failing:
+  FAIL IsComplete "id": 1 of 3 values are null
+  and 1 more
+
+Failures:
+  FAIL IsComplete "id": 1 of 3 values are null
+  and 2 more
+
+Links:
+  Report: https://example.com/orders.html
+`,
+		},
+		{
+			name:   "passed",
+			result: &eval.Result{Rules: result.Rules[:1]},
+			opts:   []Option{WithBaseline(baseline)},
+			want:   "Data quality digest\n\nPASSED: 1 rule, 1 PASS\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Text(&buf, c.result, c.opts...); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, buf.String()); diff != "" {
+				t.Errorf("unexpected output (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHTML(t *testing.T) {
+	result, baseline := testResults(t)
+	var buf bytes.Buffer
+	err := HTML(&buf, result, WithTitle("<orders>"), WithBaseline(baseline), WithLink("Report", "https://example.com/orders.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>&lt;orders&gt;</title>
+</head>
+<body style="font-family: sans-serif; color: #24292f;">
+<h1 style="font-size: 1.4em;">&lt;orders&gt;</h1>
+<p style="font-weight: bold; color: #cf222e;">FAILED: 5 rules, 1 PASS, 2 FAIL, 1 ERROR, 1 SNOOZED</p>
+<h2 style="font-size: 1.1em;">Newly failing</h2>
+<ul>
+<li><span style="color: #cf222e;">FAIL</span> <code>IsComplete &#34;id&#34;</code>: 1 of 3 values are null</li>
+<li><span style="color: #9a6700;">ERROR</span> <code>Completeness &#34;name&#34; &gt; 0.9</code>: column &#34;name&#34; not found</li>
+</ul>
+<h2 style="font-size: 1.1em;">Failures</h2>
+<ul>
+<li><span style="color: #cf222e;">FAIL</span> <code>IsComplete &#34;id&#34;</code>: 1 of 3 values are null</li>
+<li><span style="color: #9a6700;">ERROR</span> <code>Completeness &#34;name&#34; &gt; 0.9</code>: column &#34;name&#34; not found</li>
+<li><span style="color: #cf222e;">FAIL</span> <code>IsUnique &#34;id&#34;</code>: 2 of 3 values are unique</li>
+</ul>
+<h2 style="font-size: 1.1em;">Links</h2>
+<ul>
+<li><a href="https://example.com/orders.html">Report</a></li>
+</ul>
+</body>
+</html>
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+}
+
+func TestSubject(t *testing.T) {
+	result, _ := testResults(t)
+	cases := []struct {
+		result *eval.Result
+		want   string
+	}{
+		{result: result, want: "[FAILED] orders: 3 of 5 rules failing"},
+		{result: &eval.Result{Rules: result.Rules[:1]}, want: "[PASSED] orders: 1 rule"},
+	}
+	for _, c := range cases {
+		if diff := cmp.Diff(c.want, Subject(c.result, WithTitle("orders"))); diff != "" {
+			t.Errorf("unexpected subject (-want +got):\n%s", diff)
+		}
+	}
+}
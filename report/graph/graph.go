@@ -0,0 +1,168 @@
+// Package graph はルールセットの構造を Graphviz や Mermaid のグラフとして出力します。
+// Package graph renders the structure of a ruleset as a Graphviz DOT or a
+// Mermaid graph, for documentation and reviews.
+//
+// The ruleset is the root of the graph, with an edge to each rule, and
+// each rule has an edge to each of its parameters and to its expression.
+// The rules of a combined rule are drawn in a subgraph labelled with the
+// operator. Parameters and expressions are labelled with their DQDL text,
+// without comments.
+package graph
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/printer"
+)
+
+// vertex is a node of the graph, or a subgraph if group is set.
+type vertex struct {
+	id       string
+	label    string
+	group    bool // a combined rule, drawn as a subgraph of its rules
+	children []*vertex
+}
+
+// build returns the tree of vertices of ruleset.
+func build(ruleset *ast.Ruleset) *vertex {
+	b := &builder{}
+	root := b.vertex("Rules")
+	for _, decl := range ruleset.Rules {
+		switch r := decl.(type) {
+		case *ast.Rule:
+			root.children = append(root.children, b.rule(r))
+		case *ast.CombinedRule:
+			group := b.vertex(r.Operator)
+			group.group = true
+			for _, nested := range r.Rules {
+				group.children = append(group.children, b.rule(nested))
+			}
+			root.children = append(root.children, group)
+		}
+	}
+	return root
+}
+
+type builder struct {
+	n int
+}
+
+func (b *builder) vertex(label string) *vertex {
+	v := &vertex{id: fmt.Sprintf("n%d", b.n), label: label}
+	b.n++
+	return v
+}
+
+func (b *builder) rule(r *ast.Rule) *vertex {
+	name := ""
+	if r.Type != nil {
+		name = r.Type.Name
+	}
+	v := b.vertex(name)
+	for _, param := range r.Parameters {
+		v.children = append(v.children, b.vertex(text(param)))
+	}
+	if r.Expression != nil {
+		v.children = append(v.children, b.vertex(text(r.Expression)))
+	}
+	return v
+}
+
+// text returns the DQDL text of node without comments.
+func text(node ast.Node) string {
+	var buf bytes.Buffer
+	cfg := printer.Config{Mode: printer.OmitComments}
+	if err := cfg.Fprint(&buf, node); err != nil {
+		return fmt.Sprintf("%T", node)
+	}
+	return buf.String()
+}
+
+// WriteDOT はルールセットを Graphviz の DOT 形式で書き出します。
+// WriteDOT writes the graph of ruleset to w in the DOT language of
+// Graphviz, e.g. for `dot -Tsvg`. Combined rules are clusters.
+func WriteDOT(w io.Writer, ruleset *ast.Ruleset) error {
+	var b strings.Builder
+	b.WriteString("digraph ruleset {\n")
+	b.WriteString("\tnode [shape=box];\n")
+	var edges []string
+	var decl func(v, parent *vertex, indent string)
+	decl = func(v, parent *vertex, indent string) {
+		if v.group {
+			fmt.Fprintf(&b, "%ssubgraph cluster_%s {\n", indent, v.id)
+			fmt.Fprintf(&b, "%s\tlabel=%s;\n", indent, dotQuote(v.label))
+			for _, c := range v.children {
+				decl(c, parent, indent+"\t")
+			}
+			fmt.Fprintf(&b, "%s}\n", indent)
+			return
+		}
+		fmt.Fprintf(&b, "%s%s [label=%s];\n", indent, v.id, dotQuote(v.label))
+		if parent != nil {
+			edges = append(edges, fmt.Sprintf("\t%s -> %s;\n", parent.id, v.id))
+		}
+		for _, c := range v.children {
+			decl(c, v, indent)
+		}
+	}
+	decl(build(ruleset), nil, "\t")
+	for _, e := range edges {
+		b.WriteString(e)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotQuote returns s as a DOT string.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// WriteMermaid はルールセットを Mermaid のフローチャートとして書き出します。
+// WriteMermaid writes the graph of ruleset to w as a Mermaid flowchart,
+// which Markdown renderers such as GitHub's display as a diagram.
+// Combined rules are subgraphs.
+func WriteMermaid(w io.Writer, ruleset *ast.Ruleset) error {
+	var b strings.Builder
+	b.WriteString("flowchart TD\n")
+	var edges []string
+	var decl func(v, parent *vertex, indent string)
+	decl = func(v, parent *vertex, indent string) {
+		if v.group {
+			fmt.Fprintf(&b, "%ssubgraph %s [%s]\n", indent, v.id, mermaidQuote(v.label))
+			for _, c := range v.children {
+				decl(c, parent, indent+"\t")
+			}
+			fmt.Fprintf(&b, "%send\n", indent)
+			return
+		}
+		fmt.Fprintf(&b, "%s%s[%s]\n", indent, v.id, mermaidQuote(v.label))
+		if parent != nil {
+			edges = append(edges, fmt.Sprintf("\t%s --> %s\n", parent.id, v.id))
+		}
+		for _, c := range v.children {
+			decl(c, v, indent)
+		}
+	}
+	decl(build(ruleset), nil, "\t")
+	for _, e := range edges {
+		b.WriteString(e)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// mermaidEscaper writes the characters Mermaid would take as markup as
+// entity codes, since Mermaid has no escape sequences.
+var mermaidEscaper = strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;")
+
+// mermaidQuote returns s as a Mermaid label.
+func mermaidQuote(s string) string {
+	return `"` + mermaidEscaper.Replace(s) + `"`
+}
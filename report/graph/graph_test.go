@@ -0,0 +1,93 @@
+package graph
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/parser"
+)
+
+const input = `Rules = [
+	IsComplete "id", # key
+	(IsUnique "a") and (ColumnValues "b" > 0)
+]`
+
+func TestWriteDOT(t *testing.T) {
+	ruleset, err := parser.ParseRuleset(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteDOT(&buf, ruleset); err != nil {
+		t.Fatal(err)
+	}
+	want := `digraph ruleset {
+	node [shape=box];
+	n0 [label="Rules"];
+	n1 [label="IsComplete"];
+	n2 [label="\"id\""];
+	subgraph cluster_n3 {
+		label="and";
+		n4 [label="IsUnique"];
+		n5 [label="\"a\""];
+		n6 [label="ColumnValues"];
+		n7 [label="\"b\""];
+		n8 [label="> 0"];
+	}
+	n0 -> n1;
+	n1 -> n2;
+	n0 -> n4;
+	n4 -> n5;
+	n0 -> n6;
+	n6 -> n7;
+	n6 -> n8;
+}
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteMermaid(t *testing.T) {
+	ruleset, err := parser.ParseRuleset(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteMermaid(&buf, ruleset); err != nil {
+		t.Fatal(err)
+	}
+	want := `flowchart TD
+	n0["Rules"]
+	n1["IsComplete"]
+	n2["#quot;id#quot;"]
+	subgraph n3 ["and"]
+		n4["IsUnique"]
+		n5["#quot;a#quot;"]
+		n6["ColumnValues"]
+		n7["#quot;b#quot;"]
+		n8["#gt; 0"]
+	end
+	n0 --> n1
+	n1 --> n2
+	n0 --> n4
+	n4 --> n5
+	n0 --> n6
+	n6 --> n7
+	n6 --> n8
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+}
+
+func TestDOTQuote(t *testing.T) {
+	if got, want := dotQuote(`matches "\d"`), `"matches \"\\d\""`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if strings.Contains(mermaidQuote(`<b>`), "<") {
+		t.Error("mermaidQuote must escape <")
+	}
+}
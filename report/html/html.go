@@ -0,0 +1,326 @@
+// Package html はルールセットを静的な HTML のレポートとして出力します。
+// Package html renders rulesets as a static HTML report, for publishing
+// the data quality checks of a dataset.
+//
+// The report has a table of the rules with their descriptions and, if
+// given, the outcomes of an evaluation, followed by the source of the file
+// with syntax highlighting. Diagnostics and outcomes are shown inline,
+// under the line they are on. The page has no external resources.
+package html
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/diag"
+	"github.com/mashiike/go-dqdl/eval"
+	"github.com/mashiike/go-dqdl/printer"
+	"github.com/mashiike/go-dqdl/scanner"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// Option はレポートの内容を変更します。
+// An Option configures a report.
+type Option func(*config)
+
+type config struct {
+	title  string
+	diags  []diag.Diagnostic
+	result *eval.Result
+}
+
+// WithTitle はレポートの題名を指定します。既定ではファイル名です。
+// WithTitle sets the title of the report. It defaults to the name of the
+// file.
+func WithTitle(title string) Option {
+	return func(c *config) {
+		c.title = title
+	}
+}
+
+// WithDiagnostics はレポートに載せる診断情報を指定します。
+// WithDiagnostics adds diagnostics, such as the findings of lint and
+// validate, to the report. Each is shown under the line it is on.
+func WithDiagnostics(diags []diag.Diagnostic) Option {
+	return func(c *config) {
+		c.diags = append(c.diags, diags...)
+	}
+}
+
+// WithResult はレポートに載せる評価結果を指定します。
+// WithResult adds the outcomes of an evaluation of the rulesets of the
+// file to the report. The results are matched to the rules of the file
+// by node, or by position for a result of another parse of the same
+// source.
+func WithResult(result *eval.Result) Option {
+	return func(c *config) {
+		c.result = result
+	}
+}
+
+// Write はファイルの HTML のレポートを書き出します。
+// Write writes the HTML report of file to w. The source of the file is
+// taken from file.Source; without it the rules are printed instead.
+func Write(w io.Writer, file *ast.File, opts ...Option) error {
+	cfg := &config{title: file.Filename}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	src := file.Source
+	if src == "" {
+		var b strings.Builder
+		if err := printer.Fprint(&b, file); err != nil {
+			return fmt.Errorf("html: %w", err)
+		}
+		src = b.String()
+	}
+	p := page{Title: cfg.title}
+	results := newResultIndex(cfg.result)
+	notes := make(map[int][]note)
+	for _, d := range cfg.diags {
+		p.addCount(d.Severity.String())
+		text := d.Severity.String() + ": " + d.Message
+		if d.Code != "" {
+			text += " [" + d.Code + "]"
+		}
+		notes[d.Pos.Line] = append(notes[d.Pos.Line], note{Class: "diag-" + d.Severity.String(), Text: text})
+	}
+	for _, ruleset := range file.Rulesets {
+		for _, decl := range ruleset.Rules {
+			row := ruleRow{Text: ruleText(decl), Description: description(decl)}
+			if decl.Pos().IsValid() {
+				row.Line = decl.Pos().Line
+			}
+			if r, ok := results.find(decl); ok {
+				row.Outcome = r.Outcome.String()
+				row.Message = r.Message
+				p.addCount(row.Outcome)
+				text := row.Outcome
+				if r.Message != "" {
+					text += ": " + r.Message
+				}
+				notes[row.Line] = append(notes[row.Line], note{Class: "outcome-" + row.Outcome, Text: text})
+			}
+			p.Rules = append(p.Rules, row)
+		}
+	}
+	for i, l := range highlightLines(src) {
+		p.Lines = append(p.Lines, line{Number: i + 1, HTML: template.HTML(l), Notes: notes[i+1]})
+	}
+	if err := reportTemplate.Execute(w, p); err != nil {
+		return fmt.Errorf("html: %w", err)
+	}
+	return nil
+}
+
+// Highlight はソースを構文ごとに色分けした HTML を返します。
+// Highlight returns src as HTML, escaped, with each token but whitespace
+// in a span whose class names its kind: "kw" for keywords, "ident" for
+// rule types, "str", "num", "op" and "comment". Text after a lexical
+// error is not highlighted.
+func Highlight(src string) string {
+	return strings.Join(highlightLines(src), "\n")
+}
+
+// highlightLines returns the highlighted lines of src, without the line
+// endings. A span never crosses lines.
+func highlightLines(src string) []string {
+	var lines []string
+	var cur strings.Builder
+	write := func(class, text string) {
+		for i, part := range strings.Split(text, "\n") {
+			if i > 0 {
+				lines = append(lines, cur.String())
+				cur.Reset()
+			}
+			part = strings.TrimSuffix(part, "\r")
+			if part == "" {
+				continue
+			}
+			if class == "" {
+				cur.WriteString(template.HTMLEscapeString(part))
+				continue
+			}
+			fmt.Fprintf(&cur, `<span class="%s">%s</span>`, class, template.HTMLEscapeString(part))
+		}
+	}
+	s := scanner.New(src, scanner.WithTrivia())
+	for {
+		t := s.Next()
+		if t.Type == token.EOF {
+			break
+		}
+		if t.Type == token.ILLEGAL {
+			write("", src[t.Start.Index:])
+			break
+		}
+		write(tokenClass(t.Type), t.Value)
+	}
+	if cur.Len() > 0 || len(lines) == 0 {
+		lines = append(lines, cur.String())
+	}
+	return lines
+}
+
+func tokenClass(t token.TokenType) string {
+	switch {
+	case t == token.COMMENT:
+		return "comment"
+	case t == token.STRING:
+		return "str"
+	case t == token.NUMBER:
+		return "num"
+	case t == token.IDENT:
+		return "ident"
+	case t.IsKeyword():
+		return "kw"
+	case t.IsOperator():
+		return "op"
+	}
+	return ""
+}
+
+// ruleText returns the DQDL text of rule without comments.
+func ruleText(rule ast.RuleDecl) string {
+	var b strings.Builder
+	cfg := printer.Config{Mode: printer.OmitComments}
+	if err := cfg.Fprint(&b, rule); err != nil {
+		return ""
+	}
+	return b.String()
+}
+
+// description returns the text of the description of rule, without the
+// "#" of the comments.
+func description(rule ast.RuleDecl) string {
+	var group ast.CommentGroup
+	switch r := rule.(type) {
+	case *ast.Rule:
+		group = r.Description
+	case *ast.CombinedRule:
+		group = r.Description
+	}
+	texts := make([]string, 0, len(group))
+	for _, c := range group {
+		texts = append(texts, strings.TrimSpace(strings.TrimPrefix(c.Text, "#")))
+	}
+	return strings.Join(texts, " ")
+}
+
+// resultIndex finds the result of a rule.
+type resultIndex struct {
+	byNode map[ast.RuleDecl]eval.RuleResult
+	byPos  map[int]eval.RuleResult
+}
+
+func newResultIndex(result *eval.Result) resultIndex {
+	idx := resultIndex{byNode: make(map[ast.RuleDecl]eval.RuleResult), byPos: make(map[int]eval.RuleResult)}
+	if result == nil {
+		return idx
+	}
+	for _, r := range result.Rules {
+		if r.Rule == nil {
+			continue
+		}
+		idx.byNode[r.Rule] = r
+		if pos := r.Rule.Pos(); pos.IsValid() {
+			idx.byPos[pos.Index] = r
+		}
+	}
+	return idx
+}
+
+func (idx resultIndex) find(rule ast.RuleDecl) (eval.RuleResult, bool) {
+	if r, ok := idx.byNode[rule]; ok {
+		return r, true
+	}
+	if pos := rule.Pos(); pos.IsValid() {
+		r, ok := idx.byPos[pos.Index]
+		return r, ok
+	}
+	return eval.RuleResult{}, false
+}
+
+type page struct {
+	Title  string
+	Counts []count
+	Rules  []ruleRow
+	Lines  []line
+}
+
+// addCount counts an outcome or a severity for the summary.
+func (p *page) addCount(label string) {
+	for i := range p.Counts {
+		if p.Counts[i].Label == label {
+			p.Counts[i].N++
+			return
+		}
+	}
+	p.Counts = append(p.Counts, count{Label: label, N: 1})
+}
+
+type count struct {
+	Label string
+	N     int
+}
+
+type ruleRow struct {
+	Line        int
+	Text        string
+	Description string
+	Outcome     string
+	Message     string
+}
+
+type line struct {
+	Number int
+	HTML   template.HTML // highlighted source
+	Notes  []note
+}
+
+type note struct {
+	Class string
+	Text  string
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #24292f; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #d0d7de; padding: 4px 8px; text-align: left; vertical-align: top; }
+code, pre { font-family: monospace; }
+pre { background: #f6f8fa; padding: 1em; line-height: 1.4; }
+.line { display: block; min-height: 1.4em; }
+.ln { display: inline-block; width: 3em; color: #8c959f; text-align: right; margin-right: 1em; user-select: none; }
+.note { display: block; margin-left: 4em; padding: 0 0.5em; font-family: sans-serif; font-size: 90%; }
+.kw { color: #cf222e; } .ident { color: #8250df; } .str { color: #0a3069; }
+.num { color: #0550ae; } .op { color: #953800; } .comment { color: #6e7781; font-style: italic; }
+.outcome-PASS { color: #1a7f37; } .outcome-FAIL, .diag-error { color: #cf222e; }
+.outcome-ERROR, .diag-warning { color: #9a6700; } .outcome-SKIP, .outcome-SNOOZED, .diag-info, .diag-hint { color: #57606a; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- if .Counts}}
+<p class="summary">{{range $i, $c := .Counts}}{{if $i}}, {{end}}<span class="outcome-{{$c.Label}} diag-{{$c.Label}}">{{$c.N}} {{$c.Label}}</span>{{end}}</p>
+{{- end}}
+<table>
+<tr><th>Line</th><th>Rule</th><th>Description</th><th>Outcome</th></tr>
+{{- range .Rules}}
+<tr><td>{{if .Line}}<a href="#L{{.Line}}">{{.Line}}</a>{{end}}</td><td><code>{{.Text}}</code></td><td>{{.Description}}</td><td>{{if .Outcome}}<span class="outcome-{{.Outcome}}">{{.Outcome}}</span>{{if .Message}} {{.Message}}{{end}}{{end}}</td></tr>
+{{- end}}
+</table>
+<pre>
+{{- range .Lines}}<span class="line" id="L{{.Number}}"><span class="ln">{{.Number}}</span>{{.HTML}}</span>
+{{- range .Notes}}<span class="note {{.Class}}">{{.Text}}</span>{{end}}
+{{- end}}</pre>
+</body>
+</html>
+`))
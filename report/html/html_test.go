@@ -0,0 +1,59 @@
+package html
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/diag"
+	"github.com/mashiike/go-dqdl/eval"
+	"github.com/mashiike/go-dqdl/parser"
+)
+
+func TestHighlight(t *testing.T) {
+	src := "Rules = [\r\n\t# <ids>\r\n\tColumnValues \"a\" between 1 and 5\r\n]"
+	want := `<span class="kw">Rules</span> <span class="op">=</span> <span class="op">[</span>
+	<span class="comment"># &lt;ids&gt;</span>
+	<span class="ident">ColumnValues</span> <span class="str">&#34;a&#34;</span> <span class="kw">between</span> <span class="num">1</span> <span class="kw">and</span> <span class="num">5</span>
+<span class="op">]</span>`
+	if diff := cmp.Diff(want, Highlight(src)); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	src := "Rules = [\n\t# ids are set\n\tIsComplete \"id\",\n\tColumnValues \"<b>\" in [1, 2]\n]\n"
+	file, err := parser.ParseFile("orders.dqdl", strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := file.Rulesets[0].Rules
+	result := &eval.Result{Rules: []eval.RuleResult{
+		{Rule: rules[0], Outcome: eval.OutcomePass},
+		{Rule: rules[1], Outcome: eval.OutcomeFail, Message: "2 rows <3"},
+	}}
+	diags := []diag.Diagnostic{
+		{Code: "duplicate-rule", Severity: diag.SeverityWarning, Message: "duplicate", Pos: rules[1].Pos()},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, file, WithTitle("orders"), WithResult(result), WithDiagnostics(diags)); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"<title>orders</title>",
+		`<span class="outcome-warning diag-warning">1 warning</span>, <span class="outcome-PASS diag-PASS">1 PASS</span>, <span class="outcome-FAIL diag-FAIL">1 FAIL</span>`,
+		`<tr><td><a href="#L3">3</a></td><td><code>IsComplete &#34;id&#34;</code></td><td>ids are set</td><td><span class="outcome-PASS">PASS</span></td></tr>`,
+		`<td><span class="outcome-FAIL">FAIL</span> 2 rows &lt;3</td>`,
+		`<span class="line" id="L4"><span class="ln">4</span>	<span class="ident">ColumnValues</span> <span class="str">&#34;&lt;b&gt;&#34;</span>`,
+		`<span class="note diag-warning">warning: duplicate [duplicate-rule]</span><span class="note outcome-FAIL">FAIL: 2 rows &lt;3</span>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report does not contain %s\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "<b>") {
+		t.Error("the source is not escaped")
+	}
+}
@@ -0,0 +1,244 @@
+// Package junit は診断情報や評価結果を JUnit XML 形式に変換します。
+// Package junit converts diagnostics and evaluation results into JUnit XML
+// reports, so that Jenkins, GitLab and other CI systems show DQDL checks as
+// test results.
+//
+// Lint and validation diagnostics of a file become a test suite with a test
+// case per diagnostic; a file without diagnostics gets a single passing
+// test case, so that it is counted. An evaluation result becomes a test
+// suite with a test case per rule.
+package junit
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/mashiike/go-dqdl/diag"
+	"github.com/mashiike/go-dqdl/eval"
+	"github.com/mashiike/go-dqdl/printer"
+)
+
+// TestSuites は JUnit XML のトップレベルの要素です。
+// TestSuites is the top level element of a JUnit XML report.
+type TestSuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Suites   []TestSuite `xml:"testsuite"`
+}
+
+// TestSuite はテストケースの集まりです。
+// TestSuite is a group of test cases, such as the diagnostics of a file.
+type TestSuite struct {
+	Name     string     `xml:"name,attr"`
+	Tests    int        `xml:"tests,attr"`
+	Failures int        `xml:"failures,attr"`
+	Errors   int        `xml:"errors,attr"`
+	Skipped  int        `xml:"skipped,attr"`
+	Cases    []TestCase `xml:"testcase"`
+}
+
+// TestCase は1つのテストケースです。Failure、Error、Skipped のいずれも無ければ成功です。
+// TestCase is a single test case. It passed unless it has a Failure, an
+// Error or a Skipped.
+type TestCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	File      string   `xml:"file,attr,omitempty"`
+	Line      int      `xml:"line,attr,omitempty"`
+	Failure   *Failure `xml:"failure,omitempty"`
+	Error     *Failure `xml:"error,omitempty"` // the test case could not be run
+	Skipped   *Skipped `xml:"skipped,omitempty"`
+	SystemOut string   `xml:"system-out,omitempty"`
+}
+
+// Failure は失敗またはエラーの内容です。
+// Failure describes a failure or an error of a test case.
+type Failure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// Skipped はテストケースが実行されなかった理由です。
+// Skipped is the reason a test case was not run.
+type Skipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// add appends c to s and updates the counts of s.
+func (s *TestSuite) add(c TestCase) {
+	s.Cases = append(s.Cases, c)
+	s.Tests++
+	switch {
+	case c.Error != nil:
+		s.Errors++
+	case c.Failure != nil:
+		s.Failures++
+	case c.Skipped != nil:
+		s.Skipped++
+	}
+}
+
+// FromDiagnostics はファイルの診断情報からテストスイートを作ります。
+// FromDiagnostics returns the test suite of the diagnostics reported for
+// the file filename. A diagnostic at least as severe as failOn is a
+// failing test case; the others pass and keep their text in the system
+// output.
+func FromDiagnostics(filename string, diags []diag.Diagnostic, failOn diag.Severity) TestSuite {
+	suite := TestSuite{Name: filename}
+	for _, d := range diags {
+		c := TestCase{
+			Name:      caseName(d),
+			ClassName: filename,
+			File:      d.Filename,
+		}
+		if c.File == "" {
+			c.File = filename
+		}
+		if d.Pos.IsValid() {
+			c.Line = d.Pos.Line
+		}
+		if d.Severity <= failOn {
+			c.Failure = &Failure{Message: d.Message, Type: d.Severity.String(), Text: d.String()}
+		} else {
+			c.SystemOut = d.String()
+		}
+		suite.add(c)
+	}
+	if len(diags) == 0 {
+		suite.add(TestCase{Name: filename, ClassName: filename, File: filename})
+	}
+	return suite
+}
+
+// caseName returns the name of the test case of d, e.g.
+// "3:2: duplicate-rule".
+func caseName(d diag.Diagnostic) string {
+	name := d.Code
+	if name == "" {
+		name = d.Message
+	}
+	if d.Pos.IsValid() {
+		return d.Pos.String() + ": " + name
+	}
+	return name
+}
+
+// FromError は読み込めなかったファイルのテストスイートを作ります。
+// FromError returns the test suite of a file that could not be checked,
+// for example because of a syntax error. It has a single test case with
+// err as its error.
+func FromError(filename string, err error) TestSuite {
+	suite := TestSuite{Name: filename}
+	suite.add(TestCase{
+		Name:      filename,
+		ClassName: filename,
+		File:      filename,
+		Error:     &Failure{Message: err.Error(), Type: fmt.Sprintf("%T", err)},
+	})
+	return suite
+}
+
+// FromResult はルールセットの評価結果からテストスイートを作ります。
+// FromResult returns the test suite of an evaluation result, named name.
+// Each rule is a test case named after its DQDL text, without comments.
+// A failed rule is a failure, a rule that could not be evaluated an error
+// and an unsupported or snoozed rule is skipped. The observed metrics are
+// in the system output.
+func FromResult(name string, result *eval.Result) TestSuite {
+	suite := TestSuite{Name: name}
+	for _, r := range result.Rules {
+		c := TestCase{
+			Name:      ruleText(r),
+			ClassName: name,
+			SystemOut: metrics(r.Metrics),
+		}
+		if r.Rule != nil && r.Rule.Pos().IsValid() {
+			c.Line = r.Rule.Pos().Line
+		}
+		switch r.Outcome {
+		case eval.OutcomeFail:
+			c.Failure = &Failure{Message: r.Message, Type: r.Outcome.String(), Text: samples(r.Samples)}
+		case eval.OutcomeError:
+			c.Error = &Failure{Message: r.Message, Type: r.Outcome.String()}
+		case eval.OutcomeSkip, eval.OutcomeSnoozed:
+			c.Skipped = &Skipped{Message: r.Message}
+		}
+		suite.add(c)
+	}
+	return suite
+}
+
+func ruleText(r eval.RuleResult) string {
+	if r.Rule == nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	cfg := printer.Config{Mode: printer.OmitComments}
+	if err := cfg.Fprint(&buf, r.Rule); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// metrics returns a line per metric, "name = value", sorted by name.
+func metrics(m map[string]float64) string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s = %v", name, m[name]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func samples(values []interface{}) string {
+	if len(values) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("samples:")
+	for _, v := range values {
+		fmt.Fprintf(&b, " %v", v)
+	}
+	return b.String()
+}
+
+// New はテストスイートをまとめ、合計を数えます。
+// New returns the report of suites, with the counts summed.
+func New(suites ...TestSuite) *TestSuites {
+	report := &TestSuites{Suites: suites}
+	for _, s := range suites {
+		report.Tests += s.Tests
+		report.Failures += s.Failures
+		report.Errors += s.Errors
+		report.Skipped += s.Skipped
+	}
+	return report
+}
+
+// Write はテストスイートを JUnit XML として書き出します。
+// Write writes suites to w as an indented JUnit XML report with an XML
+// header.
+func Write(w io.Writer, suites ...TestSuite) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(New(suites...)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
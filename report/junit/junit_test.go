@@ -0,0 +1,90 @@
+package junit
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/diag"
+	"github.com/mashiike/go-dqdl/eval"
+	"github.com/mashiike/go-dqdl/parser"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+func TestWrite(t *testing.T) {
+	diags := []diag.Diagnostic{
+		{
+			Code:     "duplicate-rule",
+			Severity: diag.SeverityWarning,
+			Message:  "duplicate rule `IsUnique \"id\"`",
+			Source:   "lint",
+			Filename: "rules/a.dqdl",
+			Pos:      token.Pos{Index: 30, Line: 3, Column: 2},
+		},
+		{
+			Code:     "missing-description",
+			Severity: diag.SeverityInfo,
+			Message:  "rule has no description comment",
+			Filename: "rules/a.dqdl",
+			Pos:      token.Pos{Index: 10, Line: 2, Column: 2},
+		},
+	}
+	rules, err := parser.ParseRules(`RowCount > 10, IsComplete "id", Completeness "name" > 0.9, DataFreshness "ts" <= 24 hours`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := &eval.Result{Rules: []eval.RuleResult{
+		{Rule: rules[0], Outcome: eval.OutcomePass, Metrics: map[string]float64{"Dataset.*.RowCount": 12}},
+		{Rule: rules[1], Outcome: eval.OutcomeFail, Message: "1 of 3 values are null", Samples: []interface{}{nil}},
+		{Rule: rules[2], Outcome: eval.OutcomeError, Message: "column \"name\" not found"},
+		{Rule: rules[3], Outcome: eval.OutcomeSkip, Message: "rule type DataFreshness is not supported"},
+	}}
+	var buf bytes.Buffer
+	err = Write(&buf,
+		FromDiagnostics("rules/a.dqdl", diags, diag.SeverityWarning),
+		FromDiagnostics("rules/b.dqdl", nil, diag.SeverityWarning),
+		FromError("rules/c.dqdl", errors.New("rules/c.dqdl:1:10: syntax error")),
+		FromResult("orders.csv", result),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="8" failures="2" errors="2" skipped="1">
+  <testsuite name="rules/a.dqdl" tests="2" failures="1" errors="0" skipped="0">
+    <testcase name="3:2: duplicate-rule" classname="rules/a.dqdl" file="rules/a.dqdl" line="3">
+      <failure message="duplicate rule ` + "`IsUnique &#34;id&#34;`" + `" type="warning">rules/a.dqdl:3:2: warning: duplicate rule ` + "`IsUnique &#34;id&#34;`" + ` [duplicate-rule]</failure>
+    </testcase>
+    <testcase name="2:2: missing-description" classname="rules/a.dqdl" file="rules/a.dqdl" line="2">
+      <system-out>rules/a.dqdl:2:2: info: rule has no description comment [missing-description]</system-out>
+    </testcase>
+  </testsuite>
+  <testsuite name="rules/b.dqdl" tests="1" failures="0" errors="0" skipped="0">
+    <testcase name="rules/b.dqdl" classname="rules/b.dqdl" file="rules/b.dqdl"></testcase>
+  </testsuite>
+  <testsuite name="rules/c.dqdl" tests="1" failures="0" errors="1" skipped="0">
+    <testcase name="rules/c.dqdl" classname="rules/c.dqdl" file="rules/c.dqdl">
+      <error message="rules/c.dqdl:1:10: syntax error" type="*errors.errorString"></error>
+    </testcase>
+  </testsuite>
+  <testsuite name="orders.csv" tests="4" failures="1" errors="1" skipped="1">
+    <testcase name="RowCount &gt; 10" classname="orders.csv" line="1">
+      <system-out>Dataset.*.RowCount = 12</system-out>
+    </testcase>
+    <testcase name="IsComplete &#34;id&#34;" classname="orders.csv" line="1">
+      <failure message="1 of 3 values are null" type="FAIL">samples: &lt;nil&gt;</failure>
+    </testcase>
+    <testcase name="Completeness &#34;name&#34; &gt; 0.9" classname="orders.csv" line="1">
+      <error message="column &#34;name&#34; not found" type="ERROR"></error>
+    </testcase>
+    <testcase name="DataFreshness &#34;ts&#34; &lt;= 24 hours" classname="orders.csv" line="1">
+      <skipped message="rule type DataFreshness is not supported"></skipped>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("unexpected output (-want +got):\n%s", diff)
+	}
+}
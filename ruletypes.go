@@ -0,0 +1,186 @@
+package dqdl
+
+// 組み込みのルールタイプを Unmarshal で受け取るための構造体です。
+// The structs below receive the built-in rule types of AWS Glue Data
+// Quality with Unmarshal. Condition is nil for a rule without expression.
+// https://docs.aws.amazon.com/glue/latest/dg/dqdl.html#dqdl-rule-types
+
+// AggregateMatch は AggregateMatch ルールです。
+// AggregateMatch is the rule `AggregateMatch "expr1" "expr2" cond`.
+type AggregateMatch struct {
+	First     string
+	Second    string
+	Condition *Condition
+}
+
+// ColumnCorrelation は ColumnCorrelation ルールです。
+// ColumnCorrelation is the rule `ColumnCorrelation "col1" "col2" cond`.
+type ColumnCorrelation struct {
+	Column1   string
+	Column2   string
+	Condition *Condition
+}
+
+// ColumnCount は ColumnCount ルールです。
+// ColumnCount is the rule `ColumnCount cond`.
+type ColumnCount struct {
+	Condition *Condition
+}
+
+// ColumnDataType は ColumnDataType ルールです。
+// ColumnDataType is the rule `ColumnDataType "col" cond`.
+type ColumnDataType struct {
+	Column    string
+	Condition *Condition
+}
+
+// ColumnExists は ColumnExists ルールです。
+// ColumnExists is the rule `ColumnExists "col"`.
+type ColumnExists struct {
+	Column string
+}
+
+// ColumnLength は ColumnLength ルールです。
+// ColumnLength is the rule `ColumnLength "col" cond`.
+type ColumnLength struct {
+	Column    string
+	Condition *Condition
+}
+
+// ColumnNamesMatchPattern は ColumnNamesMatchPattern ルールです。
+// ColumnNamesMatchPattern is the rule `ColumnNamesMatchPattern "pattern"`.
+type ColumnNamesMatchPattern struct {
+	Pattern string
+}
+
+// ColumnValues は ColumnValues ルールです。
+// ColumnValues is the rule `ColumnValues "col" cond`.
+type ColumnValues struct {
+	Column    string
+	Condition *Condition
+}
+
+// Completeness は Completeness ルールです。
+// Completeness is the rule `Completeness "col" cond`.
+type Completeness struct {
+	Column    string
+	Condition *Condition
+}
+
+// CustomSql は CustomSql ルールです。
+// CustomSql is the rule `CustomSql "statement" cond`.
+type CustomSql struct {
+	Statement string
+	Condition *Condition
+}
+
+// DataFreshness は DataFreshness ルールです。
+// DataFreshness is the rule `DataFreshness "col" cond`.
+type DataFreshness struct {
+	Column    string
+	Condition *Condition
+}
+
+// DatasetMatch は DatasetMatch ルールです。
+// DatasetMatch is the rule `DatasetMatch "reference" "mapping" cond`.
+type DatasetMatch struct {
+	Reference string
+	Mapping   string
+	Condition *Condition
+}
+
+// DistinctValuesCount は DistinctValuesCount ルールです。
+// DistinctValuesCount is the rule `DistinctValuesCount "col" cond`.
+type DistinctValuesCount struct {
+	Column    string
+	Condition *Condition
+}
+
+// Entropy は Entropy ルールです。
+// Entropy is the rule `Entropy "col" cond`.
+type Entropy struct {
+	Column    string
+	Condition *Condition
+}
+
+// IsComplete は IsComplete ルールです。
+// IsComplete is the rule `IsComplete "col"`.
+type IsComplete struct {
+	Column string
+}
+
+// IsPrimaryKey は IsPrimaryKey ルールです。
+// IsPrimaryKey is the rule `IsPrimaryKey "col" ...`.
+type IsPrimaryKey struct {
+	Columns []string
+}
+
+// IsUnique は IsUnique ルールです。
+// IsUnique is the rule `IsUnique "col"`.
+type IsUnique struct {
+	Column string
+}
+
+// Mean は Mean ルールです。
+// Mean is the rule `Mean "col" cond`.
+type Mean struct {
+	Column    string
+	Condition *Condition
+}
+
+// ReferentialIntegrity は ReferentialIntegrity ルールです。
+// ReferentialIntegrity is the rule
+// `ReferentialIntegrity "cols" "reference.cols" cond`.
+type ReferentialIntegrity struct {
+	Columns          string
+	ReferenceColumns string
+	Condition        *Condition
+}
+
+// RowCount は RowCount ルールです。
+// RowCount is the rule `RowCount cond`.
+type RowCount struct {
+	Condition *Condition
+}
+
+// RowCountMatch は RowCountMatch ルールです。
+// RowCountMatch is the rule `RowCountMatch "reference" cond`.
+type RowCountMatch struct {
+	Reference string
+	Condition *Condition
+}
+
+// SchemaMatch は SchemaMatch ルールです。
+// SchemaMatch is the rule `SchemaMatch "reference" cond`.
+type SchemaMatch struct {
+	Reference string
+	Condition *Condition
+}
+
+// StandardDeviation は StandardDeviation ルールです。
+// StandardDeviation is the rule `StandardDeviation "col" cond`.
+type StandardDeviation struct {
+	Column    string
+	Condition *Condition
+}
+
+// Sum は Sum ルールです。
+// Sum is the rule `Sum "col" cond`.
+type Sum struct {
+	Column    string
+	Condition *Condition
+}
+
+// UniqueValueRatio は UniqueValueRatio ルールです。
+// UniqueValueRatio is the rule `UniqueValueRatio "col" cond`.
+type UniqueValueRatio struct {
+	Column    string
+	Condition *Condition
+}
+
+// Uniqueness は Uniqueness ルールです。
+// Uniqueness is the rule `Uniqueness "col" cond`.
+type Uniqueness struct {
+	Column    string
+	Condition *Condition
+}
@@ -0,0 +1,31 @@
+//go:build go1.23
+
+package scanner
+
+import (
+	"iter"
+
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// Tokens は input のトークンを順に返すイテレータです。
+// Tokens returns an iterator over the tokens of input, e.g.
+//
+//	for tok := range scanner.Tokens(src) {
+//		...
+//	}
+//
+// The final token.EOF is not yielded; a token.ILLEGAL token is yielded
+// last if the input has an error. Breaking out of the loop stops the
+// scan, with nothing left to clean up.
+func Tokens(input string, opts ...Option) iter.Seq[token.Token] {
+	return func(yield func(token.Token) bool) {
+		s := New(input, opts...)
+		for {
+			t := s.Next()
+			if t.Type == token.EOF || !yield(t) || t.Type == token.ILLEGAL {
+				return
+			}
+		}
+	}
+}
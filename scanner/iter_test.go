@@ -0,0 +1,50 @@
+//go:build go1.23
+
+package scanner
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+func TestTokens(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		stop  token.TokenType // break when a token of this type is seen
+		want  []token.TokenType
+	}{
+		{
+			name:  "all",
+			input: `IsComplete "id" # comment`,
+			want:  []token.TokenType{token.IDENT, token.STRING, token.COMMENT},
+		},
+		{
+			name:  "illegal",
+			input: `RowCount ? 1`,
+			want:  []token.TokenType{token.IDENT, token.ILLEGAL},
+		},
+		{
+			name:  "break",
+			input: `Rules = [ RowCount > 1, IsUnique "id" ]`,
+			stop:  token.COMMA,
+			want:  []token.TokenType{token.RULES, token.EQUAL, token.LEFT_BRACKET, token.IDENT, token.GREATER_THAN, token.NUMBER, token.COMMA},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var got []token.TokenType
+			for tok := range Tokens(c.input) {
+				got = append(got, tok.Type)
+				if c.stop != token.ILLEGAL && tok.Type == c.stop {
+					break
+				}
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
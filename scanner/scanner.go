@@ -0,0 +1,506 @@
+// Package scanner は DQDL の字句解析器を提供します。
+// Package scanner implements a lexical scanner for DQDL. It turns the
+// source into tokens without parsing it, for tools such as syntax
+// highlighters that need the raw tokens. The parser package uses it to
+// read its input.
+package scanner
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mashiike/go-dqdl/token"
+)
+
+const (
+	eof           = -1
+	byteOrderMark = '\uFEFF'
+)
+
+type stateFn func(*Scanner) stateFn
+
+// Scanner は入力の文字列をトークンに分割します。
+// A Scanner splits its input into tokens. Next returns them one by one,
+// scanning the input on demand in the caller's goroutine.
+type Scanner struct {
+	input       string          // the string being scanned, or the window of a reader.
+	base        int             // offset of input in the whole input.
+	reader      io.Reader       // the reader filling input, nil for a string.
+	readErr     error           // the error that ended reading, io.EOF at the end.
+	readBuf     []byte          // buffer for reading from reader.
+	trivia      bool            // emit whitespace tokens.
+	start       int             // start position of this item.
+	startLine   int             // start line of this item.
+	startCol    int             // start column of this item.
+	pos         int             // current position in the input.
+	line        int             // 1+number of newlines seen.
+	col         int             // 1+number of characters seen on this line.
+	prevLineCol int             // column of the newline ending the previous line.
+	width       int             // width of last rune read from input.
+	done        bool            // the scan has ended with EOF or an error.
+	ready       bool            // a token has been emitted but not returned by Next.
+	typ         token.TokenType // type of the emitted token.
+	tokStart    token.Pos       // start of the emitted token.
+	tokEnd      token.Pos       // end of the emitted token.
+	message     string          // value of an emitted ILLEGAL token.
+}
+
+// Option は Scanner の設定を変更します。
+// An Option configures a Scanner.
+type Option func(*Scanner)
+
+// WithTrivia は空白も token.WHITESPACE として出力するようにします。
+// WithTrivia makes the scanner emit runs of whitespace as
+// token.WHITESPACE tokens, so that the values of all tokens concatenated
+// reproduce the input.
+func WithTrivia() Option {
+	return func(s *Scanner) {
+		s.trivia = true
+	}
+}
+
+// WithStart は走査を開始する位置を指定します。
+// WithStart starts scanning the input at pos instead of its beginning.
+func WithStart(pos token.Pos) Option {
+	return func(s *Scanner) {
+		s.start, s.pos = pos.Index, pos.Index
+		s.startLine, s.line = pos.Line, pos.Line
+		s.startCol, s.col = pos.Column, pos.Column
+	}
+}
+
+// New は input を走査する Scanner を返します。
+// New returns a Scanner for input.
+func New(input string, opts ...Option) *Scanner {
+	s := &Scanner{
+		input:     input,
+		line:      1,
+		startLine: 1,
+		col:       1,
+		startCol:  1,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// NewReader は r から読み込みながら走査する Scanner を返します。
+// NewReader returns a Scanner reading its input from r as the scan
+// proceeds. Only a window of the input around the current token is kept
+// in memory, see Source. An error reading r other than io.EOF ends the
+// scan with a token.ILLEGAL token; it is also returned by Err.
+func NewReader(r io.Reader, opts ...Option) *Scanner {
+	s := New("", opts...)
+	s.reader = r
+	return s
+}
+
+// Err は入力の読み込み中に発生したエラーを返します。
+// Err returns the error reading the input of a Scanner created by
+// NewReader, or nil.
+func (s *Scanner) Err() error {
+	if s.readErr == io.EOF {
+		return nil
+	}
+	return s.readErr
+}
+
+// Source は offset 以降の入力のうち読み込み済みの部分を返します。
+// Source returns the input from the byte offset offset to the end of what
+// has been read so far. A Scanner created by NewReader keeps at least
+// KeepBefore bytes before the current token, and returns "" for offsets
+// that have already been discarded.
+func (s *Scanner) Source(offset int) string {
+	if offset < s.base || offset > s.base+len(s.input) {
+		return ""
+	}
+	return s.input[offset-s.base:]
+}
+
+const (
+	// KeepBefore は NewReader の Scanner が保持する現在のトークンより前の入力のバイト数です。
+	// KeepBefore is the number of bytes of input before the current token
+	// that a Scanner created by NewReader keeps for Source.
+	KeepBefore = 1024
+	readSize   = 4096 // bytes read from the reader at once
+)
+
+// fill reads more of the input, discarding what is no longer needed.
+func (s *Scanner) fill() {
+	if drop := s.start - KeepBefore - s.base; drop > readSize {
+		s.input = s.input[drop:]
+		s.base += drop
+	}
+	if s.readBuf == nil {
+		s.readBuf = make([]byte, readSize)
+	}
+	for {
+		n, err := s.reader.Read(s.readBuf)
+		if n > 0 {
+			s.input += string(s.readBuf[:n])
+		}
+		if err != nil {
+			s.readErr = err
+			return
+		}
+		if n > 0 {
+			return
+		}
+	}
+}
+
+// Next は次のトークンを返します。
+// Next returns the next token. The scan ends with a token.EOF token, or a
+// token.ILLEGAL token whose Value describes the error; after that, Next
+// keeps returning token.EOF at the position where the scan ended.
+func (s *Scanner) Next() token.Token {
+	var t token.Token
+	s.Scan(&t)
+	return t
+}
+
+// Scan は次のトークンを t に格納します。
+// Scan is like Next, but stores the token in *t. A caller keeping the
+// token in a variable spares a copy of it, which matters to a parser
+// reading many tokens.
+func (s *Scanner) Scan(t *token.Token) {
+	if s.done {
+		*t = token.Token{Type: token.EOF, Start: s.current(), End: s.current()}
+		return
+	}
+	// Each token is scanned from lexRule: a state function emitting a
+	// token returns lexRule, or nil after the last token. The state is not
+	// kept in s, as a function value is a pointer to store.
+	for state := stateFn(lexRule); !s.ready; {
+		if state = state(s); state == nil {
+			s.done = true
+		}
+	}
+	s.ready = false
+	// The value is sliced here rather than in emit, so that the hot path
+	// stores no pointers into the scanner.
+	t.Type, t.Start, t.End = s.typ, s.tokStart, s.tokEnd
+	if s.typ == token.ILLEGAL {
+		t.Value = s.message
+	} else {
+		t.Value = s.input[s.tokStart.Index-s.base : s.tokEnd.Index-s.base]
+	}
+}
+
+// current returns the current position.
+func (s *Scanner) current() token.Pos {
+	return token.Pos{Index: s.pos, Line: s.line, Column: s.col}
+}
+
+// emit passes a token of the pending input to Next. A state function
+// emits at most one token before it returns.
+func (s *Scanner) emit(t token.TokenType) {
+	s.ready = true
+	s.typ = t
+	s.tokStart = token.Pos{Index: s.start, Line: s.startLine, Column: s.startCol}
+	s.tokEnd = s.current()
+	s.start = s.pos
+	s.startLine = s.line
+	s.startCol = s.col
+}
+
+// next returns the next rune in the input. It is inlined for ASCII other
+// than a newline, which is a full rune in the window of a reader as well.
+func (s *Scanner) next() rune {
+	if i := s.pos - s.base; i < len(s.input) {
+		if c := s.input[i]; c < utf8.RuneSelf && c != '\n' {
+			s.pos++
+			s.col++
+			s.width = 1
+			return rune(c)
+		}
+	}
+	return s.nextRune()
+}
+
+// nextRune is next for the other runes.
+func (s *Scanner) nextRune() rune {
+	s.col++
+	for s.reader != nil && s.readErr == nil && !utf8.FullRuneInString(s.input[s.pos-s.base:]) {
+		s.fill()
+	}
+	if s.pos >= s.base+len(s.input) {
+		s.width = 0
+		return eof
+	}
+	r, w := utf8.DecodeRuneInString(s.input[s.pos-s.base:])
+	s.width = w
+	s.pos += s.width
+	if r == '\n' {
+		s.line++
+		s.prevLineCol = s.col - 1 // the column of the newline
+		s.col = 1
+	}
+	return r
+}
+
+// skip consumes the bytes of set at the current position, as many calls
+// of next would. It is a fast path for the runs of the state functions,
+// which call next after it for the rest, e.g. a multibyte rune, a newline
+// or the end of the window of a reader.
+func (s *Scanner) skip(set *byteSet) {
+	i := s.pos - s.base
+	n := i
+	for n < len(s.input) && s.input[n] < utf8.RuneSelf && set[s.input[n]] {
+		n++
+	}
+	s.pos += n - i
+	s.col += n - i
+}
+
+// byteSet is a set of ASCII bytes other than a newline, which are one
+// column each.
+type byteSet [utf8.RuneSelf]bool
+
+func newByteSet(in func(b byte) bool) *byteSet {
+	var set byteSet
+	for b := byte(0); b < utf8.RuneSelf; b++ {
+		set[b] = b != '\n' && in(b)
+	}
+	return &set
+}
+
+// the bytes skipped by the state functions
+var (
+	spaceBytes   = newByteSet(func(b byte) bool { return isSpace(rune(b)) })
+	identBytes   = newByteSet(func(b byte) bool { return isLetter(rune(b)) || isDigit(rune(b)) || b == '_' })
+	stringBytes  = newByteSet(func(b byte) bool { return b != '"' })
+	commentBytes = newByteSet(func(b byte) bool { return b != '\r' })
+)
+
+// ignore skips over the pending input before this point.
+func (s *Scanner) ignore() {
+	s.start = s.pos
+	s.startLine = s.line
+	s.startCol = s.col
+}
+
+// backup steps back one rune. Can only be called once per call of next.
+func (s *Scanner) backup() {
+	s.pos -= s.width
+	s.col--
+	if s.col < 1 {
+		s.line--
+		s.col = s.prevLineCol
+	}
+}
+
+// accept consumes the next rune if it's from the valid set.
+func (s *Scanner) accept(valid string) bool {
+	if strings.ContainsRune(valid, s.next()) {
+		return true
+	}
+	s.backup()
+	return false
+}
+
+// errorf emits an error token and terminates the scan by passing back a
+// nil pointer that will be the next state.
+func (s *Scanner) errorf(format string, args ...interface{}) stateFn {
+	s.ready = true
+	s.typ = token.ILLEGAL
+	s.message = fmt.Sprintf(format, args...)
+	s.tokStart = token.Pos{Index: s.start, Line: s.startLine, Column: s.startCol}
+	s.tokEnd = s.current()
+	return nil
+}
+
+// lexRule scans the input for a rule. It returns after each token, so
+// that Next never scans further than needed. It calls the state function
+// of a token directly, rather than returning it to the loop of Next.
+func lexRule(s *Scanner) stateFn {
+	switch r := s.next(); {
+	case r == eof:
+		if err := s.Err(); err != nil {
+			return s.errorf("%v", err)
+		}
+		s.emit(token.EOF)
+		return nil
+	case r == byteOrderMark && s.start == 0:
+		// A leading byte order mark, written by some Windows editors, is
+		// not part of the text: the first column follows it.
+		s.col = 1
+		if s.trivia {
+			s.emit(token.WHITESPACE)
+		} else {
+			s.ignore()
+		}
+	case isSpace(r):
+		return lexSpace(s)
+	case isLetter(r):
+		s.backup()
+		return lexIdentifier(s)
+	case r == '"':
+		return lexString(s)
+	case isDigit(r):
+		s.backup()
+		return lexNumber(s)
+	case r == '#':
+		return lexComment(s)
+	case r == '(':
+		s.emit(token.LEFT_PAREN)
+	case r == ')':
+		s.emit(token.RIGHT_PAREN)
+	case r == ',':
+		s.emit(token.COMMA)
+	case r == '+':
+		s.emit(token.PLUS)
+	case r == '-':
+		s.emit(token.MINUS)
+	case r == '*':
+		s.emit(token.MULTIPLY)
+	case r == '/':
+		s.emit(token.DIVIDE)
+	case r == '=':
+		s.emit(token.EQUAL)
+	case r == ']':
+		s.emit(token.RIGHT_BRACKET)
+	case r == '[':
+		s.emit(token.LEFT_BRACKET)
+	case r == '>':
+		if s.accept("=") {
+			s.emit(token.GREATER_EQUAL)
+		} else {
+			s.emit(token.GREATER_THAN)
+		}
+	case r == '<':
+		if s.accept("=") {
+			s.emit(token.LESS_EQUAL)
+		} else {
+			s.emit(token.LESS_THAN)
+		}
+	default:
+		return s.errorf("unrecognized character: %#U", r)
+	}
+	return lexRule
+}
+
+// lexSpace scans a run of whitespace.
+func lexSpace(s *Scanner) stateFn {
+	for s.skip(spaceBytes); isSpace(s.next()); s.skip(spaceBytes) {
+	}
+	s.backup()
+	if s.trivia {
+		s.emit(token.WHITESPACE)
+		return lexRule
+	}
+	// the token after the whitespace is scanned in the same call of Next.
+	s.ignore()
+	return lexRule(s)
+}
+
+// lexComment scans a comment. The comment ends before the line ending,
+// "\n" or "\r\n".
+func lexComment(s *Scanner) stateFn {
+	for {
+		s.skip(commentBytes)
+		switch r := s.next(); {
+		case r == '\n', r == eof:
+			s.backup()
+			s.emit(token.COMMENT)
+			return lexRule
+		case r == '\r':
+			if s.next() == '\n' {
+				// step back over both runes, which are one byte each
+				s.backup()
+				s.pos--
+				s.col--
+				s.emit(token.COMMENT)
+				return lexRule
+			}
+			s.backup()
+		default:
+			// absorb.
+		}
+	}
+}
+
+// lexIdentifier scans an identifier, a letter followed by letters, digits
+// and underscores, e.g. CustomSql2.
+func lexIdentifier(s *Scanner) stateFn {
+	s.skip(identBytes)
+	for {
+		switch r := s.next(); {
+		case isLetter(r), isDigit(r), r == '_':
+			// absorb.
+		default:
+			s.backup()
+			keyword := s.input[s.start-s.base : s.pos-s.base]
+			t := token.LookupIdent(keyword)
+			if t == token.NOW {
+				// NOW is a special case, it can be followed by '()'.
+				if r := s.next(); r != '(' {
+					return s.errorf("expected '()' after NOW")
+				}
+				if r := s.next(); r != ')' {
+					return s.errorf("expected '()' after NOW")
+				}
+			}
+			s.emit(t)
+			return lexRule
+		}
+	}
+}
+
+// lexString scans a quoted string.
+func lexString(s *Scanner) stateFn {
+	for {
+		s.skip(stringBytes)
+		switch r := s.next(); {
+		case r == eof:
+			return s.errorf("unterminated string")
+		case r == '"':
+			s.emit(token.STRING)
+			return lexRule
+		default:
+			// absorb.
+		}
+	}
+}
+
+// lexNumber scans a number.
+func lexNumber(s *Scanner) stateFn {
+	var seenDot bool
+	for {
+		switch r := s.next(); {
+		case isDigit(r):
+			// absorb.
+		case r == '.':
+			if seenDot {
+				return s.errorf("invalid number")
+			}
+			seenDot = true
+		case isLetter(r):
+			return s.errorf("invalid number")
+		default:
+			s.backup()
+			s.emit(token.NUMBER)
+			return lexRule
+		}
+	}
+}
+
+// isSpace reports whether r is a space character. A carriage return is a
+// space, so that files with CRLF line endings scan like the others.
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
+
+// isLetter reports whether r is a letter of an identifier. Like in Glue,
+// identifiers are ASCII; other letters may appear in strings and comments.
+func isLetter(r rune) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z'
+}
+
+// isDigit reports whether r is a digit.
+func isDigit(r rune) bool {
+	return '0' <= r && r <= '9'
+}
@@ -0,0 +1,228 @@
+package scanner
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+func scanAll(s *Scanner) []token.Token {
+	var tokens []token.Token
+	for {
+		t := s.Next()
+		tokens = append(tokens, t)
+		if t.Type == token.EOF || t.Type == token.ILLEGAL {
+			return tokens
+		}
+	}
+}
+
+func TestScanner(t *testing.T) {
+	pos := func(index, line, column int) token.Pos {
+		return token.Pos{Index: index, Line: line, Column: column}
+	}
+	cases := []struct {
+		name  string
+		input string
+		opts  []Option
+		want  []token.Token
+	}{
+		{
+			name:  "rule",
+			input: "IsComplete \"id\"\n# done",
+			want: []token.Token{
+				{Type: token.IDENT, Value: "IsComplete", Start: pos(0, 1, 1), End: pos(10, 1, 11)},
+				{Type: token.STRING, Value: `"id"`, Start: pos(11, 1, 12), End: pos(15, 1, 16)},
+				{Type: token.COMMENT, Value: "# done", Start: pos(16, 2, 1), End: pos(22, 2, 7)},
+				{Type: token.EOF, Value: "", Start: pos(22, 2, 7), End: pos(22, 2, 8)},
+			},
+		},
+		{
+			name:  "trivia",
+			input: "RowCount >= 1\n",
+			opts:  []Option{WithTrivia()},
+			want: []token.Token{
+				{Type: token.IDENT, Value: "RowCount", Start: pos(0, 1, 1), End: pos(8, 1, 9)},
+				{Type: token.WHITESPACE, Value: " ", Start: pos(8, 1, 9), End: pos(9, 1, 10)},
+				{Type: token.GREATER_EQUAL, Value: ">=", Start: pos(9, 1, 10), End: pos(11, 1, 12)},
+				{Type: token.WHITESPACE, Value: " ", Start: pos(11, 1, 12), End: pos(12, 1, 13)},
+				{Type: token.NUMBER, Value: "1", Start: pos(12, 1, 13), End: pos(13, 1, 14)},
+				{Type: token.WHITESPACE, Value: "\n", Start: pos(13, 1, 14), End: pos(14, 2, 1)},
+				{Type: token.EOF, Value: "", Start: pos(14, 2, 1), End: pos(14, 2, 2)},
+			},
+		},
+		{
+			name:  "start",
+			input: "Rules = [ Mean \"x\" > 1 ]",
+			opts:  []Option{WithStart(pos(10, 1, 11))},
+			want: []token.Token{
+				{Type: token.IDENT, Value: "Mean", Start: pos(10, 1, 11), End: pos(14, 1, 15)},
+				{Type: token.STRING, Value: `"x"`, Start: pos(15, 1, 16), End: pos(18, 1, 19)},
+				{Type: token.GREATER_THAN, Value: ">", Start: pos(19, 1, 20), End: pos(20, 1, 21)},
+				{Type: token.NUMBER, Value: "1", Start: pos(21, 1, 22), End: pos(22, 1, 23)},
+				{Type: token.RIGHT_BRACKET, Value: "]", Start: pos(23, 1, 24), End: pos(24, 1, 25)},
+				{Type: token.EOF, Value: "", Start: pos(24, 1, 25), End: pos(24, 1, 26)},
+			},
+		},
+		{
+			name:  "crlf",
+			input: "# rows\r\nRowCount > 1\r\n",
+			want: []token.Token{
+				{Type: token.COMMENT, Value: "# rows", Start: pos(0, 1, 1), End: pos(6, 1, 7)},
+				{Type: token.IDENT, Value: "RowCount", Start: pos(8, 2, 1), End: pos(16, 2, 9)},
+				{Type: token.GREATER_THAN, Value: ">", Start: pos(17, 2, 10), End: pos(18, 2, 11)},
+				{Type: token.NUMBER, Value: "1", Start: pos(19, 2, 12), End: pos(20, 2, 13)},
+				{Type: token.EOF, Value: "", Start: pos(22, 3, 1), End: pos(22, 3, 2)},
+			},
+		},
+		{
+			name:  "carriage return in comment",
+			input: "# a\rb\r",
+			want: []token.Token{
+				{Type: token.COMMENT, Value: "# a\rb\r", Start: pos(0, 1, 1), End: pos(6, 1, 7)},
+				{Type: token.EOF, Value: "", Start: pos(6, 1, 7), End: pos(6, 1, 8)},
+			},
+		},
+		{
+			name:  "byte order mark",
+			input: "\uFEFFRowCount > 1",
+			want: []token.Token{
+				{Type: token.IDENT, Value: "RowCount", Start: pos(3, 1, 1), End: pos(11, 1, 9)},
+				{Type: token.GREATER_THAN, Value: ">", Start: pos(12, 1, 10), End: pos(13, 1, 11)},
+				{Type: token.NUMBER, Value: "1", Start: pos(14, 1, 12), End: pos(15, 1, 13)},
+				{Type: token.EOF, Value: "", Start: pos(15, 1, 13), End: pos(15, 1, 14)},
+			},
+		},
+		{
+			name:  "byte order mark trivia",
+			input: "\uFEFF# x",
+			opts:  []Option{WithTrivia()},
+			want: []token.Token{
+				{Type: token.WHITESPACE, Value: "\uFEFF", Start: pos(0, 1, 1), End: pos(3, 1, 1)},
+				{Type: token.COMMENT, Value: "# x", Start: pos(3, 1, 1), End: pos(6, 1, 4)},
+				{Type: token.EOF, Value: "", Start: pos(6, 1, 4), End: pos(6, 1, 5)},
+			},
+		},
+		{
+			name:  "byte order mark not at start",
+			input: "RowCount\uFEFF",
+			want: []token.Token{
+				{Type: token.IDENT, Value: "RowCount", Start: pos(0, 1, 1), End: pos(8, 1, 9)},
+				{Type: token.ILLEGAL, Value: "unrecognized character: U+FEFF", Start: pos(8, 1, 9), End: pos(11, 1, 10)},
+			},
+		},
+		{
+			name:  "identifier with digits",
+			input: "CustomSql2 Custom_Rule_3",
+			want: []token.Token{
+				{Type: token.IDENT, Value: "CustomSql2", Start: pos(0, 1, 1), End: pos(10, 1, 11)},
+				{Type: token.IDENT, Value: "Custom_Rule_3", Start: pos(11, 1, 12), End: pos(24, 1, 25)},
+				{Type: token.EOF, Value: "", Start: pos(24, 1, 25), End: pos(24, 1, 26)},
+			},
+		},
+		{
+			name:  "illegal",
+			input: "RowCount ? 1",
+			want: []token.Token{
+				{Type: token.IDENT, Value: "RowCount", Start: pos(0, 1, 1), End: pos(8, 1, 9)},
+				{Type: token.ILLEGAL, Value: "unrecognized character: U+003F '?'", Start: pos(9, 1, 10), End: pos(10, 1, 11)},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := scanAll(New(c.input, c.opts...))
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScanner__Trivia(t *testing.T) {
+	input := "Rules = [\n\t# comment\n\tColumnValues \"a\" in [1, 2],\n\tIsUnique \"b\"\n]\n"
+	var b strings.Builder
+	for _, tok := range scanAll(New(input, WithTrivia())) {
+		b.WriteString(tok.Value)
+	}
+	if diff := cmp.Diff(input, b.String()); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+}
+
+func TestScanner__NextAfterEnd(t *testing.T) {
+	s := New(`"unterminated`)
+	if got := s.Next(); got.Type != token.ILLEGAL {
+		t.Fatalf("got %s, want ILLEGAL", got.Type)
+	}
+	for i := 0; i < 2; i++ {
+		if got := s.Next(); got.Type != token.EOF {
+			t.Errorf("got %s, want EOF", got.Type)
+		}
+	}
+}
+
+func TestScanner__Scan(t *testing.T) {
+	input := "Rules = [ ColumnValues \"a\" in [1, 2], # comment\n\tIsUnique \"b\" ] \"unterminated"
+	want := scanAll(New(input))
+	var got []token.Token
+	s := New(input)
+	for i := 0; i < len(want)+1; i++ {
+		var tok token.Token
+		s.Scan(&tok)
+		got = append(got, tok)
+	}
+	// the scan keeps returning EOF after the error
+	want = append(want, token.Token{Type: token.EOF, Start: want[len(want)-1].End, End: want[len(want)-1].End})
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+}
+
+func BenchmarkScanner(b *testing.B) {
+	var src strings.Builder
+	for i := 0; i < 1000; i++ {
+		src.WriteString("ColumnValues \"status\" in [\"a\", \"b\"] with threshold > 0.9, # comment\n")
+	}
+	b.SetBytes(int64(src.Len()))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s := New(src.String())
+		for t := s.Next(); t.Type != token.EOF; t = s.Next() {
+		}
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	// multi-byte characters in comments are split across reads
+	input := strings.Repeat("IsComplete \"id\", # 日本語のコメント\n", 500)
+	want := scanAll(New(input))
+	s := NewReader(iotest.OneByteReader(strings.NewReader(input)))
+	got := scanAll(s)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+	if s.Source(0) != "" {
+		t.Error("got the start of the input, want it discarded")
+	}
+	if got := s.Source(len(input) - 10); got != "のコメント\n"[len("のコメント\n")-10:] {
+		t.Errorf("got %q for the end of the input", got)
+	}
+}
+
+func TestNewReader__Error(t *testing.T) {
+	readErr := errors.New("read failed")
+	s := NewReader(io.MultiReader(strings.NewReader("RowCount > 1"), iotest.ErrReader(readErr)))
+	tokens := scanAll(s)
+	if got := tokens[len(tokens)-1]; got.Type != token.ILLEGAL || got.Value != "read failed" {
+		t.Errorf("got %#v, want an ILLEGAL token", got)
+	}
+	if s.Err() != readErr {
+		t.Errorf("got error %v, want %v", s.Err(), readErr)
+	}
+}
@@ -0,0 +1,227 @@
+// Package spec は構文木を位置情報を持たない意味的なモデルに変換します。
+// Package spec lowers the syntax tree into a semantic model of the rules,
+// for consumers that care about what a rule checks rather than how it is
+// written. The model has no positions or comments: numbers are parsed,
+// durations are converted to time.Duration, and dates are resolved to
+// time.Time.
+//
+// The values of the model are float64 for numbers, string, bool,
+// time.Duration for durations and time.Time for dates.
+package spec
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mashiike/go-dqdl/ast"
+)
+
+// Ruleset はルールセットの意味的なモデルです。
+// A Ruleset is the semantic model of a ruleset.
+type Ruleset struct {
+	Name  string // name given by a directive, see ast.Ruleset.Name
+	Rules []Rule
+}
+
+// Rule はルールの意味的なモデルです。
+// A Rule is the semantic model of a rule. A combined rule has no Type;
+// its rules are in Rules, joined by Operator.
+type Rule struct {
+	Type       string
+	Columns    []string // the string parameters, which name the columns for most rule types
+	Expression Expr     // the constraint, without the threshold; nil if none
+	// Threshold is the bound of a `with threshold` comparison, e.g. 0.9
+	// for `with threshold > 0.9`; nil if none. ThresholdExpr is the whole
+	// threshold expression.
+	Threshold     *float64
+	ThresholdExpr Expr
+	Operator      string // "and" or "or" for a combined rule
+	Rules         []Rule // the rules of a combined rule
+}
+
+// Expr は制約を表す式です。
+// An Expr is the constraint of a rule: a *Comparison, a *Between, an *In
+// or a *Matches.
+type Expr interface {
+	exprNode()
+}
+
+// Comparison は比較の式です。
+// A Comparison is a constraint such as `> 10`.
+type Comparison struct {
+	Operator string // "=", ">", ">=", "<" or "<="
+	Value    interface{}
+}
+
+// Between は範囲の式です。
+// A Between is a constraint such as `between 1 and 10`.
+type Between struct {
+	Low, High interface{}
+}
+
+// In は値の一覧の式です。
+// An In is a constraint such as `in ["a", "b"]`.
+type In struct {
+	Values []interface{}
+}
+
+// Matches は正規表現の式です。
+// A Matches is a constraint such as `matches "[a-z]+"`.
+type Matches struct {
+	Pattern string
+}
+
+func (*Comparison) exprNode() {}
+func (*Between) exprNode()    {}
+func (*In) exprNode()         {}
+func (*Matches) exprNode()    {}
+
+// Option は変換の設定を変更します。
+// An Option configures lowering.
+type Option func(*config)
+
+type config struct {
+	now time.Time
+}
+
+// WithNow は now() の値を指定します。デフォルトは変換を開始した時刻です。
+// WithNow sets the time now() resolves to. The default is the time the
+// lowering starts.
+func WithNow(t time.Time) Option {
+	return func(c *config) {
+		c.now = t
+	}
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{now: time.Now()}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// Lower はルールセットを意味的なモデルに変換します。
+// Lower lowers ruleset into its semantic model. It fails on a parameter
+// that can not be converted, such as a number out of range.
+func Lower(ruleset *ast.Ruleset, opts ...Option) (*Ruleset, error) {
+	cfg := newConfig(opts)
+	rs := &Ruleset{Name: ruleset.Name(), Rules: make([]Rule, 0, len(ruleset.Rules))}
+	for _, decl := range ruleset.Rules {
+		rule, err := cfg.lower(decl)
+		if err != nil {
+			return nil, err
+		}
+		rs.Rules = append(rs.Rules, rule)
+	}
+	return rs, nil
+}
+
+// LowerRule はルールを意味的なモデルに変換します。
+// LowerRule lowers a rule into its semantic model.
+func LowerRule(decl ast.RuleDecl, opts ...Option) (Rule, error) {
+	return newConfig(opts).lower(decl)
+}
+
+func (cfg *config) lower(decl ast.RuleDecl) (Rule, error) {
+	switch r := decl.(type) {
+	case *ast.Rule:
+		return cfg.lowerRule(r)
+	case *ast.CombinedRule:
+		rule := Rule{Operator: r.Operator, Rules: make([]Rule, 0, len(r.Rules))}
+		for _, nested := range r.Rules {
+			nr, err := cfg.lowerRule(nested)
+			if err != nil {
+				return Rule{}, err
+			}
+			rule.Rules = append(rule.Rules, nr)
+		}
+		return rule, nil
+	}
+	return Rule{}, fmt.Errorf("spec: unexpected rule %T", decl)
+}
+
+func (cfg *config) lowerRule(r *ast.Rule) (Rule, error) {
+	rule := Rule{Type: r.Type.Name}
+	for _, param := range r.Parameters {
+		if p, ok := param.(*ast.StringParameter); ok {
+			rule.Columns = append(rule.Columns, p.Value)
+		}
+	}
+	expr := r.Expression
+	if x, ok := expr.(*ast.WithThresholdExpression); ok {
+		threshold, err := cfg.expr(x.Threshold)
+		if err != nil {
+			return Rule{}, err
+		}
+		rule.ThresholdExpr = threshold
+		if c, ok := threshold.(*Comparison); ok {
+			if v, ok := c.Value.(float64); ok {
+				rule.Threshold = &v
+			}
+		}
+		expr = x.Target
+	}
+	if expr != nil {
+		e, err := cfg.expr(expr)
+		if err != nil {
+			return Rule{}, err
+		}
+		rule.Expression = e
+	}
+	return rule, nil
+}
+
+func (cfg *config) expr(expr ast.Expression) (Expr, error) {
+	switch x := expr.(type) {
+	case *ast.ComparisonExpression:
+		v, err := cfg.value(x.Right)
+		if err != nil {
+			return nil, err
+		}
+		return &Comparison{Operator: x.Operator, Value: v}, nil
+	case *ast.BetweenExpression:
+		lo, err := cfg.value(x.Left)
+		if err != nil {
+			return nil, err
+		}
+		hi, err := cfg.value(x.Right)
+		if err != nil {
+			return nil, err
+		}
+		return &Between{Low: lo, High: hi}, nil
+	case *ast.InExpression:
+		in := &In{Values: make([]interface{}, 0, len(x.Values))}
+		for _, param := range x.Values {
+			v, err := cfg.value(param)
+			if err != nil {
+				return nil, err
+			}
+			in.Values = append(in.Values, v)
+		}
+		return in, nil
+	case *ast.MatchesExpression:
+		return &Matches{Pattern: x.Value}, nil
+	}
+	return nil, fmt.Errorf("spec: unexpected expression %T", expr)
+}
+
+func (cfg *config) value(param ast.Parameter) (interface{}, error) {
+	switch p := param.(type) {
+	case *ast.NumberParameter:
+		f, err := p.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("spec: %s: %w", p.Pos(), err)
+		}
+		return f, nil
+	case *ast.StringParameter:
+		return p.Value, nil
+	case *ast.BoolParameter:
+		return p.Value, nil
+	case *ast.DurationParameter:
+		return p.Duration()
+	case *ast.DateParameter:
+		return p.Resolve(cfg.now)
+	}
+	return nil, fmt.Errorf("spec: unexpected parameter %T", param)
+}
@@ -0,0 +1,78 @@
+package spec
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/parser"
+)
+
+func TestLower(t *testing.T) {
+	ruleset, err := parser.ParseRuleset(`#dqdl:name orders
+Rules = [
+	RowCount between 10 and 100,
+	IsPrimaryKey "shop_id" "order_id",
+	ColumnValues "status" in ["a", "b"] with threshold > 0.9,
+	ColumnValues "created_at" > (now() - 3 days),
+	ColumnValues "code" matches "[A-Z]+",
+	DataFreshness "updated_at" <= 12 hours,
+	(IsComplete "id") and (ColumnValues "flag" = true)
+]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	got, err := Lower(ruleset, WithNow(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	threshold := 0.9
+	want := &Ruleset{
+		Name: "orders",
+		Rules: []Rule{
+			{Type: "RowCount", Expression: &Between{Low: 10.0, High: 100.0}},
+			{Type: "IsPrimaryKey", Columns: []string{"shop_id", "order_id"}},
+			{
+				Type:          "ColumnValues",
+				Columns:       []string{"status"},
+				Expression:    &In{Values: []interface{}{"a", "b"}},
+				Threshold:     &threshold,
+				ThresholdExpr: &Comparison{Operator: ">", Value: 0.9},
+			},
+			{
+				Type:       "ColumnValues",
+				Columns:    []string{"created_at"},
+				Expression: &Comparison{Operator: ">", Value: time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+			},
+			{Type: "ColumnValues", Columns: []string{"code"}, Expression: &Matches{Pattern: "[A-Z]+"}},
+			{
+				Type:       "DataFreshness",
+				Columns:    []string{"updated_at"},
+				Expression: &Comparison{Operator: "<=", Value: 12 * time.Hour},
+			},
+			{
+				Operator: "and",
+				Rules: []Rule{
+					{Type: "IsComplete", Columns: []string{"id"}},
+					{Type: "ColumnValues", Columns: []string{"flag"}, Expression: &Comparison{Operator: "=", Value: true}},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+}
+
+func TestLowerRule__Error(t *testing.T) {
+	rule, err := parser.ParseRule(`Mean "price" > 1` + strings.Repeat("0", 400))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = LowerRule(rule)
+	if err == nil || !strings.HasPrefix(err.Error(), "spec: 1:16: ast: number 1000") {
+		t.Errorf("got %v, want an out of range error", err)
+	}
+}
@@ -0,0 +1,125 @@
+package sqlgen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect は SQL の方言の違いを吸収します。
+// A Dialect renders the parts of a query that differ between databases.
+type Dialect interface {
+	// QuoteIdent quotes a column name.
+	QuoteIdent(name string) string
+	// QuoteString returns a string literal.
+	QuoteString(s string) string
+	// DoubleType returns the name of the double precision type.
+	DoubleType() string
+	// CountIf returns an aggregate counting the rows satisfying cond.
+	CountIf(cond string) string
+	// RegexpMatch returns a condition true if the whole of expr matches
+	// the regular expression pattern.
+	RegexpMatch(expr, pattern string) string
+	// Length returns the number of characters of expr.
+	Length(expr string) string
+	// TimeAgo returns the current timestamp minus n units, where unit is
+	// "hours" or "days".
+	TimeAgo(n int, unit string) string
+}
+
+var (
+	// Athena は Amazon Athena (Presto/Trino) の方言です。
+	// Athena is the dialect of Amazon Athena, Presto and Trino.
+	Athena Dialect = athena{}
+	// Redshift は Amazon Redshift の方言です。
+	// Redshift is the dialect of Amazon Redshift.
+	Redshift Dialect = redshift{}
+	// PostgreSQL は PostgreSQL の方言です。
+	// PostgreSQL is the dialect of PostgreSQL.
+	PostgreSQL Dialect = postgreSQL{}
+	// DuckDB は DuckDB の方言です。
+	// DuckDB is the dialect of DuckDB.
+	DuckDB Dialect = duckDB{}
+)
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+type athena struct{}
+
+func (athena) QuoteIdent(name string) string { return quoteIdent(name) }
+func (athena) QuoteString(s string) string   { return quoteString(s) }
+func (athena) DoubleType() string            { return "DOUBLE" }
+func (athena) CountIf(cond string) string    { return fmt.Sprintf("COUNT_IF(%s)", cond) }
+func (athena) RegexpMatch(expr, pattern string) string {
+	return fmt.Sprintf("REGEXP_LIKE(%s, %s)", expr, quoteString("^(?:"+pattern+")$"))
+}
+func (athena) Length(expr string) string { return fmt.Sprintf("LENGTH(%s)", expr) }
+func (athena) TimeAgo(n int, unit string) string {
+	if n == 0 {
+		return "CURRENT_TIMESTAMP"
+	}
+	return fmt.Sprintf("CURRENT_TIMESTAMP - INTERVAL '%d' %s", n, strings.TrimSuffix(strings.ToUpper(unit), "S"))
+}
+
+type redshift struct{}
+
+func (redshift) QuoteIdent(name string) string { return quoteIdent(name) }
+func (redshift) QuoteString(s string) string   { return quoteString(s) }
+func (redshift) DoubleType() string            { return "DOUBLE PRECISION" }
+func (redshift) CountIf(cond string) string {
+	return fmt.Sprintf("SUM(CASE WHEN %s THEN 1 ELSE 0 END)", cond)
+}
+func (redshift) RegexpMatch(expr, pattern string) string {
+	// POSIX regular expressions have no non-capturing groups.
+	return fmt.Sprintf("%s ~ %s", expr, quoteString("^("+pattern+")$"))
+}
+func (redshift) Length(expr string) string { return fmt.Sprintf("LEN(%s)", expr) }
+func (redshift) TimeAgo(n int, unit string) string {
+	if n == 0 {
+		return "GETDATE()"
+	}
+	return fmt.Sprintf("GETDATE() - INTERVAL '%d %s'", n, unit)
+}
+
+type postgreSQL struct{}
+
+func (postgreSQL) QuoteIdent(name string) string { return quoteIdent(name) }
+func (postgreSQL) QuoteString(s string) string   { return quoteString(s) }
+func (postgreSQL) DoubleType() string            { return "DOUBLE PRECISION" }
+func (postgreSQL) CountIf(cond string) string {
+	return fmt.Sprintf("COUNT(*) FILTER (WHERE %s)", cond)
+}
+func (postgreSQL) RegexpMatch(expr, pattern string) string {
+	return fmt.Sprintf("%s ~ %s", expr, quoteString("^(?:"+pattern+")$"))
+}
+func (postgreSQL) Length(expr string) string { return fmt.Sprintf("LENGTH(%s)", expr) }
+func (postgreSQL) TimeAgo(n int, unit string) string {
+	if n == 0 {
+		return "NOW()"
+	}
+	return fmt.Sprintf("NOW() - INTERVAL '%d %s'", n, unit)
+}
+
+type duckDB struct{}
+
+func (duckDB) QuoteIdent(name string) string { return quoteIdent(name) }
+func (duckDB) QuoteString(s string) string   { return quoteString(s) }
+func (duckDB) DoubleType() string            { return "DOUBLE" }
+func (duckDB) CountIf(cond string) string {
+	return fmt.Sprintf("COUNT(*) FILTER (WHERE %s)", cond)
+}
+func (duckDB) RegexpMatch(expr, pattern string) string {
+	return fmt.Sprintf("REGEXP_FULL_MATCH(%s, %s)", expr, quoteString(pattern))
+}
+func (duckDB) Length(expr string) string { return fmt.Sprintf("LENGTH(%s)", expr) }
+func (duckDB) TimeAgo(n int, unit string) string {
+	if n == 0 {
+		return "NOW()"
+	}
+	return fmt.Sprintf("NOW() - INTERVAL %d %s", n, strings.TrimSuffix(strings.ToUpper(unit), "S"))
+}
@@ -0,0 +1,292 @@
+// Package sqlgen はルールを SQL のクエリに変換します。
+// Package sqlgen translates rules into SQL queries, so that rulesets can
+// be checked directly in a data warehouse.
+//
+// The query of a rule returns a single row with two columns: metric, the
+// observed value of the rule as a double (NULL for combined rules), and
+// passed, the verdict as a boolean. The metrics are computed like the
+// ones of the eval package, so both agree on the same data.
+//
+// Supported rule types are RowCount, IsComplete, Completeness, IsUnique,
+// Uniqueness, UniqueValueRatio, DistinctValuesCount, IsPrimaryKey, Mean,
+// Sum, StandardDeviation, ColumnLength and ColumnValues, and combinations
+// of them.
+package sqlgen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mashiike/go-dqdl/ast"
+)
+
+// Query はルールと、それを検査する SQL のクエリの組です。
+// A Query is a rule and the SQL query checking it.
+type Query struct {
+	Rule ast.RuleDecl
+	SQL  string
+}
+
+// UnsupportedError は SQL に変換できなかったルールを表すエラーです。
+// An UnsupportedError lists the rules that could not be translated.
+type UnsupportedError struct {
+	Rules  []ast.RuleDecl
+	Errors []error // the reason for each rule
+}
+
+func (e *UnsupportedError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Ruleset はルールセットの各ルールを検査するクエリを返します。
+// Ruleset returns the queries checking the rules of ruleset against table,
+// which is inserted into the queries as it is and must be quoted by the
+// caller if needed. The rules that can not be translated are left out and
+// reported by an *UnsupportedError along with the queries of the others.
+func Ruleset(ruleset *ast.Ruleset, table string, d Dialect) ([]Query, error) {
+	queries := make([]Query, 0, len(ruleset.Rules))
+	var unsupported *UnsupportedError
+	for _, rule := range ruleset.Rules {
+		sql, err := Rule(rule, table, d)
+		if err != nil {
+			if unsupported == nil {
+				unsupported = &UnsupportedError{}
+			}
+			unsupported.Rules = append(unsupported.Rules, rule)
+			unsupported.Errors = append(unsupported.Errors, err)
+			continue
+		}
+		queries = append(queries, Query{Rule: rule, SQL: sql})
+	}
+	if unsupported != nil {
+		return queries, unsupported
+	}
+	return queries, nil
+}
+
+// Rule はルールを検査するクエリを返します。
+// Rule returns the query checking rule against table.
+func Rule(rule ast.RuleDecl, table string, d Dialect) (string, error) {
+	g := &generator{dialect: d, table: table}
+	switch r := rule.(type) {
+	case *ast.Rule:
+		return g.rule(r)
+	case *ast.CombinedRule:
+		return g.combined(r)
+	}
+	return "", fmt.Errorf("sqlgen: unexpected rule %T", rule)
+}
+
+type generator struct {
+	dialect Dialect
+	table   string
+}
+
+func (g *generator) errorf(rule *ast.Rule, format string, args ...interface{}) error {
+	return fmt.Errorf("sqlgen: %s: %s", rule.Type.Name, fmt.Sprintf(format, args...))
+}
+
+func (g *generator) combined(r *ast.CombinedRule) (string, error) {
+	var from, verdicts []string
+	for i, nested := range r.Rules {
+		sql, err := g.rule(nested)
+		if err != nil {
+			return "", err
+		}
+		alias := fmt.Sprintf("r%d", i+1)
+		from = append(from, fmt.Sprintf("(%s) AS %s", sql, alias))
+		verdicts = append(verdicts, alias+".passed")
+	}
+	return fmt.Sprintf("SELECT CAST(NULL AS %s) AS metric, (%s) AS passed FROM %s",
+		g.dialect.DoubleType(),
+		strings.Join(verdicts, " "+strings.ToUpper(r.Operator)+" "),
+		strings.Join(from, " CROSS JOIN "),
+	), nil
+}
+
+func (g *generator) rule(r *ast.Rule) (string, error) {
+	metric, err := g.metric(r)
+	if err != nil {
+		return "", err
+	}
+	verdict, err := g.verdict(r)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("SELECT metric, %s AS passed FROM (%s) AS m", verdict, metric), nil
+}
+
+// metric returns a query computing the metric of r in a column named
+// metric.
+func (g *generator) metric(r *ast.Rule) (string, error) {
+	d := g.dialect
+	double := func(expr string) string {
+		return fmt.Sprintf("CAST(%s AS %s)", expr, d.DoubleType())
+	}
+	ratio := func(numerator, denominator string) string {
+		return fmt.Sprintf("COALESCE(%s / NULLIF(%s, 0), 1)", double(numerator), denominator)
+	}
+	selectFrom := func(expr string) string {
+		return fmt.Sprintf("SELECT %s AS metric FROM %s", expr, g.table)
+	}
+	switch r.Type.Name {
+	case "RowCount":
+		return selectFrom(double("COUNT(*)")), nil
+	case "IsComplete", "Completeness", "IsUnique", "Uniqueness", "UniqueValueRatio", "DistinctValuesCount",
+		"IsPrimaryKey", "Mean", "Sum", "StandardDeviation", "ColumnLength", "ColumnValues":
+	default:
+		return "", g.errorf(r, "rule type is not supported")
+	}
+	column, err := g.column(r, 0)
+	if err != nil {
+		return "", err
+	}
+	// groups counts the rows of each non-null value of the column.
+	groups := fmt.Sprintf("(SELECT COUNT(*) AS n FROM %s WHERE %s IS NOT NULL GROUP BY %s) AS g", g.table, column, column)
+	switch r.Type.Name {
+	case "IsComplete", "Completeness":
+		return selectFrom(ratio(fmt.Sprintf("COUNT(%s)", column), "COUNT(*)")), nil
+	case "IsUnique", "Uniqueness":
+		total := fmt.Sprintf("(SELECT COUNT(*) FROM %s)", g.table)
+		return fmt.Sprintf("SELECT %s AS metric FROM %s", ratio(d.CountIf("n = 1"), total), groups), nil
+	case "UniqueValueRatio":
+		return fmt.Sprintf("SELECT %s AS metric FROM %s", ratio(d.CountIf("n = 1"), "COUNT(*)"), groups), nil
+	case "DistinctValuesCount":
+		return selectFrom(double(fmt.Sprintf("COUNT(DISTINCT %s)", column))), nil
+	case "IsPrimaryKey":
+		columns := []string{column}
+		for i := 1; i < len(r.Parameters); i++ {
+			c, err := g.column(r, i)
+			if err != nil {
+				return "", err
+			}
+			columns = append(columns, c)
+		}
+		conds := make([]string, 0, len(columns))
+		for _, c := range columns {
+			conds = append(conds, c+" IS NOT NULL")
+		}
+		keys := fmt.Sprintf("(SELECT COUNT(*) AS n FROM %s WHERE %s GROUP BY %s) AS g",
+			g.table, strings.Join(conds, " AND "), strings.Join(columns, ", "))
+		total := fmt.Sprintf("(SELECT COUNT(*) FROM %s)", g.table)
+		return fmt.Sprintf("SELECT %s AS metric FROM %s", ratio(d.CountIf("n = 1"), total), keys), nil
+	case "Mean":
+		return selectFrom(fmt.Sprintf("AVG(%s)", double(column))), nil
+	case "Sum":
+		return selectFrom(fmt.Sprintf("SUM(%s)", double(column))), nil
+	case "StandardDeviation":
+		return selectFrom(fmt.Sprintf("STDDEV_SAMP(%s)", double(column))), nil
+	case "ColumnLength":
+		if r.Expression == nil {
+			return "", g.errorf(r, "requires an expression")
+		}
+		cond, err := g.condition(r, r.Expression, d.Length(column))
+		if err != nil {
+			return "", err
+		}
+		return selectFrom(ratio(d.CountIf(cond), fmt.Sprintf("COUNT(%s)", column))), nil
+	case "ColumnValues":
+		expr := r.Expression
+		if x, ok := expr.(*ast.WithThresholdExpression); ok {
+			expr = x.Target
+		}
+		if expr == nil {
+			return "", g.errorf(r, "requires an expression")
+		}
+		cond, err := g.condition(r, expr, column)
+		if err != nil {
+			return "", err
+		}
+		return selectFrom(ratio(d.CountIf(cond), "COUNT(*)")), nil
+	}
+	panic("unreachable")
+}
+
+// verdict returns the condition on the metric deciding whether r passed.
+func (g *generator) verdict(r *ast.Rule) (string, error) {
+	switch r.Type.Name {
+	case "IsComplete", "IsUnique", "IsPrimaryKey", "ColumnLength":
+		return "(metric = 1)", nil
+	case "ColumnValues":
+		if x, ok := r.Expression.(*ast.WithThresholdExpression); ok {
+			return g.condition(r, x.Threshold, "metric")
+		}
+		return "(metric = 1)", nil
+	}
+	if r.Expression == nil {
+		return "", g.errorf(r, "requires an expression")
+	}
+	return g.condition(r, r.Expression, "metric")
+}
+
+func (g *generator) column(r *ast.Rule, i int) (string, error) {
+	if i >= len(r.Parameters) {
+		return "", g.errorf(r, "requires a column")
+	}
+	param, ok := r.Parameters[i].(*ast.StringParameter)
+	if !ok {
+		return "", g.errorf(r, "requires a column name")
+	}
+	return g.dialect.QuoteIdent(param.Value), nil
+}
+
+// condition returns a condition true if operand satisfies expr.
+func (g *generator) condition(r *ast.Rule, expr ast.Expression, operand string) (string, error) {
+	switch x := expr.(type) {
+	case *ast.ComparisonExpression:
+		v, err := g.literal(r, x.Right)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(%s %s %s)", operand, x.Operator, v), nil
+	case *ast.BetweenExpression:
+		lo, err := g.literal(r, x.Left)
+		if err != nil {
+			return "", err
+		}
+		hi, err := g.literal(r, x.Right)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(%s BETWEEN %s AND %s)", operand, lo, hi), nil
+	case *ast.InExpression:
+		values := make([]string, 0, len(x.Values))
+		for _, param := range x.Values {
+			v, err := g.literal(r, param)
+			if err != nil {
+				return "", err
+			}
+			values = append(values, v)
+		}
+		return fmt.Sprintf("(%s IN (%s))", operand, strings.Join(values, ", ")), nil
+	case *ast.MatchesExpression:
+		return "(" + g.dialect.RegexpMatch(operand, x.Value) + ")", nil
+	}
+	return "", g.errorf(r, "unsupported expression %T", expr)
+}
+
+func (g *generator) literal(r *ast.Rule, param ast.Parameter) (string, error) {
+	switch p := param.(type) {
+	case *ast.NumberParameter:
+		return p.Value, nil
+	case *ast.StringParameter:
+		return g.dialect.QuoteString(p.Value), nil
+	case *ast.BoolParameter:
+		return strings.ToUpper(strconv.FormatBool(p.Value)), nil
+	case *ast.DateParameter:
+		if p.Duration == nil {
+			return g.dialect.TimeAgo(0, ""), nil
+		}
+		n, err := strconv.Atoi(p.Duration.Number)
+		if err != nil {
+			return "", g.errorf(r, "invalid duration %s", p.Duration.Value)
+		}
+		return "(" + g.dialect.TimeAgo(n, strings.ToLower(p.Duration.Unit)) + ")", nil
+	}
+	return "", g.errorf(r, "unsupported parameter %T", param)
+}
@@ -0,0 +1,112 @@
+package sqlgen
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/parser"
+)
+
+func TestRule(t *testing.T) {
+	cases := []struct {
+		name    string
+		rule    string
+		dialect Dialect
+		want    string
+	}{
+		{
+			name:    "row count",
+			rule:    `RowCount between 10 and 100`,
+			dialect: Athena,
+			want:    `SELECT metric, (metric BETWEEN 10 AND 100) AS passed FROM (SELECT CAST(COUNT(*) AS DOUBLE) AS metric FROM orders) AS m`,
+		},
+		{
+			name:    "is complete",
+			rule:    `IsComplete "id"`,
+			dialect: PostgreSQL,
+			want:    `SELECT metric, (metric = 1) AS passed FROM (SELECT COALESCE(CAST(COUNT("id") AS DOUBLE PRECISION) / NULLIF(COUNT(*), 0), 1) AS metric FROM orders) AS m`,
+		},
+		{
+			name:    "uniqueness",
+			rule:    `Uniqueness "id" > 0.9`,
+			dialect: Redshift,
+			want:    `SELECT metric, (metric > 0.9) AS passed FROM (SELECT COALESCE(CAST(SUM(CASE WHEN n = 1 THEN 1 ELSE 0 END) AS DOUBLE PRECISION) / NULLIF((SELECT COUNT(*) FROM orders), 0), 1) AS metric FROM (SELECT COUNT(*) AS n FROM orders WHERE "id" IS NOT NULL GROUP BY "id") AS g) AS m`,
+		},
+		{
+			name:    "column values in with threshold",
+			rule:    `ColumnValues "status" in ["a", "it's"] with threshold >= 0.9`,
+			dialect: Athena,
+			want:    `SELECT metric, (metric >= 0.9) AS passed FROM (SELECT COALESCE(CAST(COUNT_IF(("status" IN ('a', 'it''s'))) AS DOUBLE) / NULLIF(COUNT(*), 0), 1) AS metric FROM orders) AS m`,
+		},
+		{
+			name:    "column values matches",
+			rule:    `ColumnValues "code" matches "[A-Z]+"`,
+			dialect: PostgreSQL,
+			want:    `SELECT metric, (metric = 1) AS passed FROM (SELECT COALESCE(CAST(COUNT(*) FILTER (WHERE ("code" ~ '^(?:[A-Z]+)$')) AS DOUBLE PRECISION) / NULLIF(COUNT(*), 0), 1) AS metric FROM orders) AS m`,
+		},
+		{
+			name:    "column values date",
+			rule:    `ColumnValues "updated_at" > (now() - 3 days)`,
+			dialect: Athena,
+			want:    `SELECT metric, (metric = 1) AS passed FROM (SELECT COALESCE(CAST(COUNT_IF(("updated_at" > (CURRENT_TIMESTAMP - INTERVAL '3' DAY))) AS DOUBLE) / NULLIF(COUNT(*), 0), 1) AS metric FROM orders) AS m`,
+		},
+		{
+			name:    "column length",
+			rule:    `ColumnLength "name" <= 16`,
+			dialect: Redshift,
+			want:    `SELECT metric, (metric = 1) AS passed FROM (SELECT COALESCE(CAST(SUM(CASE WHEN (LEN("name") <= 16) THEN 1 ELSE 0 END) AS DOUBLE PRECISION) / NULLIF(COUNT("name"), 0), 1) AS metric FROM orders) AS m`,
+		},
+		{
+			name:    "duckdb matches",
+			rule:    `ColumnValues "code" matches "[A-Z]+" with threshold > 0.9`,
+			dialect: DuckDB,
+			want:    `SELECT metric, (metric > 0.9) AS passed FROM (SELECT COALESCE(CAST(COUNT(*) FILTER (WHERE (REGEXP_FULL_MATCH("code", '[A-Z]+'))) AS DOUBLE) / NULLIF(COUNT(*), 0), 1) AS metric FROM orders) AS m`,
+		},
+		{
+			name:    "duckdb date",
+			rule:    `ColumnValues "updated_at" > (now() - 2 hours)`,
+			dialect: DuckDB,
+			want:    `SELECT metric, (metric = 1) AS passed FROM (SELECT COALESCE(CAST(COUNT(*) FILTER (WHERE ("updated_at" > (NOW() - INTERVAL 2 HOUR))) AS DOUBLE) / NULLIF(COUNT(*), 0), 1) AS metric FROM orders) AS m`,
+		},
+		{
+			name:    "combined",
+			rule:    `(Mean "price" > 0) or (Sum "price" = 0)`,
+			dialect: PostgreSQL,
+			want:    `SELECT CAST(NULL AS DOUBLE PRECISION) AS metric, (r1.passed OR r2.passed) AS passed FROM (SELECT metric, (metric > 0) AS passed FROM (SELECT AVG(CAST("price" AS DOUBLE PRECISION)) AS metric FROM orders) AS m) AS r1 CROSS JOIN (SELECT metric, (metric = 0) AS passed FROM (SELECT SUM(CAST("price" AS DOUBLE PRECISION)) AS metric FROM orders) AS m) AS r2`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rule, err := parser.ParseRule(c.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Rule(rule, "orders", c.dialect)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRuleset__Unsupported(t *testing.T) {
+	ruleset, err := parser.ParseRuleset(`Rules = [ RowCount > 0, ColumnCount > 1, IsUnique "id" ]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queries, err := Ruleset(ruleset, "orders", Athena)
+	if len(queries) != 2 {
+		t.Errorf("got %d queries, want 2", len(queries))
+	}
+	var unsupported *UnsupportedError
+	if !errors.As(err, &unsupported) || len(unsupported.Rules) != 1 {
+		t.Fatalf("got %v, want an UnsupportedError for one rule", err)
+	}
+	if got, want := err.Error(), "sqlgen: ColumnCount: rule type is not supported"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
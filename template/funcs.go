@@ -0,0 +1,132 @@
+package template
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// dateLayout is the layout of the dates of DQDL, e.g. "2024-01-31".
+const dateLayout = "2006-01-02"
+
+// Funcs は Execute で使える関数を返します。
+// Funcs returns the functions Execute provides to templates, for use with
+// another text/template:
+//
+//	upper s        s in upper case
+//	lower s        s in lower case
+//	quote s        s as a DQDL string, e.g. "id"
+//	list values    a DQDL list of strings or numbers, e.g. ["a", "b"] or [1, 2]
+//	date d         a date as a DQDL string, e.g. "2024-01-31"
+//	addDays d n    the date n days after d, n may be negative
+//
+// A date is a time.Time or a string in the layout 2006-01-02. The
+// functions return an error for a value DQDL can not represent, such as a
+// string with a double quote, rather than produce a different ruleset.
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		"upper":   strings.ToUpper,
+		"lower":   strings.ToLower,
+		"quote":   quote,
+		"list":    list,
+		"date":    date,
+		"addDays": addDays,
+	}
+}
+
+func quote(s string) (string, error) {
+	if strings.ContainsRune(s, '"') {
+		return "", fmt.Errorf("string can not contain a double quote: %s", s)
+	}
+	return `"` + s + `"`, nil
+}
+
+func list(values interface{}) (string, error) {
+	v := reflect.ValueOf(values)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("list of %T, want a slice", values)
+	}
+	if v.Len() == 0 {
+		return "", fmt.Errorf("list is empty")
+	}
+	elems := make([]string, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		elem, err := literal(v.Index(i).Interface())
+		if err != nil {
+			return "", err
+		}
+		elems = append(elems, elem)
+	}
+	return "[" + strings.Join(elems, ", ") + "]", nil
+}
+
+// literal returns v as a DQDL string or number.
+func literal(v interface{}) (string, error) {
+	switch x := reflect.ValueOf(v); x.Kind() {
+	case reflect.String:
+		return quote(x.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(x.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(x.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(x.Float(), 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("list element of %T, want a string or a number", v)
+}
+
+func date(d interface{}) (string, error) {
+	t, err := toDate(d)
+	if err != nil {
+		return "", err
+	}
+	return `"` + t.Format(dateLayout) + `"`, nil
+}
+
+func addDays(d interface{}, n int) (time.Time, error) {
+	t, err := toDate(d)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.AddDate(0, 0, n), nil
+}
+
+func toDate(d interface{}) (time.Time, error) {
+	switch x := d.(type) {
+	case time.Time:
+		return x, nil
+	case string:
+		t, err := time.Parse(dateLayout, x)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q, want YYYY-MM-DD", x)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("date of %T, want a time.Time or a string", d)
+}
+
+// templateError matches the location text/template gives to an error,
+// "template: NAME:LINE:COLUMN: ", and the context of an execution error,
+// `executing "NAME" at <ACTION>: `.
+var templateError = regexp.MustCompile(`^template: [^:]*:(\d+)(?::(\d+))?: (?:executing "[^"]*" at <.*?>: )?(?:error calling \w+: )?`)
+
+// mapError returns err of text/template with its location in src as in
+// Expand, LINE:COLUMN, or LINE if the column is not known. text/template
+// gives the column as an offset in bytes from the start of the line.
+func mapError(err error) error {
+	m := templateError.FindStringSubmatchIndex(err.Error())
+	if m == nil {
+		return fmt.Errorf("template: %w", err)
+	}
+	msg := err.Error()
+	loc := msg[m[2]:m[3]]
+	if m[4] >= 0 {
+		column, _ := strconv.Atoi(msg[m[4]:m[5]])
+		loc += ":" + strconv.Itoa(column+1)
+	}
+	return fmt.Errorf("template: %s: %s", loc, msg[m[1]:])
+}
@@ -0,0 +1,174 @@
+// Package template はパラメータ化された DQDL をテーブルごとに展開します。
+// Package template instantiates parametrized DQDL, so that one ruleset can
+// be used for many tables.
+//
+// Expand and Execute work on the source text before parsing: Expand
+// replaces ${name} placeholders and Execute runs the source as a Go
+// template. Substitute works on a parsed tree and replaces placeholders in
+// string parameters only, so the values can never change the structure of
+// the rules.
+package template
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/mashiike/go-dqdl/ast"
+)
+
+// placeholder matches ${name} and the escape $$.
+var placeholder = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Expand はソース中の ${name} を vars の値で置き換えます。
+// Expand replaces every ${name} placeholder in src with vars[name], and
+// $$ with $. Values are inserted as they are, so a value used inside a
+// string literal must not contain a double quote. It returns an error
+// naming the position of the first placeholder without a value.
+func Expand(src string, vars map[string]string) (string, error) {
+	var err error
+	expanded := replace(src, vars, func(offset int, name string) {
+		if err == nil {
+			line := strings.Count(src[:offset], "\n") + 1
+			column := offset - strings.LastIndex(src[:offset], "\n")
+			err = fmt.Errorf("template: %d:%d: undefined variable %q", line, column, name)
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	return expanded, nil
+}
+
+func replace(s string, vars map[string]string, undefined func(offset int, name string)) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range placeholder.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(s[last:m[0]])
+		last = m[1]
+		if m[2] < 0 {
+			b.WriteByte('$')
+			continue
+		}
+		name := s[m[2]:m[3]]
+		v, ok := vars[name]
+		if !ok {
+			undefined(m[0], name)
+		}
+		b.WriteString(v)
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// Execute はソースを Go のテンプレートとして実行します。
+// Execute runs src as a text/template with data, e.g.
+//
+//	Rules = [ {{range .Keys}}IsUnique "{{.}}", {{end}}RowCount > 0 ]
+//
+// Referring to a missing map key is an error. The functions of Funcs
+// quote values for DQDL, e.g.
+//
+//	Rules = [ ColumnValues {{quote .Column}} in {{list .Values}} ]
+//
+// Errors are located in src as in Expand.
+func Execute(src string, data interface{}) (string, error) {
+	tmpl, err := template.New("dqdl").Option("missingkey=error").Funcs(Funcs()).Parse(src)
+	if err != nil {
+		return "", mapError(err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", mapError(err)
+	}
+	return b.String(), nil
+}
+
+// Substitute は構文木の文字列パラメータ中の ${name} を vars の値で置き換えます。
+// Substitute replaces the ${name} placeholders in the string parameters of
+// node, which is an *ast.File or an ast.Node, with vars[name] in place.
+// Strings of matches expressions are left as they are, since $ is a
+// regular expression anchor. It returns an error naming the first
+// placeholder without a value or the first value containing a double
+// quote, which DQDL strings can not contain; the other parameters are
+// replaced regardless.
+//
+// Positions are not updated. Use ast.Clone to keep the original tree and
+// printer.Relayout for positions matching the printed form.
+func Substitute(node interface{}, vars map[string]string) error {
+	var err error
+	undefined := func(param *ast.StringParameter) func(int, string) {
+		return func(_ int, name string) {
+			if err == nil {
+				err = fmt.Errorf("template: %s: undefined variable %q", param.Pos(), name)
+			}
+		}
+	}
+	substitute := func(params []ast.Parameter) {
+		for _, param := range params {
+			s, ok := param.(*ast.StringParameter)
+			if !ok {
+				continue
+			}
+			v := replace(s.Value, vars, undefined(s))
+			if strings.ContainsRune(v, '"') {
+				if err == nil {
+					err = fmt.Errorf("template: %s: string can not contain a double quote: %s", s.Pos(), v)
+				}
+				continue
+			}
+			s.Value = v
+		}
+	}
+	var expression func(expr ast.Expression)
+	expression = func(expr ast.Expression) {
+		switch x := expr.(type) {
+		case *ast.ComparisonExpression:
+			substitute([]ast.Parameter{x.Right})
+		case *ast.BetweenExpression:
+			substitute([]ast.Parameter{x.Left, x.Right})
+		case *ast.InExpression:
+			substitute(x.Values)
+		case *ast.WithThresholdExpression:
+			expression(x.Target)
+			expression(x.Threshold)
+		}
+	}
+	rule := func(r *ast.Rule) {
+		substitute(r.Parameters)
+		expression(r.Expression)
+	}
+	ruleDecl := func(decl ast.RuleDecl) {
+		switch r := decl.(type) {
+		case *ast.Rule:
+			rule(r)
+		case *ast.CombinedRule:
+			for _, nested := range r.Rules {
+				rule(nested)
+			}
+		}
+	}
+	ruleset := func(rs *ast.Ruleset) {
+		for _, decl := range rs.Rules {
+			ruleDecl(decl)
+		}
+	}
+	switch n := node.(type) {
+	case *ast.File:
+		for _, rs := range n.Rulesets {
+			ruleset(rs)
+		}
+	case *ast.Ruleset:
+		ruleset(n)
+	case ast.RuleDecl:
+		ruleDecl(n)
+	case ast.Parameter:
+		substitute([]ast.Parameter{n})
+	case ast.Expression:
+		expression(n)
+	default:
+		return fmt.Errorf("template: unsupported node type %T", node)
+	}
+	return err
+}
@@ -0,0 +1,192 @@
+package template
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/parser"
+	"github.com/mashiike/go-dqdl/printer"
+)
+
+func TestExpand(t *testing.T) {
+	vars := map[string]string{"column": "user_id", "max": "100"}
+	cases := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "placeholders",
+			input: `Rules = [ IsComplete "${column}", ColumnLength "${column}" <= ${max} ]`,
+			want:  `Rules = [ IsComplete "user_id", ColumnLength "user_id" <= 100 ]`,
+		},
+		{
+			name:  "escape",
+			input: `Rules = [ ColumnValues "${column}" matches "^a$$" ]`,
+			want:  `Rules = [ ColumnValues "user_id" matches "^a$" ]`,
+		},
+		{
+			name:    "undefined",
+			input:   "Rules = [\n  IsUnique \"${key}\"\n]",
+			wantErr: `template: 2:13: undefined variable "key"`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := Expand(c.input, vars)
+			if c.wantErr != "" {
+				if err == nil || err.Error() != c.wantErr {
+					t.Fatalf("got error %v, want %s", err, c.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExecute(t *testing.T) {
+	const src = `Rules = [ {{range .Keys}}IsUnique "{{.}}", {{end}}RowCount > {{.MinRows}} ]`
+	got, err := Execute(src, map[string]interface{}{"Keys": []string{"a", "b"}, "MinRows": 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `Rules = [ IsUnique "a", IsUnique "b", RowCount > 10 ]`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+	if _, err := Execute(src, map[string]interface{}{"Keys": []string{"a"}}); err == nil {
+		t.Error("expected an error for a missing key")
+	}
+}
+
+func TestExecute__Funcs(t *testing.T) {
+	data := map[string]interface{}{
+		"Column": "Status",
+		"Values": []string{"a", "b"},
+		"Sizes":  []interface{}{1, 2.5, uint8(3)},
+		"Day":    time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		"Quoted": `a"b`,
+		"Mixed":  []string{"a", `b"`},
+		"Empty":  []string{},
+	}
+	cases := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "strings",
+			input: `IsComplete {{quote (lower .Column)}}, IsComplete {{quote (upper .Column)}}`,
+			want:  `IsComplete "status", IsComplete "STATUS"`,
+		},
+		{
+			name:  "lists",
+			input: `ColumnValues "s" in {{list .Values}}, ColumnValues "n" in {{list .Sizes}}`,
+			want:  `ColumnValues "s" in ["a", "b"], ColumnValues "n" in [1, 2.5, 3]`,
+		},
+		{
+			name:  "dates",
+			input: `ColumnValues "d" between {{date (addDays .Day -7)}} and {{date "2024-03-01"}}`,
+			want:  `ColumnValues "d" between "2024-02-23" and "2024-03-01"`,
+		},
+		{
+			name:    "double quote",
+			input:   "Rules = [\n\tIsComplete {{quote .Quoted}}\n]",
+			wantErr: `template: 2:15: string can not contain a double quote: a"b`,
+		},
+		{
+			name:    "double quote in a list",
+			input:   `ColumnValues "s" in {{list .Mixed}}`,
+			wantErr: `template: 1:23: string can not contain a double quote: b"`,
+		},
+		{
+			name:    "not a list",
+			input:   `ColumnValues "s" in {{list .Column}}`,
+			wantErr: `template: 1:23: list of string, want a slice`,
+		},
+		{
+			name:    "empty list",
+			input:   `ColumnValues "s" in {{list .Empty}}`,
+			wantErr: `template: 1:23: list is empty`,
+		},
+		{
+			name:    "invalid date",
+			input:   `ColumnValues "d" > {{date "March 1"}}`,
+			wantErr: `template: 1:22: invalid date "March 1", want YYYY-MM-DD`,
+		},
+		{
+			name:    "missing key",
+			input:   "Rules = [\n\tIsComplete {{quote .Missing}}\n]",
+			wantErr: `template: 2:21: map has no entry for key "Missing"`,
+		},
+		{
+			name:    "undefined function",
+			input:   "Rules = [\n\tIsComplete {{squote .Column}}\n]",
+			wantErr: `template: 2: function "squote" not defined`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := Execute(c.input, data)
+			if c.wantErr != "" {
+				if err == nil || err.Error() != c.wantErr {
+					t.Fatalf("got error %v, want %s", err, c.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	const src = `Rules = [
+	IsComplete "${column}",
+	(ColumnValues "${column}" in ["${a}", "b"]) or (ColumnValues "${column}" matches "x$"),
+	ColumnValues "${column}" = "${unknown}"
+]
+`
+	file, err := parser.ParseFile("test.dqdl", bytes.NewReader([]byte(src)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Substitute(file, map[string]string{"column": "user_id", "a": "A"})
+	if err == nil || err.Error() != `template: 4:29: undefined variable "unknown"` {
+		t.Errorf("got error %v", err)
+	}
+	rule, err := parser.ParseRule(`IsComplete "${column}"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Substitute(rule, map[string]string{"column": `a"b`}); err == nil {
+		t.Error("expected an error for a value with a double quote")
+	}
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, file); err != nil {
+		t.Fatal(err)
+	}
+	want := `Rules = [
+	IsComplete "user_id",
+	(ColumnValues "user_id" in ["A", "b"]) or (ColumnValues "user_id" matches "x$"),
+	ColumnValues "user_id" = ""
+]
+`
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+}
@@ -0,0 +1,218 @@
+package token
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+// FilePos は FileSet 内の位置をコンパクトに表現したものです。
+// FilePos is a compact encoding of a position within a FileSet.
+// It can be resolved to a Position with FileSet.Position.
+// The zero value NoFilePos is not a valid position.
+type FilePos int
+
+// NoFilePos は無効な FilePos を表します。
+// NoFilePos is the zero value for FilePos; it is not associated with any file.
+const NoFilePos FilePos = 0
+
+// IsValid reports whether the position is valid.
+func (p FilePos) IsValid() bool {
+	return p != NoFilePos
+}
+
+// Position はファイル名を含む解決済みの位置を表します。
+// Position is a resolved position including the name of the file.
+type Position struct {
+	Filename string
+	Pos
+}
+
+// String returns a string in the form "filename:line:column",
+// or "line:column" if the position has no filename.
+func (p Position) String() string {
+	if p.Filename == "" {
+		return p.Pos.String()
+	}
+	if !p.IsValid() {
+		return p.Filename
+	}
+	return fmt.Sprintf("%s:%s", p.Filename, p.Pos)
+}
+
+// File は FileSet に登録されたファイルです。
+// A File is a file registered in a FileSet. Like a file of go/token, it
+// does not keep the source but the offsets of the lines, and those of the
+// multibyte characters to count the columns in characters.
+type File struct {
+	name  string
+	base  int
+	size  int
+	lines []int      // byte offset of the first character of each line
+	wide  []wideChar // multibyte characters, in source order
+}
+
+// wideChar is a character encoded in more than one byte.
+type wideChar struct {
+	offset int // byte offset of the character
+	extra  int // bytes beyond the first of the characters up to and including it
+}
+
+// Name returns the file name of f.
+func (f *File) Name() string {
+	return f.name
+}
+
+// Base returns the base offset of f in its FileSet.
+func (f *File) Base() int {
+	return f.base
+}
+
+// Size returns the size of the source of f in bytes.
+func (f *File) Size() int {
+	return f.size
+}
+
+// LineCount returns the number of lines in f.
+func (f *File) LineCount() int {
+	return len(f.lines)
+}
+
+// FilePos は f 内の pos を FilePos に変換します。
+// FilePos returns the compact position for pos within f.
+func (f *File) FilePos(pos Pos) FilePos {
+	if pos.Index < 0 || pos.Index > f.size {
+		panic(fmt.Sprintf("invalid index %d (should be in [0, %d])", pos.Index, f.size))
+	}
+	return FilePos(f.base + pos.Index)
+}
+
+// Pos はバイトオフセットを行と列を含む Pos に変換します。
+// Pos returns the Pos of the byte offset within f.
+// Columns are counted in characters, starting at 1, like the lexer does.
+func (f *File) Pos(offset int) Pos {
+	if offset < 0 || offset > f.size {
+		panic(fmt.Sprintf("invalid offset %d (should be in [0, %d])", offset, f.size))
+	}
+	i := sort.SearchInts(f.lines, offset+1) - 1
+	start := f.lines[i]
+	return Pos{
+		Index:  offset,
+		Line:   i + 1,
+		Column: offset - start - (f.extraBefore(offset) - f.extraBefore(start)) + 1,
+	}
+}
+
+// extraBefore returns the bytes beyond the first of the multibyte
+// characters before offset.
+func (f *File) extraBefore(offset int) int {
+	i := sort.Search(len(f.wide), func(i int) bool { return f.wide[i].offset >= offset })
+	if i == 0 {
+		return 0
+	}
+	return f.wide[i-1].extra
+}
+
+// Position は f 内の pos をファイル名を含む Position に変換します。
+// Position returns the Position of pos within f.
+func (f *File) Position(pos Pos) Position {
+	return Position{Filename: f.name, Pos: pos}
+}
+
+// FileSet は複数のファイルを保持し、FilePos を解決します。
+// A FileSet represents a set of source files. Positions of all files in
+// the set can be stored as compact FilePos values and resolved back to
+// filename, line and column. Methods of FileSet are safe for concurrent use.
+type FileSet struct {
+	mu    sync.RWMutex
+	base  int
+	files []*File
+}
+
+// NewFileSet は新しい FileSet を返します。
+// NewFileSet creates a new file set.
+func NewFileSet() *FileSet {
+	return &FileSet{
+		base: 1, // 0 is reserved for NoFilePos
+	}
+}
+
+// AddFile はファイルを FileSet に登録します。
+// AddFile adds a new file with the given filename and source to the set.
+func (s *FileSet) AddFile(filename string, src string) *File {
+	lines := []int{0}
+	var wide []wideChar
+	extra := 0
+	for i := 0; i < len(src); i++ {
+		switch c := src[i]; {
+		case c == '\n':
+			lines = append(lines, i+1)
+		case c >= utf8.RuneSelf:
+			// an invalid byte counts as a character, as in the lexer.
+			if _, n := utf8.DecodeRuneInString(src[i:]); n > 1 {
+				extra += n - 1
+				wide = append(wide, wideChar{offset: i, extra: extra})
+				i += n - 1
+			}
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := &File{
+		name:  filename,
+		base:  s.base,
+		size:  len(src),
+		lines: lines,
+		wide:  wide,
+	}
+	s.base += len(src) + 1 // +1 so that the EOF position of each file is distinct
+	s.files = append(s.files, f)
+	return f
+}
+
+// File は p を含むファイルを返します。見つからない場合は nil を返します。
+// File returns the file that contains the position p, or nil if there is none.
+func (s *FileSet) File(p FilePos) *File {
+	if !p.IsValid() {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := sort.Search(len(s.files), func(i int) bool {
+		return s.files[i].base > int(p)
+	}) - 1
+	if i < 0 {
+		return nil
+	}
+	f := s.files[i]
+	if int(p) > f.base+f.size {
+		return nil
+	}
+	return f
+}
+
+// Position は p をファイル名を含む Position に変換します。
+// Position converts p to a Position. It returns the zero Position if p
+// does not belong to any file of the set.
+func (s *FileSet) Position(p FilePos) Position {
+	f := s.File(p)
+	if f == nil {
+		return Position{}
+	}
+	return f.Position(f.Pos(int(p) - f.base))
+}
+
+// Iterate は登録されたファイルを順に f に渡します。f が false を返すと終了します。
+// Iterate calls f for the files in the set in the order they were added
+// until f returns false.
+func (s *FileSet) Iterate(f func(*File) bool) {
+	s.mu.RLock()
+	files := append([]*File(nil), s.files...)
+	s.mu.RUnlock()
+	for _, file := range files {
+		if !f(file) {
+			break
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package token
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFileSet(t *testing.T) {
+	fset := NewFileSet()
+	a := fset.AddFile("a.dqdl", "Rules = [\n\tIsUnique \"id\"\n]")
+	b := fset.AddFile("dir/b.dqdl", "# ルール\nRules = [ RowCount > 0 ]")
+
+	cases := []struct {
+		file *File
+		pos  Pos
+		want string
+	}{
+		{file: a, pos: a.Pos(0), want: "a.dqdl:1:1"},
+		{file: a, pos: a.Pos(11), want: "a.dqdl:2:2"},
+		{file: a, pos: a.Pos(a.Size()), want: "a.dqdl:3:2"},
+		{file: b, pos: b.Pos(0), want: "dir/b.dqdl:1:1"},
+		{file: b, pos: b.Pos(len("# ルール")), want: "dir/b.dqdl:1:6"},
+		{file: b, pos: b.Pos(len("# ルール\nRules")), want: "dir/b.dqdl:2:6"},
+	}
+	for _, c := range cases {
+		p := c.file.FilePos(c.pos)
+		if got := fset.File(p); got != c.file {
+			t.Errorf("File(%d) = %v, want %s", p, got, c.file.Name())
+		}
+		if got := fset.Position(p).String(); got != c.want {
+			t.Errorf("Position(%d) = %q, want %q", p, got, c.want)
+		}
+	}
+	if got := fset.Position(NoFilePos).String(); got != "-" {
+		t.Errorf("Position(NoFilePos) = %q, want %q", got, "-")
+	}
+	if got := fset.File(FilePos(1 << 20)); got != nil {
+		t.Errorf("File(out of range) = %v, want nil", got)
+	}
+}
+
+func TestFile__Pos(t *testing.T) {
+	srcs := []string{
+		"",
+		"Rules = [\n\tIsUnique \"id\"\n]\n",
+		"# ルール\nRules = [ ColumnValues \"é\" in [\"α\", \"😀\"] ]\n# 終わり",
+		"\xff\xfeé\n\xe3\x81x",
+	}
+	for _, src := range srcs {
+		f := NewFileSet().AddFile("test.dqdl", src)
+		line, start := 1, 0
+		for offset := 0; offset <= len(src); {
+			want := Pos{Index: offset, Line: line, Column: utf8.RuneCountInString(src[start:offset]) + 1}
+			if got := f.Pos(offset); got != want {
+				t.Errorf("%q: Pos(%d) = %v, want %v", src, offset, got, want)
+			}
+			if offset == len(src) {
+				break
+			}
+			_, n := utf8.DecodeRuneInString(src[offset:])
+			if src[offset] == '\n' {
+				line, start = line+1, offset+1
+			}
+			offset += n
+		}
+	}
+}
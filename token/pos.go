@@ -1,6 +1,12 @@
 package token
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
 
 // Pos は元の入力テキストのバイト位置、行、列を表します。
 // Pos represents a byte position in the original input text from which
@@ -30,7 +36,9 @@ func (pos Pos) String() string {
 	return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
 }
 
-// AddColumn adds the given number of columns to the position.
+// AddColumn adds the given number of columns to the position. It adds n
+// to the byte offset too, so it is only right for ASCII text; use Advance
+// for text that may contain other characters.
 func (pos Pos) AddColumn(n int) Pos {
 	pos.Index += n
 	pos.Column += n
@@ -45,7 +53,90 @@ func (pos Pos) AddLine(n int) Pos {
 	return pos
 }
 
+// Advance は s の直後の位置を返します。列は文字(rune)単位で数えます。
+// Advance returns the position after the text s starting at pos. The byte
+// offset advances by the bytes of s while the column advances by its
+// runes, and each newline in s starts a new line.
+func (pos Pos) Advance(s string) Pos {
+	pos.Index += len(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		pos.Line += strings.Count(s, "\n")
+		pos.Column = 1 + utf8.RuneCountInString(s[i+1:])
+		return pos
+	}
+	pos.Column += utf8.RuneCountInString(s)
+	return pos
+}
+
 // Ptr returns a pointer to pos.
 func (pos Pos) Ptr() *Pos {
 	return &pos
 }
+
+// MarshalText は位置を "3:14" のような文字列に変換します。
+// MarshalText implements encoding.TextMarshaler, encoding the position as
+// its String, e.g. "3:14", or "-" if it is invalid. The byte offset is not
+// part of the text, so it is lost on a round trip through UnmarshalText.
+func (pos Pos) MarshalText() ([]byte, error) {
+	return []byte(pos.String()), nil
+}
+
+// UnmarshalText は "3:14" のような文字列を位置に変換します。
+// UnmarshalText implements encoding.TextUnmarshaler. It accepts the forms
+// written by String: "line:column", "line" and "-". Index is set to 0.
+func (pos *Pos) UnmarshalText(text []byte) error {
+	s := string(text)
+	if s == "-" {
+		*pos = NoPos
+		return nil
+	}
+	var p Pos
+	line, column, hasColumn := strings.Cut(s, ":")
+	var err error
+	if p.Line, err = strconv.Atoi(line); err != nil || p.Line < 1 {
+		return fmt.Errorf("token: invalid position %q", s)
+	}
+	if hasColumn {
+		if p.Column, err = strconv.Atoi(column); err != nil || p.Column < 1 {
+			return fmt.Errorf("token: invalid position %q", s)
+		}
+	}
+	*pos = p
+	return nil
+}
+
+// jsonPos is the JSON form of a Pos.
+type jsonPos struct {
+	Index  int
+	Line   int
+	Column int
+}
+
+// MarshalJSON は位置を Index、Line、Column を持つオブジェクトに変換します。
+// MarshalJSON encodes the position as an object with "Index", "Line" and
+// "Column" keys. It keeps the position an object, rather than the text of
+// MarshalText, so that no information is lost and the JSON form of the
+// syntax trees stays stable; see package ast.
+func (pos Pos) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonPos(pos))
+}
+
+// UnmarshalJSON は位置のオブジェクトまたは "3:14" のような文字列を位置に変換します。
+// UnmarshalJSON implements json.Unmarshaler. It accepts both the object
+// written by MarshalJSON and a string in a form accepted by UnmarshalText,
+// for positions written by hand or by other tools.
+func (pos *Pos) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return pos.UnmarshalText([]byte(s))
+	}
+	var p jsonPos
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*pos = Pos(p)
+	return nil
+}
@@ -0,0 +1,74 @@
+package token
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPos__Text(t *testing.T) {
+	cases := []struct {
+		text   string
+		want   Pos
+		errStr string
+	}{
+		{text: "3:14", want: Pos{Line: 3, Column: 14}},
+		{text: "3", want: Pos{Line: 3}},
+		{text: "-", want: NoPos},
+		{text: "0:1", errStr: `token: invalid position "0:1"`},
+		{text: "3:", errStr: `token: invalid position "3:"`},
+		{text: "a:b", errStr: `token: invalid position "a:b"`},
+	}
+	for _, c := range cases {
+		t.Run(c.text, func(t *testing.T) {
+			var got Pos
+			err := got.UnmarshalText([]byte(c.text))
+			if c.errStr != "" {
+				if err == nil || err.Error() != c.errStr {
+					t.Fatalf("got error %v, want %q", err, c.errStr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Errorf("got %#v, want %#v", got, c.want)
+			}
+			text, err := got.MarshalText()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(text) != c.text {
+				t.Errorf("got %q, want %q", text, c.text)
+			}
+		})
+	}
+}
+
+func TestPos__JSON(t *testing.T) {
+	pos := Pos{Index: 20, Line: 3, Column: 14}
+	bs, err := json.Marshal(pos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"Index":20,"Line":3,"Column":14}`; string(bs) != want {
+		t.Errorf("got %s, want %s", bs, want)
+	}
+	var got struct {
+		Object, Text, Null Pos
+	}
+	if err := json.Unmarshal([]byte(`{"Object":`+string(bs)+`,"Text":"3:14","Null":null}`), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := struct {
+		Object, Text, Null Pos
+	}{Object: pos, Text: Pos{Line: 3, Column: 14}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+	if err := json.Unmarshal([]byte(`"x"`), &got.Text); err == nil {
+		t.Error("expected an error for an invalid position")
+	}
+}
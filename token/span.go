@@ -0,0 +1,68 @@
+package token
+
+import "fmt"
+
+// Before は pos が other より前にあるかどうかを行と列で判定します。
+// Before reports whether pos is before other, comparing lines and then
+// columns. The byte offsets are not compared, so positions computed by
+// hand without an Index compare as expected.
+func (pos Pos) Before(other Pos) bool {
+	if pos.Line != other.Line {
+		return pos.Line < other.Line
+	}
+	return pos.Column < other.Column
+}
+
+// After は pos が other より後にあるかどうかを行と列で判定します。
+// After reports whether pos is after other, comparing lines and then
+// columns.
+func (pos Pos) After(other Pos) bool {
+	return other.Before(pos)
+}
+
+// Span は元の入力テキストの範囲 [Start, End) を表します。
+// A Span is the range [Start, End) of the input text, such as the range
+// of a node. End is exclusive.
+type Span struct {
+	Start Pos
+	End   Pos
+}
+
+// IsValid は範囲が有効かどうかを返します。
+// IsValid reports whether both ends are valid and End is not before Start.
+func (s Span) IsValid() bool {
+	return s.Start.IsValid() && s.End.IsValid() && !s.End.Before(s.Start)
+}
+
+// IsEmpty は範囲が空かどうかを返します。
+// IsEmpty reports whether the span contains no position.
+func (s Span) IsEmpty() bool {
+	return !s.Start.Before(s.End)
+}
+
+// Contains は pos が範囲に含まれるかどうかを返します。
+// Contains reports whether pos is in [Start, End). An invalid span or
+// position is contained in nothing.
+func (s Span) Contains(pos Pos) bool {
+	if !s.IsValid() || !pos.IsValid() {
+		return false
+	}
+	return !pos.Before(s.Start) && pos.Before(s.End)
+}
+
+// Overlaps は2つの範囲が重なるかどうかを返します。
+// Overlaps reports whether s and other have a position in common. Spans
+// that only touch, where one ends at the start of the other, do not
+// overlap.
+func (s Span) Overlaps(other Span) bool {
+	if !s.IsValid() || !other.IsValid() || s.IsEmpty() || other.IsEmpty() {
+		return false
+	}
+	return s.Start.Before(other.End) && other.Start.Before(s.End)
+}
+
+// String は "3:14-3:20" のような文字列を返します。
+// String returns a string in the form "start-end", e.g. "3:14-3:20".
+func (s Span) String() string {
+	return fmt.Sprintf("%s-%s", s.Start, s.End)
+}
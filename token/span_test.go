@@ -0,0 +1,94 @@
+package token
+
+import "testing"
+
+func TestPos__Before(t *testing.T) {
+	a := Pos{Index: 10, Line: 2, Column: 3}
+	b := Pos{Index: 5, Line: 2, Column: 4}
+	c := Pos{Line: 3, Column: 1}
+	if !a.Before(b) || !b.Before(c) || !a.Before(c) {
+		t.Error("expected a < b < c")
+	}
+	if a.Before(a) || a.After(a) {
+		t.Error("a position is neither before nor after itself")
+	}
+	if !c.After(a) || a.After(b) {
+		t.Error("unexpected After result")
+	}
+}
+
+func TestSpan(t *testing.T) {
+	span := Span{Start: Pos{Line: 1, Column: 5}, End: Pos{Line: 2, Column: 3}}
+	cases := []struct {
+		name string
+		pos  Pos
+		want bool
+	}{
+		{name: "start", pos: Pos{Line: 1, Column: 5}, want: true},
+		{name: "inside", pos: Pos{Line: 1, Column: 80}, want: true},
+		{name: "last", pos: Pos{Line: 2, Column: 2}, want: true},
+		{name: "end", pos: Pos{Line: 2, Column: 3}},
+		{name: "before", pos: Pos{Line: 1, Column: 4}},
+		{name: "invalid", pos: NoPos},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := span.Contains(c.pos); got != c.want {
+				t.Errorf("Contains(%s) = %v, want %v", c.pos, got, c.want)
+			}
+		})
+	}
+	if got := span.String(); got != "1:5-2:3" {
+		t.Errorf("got %q, want %q", got, "1:5-2:3")
+	}
+	if (Span{Start: span.End, End: span.Start}).Contains(Pos{Line: 1, Column: 6}) {
+		t.Error("a reversed span contains nothing")
+	}
+}
+
+func TestSpan__Overlaps(t *testing.T) {
+	span := func(l1, c1, l2, c2 int) Span {
+		return Span{Start: Pos{Line: l1, Column: c1}, End: Pos{Line: l2, Column: c2}}
+	}
+	cases := []struct {
+		name string
+		a, b Span
+		want bool
+	}{
+		{name: "same", a: span(1, 1, 1, 5), b: span(1, 1, 1, 5), want: true},
+		{name: "inside", a: span(1, 1, 3, 1), b: span(2, 1, 2, 5), want: true},
+		{name: "crossing", a: span(1, 1, 2, 5), b: span(2, 1, 3, 1), want: true},
+		{name: "touching", a: span(1, 1, 1, 5), b: span(1, 5, 1, 9)},
+		{name: "apart", a: span(1, 1, 1, 5), b: span(2, 1, 2, 5)},
+		{name: "empty", a: span(1, 1, 1, 5), b: span(1, 3, 1, 3)},
+		{name: "invalid", a: span(1, 1, 1, 5), b: Span{}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.a.Overlaps(c.b); got != c.want {
+				t.Errorf("%s.Overlaps(%s) = %v, want %v", c.a, c.b, got, c.want)
+			}
+			if got := c.b.Overlaps(c.a); got != c.want {
+				t.Errorf("%s.Overlaps(%s) = %v, want %v", c.b, c.a, got, c.want)
+			}
+		})
+	}
+}
+
+func TestPos__Advance(t *testing.T) {
+	start := Pos{Index: 10, Line: 2, Column: 5}
+	cases := []struct {
+		text string
+		want Pos
+	}{
+		{text: "", want: start},
+		{text: `"id"`, want: Pos{Index: 14, Line: 2, Column: 9}},
+		{text: `"名前"`, want: Pos{Index: 18, Line: 2, Column: 9}},
+		{text: "# é\n  x", want: Pos{Index: 18, Line: 3, Column: 4}},
+	}
+	for _, c := range cases {
+		if got := start.Advance(c.text); got != c.want {
+			t.Errorf("Advance(%q) = %#v, want %#v", c.text, got, c.want)
+		}
+	}
+}
@@ -2,7 +2,10 @@
 // Package token defines constants representing the lexical tokens of DQDL and basic operations on tokens (printing, predicates).
 package token
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 // TokenTypeはトークンの種類を表します。
 // TokenType represents the type of a token.
@@ -42,6 +45,7 @@ const (
 	DIVIDE
 	RULES
 	COMMENT
+	WHITESPACE // emitted only by a scanner with trivia
 )
 
 var tokenTypeStrings = map[TokenType]string{
@@ -49,6 +53,7 @@ var tokenTypeStrings = map[TokenType]string{
 	EOF:           "EOF",
 	IDENT:         "IDENT",
 	COMMENT:       "COMMENT",
+	WHITESPACE:    "WHITESPACE",
 	NUMBER:        "NUMBER",
 	STRING:        "STRING",
 	BETWEEN:       "between",
@@ -73,7 +78,10 @@ var tokenTypeStrings = map[TokenType]string{
 	COMMA:         ",",
 	TRUE:          "true",
 	FALSE:         "false",
+	PLUS:          "+",
 	MINUS:         "-",
+	MULTIPLY:      "*",
+	DIVIDE:        "/",
 	RULES:         "Rules",
 }
 
@@ -86,6 +94,29 @@ func (t TokenType) String() string {
 	return "unknown token"
 }
 
+// MarshalText はトークンの種類を ">=" のような文字列に変換します。
+// MarshalText implements encoding.TextMarshaler, encoding the type as its
+// String, e.g. ">=" or "IDENT".
+func (t TokenType) MarshalText() ([]byte, error) {
+	s, ok := tokenTypeStrings[t]
+	if !ok {
+		return nil, fmt.Errorf("token: invalid token type %d", int(t))
+	}
+	return []byte(s), nil
+}
+
+// UnmarshalText は ">=" のような文字列をトークンの種類に変換します。
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *TokenType) UnmarshalText(text []byte) error {
+	for typ, s := range tokenTypeStrings {
+		if s == string(text) {
+			*t = typ
+			return nil
+		}
+	}
+	return fmt.Errorf("token: unknown token type %q", text)
+}
+
 // IsExpressionStart はExpressionの始まりの字句であるかどうかを返します。
 // IsExpressionStart returns true if the token is the start of an expression.
 func (t TokenType) IsExpressionStart() bool {
@@ -148,8 +179,91 @@ var keywords = map[string]TokenType{
 // LookupIdentは識別子として登録されている場合はそのトークンの種類を返します。そうでない場合はtoken.IDENTをかえします。
 // LookupIdent returns the token type of the string s if it is a keyword, and token.IDENT otherwise.
 func LookupIdent(ident string) TokenType {
-	if tok, ok := keywords[ident]; ok {
-		return tok
+	if ident == "" {
+		return IDENT
+	}
+	if kw := &keywordTable[keywordHash(ident)]; kw.ident == ident {
+		return kw.tok
 	}
 	return IDENT
 }
+
+// keywordTable has the keywords at their keywordHash, so that LookupIdent
+// compares an identifier with a single keyword rather than looking it up
+// in keywords. The hashes of the keywords are distinct, which is checked
+// as the table is built.
+var keywordTable = func() (table [64]struct {
+	ident string
+	tok   TokenType
+}) {
+	for kw, tok := range keywords {
+		h := keywordHash(kw)
+		if table[h].ident != "" {
+			panic("token: keywords " + table[h].ident + " and " + kw + " have the same hash")
+		}
+		table[h].ident, table[h].tok = kw, tok
+	}
+	return table
+}()
+
+// keywordHash hashes the first byte and the length of a non-empty ident.
+func keywordHash(ident string) int {
+	return (int(ident[0]) + 5*len(ident)) & 63
+}
+
+// Keywords はキーワードの綴りを整列して返します。
+// Keywords returns the spellings of the keywords in sorted order, e.g.
+// "Rules", "and", "between". Note that the keyword of now() is "now".
+func Keywords() []string {
+	kws := make([]string, 0, len(keywords))
+	for kw := range keywords {
+		kws = append(kws, kw)
+	}
+	sort.Strings(kws)
+	return kws
+}
+
+// IsKeyword は name がキーワードであるかどうかを返します。大文字と小文字は区別されます。
+// IsKeyword reports whether name is a keyword, such as "between" or
+// "Rules". Keywords are case sensitive, as in the scanner.
+func IsKeyword(name string) bool {
+	_, ok := keywords[name]
+	return ok
+}
+
+// IsKeyword はキーワードの字句であるかどうかを返します。
+// IsKeyword reports whether t is the token of a keyword, such as BETWEEN,
+// TRUE or RULES.
+func (t TokenType) IsKeyword() bool {
+	switch t {
+	case BETWEEN, AND, OR, IN, MATCHES, NOW, DAYS, HOURS, WITH, THRESHOLD, TRUE, FALSE, RULES:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsLiteral は識別子、数値、文字列の字句であるかどうかを返します。
+// IsLiteral reports whether t is the token of an identifier, a number or a
+// string. The booleans are keywords.
+func (t TokenType) IsLiteral() bool {
+	switch t {
+	case IDENT, NUMBER, STRING:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsOperator は演算子や区切り記号の字句であるかどうかを返します。
+// IsOperator reports whether t is the token of an operator or a
+// delimiter, such as GREATER_EQUAL, MINUS or LEFT_BRACKET.
+func (t TokenType) IsOperator() bool {
+	switch t {
+	case RIGHT_BRACKET, LEFT_BRACKET, GREATER_THAN, LESS_THAN, EQUAL, GREATER_EQUAL, LESS_EQUAL,
+		RIGHT_PAREN, LEFT_PAREN, COMMA, PLUS, MINUS, MULTIPLY, DIVIDE:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,105 @@
+package token
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestKeywords(t *testing.T) {
+	want := []string{"Rules", "and", "between", "days", "false", "hours", "in", "matches", "now", "or", "threshold", "true", "with"}
+	if diff := cmp.Diff(want, Keywords()); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+	for _, kw := range Keywords() {
+		if !IsKeyword(kw) {
+			t.Errorf("IsKeyword(%q) = false", kw)
+		}
+		if !LookupIdent(kw).IsKeyword() {
+			t.Errorf("%s.IsKeyword() = false", LookupIdent(kw))
+		}
+	}
+	for _, name := range []string{"rules", "Between", "IsComplete", "now()", ""} {
+		if IsKeyword(name) {
+			t.Errorf("IsKeyword(%q) = true", name)
+		}
+	}
+}
+
+func TestLookupIdent(t *testing.T) {
+	cases := map[string]TokenType{
+		"between":    BETWEEN,
+		"Rules":      RULES,
+		"threshold":  THRESHOLD,
+		"true":       TRUE,
+		"bitween":    IDENT, // the hash of between
+		"tree":       IDENT,
+		"IsComplete": IDENT,
+		"":           IDENT,
+	}
+	for ident, want := range cases {
+		if got := LookupIdent(ident); got != want {
+			t.Errorf("LookupIdent(%q) = %s, want %s", ident, got, want)
+		}
+	}
+}
+
+func TestTokenType__Categories(t *testing.T) {
+	for tt := ILLEGAL; tt <= WHITESPACE; tt++ {
+		var got []string
+		if tt.IsKeyword() {
+			got = append(got, "keyword")
+		}
+		if tt.IsLiteral() {
+			got = append(got, "literal")
+		}
+		if tt.IsOperator() {
+			got = append(got, "operator")
+		}
+		switch tt {
+		case ILLEGAL, EOF, COMMENT, WHITESPACE:
+			if len(got) != 0 {
+				t.Errorf("%v: got categories %v, want none", tt, got)
+			}
+		default:
+			if len(got) != 1 {
+				t.Errorf("%v: got categories %v, want one", tt, got)
+			}
+		}
+	}
+}
+
+func TestTokenType__Text(t *testing.T) {
+	for typ := ILLEGAL; typ <= WHITESPACE; typ++ {
+		text, err := typ.MarshalText()
+		if err != nil {
+			t.Errorf("%d: %v", int(typ), err)
+			continue
+		}
+		var got TokenType
+		if err := got.UnmarshalText(text); err != nil {
+			t.Errorf("%s: %v", text, err)
+		}
+		if got != typ {
+			t.Errorf("%s: got %d, want %d", text, int(got), int(typ))
+		}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode([]TokenType{GREATER_EQUAL, IDENT, NOW, PLUS, MINUS, MULTIPLY, DIVIDE}); err != nil {
+		t.Fatal(err)
+	}
+	if want := `[">=","IDENT","now()","+","-","*","/"]` + "\n"; buf.String() != want {
+		t.Errorf("got %s, want %s", buf.String(), want)
+	}
+	if _, err := TokenType(-1).MarshalText(); err == nil {
+		t.Error("expected an error for an invalid token type")
+	}
+	var typ TokenType
+	if err := typ.UnmarshalText([]byte("=>")); err == nil {
+		t.Error("expected an error for an unknown token type")
+	}
+}
@@ -0,0 +1,310 @@
+package dqdl
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/printer"
+)
+
+// Condition はルールの式を Go の値で表したものです。
+// A Condition is the decoded form of the expression of a rule.
+//
+// Values holds the operands: a string for a string literal, a float64 for
+// a number and a bool for a boolean. Durations and dates are kept as their
+// ast.Parameter nodes.
+type Condition struct {
+	Operator  string        // ">", ">=", "<", "<=", "=", "between", "in" or "matches"
+	Values    []interface{} // operands, e.g. [1, 10] for `between 1 and 10`
+	Threshold *Condition    // the condition of `with threshold`, if any
+}
+
+// RuleTyper はルールタイプの名前を返す型が実装するインタフェースです。
+// RuleTyper is implemented by structs whose name differs from the rule
+// type they represent.
+type RuleTyper interface {
+	RuleType() string
+}
+
+var (
+	expressionType = reflect.TypeOf((*ast.Expression)(nil)).Elem()
+	parameterType  = reflect.TypeOf((*ast.Parameter)(nil)).Elem()
+	conditionType  = reflect.TypeOf(Condition{})
+)
+
+// Unmarshal はルールのパラメータと式を構造体に格納します。
+// Unmarshal stores the parameters and the expression of rule in the
+// struct pointed to by v, e.g.
+//
+//	var r dqdl.ColumnValues
+//	err := dqdl.Unmarshal(rule, &r)
+//
+// The rule type must match the one of the struct, which is the result of
+// its RuleType method if it implements RuleTyper, or else the name of the
+// struct type. Anonymous structs accept any rule type.
+//
+// Exported fields are filled in order of declaration. A field of type
+// ast.Expression, Condition or *Condition receives the expression, and is
+// left zero if the rule has none. The other fields receive the parameters
+// in order: a string, bool, integer, float or ast.Parameter field takes
+// one parameter, and a slice of those as the last such field takes all
+// remaining ones. Fields tagged `dqdl:"-"` are skipped. It is an error if
+// the number or the types of the parameters do not match.
+func Unmarshal(rule ast.RuleDecl, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("dqdl: Unmarshal requires a non-nil pointer to a struct, got %T", v)
+	}
+	r, ok := rule.(*ast.Rule)
+	if !ok {
+		return fmt.Errorf("dqdl: can not unmarshal %T into %s", rule, rv.Elem().Type())
+	}
+	st := rv.Elem().Type()
+	if name := ruleTypeOf(rv); name != "" && name != r.Type.Name {
+		return fmt.Errorf("dqdl: can not unmarshal %s rule into %s", r.Type.Name, st)
+	}
+	fields, err := ruleFields(st)
+	if err != nil {
+		return err
+	}
+	params := r.Parameters
+	for _, f := range fields {
+		fv := rv.Elem().Field(f.index)
+		switch {
+		case f.expression:
+			if err := setExpression(fv, r.Expression); err != nil {
+				return fmt.Errorf("dqdl: %s.%s: %w", st, f.name, err)
+			}
+		case f.variadic:
+			slice := reflect.MakeSlice(fv.Type(), len(params), len(params))
+			for i, param := range params {
+				if err := setParameter(slice.Index(i), param); err != nil {
+					return fmt.Errorf("dqdl: %s.%s: %w", st, f.name, err)
+				}
+			}
+			fv.Set(slice)
+			params = nil
+		default:
+			if len(params) == 0 {
+				return fmt.Errorf("dqdl: %s rule has too few parameters for %s", r.Type.Name, st)
+			}
+			if err := setParameter(fv, params[0]); err != nil {
+				return fmt.Errorf("dqdl: %s.%s: %w", st, f.name, err)
+			}
+			params = params[1:]
+		}
+	}
+	if len(params) > 0 {
+		return fmt.Errorf("dqdl: %s rule has too many parameters for %s", r.Type.Name, st)
+	}
+	if r.Expression != nil && !hasExpressionField(fields) {
+		return fmt.Errorf("dqdl: %s has no field for the expression of %s rule", st, r.Type.Name)
+	}
+	return nil
+}
+
+// ruleTypeOf returns the rule type represented by the struct pointed to by
+// rv, or "" for an anonymous struct.
+func ruleTypeOf(rv reflect.Value) string {
+	if t, ok := rv.Interface().(RuleTyper); ok {
+		return t.RuleType()
+	}
+	if t, ok := rv.Elem().Interface().(RuleTyper); ok {
+		return t.RuleType()
+	}
+	return rv.Elem().Type().Name()
+}
+
+type ruleField struct {
+	index      int
+	name       string
+	expression bool // receives the expression
+	variadic   bool // receives the remaining parameters
+}
+
+// ruleFields returns the fields of the struct type st that are mapped to
+// the parameters and the expression of a rule.
+func ruleFields(st reflect.Type) ([]ruleField, error) {
+	var fields []ruleField
+	var expression, variadic bool
+	for i := 0; i < st.NumField(); i++ {
+		sf := st.Field(i)
+		if sf.PkgPath != "" || sf.Tag.Get("dqdl") == "-" {
+			continue
+		}
+		f := ruleField{index: i, name: sf.Name}
+		switch {
+		case isExpressionType(sf.Type):
+			if expression {
+				return nil, fmt.Errorf("dqdl: %s has more than one expression field", st)
+			}
+			f.expression, expression = true, true
+		case sf.Type.Kind() == reflect.Slice && isParameterType(sf.Type.Elem()):
+			f.variadic = true
+		case isParameterType(sf.Type):
+		default:
+			return nil, fmt.Errorf("dqdl: %s.%s has unsupported type %s", st, sf.Name, sf.Type)
+		}
+		if variadic && !f.expression {
+			return nil, fmt.Errorf("dqdl: %s.%s follows the variadic field", st, sf.Name)
+		}
+		variadic = variadic || f.variadic
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func hasExpressionField(fields []ruleField) bool {
+	for _, f := range fields {
+		if f.expression {
+			return true
+		}
+	}
+	return false
+}
+
+func isExpressionType(t reflect.Type) bool {
+	return t == expressionType || t == conditionType || t == reflect.PtrTo(conditionType)
+}
+
+func isParameterType(t reflect.Type) bool {
+	if t == parameterType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func setExpression(fv reflect.Value, expr ast.Expression) error {
+	if expr == nil {
+		return nil
+	}
+	if fv.Type() == expressionType {
+		fv.Set(reflect.ValueOf(expr))
+		return nil
+	}
+	cond, err := conditionOf(expr)
+	if err != nil {
+		return err
+	}
+	if fv.Type() == conditionType {
+		fv.Set(reflect.ValueOf(*cond))
+	} else {
+		fv.Set(reflect.ValueOf(cond))
+	}
+	return nil
+}
+
+// conditionOf decodes expr.
+func conditionOf(expr ast.Expression) (*Condition, error) {
+	var cond Condition
+	var params []ast.Parameter
+	switch x := expr.(type) {
+	case *ast.ComparisonExpression:
+		cond.Operator = x.Operator
+		params = []ast.Parameter{x.Right}
+	case *ast.BetweenExpression:
+		cond.Operator = "between"
+		params = []ast.Parameter{x.Left, x.Right}
+	case *ast.InExpression:
+		cond.Operator = "in"
+		params = x.Values
+	case *ast.MatchesExpression:
+		cond.Operator = "matches"
+		cond.Values = []interface{}{x.Value}
+	case *ast.WithThresholdExpression:
+		target, err := conditionOf(x.Target)
+		if err != nil {
+			return nil, err
+		}
+		threshold, err := conditionOf(x.Threshold)
+		if err != nil {
+			return nil, err
+		}
+		target.Threshold = threshold
+		return target, nil
+	default:
+		return nil, fmt.Errorf("unsupported expression %T", expr)
+	}
+	for _, param := range params {
+		v, err := parameterValue(param)
+		if err != nil {
+			return nil, err
+		}
+		cond.Values = append(cond.Values, v)
+	}
+	return &cond, nil
+}
+
+// parameterValue returns the Go value of a literal parameter, or param
+// itself for a duration or a date.
+func parameterValue(param ast.Parameter) (interface{}, error) {
+	switch p := param.(type) {
+	case *ast.StringParameter:
+		return p.Value, nil
+	case *ast.NumberParameter:
+		return p.Float64()
+	case *ast.BoolParameter:
+		return p.Value, nil
+	}
+	return param, nil
+}
+
+func setParameter(fv reflect.Value, param ast.Parameter) error {
+	if fv.Type() == parameterType {
+		fv.Set(reflect.ValueOf(param))
+		return nil
+	}
+	mismatch := func() error {
+		var buf bytes.Buffer
+		printer.Fprint(&buf, param)
+		return fmt.Errorf("can not store `%s` into %s", buf.String(), fv.Type())
+	}
+	switch p := param.(type) {
+	case *ast.StringParameter:
+		if fv.Kind() != reflect.String {
+			return mismatch()
+		}
+		fv.SetString(p.Value)
+	case *ast.BoolParameter:
+		if fv.Kind() != reflect.Bool {
+			return mismatch()
+		}
+		fv.SetBool(p.Value)
+	case *ast.NumberParameter:
+		switch fv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n, err := p.Int64()
+			if err != nil || fv.OverflowInt(n) {
+				return mismatch()
+			}
+			fv.SetInt(n)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n, err := strconv.ParseUint(p.Value, 10, fv.Type().Bits())
+			if err != nil {
+				return mismatch()
+			}
+			fv.SetUint(n)
+		case reflect.Float32, reflect.Float64:
+			n, err := strconv.ParseFloat(p.Value, fv.Type().Bits())
+			if err != nil {
+				return mismatch()
+			}
+			fv.SetFloat(n)
+		default:
+			return mismatch()
+		}
+	default:
+		return mismatch()
+	}
+	return nil
+}
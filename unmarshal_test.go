@@ -0,0 +1,168 @@
+package dqdl
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/parser"
+)
+
+func TestUnmarshal(t *testing.T) {
+	type custom struct {
+		Column  string
+		Limit   int
+		Skipped string `dqdl:"-"`
+		Expr    ast.Expression
+	}
+	cases := []struct {
+		name  string
+		input string
+		v     interface{}
+		want  interface{}
+	}{
+		{
+			name:  "no expression",
+			input: `IsUnique "id"`,
+			v:     &IsUnique{},
+			want:  &IsUnique{Column: "id"},
+		},
+		{
+			name:  "comparison",
+			input: `Completeness "name" >= 0.95`,
+			v:     &Completeness{},
+			want:  &Completeness{Column: "name", Condition: &Condition{Operator: ">=", Values: []interface{}{0.95}}},
+		},
+		{
+			name:  "in with threshold",
+			input: `ColumnValues "status" in ["a", "b"] with threshold > 0.8`,
+			v:     &ColumnValues{},
+			want: &ColumnValues{Column: "status", Condition: &Condition{
+				Operator:  "in",
+				Values:    []interface{}{"a", "b"},
+				Threshold: &Condition{Operator: ">", Values: []interface{}{0.8}},
+			}},
+		},
+		{
+			name:  "between",
+			input: `RowCount between 1 and 10`,
+			v:     &RowCount{},
+			want:  &RowCount{Condition: &Condition{Operator: "between", Values: []interface{}{1.0, 10.0}}},
+		},
+		{
+			name:  "variadic",
+			input: `IsPrimaryKey "a" "b"`,
+			v:     &IsPrimaryKey{},
+			want:  &IsPrimaryKey{Columns: []string{"a", "b"}},
+		},
+		{
+			name:  "anonymous struct",
+			input: `Mean "price" matches "[0-9]+"`,
+			v: &struct {
+				Column    string
+				Condition Condition
+			}{},
+			want: &struct {
+				Column    string
+				Condition Condition
+			}{
+				Column:    "price",
+				Condition: Condition{Operator: "matches", Values: []interface{}{"[0-9]+"}},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rule, err := parser.ParseRule(c.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := Unmarshal(rule, c.v); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(c.want, c.v); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+	t.Run("custom", func(t *testing.T) {
+		rule, err := parser.ParseRule(`custom "col" 10 > 5`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got custom
+		if err := Unmarshal(rule, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Column != "col" || got.Limit != 10 || got.Expr != rule.(*ast.Rule).Expression {
+			t.Errorf("got %+v", got)
+		}
+	})
+}
+
+func TestUnmarshal__Error(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		v     interface{}
+		want  string
+	}{
+		{
+			name:  "rule type",
+			input: `IsComplete "id"`,
+			v:     &IsUnique{},
+			want:  "dqdl: can not unmarshal IsComplete rule into dqdl.IsUnique",
+		},
+		{
+			name:  "too few parameters",
+			input: `ColumnCorrelation "a"`,
+			v:     &ColumnCorrelation{},
+			want:  "dqdl: ColumnCorrelation rule has too few parameters for dqdl.ColumnCorrelation",
+		},
+		{
+			name:  "too many parameters",
+			input: `IsUnique "a" "b"`,
+			v:     &IsUnique{},
+			want:  "dqdl: IsUnique rule has too many parameters for dqdl.IsUnique",
+		},
+		{
+			name:  "parameter type",
+			input: `IsUnique 1`,
+			v:     &IsUnique{},
+			want:  "dqdl: dqdl.IsUnique.Column: can not store `1` into string",
+		},
+		{
+			name:  "unexpected expression",
+			input: `IsUnique "a" > 1`,
+			v:     &IsUnique{},
+			want:  "dqdl: dqdl.IsUnique has no field for the expression of IsUnique rule",
+		},
+		{
+			name:  "combined rule",
+			input: `(IsUnique "a") and (IsComplete "a")`,
+			v:     &IsUnique{},
+			want:  "dqdl: can not unmarshal *ast.CombinedRule into dqdl.IsUnique",
+		},
+		{
+			name:  "not a pointer",
+			input: `IsUnique "a"`,
+			v:     IsUnique{},
+			want:  "dqdl: Unmarshal requires a non-nil pointer to a struct, got dqdl.IsUnique",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rule, err := parser.ParseRule(c.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = Unmarshal(rule, c.v)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if diff := cmp.Diff(c.want, err.Error()); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/mashiike/go-dqdl/ast"
+)
+
+// ParamType はルールのパラメータの型です。
+// ParamType is the type of a rule parameter.
+type ParamType int
+
+const (
+	ParamString ParamType = iota + 1
+	ParamNumber
+)
+
+func (t ParamType) String() string {
+	switch t {
+	case ParamString:
+		return "string"
+	case ParamNumber:
+		return "number"
+	}
+	return "unknown"
+}
+
+// ExpressionRequirement はルールが式を必要とするかどうかを表します。
+// ExpressionRequirement tells whether a rule type takes an expression.
+type ExpressionRequirement int
+
+const (
+	ExpressionRequired ExpressionRequirement = iota
+	ExpressionForbidden
+	ExpressionOptional
+)
+
+// ExpressionKind はルールが受け付ける式の種類の集合です。
+// An ExpressionKind is a set of kinds of expressions (or 0 for any).
+type ExpressionKind uint
+
+const (
+	ExpressionComparison    ExpressionKind = 1 << iota // e.g. `> 0.5`
+	ExpressionBetween                                  // e.g. `between 1 and 5`
+	ExpressionIn                                       // e.g. `in ["a", "b"]`
+	ExpressionMatches                                  // e.g. `matches "[a-z]+"`
+	ExpressionWithThreshold                            // e.g. `in ["a"] with threshold > 0.9`
+)
+
+var expressionKindStrings = []struct {
+	kind ExpressionKind
+	str  string
+}{
+	{ExpressionComparison, "comparison"},
+	{ExpressionBetween, "between"},
+	{ExpressionIn, "in"},
+	{ExpressionMatches, "matches"},
+	{ExpressionWithThreshold, "with threshold"},
+}
+
+func (k ExpressionKind) String() string {
+	for _, s := range expressionKindStrings {
+		if k == s.kind {
+			return s.str
+		}
+	}
+	return fmt.Sprintf("ExpressionKind(%d)", uint(k))
+}
+
+// expressionKindOf returns the kind of expr.
+func expressionKindOf(expr ast.Expression) ExpressionKind {
+	switch expr.(type) {
+	case *ast.ComparisonExpression:
+		return ExpressionComparison
+	case *ast.BetweenExpression:
+		return ExpressionBetween
+	case *ast.InExpression:
+		return ExpressionIn
+	case *ast.MatchesExpression:
+		return ExpressionMatches
+	case *ast.WithThresholdExpression:
+		return ExpressionWithThreshold
+	}
+	return 0
+}
+
+// Param はルールのパラメータの仕様です。
+// A Param describes a parameter of a rule type.
+type Param struct {
+	Name string    // name used in messages, e.g. "column"
+	Type ParamType // expected type
+}
+
+// RuleSpec はルールタイプの仕様です。
+// A RuleSpec describes a rule type: its parameters, whether it takes an
+// expression and which kinds of expressions it accepts.
+type RuleSpec struct {
+	Name        string
+	Params      []Param
+	Variadic    bool // the last parameter may be repeated
+	Expression  ExpressionRequirement
+	Expressions ExpressionKind // accepted kinds of expressions; 0 means any
+}
+
+// Param はi番目のパラメータの仕様を返します。
+// Param returns the spec of the i-th parameter, repeating the last one
+// for a variadic rule type.
+func (s *RuleSpec) Param(i int) (Param, bool) {
+	if i < len(s.Params) {
+		return s.Params[i], true
+	}
+	if s.Variadic && len(s.Params) > 0 {
+		return s.Params[len(s.Params)-1], true
+	}
+	return Param{}, false
+}
+
+var (
+	column    = Param{Name: "column", Type: ParamString}
+	reference = Param{Name: "reference", Type: ParamString}
+)
+
+// builtinSpecs are the rule types documented for AWS Glue Data Quality.
+// https://docs.aws.amazon.com/glue/latest/dg/dqdl.html#dqdl-rule-types
+var builtinSpecs = []*RuleSpec{
+	{Name: "AggregateMatch", Params: []Param{{Name: "expression", Type: ParamString}, {Name: "expression", Type: ParamString}}},
+	{Name: "ColumnCorrelation", Params: []Param{column, column}},
+	{Name: "ColumnCount"},
+	{Name: "ColumnDataType", Params: []Param{column}},
+	{Name: "ColumnExists", Params: []Param{column}, Expression: ExpressionForbidden},
+	{Name: "ColumnLength", Params: []Param{column}},
+	{Name: "ColumnNamesMatchPattern", Params: []Param{{Name: "pattern", Type: ParamString}}, Expression: ExpressionForbidden},
+	{Name: "ColumnValues", Params: []Param{column}},
+	{Name: "Completeness", Params: []Param{column}},
+	{Name: "CustomSql", Params: []Param{{Name: "statement", Type: ParamString}}},
+	{Name: "DataFreshness", Params: []Param{column}},
+	{Name: "DatasetMatch", Params: []Param{reference, {Name: "mapping", Type: ParamString}}},
+	{Name: "DistinctValuesCount", Params: []Param{column}},
+	{Name: "Entropy", Params: []Param{column}},
+	{Name: "IsComplete", Params: []Param{column}, Expression: ExpressionForbidden},
+	{Name: "IsPrimaryKey", Params: []Param{column}, Variadic: true, Expression: ExpressionForbidden},
+	{Name: "IsUnique", Params: []Param{column}, Expression: ExpressionForbidden},
+	{Name: "Mean", Params: []Param{column}},
+	{Name: "ReferentialIntegrity", Params: []Param{{Name: "columns", Type: ParamString}, {Name: "reference columns", Type: ParamString}}},
+	{Name: "RowCount"},
+	{Name: "RowCountMatch", Params: []Param{reference}},
+	{Name: "SchemaMatch", Params: []Param{reference}},
+	{Name: "StandardDeviation", Params: []Param{column}},
+	{Name: "Sum", Params: []Param{column}},
+	{Name: "UniqueValueRatio", Params: []Param{column}},
+	{Name: "Uniqueness", Params: []Param{column}},
+}
+
+var builtinIndex = func() map[string]*RuleSpec {
+	m := make(map[string]*RuleSpec, len(builtinSpecs))
+	for _, spec := range builtinSpecs {
+		m[spec.Name] = spec
+	}
+	return m
+}()
+
+// Lookup は組み込みのルールタイプの仕様を返します。
+// Lookup returns the spec of the built-in rule type with the given name.
+func Lookup(name string) (*RuleSpec, bool) {
+	spec, ok := builtinIndex[name]
+	return spec, ok
+}
+
+// RuleSpecs は組み込みのルールタイプの仕様を名前順に返します。
+// RuleSpecs returns the specs of all built-in rule types sorted by name.
+func RuleSpecs() []*RuleSpec {
+	specs := make([]*RuleSpec, len(builtinSpecs))
+	copy(specs, builtinSpecs)
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Name < specs[j].Name
+	})
+	return specs
+}
+
+// Registry はルールタイプの仕様の集合です。
+// A Registry is a set of rule specs. It starts with the built-in rule types
+// and accepts custom ones, so that in-house extensions of DQDL can be
+// validated. It is safe for concurrent use.
+type Registry struct {
+	mu    sync.RWMutex
+	specs map[string]*RuleSpec
+}
+
+// NewRegistry は組み込みのルールタイプを含むRegistryを作成します。
+// NewRegistry returns a registry containing the built-in rule types.
+func NewRegistry() *Registry {
+	r := &Registry{specs: make(map[string]*RuleSpec, len(builtinSpecs))}
+	for _, spec := range builtinSpecs {
+		r.specs[spec.Name] = spec
+	}
+	return r
+}
+
+// Register はルールタイプの仕様を追加します。同じ名前のルールタイプが既に存在する場合はエラーを返します。
+// Register adds a rule spec. It fails if a rule type of the same name is
+// already registered, including the built-in ones.
+func (r *Registry) Register(spec *RuleSpec) error {
+	if spec == nil || spec.Name == "" {
+		return errors.New("validate: rule spec must have a name")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.specs[spec.Name]; ok {
+		return fmt.Errorf("validate: rule type %s is already registered", spec.Name)
+	}
+	r.specs[spec.Name] = spec
+	return nil
+}
+
+// Lookup は指定した名前のルールタイプの仕様を返します。
+// Lookup returns the spec of the rule type with the given name.
+func (r *Registry) Lookup(name string) (*RuleSpec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	spec, ok := r.specs[name]
+	return spec, ok
+}
+
+// RuleSpecs は登録されている全てのルールタイプの仕様を名前順に返します。
+// RuleSpecs returns the specs of all registered rule types sorted by name.
+func (r *Registry) RuleSpecs() []*RuleSpec {
+	r.mu.RLock()
+	specs := make([]*RuleSpec, 0, len(r.specs))
+	for _, spec := range r.specs {
+		specs = append(specs, spec)
+	}
+	r.mu.RUnlock()
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Name < specs[j].Name
+	})
+	return specs
+}
@@ -0,0 +1,113 @@
+package validate
+
+import (
+	"errors"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/diag"
+	"github.com/mashiike/go-dqdl/regexcompat"
+	"github.com/mashiike/go-dqdl/token"
+)
+
+// RegexpDialect は matches 式の正規表現をどの方言として検査するかを表します。
+// RegexpDialect selects the regular expression syntax that patterns of
+// matches expressions are checked against.
+type RegexpDialect int
+
+const (
+	// RegexpRE2 は Go の RE2 の構文として検査します。
+	// RegexpRE2 checks patterns with Go's regexp package.
+	RegexpRE2 RegexpDialect = iota
+	// RegexpJava は AWS Glue が用いる Java の正規表現の構文として検査します。
+	// RegexpJava checks patterns against Java regular expressions, which AWS
+	// Glue uses. Constructs only RE2 accepts are errors, constructs with
+	// different meanings are warnings, and constructs only Java accepts
+	// are not reported.
+	RegexpJava
+)
+
+// WithRegexpDialect は matches 式の正規表現を検査する方言を指定します。デフォルトは RegexpRE2 です。
+// WithRegexpDialect sets the dialect patterns of matches expressions are
+// checked against. The default is RegexpRE2.
+func WithRegexpDialect(d RegexpDialect) Option {
+	return func(c *config) {
+		c.regexpDialect = d
+	}
+}
+
+// checkRegexp validates the pattern of every matches expression in expr.
+func (cfg *config) checkRegexp(expr ast.Expression) []diag.Diagnostic {
+	switch x := expr.(type) {
+	case *ast.WithThresholdExpression:
+		return cfg.checkRegexp(x.Target)
+	case *ast.MatchesExpression:
+		return cfg.checkPattern(x)
+	}
+	return nil
+}
+
+func (cfg *config) checkPattern(x *ast.MatchesExpression) []diag.Diagnostic {
+	_, compileErr := regexp.Compile(x.Value)
+	if cfg.regexpDialect != RegexpJava {
+		if compileErr == nil {
+			return nil
+		}
+		return []diag.Diagnostic{regexpDiagnostic(x, compileErr)}
+	}
+	var diags []diag.Diagnostic
+	javaOnly := false
+	for _, issue := range regexcompat.Check(x.Value) {
+		severity := diag.SeverityError
+		switch issue.Kind {
+		case regexcompat.JavaOnly:
+			javaOnly = true
+			continue
+		case regexcompat.Different:
+			severity = diag.SeverityWarning
+		}
+		pos := patternPos(x, issue.Offset)
+		diags = append(diags, diag.Diagnostic{
+			Code:     "regexp-compat",
+			Severity: severity,
+			Message:  issue.Message,
+			Source:   source,
+			Pos:      pos,
+			End:      pos.Advance(issue.Text),
+		})
+	}
+	// RE2 rejects Java only constructs, so a compile error is only trusted
+	// when the pattern has none of them.
+	if compileErr != nil && !javaOnly {
+		diags = append(diags, regexpDiagnostic(x, compileErr))
+	}
+	return diags
+}
+
+func regexpDiagnostic(x *ast.MatchesExpression, err error) diag.Diagnostic {
+	d := diag.Diagnostic{
+		Code:     "invalid-regexp",
+		Severity: diag.SeverityError,
+		Message:  "invalid regular expression: " + err.Error(),
+		Source:   source,
+		Pos:      x.RegexpPos,
+		End:      x.RegexpPos.Advance(`"` + x.Value + `"`),
+	}
+	var syntaxErr *syntax.Error
+	if errors.As(err, &syntaxErr) {
+		d.Message = "invalid regular expression: " + syntaxErr.Code.String() + ": `" + syntaxErr.Expr + "`"
+		if i := strings.Index(x.Value, syntaxErr.Expr); i >= 0 && syntaxErr.Expr != "" {
+			d.Pos = patternPos(x, i)
+			d.End = d.Pos.Advance(syntaxErr.Expr)
+		}
+	}
+	return d
+}
+
+// patternPos returns the position of the byte offset in the pattern of x.
+// DQDL strings have no escapes, so the pattern follows the quote verbatim.
+func patternPos(x *ast.MatchesExpression, offset int) token.Pos {
+	return x.RegexpPos.Advance(`"` + x.Value[:offset])
+}
@@ -0,0 +1,54 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/parser"
+)
+
+func TestCheckRegexp(t *testing.T) {
+	input := `Rules = [
+	ColumnValues "a" matches "[a-z]+",
+	ColumnValues "b" matches "[a-z",
+	ColumnValues "c" matches "(?P<name>x)" with threshold > 0.5,
+	ColumnValues "d" matches "a*+",
+	ColumnValues "e" matches "\\z"
+]`
+	cases := []struct {
+		name string
+		opts []Option
+		want []string
+	}{
+		{
+			name: "re2",
+			want: []string{
+				"3:28: error: invalid regular expression: missing closing ]: `[a-z` [invalid-regexp]",
+				"5:29: error: invalid regular expression: invalid nested repetition operator: `*+` [invalid-regexp]",
+			},
+		},
+		{
+			name: "java",
+			opts: []Option{WithRegexpDialect(RegexpJava)},
+			want: []string{
+				"3:28: error: invalid regular expression: missing closing ]: `[a-z` [invalid-regexp]",
+				"4:28: error: named group \"(?P<\" is not supported by Java; use (?<name>...) instead [regexp-compat]",
+			},
+		},
+	}
+	ruleset, err := parser.ParseRuleset(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var got []string
+			for _, d := range Ruleset(ruleset, c.opts...) {
+				got = append(got, d.String())
+			}
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Errorf("unexpected diagnostics (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package validate
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxSuggestions is the maximum number of suggestions for a name.
+const maxSuggestions = 3
+
+// suggest returns the candidates closest to name, best first. A candidate
+// is close if it can be made from name by a few insertions, deletions,
+// substitutions or transpositions of adjacent characters, ignoring case.
+func suggest(name string, candidates []string) []string {
+	type scored struct {
+		name     string
+		distance int
+	}
+	limit := len(name) / 3
+	if limit < 1 {
+		limit = 1
+	}
+	var found []scored
+	lower := strings.ToLower(name)
+	for _, c := range candidates {
+		d := editDistance(lower, strings.ToLower(c))
+		if d <= limit {
+			found = append(found, scored{name: c, distance: d})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].distance != found[j].distance {
+			return found[i].distance < found[j].distance
+		}
+		return found[i].name < found[j].name
+	})
+	if len(found) > maxSuggestions {
+		found = found[:maxSuggestions]
+	}
+	var names []string
+	for _, f := range found {
+		names = append(names, f.name)
+	}
+	return names
+}
+
+// editDistance returns the optimal string alignment distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = minInt(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+func minInt(first int, rest ...int) int {
+	m := first
+	for _, v := range rest {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,55 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/parser"
+)
+
+func TestSuggest(t *testing.T) {
+	names := make([]string, 0, len(builtinSpecs))
+	for _, spec := range RuleSpecs() {
+		names = append(names, spec.Name)
+	}
+	cases := []struct {
+		input string
+		want  []string
+	}{
+		{input: "IsUnqiue", want: []string{"IsUnique"}},
+		{input: "isunique", want: []string{"IsUnique"}},
+		{input: "Complteness", want: []string{"Completeness"}},
+		{input: "ColumnValue", want: []string{"ColumnValues"}},
+		{input: "RowCont", want: []string{"RowCount"}},
+		{input: "NoSuchRule"},
+		{input: "X"},
+	}
+	for _, c := range cases {
+		t.Run(c.input, func(t *testing.T) {
+			if diff := cmp.Diff(c.want, suggest(c.input, names)); diff != "" {
+				t.Errorf("unexpected suggestions (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRule__UnknownRuleType(t *testing.T) {
+	rule, err := parser.ParseRule(`IsUnqiue "id"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diags := Rule(rule)
+	if len(diags) != 1 {
+		t.Fatalf("got %d diagnostics, want 1", len(diags))
+	}
+	d := diags[0]
+	if got, want := d.String(), "1:1: error: unknown rule type `IsUnqiue`, did you mean `IsUnique`? [unknown-rule-type]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if diff := cmp.Diff([]string{"IsUnique"}, d.Suggestions); diff != "" {
+		t.Errorf("unexpected suggestions (-want +got):\n%s", diff)
+	}
+	if len(d.Fixes) != 1 || d.Fixes[0].Edits[0].NewText != "IsUnique" || d.Fixes[0].Edits[0].End.Column != 9 {
+		t.Errorf("unexpected fixes: %+v", d.Fixes)
+	}
+}
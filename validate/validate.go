@@ -0,0 +1,211 @@
+// Package validate はDQDLのルールがAWS Glue Data Qualityのルールタイプの仕様に合っているかを検査します。
+// Package validate checks that DQDL rules match the specs of the AWS Glue
+// Data Quality rule types: known rule type, parameter count and types,
+// presence of an expression and valid patterns in matches expressions.
+// Findings are reported as diagnostics.
+package validate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mashiike/go-dqdl/ast"
+	"github.com/mashiike/go-dqdl/diag"
+)
+
+const source = "validate"
+
+// Option は検査の設定を変更します。
+// An Option configures validation.
+type Option func(*config)
+
+type config struct {
+	registry      *Registry
+	regexpDialect RegexpDialect
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.registry == nil {
+		cfg.registry = defaultRegistry
+	}
+	return cfg
+}
+
+var defaultRegistry = NewRegistry()
+
+// WithRegistry は検査に用いるルールタイプの仕様の集合を指定します。デフォルトは組み込みのルールタイプのみです。
+// WithRegistry sets the registry to look up rule types in. By default only
+// the built-in rule types are known.
+func WithRegistry(r *Registry) Option {
+	return func(c *config) {
+		c.registry = r
+	}
+}
+
+// File はファイル内の全てのルールセットを検査します。
+// File validates all rulesets in the file.
+func File(file *ast.File, opts ...Option) []diag.Diagnostic {
+	var diags []diag.Diagnostic
+	for _, ruleset := range file.Rulesets {
+		for _, d := range Ruleset(ruleset, opts...) {
+			d.Filename = file.Filename
+			diags = append(diags, d)
+		}
+	}
+	return diags
+}
+
+// Ruleset はルールセット内の全てのルールを検査します。
+// Ruleset validates all rules in the ruleset.
+func Ruleset(ruleset *ast.Ruleset, opts ...Option) []diag.Diagnostic {
+	cfg := newConfig(opts)
+	var diags []diag.Diagnostic
+	for _, rule := range ruleset.Rules {
+		diags = append(diags, cfg.rule(rule)...)
+	}
+	return diags
+}
+
+// Rule はルールを検査します。結合されたルールは各ルールを検査します。
+// Rule validates a rule. Each rule of a combined rule is validated.
+func Rule(rule ast.RuleDecl, opts ...Option) []diag.Diagnostic {
+	return newConfig(opts).rule(rule)
+}
+
+func (cfg *config) rule(rule ast.RuleDecl) []diag.Diagnostic {
+	switch r := rule.(type) {
+	case *ast.Rule:
+		return cfg.validateRule(r)
+	case *ast.CombinedRule:
+		var diags []diag.Diagnostic
+		for _, nested := range r.Rules {
+			diags = append(diags, cfg.validateRule(nested)...)
+		}
+		return diags
+	}
+	return nil
+}
+
+func (cfg *config) validateRule(rule *ast.Rule) []diag.Diagnostic {
+	if rule.Type == nil {
+		return nil
+	}
+	spec, ok := cfg.registry.Lookup(rule.Type.Name)
+	if !ok {
+		return []diag.Diagnostic{cfg.unknownRuleType(rule.Type)}
+	}
+	var diags []diag.Diagnostic
+	if msg, ok := checkParamCount(spec, len(rule.Parameters)); !ok {
+		diags = append(diags, newDiagnostic("parameter-count", rule, "%s", msg))
+	}
+	for i, param := range rule.Parameters {
+		want, ok := spec.Param(i)
+		if !ok {
+			break
+		}
+		if got := paramType(param); got != want.Type.String() {
+			diags = append(diags, newDiagnostic("parameter-type", param,
+				"%s parameter of %s must be %s, got %s", want.Name, spec.Name, want.Type, got))
+		}
+	}
+	if _, ok := rule.Expression.(*ast.BadExpr); ok {
+		// a syntax error, reported by the parser
+		return diags
+	}
+	switch {
+	case spec.Expression == ExpressionRequired && rule.Expression == nil:
+		diags = append(diags, newDiagnostic("missing-expression", rule, "%s requires an expression", spec.Name))
+	case spec.Expression == ExpressionForbidden && rule.Expression != nil:
+		diags = append(diags, newDiagnostic("unexpected-expression", rule.Expression, "%s does not take an expression", spec.Name))
+	case spec.Expressions != 0 && rule.Expression != nil:
+		if kind := expressionKindOf(rule.Expression); spec.Expressions&kind == 0 {
+			diags = append(diags, newDiagnostic("unsupported-expression", rule.Expression,
+				"%s does not accept `%s` expressions", spec.Name, kind))
+		}
+	}
+	diags = append(diags, cfg.checkRegexp(rule.Expression)...)
+	return diags
+}
+
+// unknownRuleType reports an unknown rule type together with the known
+// rule types it is likely a misspelling of.
+func (cfg *config) unknownRuleType(ident *ast.Ident) diag.Diagnostic {
+	d := newDiagnostic("unknown-rule-type", ident, "unknown rule type `%s`", ident.Name)
+	specs := cfg.registry.RuleSpecs()
+	names := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		names = append(names, spec.Name)
+	}
+	d.Suggestions = suggest(ident.Name, names)
+	if len(d.Suggestions) > 0 {
+		d.Message += fmt.Sprintf(", did you mean `%s`?", d.Suggestions[0])
+	}
+	for _, s := range d.Suggestions {
+		d.Fixes = append(d.Fixes, diag.Fix{
+			Message: fmt.Sprintf("replace with `%s`", s),
+			Edits:   []diag.Edit{{Pos: ident.Pos(), End: ident.End(), NewText: s}},
+		})
+	}
+	return d
+}
+
+// checkParamCount returns a message describing the expected parameters
+// if n parameters do not satisfy the spec.
+func checkParamCount(spec *RuleSpec, n int) (string, bool) {
+	want := len(spec.Params)
+	if spec.Variadic {
+		if n >= want {
+			return "", true
+		}
+		return fmt.Sprintf("%s takes at least %s", spec.Name, describeParams(spec.Params)), false
+	}
+	if n == want {
+		return "", true
+	}
+	if want == 0 {
+		return fmt.Sprintf("%s takes no parameters", spec.Name), false
+	}
+	return fmt.Sprintf("%s takes exactly %s", spec.Name, describeParams(spec.Params)), false
+}
+
+func describeParams(params []Param) string {
+	if len(params) == 1 {
+		return "one " + params[0].Name + " parameter"
+	}
+	names := make([]string, 0, len(params))
+	for _, p := range params {
+		names = append(names, p.Name)
+	}
+	return fmt.Sprintf("%d parameters (%s)", len(params), strings.Join(names, ", "))
+}
+
+func paramType(param ast.Parameter) string {
+	switch param.(type) {
+	case *ast.StringParameter:
+		return "string"
+	case *ast.NumberParameter:
+		return "number"
+	case *ast.BoolParameter:
+		return "bool"
+	case *ast.DurationParameter:
+		return "duration"
+	case *ast.DateParameter:
+		return "date"
+	}
+	return "unknown"
+}
+
+func newDiagnostic(code string, node ast.Node, format string, args ...interface{}) diag.Diagnostic {
+	return diag.Diagnostic{
+		Code:     code,
+		Severity: diag.SeverityError,
+		Message:  fmt.Sprintf(format, args...),
+		Source:   source,
+		Pos:      node.Pos(),
+		End:      node.End(),
+	}
+}
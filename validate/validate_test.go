@@ -0,0 +1,109 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mashiike/go-dqdl/parser"
+)
+
+func TestFile(t *testing.T) {
+	input := `Rules = [
+	IsUnique "a",
+	IsUnique "a" "b",
+	IsComplete "a" > 0.5,
+	ColumnValues "a",
+	IsPrimaryKey "a" "b",
+	IsPrimaryKey,
+	ColumnLength 10 = 3,
+	RowCount "a" > 10,
+	NoSuchRule "a",
+	(Mean "a" > 1) and (DatasetMatch "ref" > 0.9),
+	DataFreshness "load_date" <= 24 hours
+]`
+	file, err := parser.ParseFile("test.dqdl", strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range File(file) {
+		got = append(got, d.String())
+	}
+	want := []string{
+		"test.dqdl:3:2: error: IsUnique takes exactly one column parameter [parameter-count]",
+		"test.dqdl:4:17: error: IsComplete does not take an expression [unexpected-expression]",
+		"test.dqdl:5:2: error: ColumnValues requires an expression [missing-expression]",
+		"test.dqdl:7:2: error: IsPrimaryKey takes at least one column parameter [parameter-count]",
+		"test.dqdl:8:15: error: column parameter of ColumnLength must be string, got number [parameter-type]",
+		"test.dqdl:9:2: error: RowCount takes no parameters [parameter-count]",
+		"test.dqdl:10:2: error: unknown rule type `NoSuchRule` [unknown-rule-type]",
+		"test.dqdl:11:22: error: DatasetMatch takes exactly 2 parameters (reference, mapping) [parameter-count]",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected diagnostics (-want +got):\n%s", diff)
+	}
+}
+
+func TestRuleSpecs(t *testing.T) {
+	specs := RuleSpecs()
+	for i, spec := range specs {
+		if i > 0 && specs[i-1].Name >= spec.Name {
+			t.Errorf("specs are not sorted: %s >= %s", specs[i-1].Name, spec.Name)
+		}
+		if got, ok := Lookup(spec.Name); !ok || got != spec {
+			t.Errorf("Lookup(%q) does not return the spec", spec.Name)
+		}
+	}
+	if _, ok := Lookup("NoSuchRule"); ok {
+		t.Error("Lookup(NoSuchRule) should fail")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	err := registry.Register(&RuleSpec{
+		Name:        "IsValidJAN",
+		Params:      []Param{{Name: "column", Type: ParamString}},
+		Expressions: ExpressionComparison | ExpressionWithThreshold,
+		Expression:  ExpressionOptional,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register(&RuleSpec{Name: "IsUnique"}); err == nil {
+		t.Error("registering a built-in rule type should fail")
+	}
+	if err := registry.Register(&RuleSpec{}); err == nil {
+		t.Error("registering a rule spec without name should fail")
+	}
+
+	ruleset, err := parser.ParseRuleset(`Rules = [
+	IsValidJAN "code",
+	IsValidJAN "code" = 1,
+	IsValidJAN "code" between 1 and 2,
+	IsUnique "id"
+]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range Ruleset(ruleset, WithRegistry(registry)) {
+		got = append(got, d.String())
+	}
+	want := []string{
+		"4:20: error: IsValidJAN does not accept `between` expressions [unsupported-expression]",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected diagnostics (-want +got):\n%s", diff)
+	}
+
+	// without the registry, the custom rule type is unknown.
+	diags := Ruleset(ruleset)
+	if len(diags) != 3 || diags[0].Code != "unknown-rule-type" {
+		t.Errorf("unexpected diagnostics: %v", diags)
+	}
+	if _, ok := Lookup("IsValidJAN"); ok {
+		t.Error("custom rule type leaked into the built-in rule types")
+	}
+}